@@ -0,0 +1,55 @@
+package commands
+
+// specscore: feature/cli/alter
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// Alter returns the `ingitdb alter` command. One kind is supported today:
+// `alter view <name> --in=<collection>`, which changes only the attributes
+// named by the supplied flags, re-validates the view and re-materializes it.
+func Alter(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	viewBuilder materializer.ViewBuilder,
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alter <kind> <name>",
+		Short: "Change a schema object (view)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return fmt.Errorf("alter requires a kind: view")
+		},
+	}
+	cmd.PersistentFlags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.AddCommand(alterView(homeDir, getWd, readDefinition, viewBuilder, logf))
+	return cmd
+}
+
+// alterView returns the `alter view <name>` subcommand. Passing a flag with
+// an empty value clears the attribute (e.g. --where= removes the filter).
+func alterView(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	viewBuilder materializer.ViewBuilder,
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view <name>",
+		Short: "Change a view definition and re-materialize it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWriteView(cmd, args[0], true, homeDir, getWd, readDefinition, viewBuilder, logf)
+		},
+	}
+	addViewDefFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func runAlterCmd(t *testing.T, f *viewCmdFixture, args ...string) error {
+	t.Helper()
+	homeDir := func() (string, error) { return "/tmp/home", nil }
+	getWd := func() (string, error) { return f.dir, nil }
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil }
+	cmd := Alter(homeDir, getWd, readDef, nil, func(...any) {})
+	return runCobraCommand(cmd, append([]string{"--path=" + f.dir}, args...)...)
+}
+
+func TestAlterView_ChangesOnlySuppliedFlags(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	if err := runCreateCmd(t, f, nil, "view", "big", "--in=cities",
+		"--columns=name,population", "--top=5", "--where=population>1000"); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+
+	if err := runAlterCmd(t, f, "view", "big", "--in=cities", "--top=2", "--order-by=name"); err != nil {
+		t.Fatalf("alter view: %v", err)
+	}
+	view, err := readViewDefFile(f.viewPath("big"))
+	if err != nil {
+		t.Fatalf("read view: %v", err)
+	}
+	if view.Top != 2 || view.OrderBy != "name" {
+		t.Errorf("altered attributes: top=%d order_by=%q", view.Top, view.OrderBy)
+	}
+	if strings.Join(view.Columns, ",") != "name,population" || view.Where != "population>1000" {
+		t.Errorf("untouched attributes changed: columns=%v where=%q", view.Columns, view.Where)
+	}
+}
+
+func TestAlterView_EmptyWhereClearsFilter(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	if err := runCreateCmd(t, f, nil, "view", "big", "--in=cities", "--where=population>1000"); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	if err := runAlterCmd(t, f, "view", "big", "--in=cities", "--where="); err != nil {
+		t.Fatalf("alter view: %v", err)
+	}
+	view, err := readViewDefFile(f.viewPath("big"))
+	if err != nil {
+		t.Fatalf("read view: %v", err)
+	}
+	if view.Where != "" {
+		t.Errorf("expected where cleared, got %q", view.Where)
+	}
+}
+
+func TestAlterView_Rejections(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	if err := runCreateCmd(t, f, nil, "view", "big", "--in=cities"); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+
+	err := runAlterCmd(t, f, "view", "missing", "--in=cities", "--top=1")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not-found error, got %v", err)
+	}
+	err = runAlterCmd(t, f, "view", "big", "--in=cities", "--columns=area")
+	if err == nil || !strings.Contains(err.Error(), "area") {
+		t.Errorf("expected unknown-column error, got %v", err)
+	}
+	view, readErr := readViewDefFile(f.viewPath("big"))
+	if readErr != nil {
+		t.Fatalf("read view: %v", readErr)
+	}
+	if len(view.Columns) != 0 {
+		t.Errorf("rejected alter must not touch the file, columns=%v", view.Columns)
+	}
+}
@@ -1,43 +1,325 @@
 package commands
 
+// specscore: feature/cli/ci
+
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"io"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
 	"github.com/ingitdb/ingitdb-go/ingitdb/gitrepo"
 	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
 )
 
-// materializeRunE is the views-only materialize path retained for the `ci`
-// command. The `materialize` command uses its own run function
-// (materializeCommandRunE) with tri-state --collections/--views selection.
-func materializeRunE(
+// CI pipeline step names, in execution order.
+const (
+	ciStepDefinition = "definition"
+	ciStepRecords    = "records"
+	ciStepViews      = "views"
+	ciStepDiff       = "diff"
+)
+
+var ciStepOrder = []string{ciStepDefinition, ciStepRecords, ciStepViews, ciStepDiff}
+
+// CI step and pipeline statuses.
+const (
+	ciPassed  = "passed"
+	ciFailed  = "failed"
+	ciSkipped = "skipped"
+)
+
+// ciReport is the machine-readable result document written by --report.
+type ciReport struct {
+	Status string          `json:"status"`
+	DBPath string          `json:"db_path"`
+	Since  string          `json:"since,omitempty"`
+	Steps  []*ciStepResult `json:"steps"`
+}
+
+// ciStepResult is the outcome of one pipeline step. Only the fields relevant
+// to the step are set.
+type ciStepResult struct {
+	Name    string        `json:"name"`
+	Status  string        `json:"status"`
+	Message string        `json:"message,omitempty"`
+	Errors  []string      `json:"errors,omitempty"`
+	Views   *ciViewCounts `json:"views,omitempty"`
+	Stale   []string      `json:"stale,omitempty"`
+	Diff    *diffReport   `json:"diff,omitempty"`
+}
+
+// ciViewCounts mirrors ingitdb.MaterializeResult without the error values.
+type ciViewCounts struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// parseCISteps validates a comma-separated --steps value and returns the
+// selected steps as a set. An empty value selects every step.
+func parseCISteps(raw string) (map[string]bool, error) {
+	selected := map[string]bool{}
+	if strings.TrimSpace(raw) == "" {
+		for _, step := range ciStepOrder {
+			selected[step] = true
+		}
+		return selected, nil
+	}
+	for _, part := range strings.Split(raw, ",") {
+		step := strings.ToLower(strings.TrimSpace(part))
+		if step == "" {
+			continue
+		}
+		known := false
+		for _, s := range ciStepOrder {
+			if s == step {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown --steps value %q (expected a list of %s)", part, strings.Join(ciStepOrder, ", "))
+		}
+		selected[step] = true
+	}
+	return selected, nil
+}
+
+// ciPipeline holds the state shared by the steps of one `ci` run.
+type ciPipeline struct {
+	cmd              *cobra.Command
+	readDefinition   func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error)
+	viewBuilder      materializer.ViewBuilder
+	dataVal          datavalidator.DataValidator
+	incVal           datavalidator.IncrementalValidator
+	logf             func(...any)
+	dirPath          string
+	repoRoot         string
+	def              *ingitdb.Definition
+	since            string
+	changedCols      changedCollectionSet
+	recordsDelimiter *int
+	// diffOut receives the unified diffs of stale views; io.Discard when the
+	// report document itself goes to stdout.
+	diffOut io.Writer
+}
+
+// runRecords validates the records changed since the base (or every record
+// when there is no base).
+func (p *ciPipeline) runRecords(ctx context.Context) *ciStepResult {
+	step := &ciStepResult{Name: ciStepRecords}
+	var (
+		result *ingitdb.ValidationResult
+		err    error
+	)
+	switch {
+	case p.since != "" && p.incVal != nil && p.repoRoot != "":
+		step.Message = "validated records changed since " + p.since
+		result, err = p.incVal.ValidateChanges(ctx, p.repoRoot, p.def, p.since, "")
+	case p.dataVal != nil:
+		step.Message = "validated all records"
+		result, err = p.dataVal.Validate(ctx, p.dirPath, p.def)
+	default:
+		step.Status = ciSkipped
+		step.Message = "no record validator configured"
+		return step
+	}
+	if err == nil {
+		// Unique keys, list record keys and, for an incremental pass,
+		// foreign keys are checked across whole collections, as in validate.
+		result, err = appendIntegrityViolations(result, p.def, p.since != "")
+	}
+	if err != nil {
+		step.Status = ciFailed
+		step.Errors = []string{err.Error()}
+		return step
+	}
+	step.Status = ciPassed
+	for _, validationErr := range result.Errors() {
+		step.Status = ciFailed
+		step.Errors = append(step.Errors, formatValidationError(validationErr))
+	}
+	return step
+}
+
+// buildViews materializes the views of the changed top-level collections.
+func (p *ciPipeline) buildViews(ctx context.Context, def *ingitdb.Definition, dirPath, repoRoot string, changedCols changedCollectionSet) (*ingitdb.MaterializeResult, error) {
+	def.RuntimeOverrides.RecordsDelimiter = p.recordsDelimiter
+	var totalResult ingitdb.MaterializeResult
+	for _, col := range def.Collections {
+		if !changedCols.contains(col) {
+			continue
+		}
+		result, buildErr := p.viewBuilder.BuildViews(ctx, dirPath, repoRoot, col, def)
+		if buildErr != nil {
+			return nil, fmt.Errorf("failed to materialize views for collection %s: %w", col.ID, buildErr)
+		}
+		mergeMaterializeResult(&totalResult, result)
+	}
+	return &totalResult, nil
+}
+
+// runViews materializes views, or with --check reports the stale ones
+// without writing.
+func (p *ciPipeline) runViews(ctx context.Context) *ciStepResult {
+	step := &ciStepResult{Name: ciStepViews, Status: ciPassed}
+	var result *ingitdb.MaterializeResult
+	if check, _ := p.cmd.Flags().GetBool("check"); check {
+		stale, checkResult, err := findStaleFiles(p.dirPath, p.repoRoot, func(tmpDBPath, tmpRoot string) (*ingitdb.MaterializeResult, error) {
+			tmpDef, readErr := p.readDefinition(tmpDBPath)
+			if readErr != nil {
+				return nil, fmt.Errorf("failed to read database definition: %w", readErr)
+			}
+			return p.buildViews(ctx, tmpDef, tmpDBPath, tmpRoot, p.changedCols.rebase(p.dirPath, tmpDBPath, tmpDef))
+		})
+		if err != nil {
+			step.Status = ciFailed
+			step.Errors = []string{err.Error()}
+			return step
+		}
+		for _, f := range stale {
+			step.Stale = append(step.Stale, f.path)
+			writeUnifiedDiff(p.diffOut, f)
+		}
+		if len(stale) > 0 {
+			step.Status = ciFailed
+			step.Message = fmt.Sprintf("%d view file(s) are stale; run `ingitdb materialize` and commit the result", len(stale))
+		}
+		result = checkResult
+	} else {
+		buildResult, err := p.buildViews(ctx, p.def, p.dirPath, p.repoRoot, p.changedCols)
+		if err != nil {
+			step.Status = ciFailed
+			step.Errors = []string{err.Error()}
+			return step
+		}
+		result = buildResult
+	}
+	step.Views = &ciViewCounts{
+		Created:   result.FilesCreated,
+		Updated:   result.FilesUpdated,
+		Deleted:   result.FilesDeleted,
+		Unchanged: result.FilesUnchanged,
+	}
+	for _, e := range result.Errors {
+		step.Status = ciFailed
+		step.Errors = append(step.Errors, e.Error())
+	}
+	return step
+}
+
+// runDiff summarises the record-level changes since the base.
+func (p *ciPipeline) runDiff(ctx context.Context) *ciStepResult {
+	step := &ciStepResult{Name: ciStepDiff}
+	if p.since == "" || p.repoRoot == "" {
+		step.Status = ciSkipped
+		step.Message = "no base ref to diff against"
+		return step
+	}
+	report, err := computeDiff(ctx, p.repoRoot, p.def, p.since, "", "", "")
+	if err != nil {
+		step.Status = ciFailed
+		step.Errors = []string{err.Error()}
+		return step
+	}
+	step.Status = ciPassed
+	step.Diff = report
+	added, updated, deleted := 0, 0, 0
+	for _, c := range report.Summary {
+		added += c.Added
+		updated += c.Updated
+		deleted += c.Deleted
+	}
+	step.Message = fmt.Sprintf("%d record(s) added, %d updated, %d deleted", added, updated, deleted)
+	return step
+}
+
+// ciChangedCollections decides which collections `ci` works on and returns
+// the ref it diffs against. --full selects everything; --since diffs against
+// the given ref; otherwise the merge-base of HEAD and the base branch is used.
+// When no base branch is configured or the merge-base cannot be found, it
+// falls back to everything (empty ref, nil set) rather than failing the build.
+func ciChangedCollections(
+	cmd *cobra.Command,
+	repoRoot string,
+	def *ingitdb.Definition,
+	logf func(...any),
+) (string, changedCollectionSet, error) {
+	if full, _ := cmd.Flags().GetBool("full"); full {
+		return "", nil, nil
+	}
+	ctx := cmd.Context()
+	since, _ := cmd.Flags().GetString("since")
+	if since == "" {
+		baseFlag, _ := cmd.Flags().GetString("base")
+		base := ciBaseRef(baseFlag)
+		if base == "" || repoRoot == "" {
+			logf("no base branch to diff against (--base, BASE_REF, GITHUB_BASE_REF): checking everything")
+			return "", nil, nil
+		}
+		mergeBase, err := gitMergeBase(ctx, repoRoot, base)
+		if err != nil {
+			logf(fmt.Sprintf("%v: checking everything", err))
+			return "", nil, nil
+		}
+		since = mergeBase
+	}
+	changedCols, err := collectionsChangedSince(ctx, repoRoot, def, since)
+	if err != nil {
+		return "", nil, err
+	}
+	logf(changedCollectionsSummary(changedCols, since))
+	return since, changedCols, nil
+}
+
+// writeCIReport writes the result document as indented JSON to path ("-" is
+// stdout).
+func writeCIReport(cmd *cobra.Command, path string, report *ciReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode ci report: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write ci report: %w", err)
+	}
+	return nil
+}
+
+func ciRunE(
 	homeDir func() (string, error),
 	getWd func() (string, error),
 	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
 	viewBuilder materializer.ViewBuilder,
+	dataVal datavalidator.DataValidator,
+	incVal datavalidator.IncrementalValidator,
 	logf func(...any),
 ) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, _ []string) error {
-		if viewBuilder == nil {
-			return fmt.Errorf("not yet implemented")
+		stepsRaw, _ := cmd.Flags().GetString("steps")
+		steps, err := parseCISteps(stepsRaw)
+		if err != nil {
+			return err
 		}
-		dirPath, _ := cmd.Flags().GetString("path")
-		if dirPath == "" {
-			wd, err := getWd()
-			if err != nil {
-				return fmt.Errorf("failed to get working directory: %w", err)
-			}
-			dirPath = wd
+		if steps[ciStepViews] && viewBuilder == nil {
+			return fmt.Errorf("not yet implemented")
 		}
-		expanded, err := expandHome(dirPath, homeDir)
+		dirPath, err := resolveMaterializePath(cmd, homeDir, getWd)
 		if err != nil {
 			return err
 		}
-		dirPath, _ = filepath.Abs(expanded)
 		logf("inGitDB db path: ", dirPath)
 
 		ctx := cmd.Context()
@@ -47,50 +329,130 @@ func materializeRunE(
 			repoRoot = ""
 		}
 
-		def, err := readDefinition(dirPath)
-		if err != nil {
-			return fmt.Errorf("failed to read database definition: %w", err)
+		reportPath, _ := cmd.Flags().GetString("report")
+		p := &ciPipeline{
+			cmd:            cmd,
+			readDefinition: readDefinition,
+			viewBuilder:    viewBuilder,
+			dataVal:        dataVal,
+			incVal:         incVal,
+			logf:           logf,
+			dirPath:        dirPath,
+			repoRoot:       repoRoot,
+			diffOut:        cmd.OutOrStdout(),
+		}
+		if reportPath == "-" {
+			p.diffOut = io.Discard
 		}
-		var recordsDelimiter *int
 		if cmd.Flags().Changed("records-delimiter") {
 			v, _ := cmd.Flags().GetInt("records-delimiter")
-			recordsDelimiter = &v
-		}
-		def.RuntimeOverrides.RecordsDelimiter = recordsDelimiter
-		var totalResult ingitdb.MaterializeResult
-		for _, col := range def.Collections {
-			result, buildErr := viewBuilder.BuildViews(ctx, dirPath, repoRoot, col, def)
-			if buildErr != nil {
-				return fmt.Errorf("failed to materialize views for collection %s: %w", col.ID, buildErr)
+			p.recordsDelimiter = &v
+		}
+
+		report := &ciReport{DBPath: dirPath}
+		defStep := &ciStepResult{Name: ciStepDefinition, Status: ciPassed}
+		if steps[ciStepDefinition] {
+			p.def, err = readDefinition(dirPath, ingitdb.Validate())
+		} else {
+			p.def, err = readDefinition(dirPath)
+		}
+		if err != nil {
+			if !steps[ciStepDefinition] {
+				return fmt.Errorf("failed to read database definition: %w", err)
+			}
+			defStep.Status = ciFailed
+			defStep.Errors = []string{err.Error()}
+		}
+		if steps[ciStepDefinition] {
+			report.Steps = append(report.Steps, defStep)
+		}
+
+		if defStep.Status == ciPassed {
+			p.since, p.changedCols, err = ciChangedCollections(cmd, repoRoot, p.def, logf)
+			if err != nil {
+				return err
+			}
+			report.Since = p.since
+		}
+
+		runners := map[string]func(context.Context) *ciStepResult{
+			ciStepRecords: p.runRecords,
+			ciStepViews:   p.runViews,
+			ciStepDiff:    p.runDiff,
+		}
+		var failed []string
+		if defStep.Status == ciFailed {
+			failed = append(failed, ciStepDefinition)
+		}
+		for _, name := range ciStepOrder[1:] {
+			if !steps[name] {
+				continue
+			}
+			var step *ciStepResult
+			if defStep.Status == ciFailed {
+				step = &ciStepResult{Name: name, Status: ciSkipped, Message: "database definition is invalid"}
+			} else {
+				step = runners[name](ctx)
+			}
+			report.Steps = append(report.Steps, step)
+			if step.Status == ciFailed {
+				failed = append(failed, name)
+			}
+		}
+
+		report.Status = ciPassed
+		if len(failed) > 0 {
+			report.Status = ciFailed
+		}
+		for _, step := range report.Steps {
+			line := fmt.Sprintf("%s: %s", step.Name, step.Status)
+			if step.Message != "" {
+				line += " (" + step.Message + ")"
+			}
+			logf(line)
+			for _, e := range step.Errors {
+				logf("  " + e)
+			}
+		}
+		if reportPath != "" {
+			if err = writeCIReport(cmd, reportPath, report); err != nil {
+				return err
 			}
-			totalResult.FilesCreated += result.FilesCreated
-			totalResult.FilesUpdated += result.FilesUpdated
-			totalResult.FilesUnchanged += result.FilesUnchanged
-			totalResult.FilesDeleted += result.FilesDeleted
-			totalResult.Errors = append(totalResult.Errors, result.Errors...)
-		}
-		logf(fmt.Sprintf("materialized views: %d created, %d updated, %d deleted, %d unchanged",
-			totalResult.FilesCreated, totalResult.FilesUpdated, totalResult.FilesDeleted, totalResult.FilesUnchanged))
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("ci failed: %s", strings.Join(failed, ", "))
+		}
 		return nil
 	}
 }
 
-// CI returns the ci command.
-// Currently it executes materialize; future versions may add CI-specific
-// optimisations such as validating and materializing only the collections
-// affected by a pull-request diff.
+// CI returns the ci command: a pipeline of definition validation, record
+// validation over the pull-request range, view materialization (or a
+// staleness check with --check) and a record-level diff summary.
 func CI(
 	homeDir func() (string, error),
 	getWd func() (string, error),
 	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
 	viewBuilder materializer.ViewBuilder,
+	dataVal datavalidator.DataValidator,
+	incVal datavalidator.IncrementalValidator,
 	logf func(...any),
 ) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "ci",
-		Short: "Run CI checks for the database (currently: materialize views)",
-		RunE:  materializeRunE(homeDir, getWd, readDefinition, viewBuilder, logf),
+		Short: "Run the CI pipeline: validate, materialize or check views, summarise changes",
+		RunE:  ciRunE(homeDir, getWd, readDefinition, viewBuilder, dataVal, incVal, logf),
 	}
 	addMaterializeFlags(cmd)
+	cmd.Flags().String("steps", "",
+		"comma-separated steps to run: "+strings.Join(ciStepOrder, ", ")+" (default: all)")
+	cmd.Flags().String("since", "", "check only what changed since this git ref")
+	cmd.Flags().String("base", "",
+		"base branch whose merge-base with HEAD is the default --since; defaults to $BASE_REF or $GITHUB_BASE_REF")
+	cmd.Flags().Bool("full", false, "check everything, ignoring git changes")
+	cmd.Flags().Bool("check", false,
+		"write nothing; print a diff of every stale view file and fail if any is out of date")
+	cmd.Flags().String("report", "", "write a JSON result document to this file ('-' for stdout)")
+	cmd.MarkFlagsMutuallyExclusive("since", "full")
 	return cmd
 }
@@ -1,10 +1,16 @@
 package commands
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
+
 	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
 )
 
 func TestCI_ReturnsCommand(t *testing.T) {
@@ -17,7 +23,7 @@ func TestCI_ReturnsCommand(t *testing.T) {
 	}
 	logf := func(...any) {}
 
-	cmd := CI(homeDir, getWd, readDef, nil, logf)
+	cmd := CI(homeDir, getWd, readDef, nil, nil, nil, logf)
 	if cmd == nil {
 		t.Fatal("CI() returned nil")
 		return
@@ -40,7 +46,7 @@ func TestCI_NotYetImplemented(t *testing.T) {
 	}
 	logf := func(...any) {}
 
-	cmd := CI(homeDir, getWd, readDef, nil, logf)
+	cmd := CI(homeDir, getWd, readDef, nil, nil, nil, logf)
 	err := runCobraCommand(cmd)
 	if err == nil {
 		t.Fatal("expected error when viewBuilder is nil")
@@ -74,7 +80,7 @@ func TestCI_Success(t *testing.T) {
 	}
 	logf := func(...any) {}
 
-	cmd := CI(homeDir, getWd, readDef, viewBuilder, logf)
+	cmd := CI(homeDir, getWd, readDef, viewBuilder, nil, nil, logf)
 	err := runCobraCommand(cmd, "--path="+dir)
 	if err != nil {
 		t.Fatalf("CI: %v", err)
@@ -104,7 +110,7 @@ func TestCI_BuildViewsError(t *testing.T) {
 	}
 	logf := func(...any) {}
 
-	cmd := CI(homeDir, getWd, readDef, viewBuilder, logf)
+	cmd := CI(homeDir, getWd, readDef, viewBuilder, nil, nil, logf)
 	err := runCobraCommand(cmd, "--path="+dir)
 	if err == nil {
 		t.Fatal("expected error when BuildViews fails")
@@ -122,9 +128,151 @@ func TestCI_GetWdError(t *testing.T) {
 	viewBuilder := &mockViewBuilder{}
 	logf := func(...any) {}
 
-	cmd := CI(homeDir, getWd, readDef, viewBuilder, logf)
+	cmd := CI(homeDir, getWd, readDef, viewBuilder, nil, nil, logf)
 	err := runCobraCommand(cmd)
 	if err == nil {
 		t.Fatal("expected error when getWd fails")
 	}
 }
+
+func TestParseCISteps(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: []string{"definition", "records", "views", "diff"}},
+		{raw: "views", want: []string{"views"}},
+		{raw: " Records , diff,", want: []string{"records", "diff"}},
+		{raw: "lint", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseCISteps(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q", tc.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for _, step := range tc.want {
+				if !got[step] {
+					t.Errorf("step %q not selected in %v", step, got)
+				}
+			}
+		})
+	}
+}
+
+// runCIReport runs `ci --report=-` against the fixture and decodes the
+// result document from stdout.
+func runCIReport(t *testing.T, f *sinceFixture, dataVal datavalidator.DataValidator, incVal datavalidator.IncrementalValidator, args ...string) (*ciReport, error) {
+	t.Helper()
+	cmd := CI(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return f.dir, nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil },
+		&mockViewBuilder{result: &ingitdb.MaterializeResult{FilesUpdated: 1}},
+		dataVal, incVal, func(...any) {},
+	)
+	root := &cobra.Command{Use: "app", SilenceUsage: true, SilenceErrors: true}
+	root.AddCommand(cmd)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(append([]string{"ci", "--path=" + f.dir, "--report=-"}, args...))
+	runErr := root.Execute()
+	var report ciReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v\n%s", err, out.String())
+	}
+	return &report, runErr
+}
+
+func TestCI_PipelineReport(t *testing.T) {
+	t.Parallel()
+	f := newSinceFixture(t)
+	f.write(t, ".collections/cities/$records/a.yaml", "name: b\n")
+	incVal := &mockIncrementalValidator{result: &ingitdb.ValidationResult{}}
+
+	report, err := runCIReport(t, f, nil, incVal, "--since=HEAD")
+	if err != nil {
+		t.Fatalf("ci: %v", err)
+	}
+	if report.Status != ciPassed || report.Since != "HEAD" {
+		t.Errorf("report status=%q since=%q", report.Status, report.Since)
+	}
+	var names []string
+	for _, step := range report.Steps {
+		names = append(names, step.Name+"="+step.Status)
+	}
+	want := "definition=passed,records=passed,views=passed,diff=passed"
+	if strings.Join(names, ",") != want {
+		t.Errorf("steps: got %s, want %s", strings.Join(names, ","), want)
+	}
+	if views := report.Steps[2].Views; views == nil || views.Updated != 1 {
+		t.Errorf("views step counts: %+v", views)
+	}
+}
+
+func TestCI_PipelineFailures(t *testing.T) {
+	t.Parallel()
+	failing := &ingitdb.ValidationResult{}
+	failing.Append(ingitdb.ValidationError{Severity: ingitdb.SeverityError, RecordKey: "a", Message: "bad value"})
+
+	f := newSinceFixture(t)
+	report, err := runCIReport(t, f, &mockDataValidator{result: failing}, nil, "--full", "--steps=records,diff")
+	if err == nil || !strings.Contains(err.Error(), "records") {
+		t.Fatalf("expected the records step to fail the run, got %v", err)
+	}
+	if report.Status != ciFailed || len(report.Steps) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if rec := report.Steps[0]; rec.Status != ciFailed || len(rec.Errors) != 1 || !strings.Contains(rec.Errors[0], "bad value") {
+		t.Errorf("records step: %+v", rec)
+	}
+	if diff := report.Steps[1]; diff.Status != ciSkipped {
+		t.Errorf("diff step without a base ref should be skipped, got %+v", diff)
+	}
+}
+
+func TestCI_RecordsStepChecksUniqueKeys(t *testing.T) {
+	t.Parallel()
+	f := newSinceFixture(t)
+	f.def.Collections["cities"].Columns = map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}}
+	f.write(t, ".collections/cities/constraints.yaml", "columns:\n  name:\n    unique: true\n")
+	f.write(t, ".collections/cities/$records/b.yaml", "name: a\n")
+
+	report, err := runCIReport(t, f, &mockDataValidator{result: &ingitdb.ValidationResult{}}, nil, "--full", "--steps=records")
+	if err == nil || !strings.Contains(err.Error(), "records") {
+		t.Fatalf("expected the records step to fail the run, got %v", err)
+	}
+	if rec := report.Steps[0]; rec.Status != ciFailed || len(rec.Errors) != 1 || !strings.Contains(rec.Errors[0], "unique") {
+		t.Errorf("records step: %+v", rec)
+	}
+}
+
+func TestCI_InvalidDefinitionSkipsLaterSteps(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	cmd := CI(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+			return nil, fmt.Errorf("bad definition")
+		},
+		&mockViewBuilder{result: &ingitdb.MaterializeResult{}}, nil, nil, func(...any) {},
+	)
+	err := runCobraCommand(cmd, "--path="+dir, "--steps=definition,views")
+	if err == nil || !strings.Contains(err.Error(), "definition") {
+		t.Fatalf("expected definition failure, got %v", err)
+	}
+}
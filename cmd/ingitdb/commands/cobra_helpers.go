@@ -47,6 +47,8 @@ func ResolveDBPathArgs(
 
 // resolveRecordContext resolves DB + collection + record key for CRUD operations.
 // Replaces the old urfave/cli resolveRecordContext in record_context.go.
+// An --id of a subcollection record (customers/c1/orders/o1) resolves its
+// instance the way --into does.
 func resolveRecordContext(
 	ctx context.Context,
 	cmd *cobra.Command,
@@ -56,6 +58,18 @@ func resolveRecordContext(
 	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
 	newDB func(string, *ingitdb.Definition) (dal.DB, error),
 ) (recordContext, error) {
+	if collectionPath, key, ok := splitRecordPath(id); ok {
+		if key == "" {
+			return recordContext{}, fmt.Errorf("invalid --id %q: empty record key", id)
+		}
+		ictx, err := resolveInsertContext(ctx, cmd, collectionPath, homeDir, getWd, readDefinition, newDB)
+		if err != nil {
+			return recordContext{}, fmt.Errorf("invalid --id: %w", err)
+		}
+		rctx := ictx.toRecordContext()
+		rctx.recordKey = key
+		return rctx, nil
+	}
 	remoteValue, _ := cmd.Flags().GetString("remote")
 	if remoteValue != "" {
 		return resolveRemoteRecordContext(ctx, cmd, id, remoteValue)
@@ -0,0 +1,259 @@
+package commands
+
+// specscore: feature/cli/codegen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// codegenEmitters maps each --lang value to the function rendering the
+// models in that language.
+var codegenEmitters = map[string]func(w *bytes.Buffer, models []codegenModel, pkg string){
+	"go":         emitGoModels,
+	"typescript": emitTypeScriptModels,
+	"python":     emitPythonModels,
+}
+
+// Codegen returns the `ingitdb codegen` command. It generates typed record
+// models from the collection definitions. The output depends only on the
+// definitions, so CI can regenerate it and fail on a diff with --check.
+func Codegen(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "codegen",
+		Short: "Generate typed record models from collection definitions",
+		Long: "Generates one record model per collection — Go structs (with load/save helpers for " +
+			"root collections), TypeScript interfaces or Python TypedDicts — with the key type, " +
+			"column types, optional fields and locale maps of the definition.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			lang, _ := cmd.Flags().GetString("lang")
+			emit, ok := codegenEmitters[lang]
+			if !ok {
+				return fmt.Errorf("invalid --lang=%q (must be go, typescript, or python)", lang)
+			}
+			outFile, _ := cmd.Flags().GetString("out")
+			check, _ := cmd.Flags().GetBool("check")
+			if check && outFile == "" {
+				return fmt.Errorf("--check requires --out")
+			}
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			def, err := readDefinition(dirPath)
+			if err != nil {
+				return fmt.Errorf("failed to read database definition: %w", err)
+			}
+			only, _ := cmd.Flags().GetString("collection")
+			pkg, _ := cmd.Flags().GetString("package")
+			cols := exportedCollections(def.Collections, nil, only)
+			if only != "" && len(cols) == 0 {
+				return fmt.Errorf("collection %q not found", only)
+			}
+			var buf bytes.Buffer
+			emit(&buf, codegenModels(cols), pkg)
+			return writeCodegenOutput(cmd.OutOrStdout(), buf.Bytes(), outFile, check)
+		},
+	}
+	cmd.Flags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.Flags().String("lang", "", "target language: go, typescript, python")
+	_ = cmd.MarkFlagRequired("lang")
+	cmd.Flags().String("out", "", "file to write the models to (default: stdout)")
+	cmd.Flags().String("package", "models", "Go package name of the generated file")
+	cmd.Flags().String("collection", "", "generate only this root collection and its subcollections")
+	cmd.Flags().Bool("check", false, "fail when --out differs from the generated models instead of writing it")
+	return cmd
+}
+
+// writeCodegenOutput writes the generated code to outFile, or to w when no
+// file is given. With check it compares instead of writing.
+func writeCodegenOutput(w io.Writer, code []byte, outFile string, check bool) error {
+	if outFile == "" {
+		_, err := w.Write(code)
+		return err
+	}
+	if check {
+		existing, err := os.ReadFile(outFile)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", outFile, err)
+		}
+		if !bytes.Equal(existing, code) {
+			return fmt.Errorf("%s is out of date; regenerate it with ingitdb codegen", outFile)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outFile), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(outFile), err)
+	}
+	if err := os.WriteFile(outFile, code, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outFile, err)
+	}
+	return nil
+}
+
+// codegenModel is the record model of one collection.
+type codegenModel struct {
+	// fullID is the slash-separated path of the collection.
+	fullID string
+
+	// typeName is the name of the generated record type: "Country" for
+	// countries, "CountryRegion" for countries/regions.
+	typeName string
+
+	// root reports whether the collection is a root collection; only root
+	// collections get Go load/save helpers.
+	root bool
+
+	// keyType is the column type of the record key: the type of a single
+	// primary_key column, string otherwise.
+	keyType ingitdb.ColumnType
+
+	fields []codegenField
+}
+
+// codegenField is a stored column of a record model.
+type codegenField struct {
+	column string
+	def    *ingitdb.ColumnDef
+}
+
+// codegenModels builds the models of the collections. Computed columns and
+// $-prefixed columns are not stored in the record and are left out. Fields
+// follow columns_order, then the remaining columns sorted by name.
+func codegenModels(cols []exportedCollection) []codegenModel {
+	models := make([]codegenModel, 0, len(cols))
+	for _, e := range cols {
+		m := codegenModel{
+			fullID:   e.fullID,
+			typeName: codegenTypeName(e.fullID),
+			root:     !strings.Contains(e.fullID, "/"),
+			keyType:  ingitdb.ColumnTypeString,
+		}
+		if pk := e.col.PrimaryKey; len(pk) == 1 && e.col.Columns[pk[0]] != nil {
+			switch t := e.col.Columns[pk[0]].Type; t {
+			case ingitdb.ColumnTypeInt, ingitdb.ColumnTypeString:
+				m.keyType = t
+			}
+		}
+		names := slices.Clone(e.col.ColumnsOrder)
+		for _, name := range slices.Sorted(maps.Keys(e.col.Columns)) {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			col := e.col.Columns[name]
+			if col == nil || col.Formula != "" || strings.HasPrefix(name, "$") {
+				continue
+			}
+			m.fields = append(m.fields, codegenField{column: name, def: col})
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// codegenTypeName derives a record type name from a collection path: every
+// segment is singularised and title-cased.
+func codegenTypeName(fullID string) string {
+	var b strings.Builder
+	for _, segment := range strings.Split(fullID, "/") {
+		b.WriteString(pascalCase(singularize(segment)))
+	}
+	return b.String()
+}
+
+// singularize strips the plural ending of a collection name: "countries" →
+// "country", "statuses" → "status", "users" → "user". Names that do not end
+// in a plural "s" are kept.
+func singularize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"),
+		strings.HasSuffix(name, "shes"), strings.HasSuffix(name, "uses") && !strings.HasSuffix(name, "ouses"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
+
+// goInitialisms are the words Go style spells in upper case.
+var goInitialisms = map[string]string{"id": "ID", "url": "URL", "uri": "URI", "api": "API", "http": "HTTP", "json": "JSON"}
+
+// pascalCase turns a snake_case, kebab-case or dotted name into PascalCase,
+// upper-casing the Go initialisms: "country_id" → "CountryID".
+func pascalCase(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper, ok := goInitialisms[strings.ToLower(word)]; ok {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	out := b.String()
+	if out == "" || unicode.IsDigit([]rune(out)[0]) {
+		out = "X" + out
+	}
+	return out
+}
+
+// codegenComment returns the doc comment lines of a field: its title, the
+// members of its enum and the collection it references. With literalEnums the
+// members of a string enum are left out, as the field type spells them.
+func codegenComment(f codegenField, literalEnums bool) []string {
+	var lines []string
+	if f.def.Title != "" {
+		lines = append(lines, f.def.Title)
+	}
+	if len(f.def.Enum) > 0 && !(literalEnums && codegenHasLiteralEnum(f.def)) {
+		members := make([]string, len(f.def.Enum))
+		for i, member := range f.def.Enum {
+			members[i] = fmt.Sprint(member)
+		}
+		lines = append(lines, "One of: "+strings.Join(members, ", ")+".")
+	}
+	if f.def.ForeignKey != "" {
+		lines = append(lines, "Key of a record in "+f.def.ForeignKey+".")
+	}
+	return lines
+}
+
+// codegenHasLiteralEnum reports whether the enum of a string column can be
+// spelled as a union of string literals (TypeScript) or a Literal (Python).
+func codegenHasLiteralEnum(col *ingitdb.ColumnDef) bool {
+	if col.Type != ingitdb.ColumnTypeString || len(col.Enum) == 0 {
+		return false
+	}
+	for _, member := range col.Enum {
+		if _, ok := member.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
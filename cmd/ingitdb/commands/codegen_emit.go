@@ -0,0 +1,288 @@
+package commands
+
+// specscore: feature/cli/codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// codegenHeader is the first line of every generated file, in the form Go
+// tooling recognises as generated code.
+const codegenHeader = "Code generated by ingitdb codegen. DO NOT EDIT."
+
+// identifierPattern matches the names TypeScript and Python accept as
+// identifiers without quoting.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pythonKeywords are the Python keywords that cannot name a class attribute.
+var pythonKeywords = []string{
+	"False", "None", "True", "and", "as", "assert", "async", "await", "break", "class", "continue",
+	"def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import", "in",
+	"is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+}
+
+// emitGoModels renders the models as Go structs with json tags — the tags
+// record.MapToData and record.DataToMap convert through — and, for root
+// collections, Get/Set helpers over a dalgo dal.DB. The output is gofmt-ed.
+func emitGoModels(w *bytes.Buffer, models []codegenModel, pkg string) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// %s\n\npackage %s\n", codegenHeader, pkg)
+	if slices.ContainsFunc(models, func(m codegenModel) bool { return m.root }) {
+		b.WriteString("\nimport (\n\t\"context\"\n\t\"fmt\"\n\n\t\"github.com/dal-go/dalgo/dal\"\n\t\"github.com/dal-go/record\"\n)\n")
+	}
+	for _, m := range models {
+		keyType := "string"
+		if m.keyType == ingitdb.ColumnTypeInt {
+			keyType = "int"
+		}
+		fmt.Fprintf(&b, "\n// %sCollection is the ID of the %s collection.\nconst %sCollection = %q\n", m.typeName, m.fullID, m.typeName, m.fullID)
+		fmt.Fprintf(&b, "\n// %sKey is the key of a %s record.\ntype %sKey = %s\n", m.typeName, m.fullID, m.typeName, keyType)
+		fmt.Fprintf(&b, "\n// %s is a record of the %s collection.\ntype %s struct {\n", m.typeName, m.fullID, m.typeName)
+		for _, f := range m.fields {
+			for _, line := range codegenComment(f, false) {
+				fmt.Fprintf(&b, "\t// %s\n", line)
+			}
+			goType, nilable := goColumnType(f.def.Type)
+			tag := f.column
+			if !f.def.Required {
+				tag += ",omitempty"
+				if !nilable {
+					goType = "*" + goType
+				}
+			}
+			fmt.Fprintf(&b, "\t%s %s `json:%q`\n", pascalCase(f.column), goType, tag)
+		}
+		b.WriteString("}\n")
+		if m.root {
+			fmt.Fprintf(&b, goHelpersTemplate, m.typeName, m.fullID)
+		}
+	}
+	out, err := format.Source(b.Bytes())
+	if err != nil {
+		// Unformatted output still compiles, and the error surfaces when
+		// the caller builds it.
+		out = b.Bytes()
+	}
+	w.Write(out)
+}
+
+// goHelpersTemplate renders the Get and Set helpers of a root collection.
+// Arguments: the type name, then the collection ID.
+const goHelpersTemplate = `
+// Get%[1]s loads the %[2]s record with the given key.
+func Get%[1]s(ctx context.Context, db dal.DB, key %[1]sKey) (*%[1]s, error) {
+	data := map[string]any{}
+	rec := record.NewRecordWithData(record.NewKeyWithID(%[1]sCollection, key), data)
+	err := db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
+		return tx.Get(ctx, rec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	v := new(%[1]s)
+	if err = record.MapToData(v, data); err != nil {
+		return nil, fmt.Errorf("decode %[2]s record %%v: %%w", key, err)
+	}
+	return v, nil
+}
+
+// Set%[1]s creates or replaces the %[2]s record with the given key.
+func Set%[1]s(ctx context.Context, db dal.DB, key %[1]sKey, v *%[1]s) error {
+	data, err := record.DataToMap(v)
+	if err != nil {
+		return fmt.Errorf("encode %[2]s record %%v: %%w", key, err)
+	}
+	rec := record.NewRecordWithData(record.NewKeyWithID(%[1]sCollection, key), data)
+	return db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		return tx.Set(ctx, rec)
+	})
+}
+`
+
+// goColumnType maps a column type to Go. nilable reports whether the Go type
+// has a zero value of its own for an absent optional field (maps, slices,
+// any) and so needs no pointer.
+func goColumnType(ct ingitdb.ColumnType) (goType string, nilable bool) {
+	switch ct {
+	case ingitdb.ColumnTypeString, ingitdb.ColumnTypeDate, ingitdb.ColumnTypeTime, ingitdb.ColumnTypeDateTime:
+		return "string", false
+	case ingitdb.ColumnTypeInt:
+		return "int", false
+	case ingitdb.ColumnTypeFloat:
+		return "float64", false
+	case ingitdb.ColumnTypeBool:
+		return "bool", false
+	}
+	if elem, ok := ingitdb.ListElementType(ct); ok {
+		elemType, _ := goColumnType(elem)
+		return "[]" + elemType, true
+	}
+	if valueType, ok := mapColumnValueType(ct); ok {
+		goValue, _ := goColumnType(valueType)
+		return "map[string]" + goValue, true
+	}
+	return "any", true
+}
+
+// mapColumnValueType returns the value type of a map[K]V column type. Map
+// keys are strings in YAML and JSON whatever K is.
+func mapColumnValueType(ct ingitdb.ColumnType) (ingitdb.ColumnType, bool) {
+	rest, ok := strings.CutPrefix(string(ct), "map[")
+	if !ok {
+		return "", false
+	}
+	_, valueType, found := strings.Cut(rest, "]")
+	return ingitdb.ColumnType(valueType), found
+}
+
+// emitTypeScriptModels renders the models as TypeScript interfaces. Optional
+// columns are optional properties; a string enum is a union of literals.
+func emitTypeScriptModels(w *bytes.Buffer, models []codegenModel, _ string) {
+	fmt.Fprintf(w, "// %s\n", codegenHeader)
+	for _, m := range models {
+		keyType := "string"
+		if m.keyType == ingitdb.ColumnTypeInt {
+			keyType = "number"
+		}
+		fmt.Fprintf(w, "\n/** ID of the %s collection. */\nexport const %sCollection = %q;\n", m.fullID, m.typeName, m.fullID)
+		fmt.Fprintf(w, "\n/** Key of a %s record. */\nexport type %sKey = %s;\n", m.fullID, m.typeName, keyType)
+		fmt.Fprintf(w, "\n/** A record of the %s collection. */\nexport interface %s {\n", m.fullID, m.typeName)
+		for _, f := range m.fields {
+			if lines := codegenComment(f, true); len(lines) > 0 {
+				fmt.Fprintf(w, "  /** %s */\n", strings.Join(lines, " "))
+			}
+			name := f.column
+			if !identifierPattern.MatchString(name) {
+				name = strconv.Quote(name)
+			}
+			optional := "?"
+			if f.def.Required {
+				optional = ""
+			}
+			tsType := typeScriptColumnType(f.def.Type)
+			if codegenHasLiteralEnum(f.def) {
+				tsType = literalUnion(f.def.Enum, " | ")
+			}
+			fmt.Fprintf(w, "  %s%s: %s;\n", name, optional, tsType)
+		}
+		w.WriteString("}\n")
+	}
+}
+
+// typeScriptColumnType maps a column type to TypeScript.
+func typeScriptColumnType(ct ingitdb.ColumnType) string {
+	switch ct {
+	case ingitdb.ColumnTypeString, ingitdb.ColumnTypeDate, ingitdb.ColumnTypeTime, ingitdb.ColumnTypeDateTime:
+		return "string"
+	case ingitdb.ColumnTypeInt, ingitdb.ColumnTypeFloat:
+		return "number"
+	case ingitdb.ColumnTypeBool:
+		return "boolean"
+	}
+	if elem, ok := ingitdb.ListElementType(ct); ok {
+		return typeScriptColumnType(elem) + "[]"
+	}
+	if valueType, ok := mapColumnValueType(ct); ok {
+		return "Record<string, " + typeScriptColumnType(valueType) + ">"
+	}
+	return "unknown"
+}
+
+// literalUnion joins the string members of an enum as quoted literals.
+func literalUnion(members []any, sep string) string {
+	quoted := make([]string, len(members))
+	for i, member := range members {
+		quoted[i] = strconv.Quote(member.(string))
+	}
+	return strings.Join(quoted, sep)
+}
+
+// emitPythonModels renders the models as Python TypedDicts. Optional columns
+// are NotRequired; a string enum is a Literal. A model with a column name
+// that is not a Python identifier uses the functional TypedDict syntax.
+func emitPythonModels(w *bytes.Buffer, models []codegenModel, _ string) {
+	var body bytes.Buffer
+	imports := map[string]bool{"TypedDict": true}
+	for _, m := range models {
+		keyType := "str"
+		if m.keyType == ingitdb.ColumnTypeInt {
+			keyType = "int"
+		}
+		fmt.Fprintf(&body, "\n\n%sCollection = %q\n\"\"\"ID of the %s collection.\"\"\"\n", m.typeName, m.fullID, m.fullID)
+		fmt.Fprintf(&body, "\n%sKey = %s\n\"\"\"Key of a %s record.\"\"\"\n", m.typeName, keyType, m.fullID)
+		type field struct{ name, pyType, doc string }
+		fields := make([]field, 0, len(m.fields))
+		functional := false
+		for _, f := range m.fields {
+			pyType := pythonColumnType(f.def.Type, imports)
+			if codegenHasLiteralEnum(f.def) {
+				pyType = "Literal[" + literalUnion(f.def.Enum, ", ") + "]"
+				imports["Literal"] = true
+			}
+			if !f.def.Required {
+				pyType = "NotRequired[" + pyType + "]"
+				imports["NotRequired"] = true
+			}
+			if !identifierPattern.MatchString(f.column) || slices.Contains(pythonKeywords, f.column) {
+				functional = true
+			}
+			fields = append(fields, field{f.column, pyType, strings.Join(codegenComment(f, true), " ")})
+		}
+		if functional {
+			fmt.Fprintf(&body, "\n\n%s = TypedDict(\n    %q,\n    {\n", m.typeName, m.typeName)
+			for _, f := range fields {
+				if f.doc != "" {
+					fmt.Fprintf(&body, "        # %s\n", f.doc)
+				}
+				fmt.Fprintf(&body, "        %q: %s,\n", f.name, f.pyType)
+			}
+			fmt.Fprintf(&body, "    },\n)\n\"\"\"A record of the %s collection.\"\"\"\n", m.fullID)
+			continue
+		}
+		fmt.Fprintf(&body, "\n\nclass %s(TypedDict):\n    \"\"\"A record of the %s collection.\"\"\"\n\n", m.typeName, m.fullID)
+		for _, f := range fields {
+			fmt.Fprintf(&body, "    %s: %s\n", f.name, f.pyType)
+			if f.doc != "" {
+				fmt.Fprintf(&body, "    \"\"\"%s\"\"\"\n", f.doc)
+			}
+		}
+	}
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	fmt.Fprintf(w, "# %s\n\nfrom __future__ import annotations\n\nfrom typing import %s\n", codegenHeader, strings.Join(names, ", "))
+	w.Write(body.Bytes())
+}
+
+// pythonColumnType maps a column type to a Python annotation, recording the
+// typing names it needs in imports.
+func pythonColumnType(ct ingitdb.ColumnType, imports map[string]bool) string {
+	switch ct {
+	case ingitdb.ColumnTypeString, ingitdb.ColumnTypeDate, ingitdb.ColumnTypeTime, ingitdb.ColumnTypeDateTime:
+		return "str"
+	case ingitdb.ColumnTypeInt:
+		return "int"
+	case ingitdb.ColumnTypeFloat:
+		return "float"
+	case ingitdb.ColumnTypeBool:
+		return "bool"
+	}
+	if elem, ok := ingitdb.ListElementType(ct); ok {
+		return "list[" + pythonColumnType(elem, imports) + "]"
+	}
+	if valueType, ok := mapColumnValueType(ct); ok {
+		return "dict[str, " + pythonColumnType(valueType, imports) + "]"
+	}
+	imports["Any"] = true
+	return "Any"
+}
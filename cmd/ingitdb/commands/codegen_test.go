@@ -0,0 +1,207 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func codegenTestModels() []codegenModel {
+	return codegenModels([]exportedCollection{
+		{
+			fullID: "statuses",
+			col: &ingitdb.CollectionDef{
+				ColumnsOrder: []string{"title"},
+				Columns: map[string]*ingitdb.ColumnDef{
+					"title":  {Type: ingitdb.ColumnTypeL10N, Required: true},
+					"kind":   {Type: ingitdb.ColumnTypeString, Enum: []any{"open", "closed"}},
+					"rank":   {Type: ingitdb.ColumnTypeInt, Title: "Sort rank"},
+					"label":  {Type: ingitdb.ColumnTypeString, Formula: "kind.upper()"},
+					"$ID":    {Type: ingitdb.ColumnTypeString},
+					"max-no": {Type: "[]float"},
+				},
+			},
+		},
+		{
+			fullID: "statuses/events",
+			col: &ingitdb.CollectionDef{
+				PrimaryKey: []string{"seq"},
+				Columns: map[string]*ingitdb.ColumnDef{
+					"seq":   {Type: ingitdb.ColumnTypeInt, Required: true},
+					"owner": {Type: ingitdb.ColumnTypeString, ForeignKey: "users"},
+				},
+			},
+		},
+	})
+}
+
+func TestCodegen_TypeScript(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	emitTypeScriptModels(&b, codegenTestModels(), "")
+	want := `// Code generated by ingitdb codegen. DO NOT EDIT.
+
+/** ID of the statuses collection. */
+export const StatusCollection = "statuses";
+
+/** Key of a statuses record. */
+export type StatusKey = string;
+
+/** A record of the statuses collection. */
+export interface Status {
+  title: Record<string, string>;
+  kind?: "open" | "closed";
+  "max-no"?: number[];
+  /** Sort rank */
+  rank?: number;
+}
+
+/** ID of the statuses/events collection. */
+export const StatusEventCollection = "statuses/events";
+
+/** Key of a statuses/events record. */
+export type StatusEventKey = number;
+
+/** A record of the statuses/events collection. */
+export interface StatusEvent {
+  /** Key of a record in users. */
+  owner?: string;
+  seq: number;
+}
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestCodegen_Python(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	emitPythonModels(&b, codegenTestModels(), "")
+	want := `# Code generated by ingitdb codegen. DO NOT EDIT.
+
+from __future__ import annotations
+
+from typing import Literal, NotRequired, TypedDict
+
+
+StatusCollection = "statuses"
+"""ID of the statuses collection."""
+
+StatusKey = str
+"""Key of a statuses record."""
+
+
+Status = TypedDict(
+    "Status",
+    {
+        "title": dict[str, str],
+        "kind": NotRequired[Literal["open", "closed"]],
+        "max-no": NotRequired[list[float]],
+        # Sort rank
+        "rank": NotRequired[int],
+    },
+)
+"""A record of the statuses collection."""
+
+
+StatusEventCollection = "statuses/events"
+"""ID of the statuses/events collection."""
+
+StatusEventKey = int
+"""Key of a statuses/events record."""
+
+
+class StatusEvent(TypedDict):
+    """A record of the statuses/events collection."""
+
+    owner: NotRequired[str]
+    """Key of a record in users."""
+    seq: int
+`
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestCodegen_Go(t *testing.T) {
+	t.Parallel()
+	var b bytes.Buffer
+	emitGoModels(&b, codegenTestModels(), "models")
+	got := b.String()
+	for _, want := range []string{
+		"// Code generated by ingitdb codegen. DO NOT EDIT.\n\npackage models\n",
+		"\t\"github.com/dal-go/record\"\n",
+		"type StatusKey = string\n",
+		"type Status struct {\n" +
+			"\tTitle map[string]string `json:\"title\"`\n" +
+			"\t// One of: open, closed.\n" +
+			"\tKind  *string   `json:\"kind,omitempty\"`\n" +
+			"\tMaxNo []float64 `json:\"max-no,omitempty\"`\n" +
+			"\t// Sort rank\n" +
+			"\tRank *int `json:\"rank,omitempty\"`\n" +
+			"}\n",
+		"func GetStatus(ctx context.Context, db dal.DB, key StatusKey) (*Status, error) {\n",
+		"func SetStatus(ctx context.Context, db dal.DB, key StatusKey, v *Status) error {\n",
+		"type StatusEventKey = int\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("generated Go code lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "func GetStatusEvent") {
+		t.Errorf("subcollections must not get load/save helpers:\n%s", got)
+	}
+}
+
+func TestCodegenTypeName(t *testing.T) {
+	t.Parallel()
+	for fullID, want := range map[string]string{
+		"countries":         "Country",
+		"users":             "User",
+		"status":            "Status",
+		"addresses":         "Address",
+		"houses":            "House",
+		"statuses":          "Status",
+		"user_ids":          "UserID",
+		"countries/regions": "CountryRegion",
+		"2fa-codes":         "X2faCode",
+	} {
+		if got := codegenTypeName(fullID); got != want {
+			t.Errorf("codegenTypeName(%q) = %q, want %q", fullID, got, want)
+		}
+	}
+}
+
+func TestWriteCodegenOutput(t *testing.T) {
+	t.Parallel()
+	file := filepath.Join(t.TempDir(), "gen", "models.ts")
+	code := []byte("// generated\n")
+
+	err := writeCodegenOutput(nil, code, file, true)
+	if err == nil || !strings.Contains(err.Error(), "failed to read") {
+		t.Errorf("--check without the file must fail, got %v", err)
+	}
+	if err = writeCodegenOutput(nil, code, file, false); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err = writeCodegenOutput(nil, code, file, true); err != nil {
+		t.Errorf("--check on a fresh file: %v", err)
+	}
+	if err = os.WriteFile(file, []byte("// edited\n"), 0o644); err != nil {
+		t.Fatalf("edit: %v", err)
+	}
+	err = writeCodegenOutput(nil, code, file, true)
+	if err == nil || !strings.Contains(err.Error(), "is out of date") {
+		t.Errorf("--check on a stale file must fail, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err = writeCodegenOutput(&out, code, "", false); err != nil || out.String() != string(code) {
+		t.Errorf("stdout: %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,199 @@
+package commands
+
+// specscore: feature/subcollection-paths
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// collectionPathWildcard is the parent key segment that matches every
+// record of the parent collection.
+const collectionPathWildcard = "*"
+
+// collectionTarget is one collection instance a --from, --into or --id path
+// resolves to: a copy of the subcollection definition whose DirPath points
+// at one parent record's instance and whose ID is the concrete path, such
+// as customers/c1/orders.
+type collectionTarget struct {
+	col *ingitdb.CollectionDef
+	// parentFields holds a $<parent ID>_key pseudo-field for every parent
+	// key a wildcard segment matched.
+	parentFields map[string]any
+}
+
+// isSubCollectionPath reports whether a --from or --into value addresses a
+// subcollection (<collection>/<key>/<subcollection>) rather than a
+// top-level collection ID, which never contains a slash.
+func isSubCollectionPath(path string) bool {
+	return strings.Contains(path, "/")
+}
+
+// parentKeyField returns the pseudo-field a wildcard path adds to the rows
+// of a subcollection for the key of a parent record of collection parentID.
+func parentKeyField(parentID string) string {
+	return "$" + parentID + "_key"
+}
+
+// withParentFields returns data with the parent key pseudo-fields added,
+// leaving data itself untouched.
+func withParentFields(data, parentFields map[string]any) map[string]any {
+	if len(parentFields) == 0 {
+		return data
+	}
+	out := make(map[string]any, len(data)+len(parentFields))
+	maps.Copy(out, data)
+	maps.Copy(out, parentFields)
+	return out
+}
+
+// resolveCollectionPath resolves a subcollection path of the form
+// <collection>/<key>/<subcollection>[/<key>/<subcollection>...] to the
+// instances it addresses. A key segment of * matches every record of its
+// parent, in key order, and gives each instance the parent key as a
+// pseudo-field; any other key must name an existing parent record.
+func resolveCollectionPath(def *ingitdb.Definition, path string) ([]collectionTarget, error) {
+	segments := strings.Split(path, "/")
+	if len(segments) < 3 || len(segments)%2 == 0 {
+		return nil, fmt.Errorf("invalid collection path %q: expected <collection>/<key>/<subcollection>", path)
+	}
+	root, ok := def.Collections[segments[0]]
+	if !ok {
+		return nil, fmt.Errorf("collection %q not found in definition", segments[0])
+	}
+	targets := []collectionTarget{{col: root}}
+	for i := 1; i < len(segments); i += 2 {
+		key, subID := segments[i], segments[i+1]
+		if key == "" {
+			return nil, fmt.Errorf("invalid collection path %q: empty record key", path)
+		}
+		var next []collectionTarget
+		for _, parent := range targets {
+			sub, found := parent.col.SubCollections[subID]
+			if !found {
+				return nil, fmt.Errorf("collection %s has no subcollection %q", parent.col.ID, subID)
+			}
+			records, _, err := loadCollectionRecords(parent.col)
+			if err != nil {
+				return nil, err
+			}
+			keys := []string{key}
+			if key == collectionPathWildcard {
+				keys = slices.Sorted(maps.Keys(records))
+			} else if _, exists := records[key]; !exists {
+				return nil, fmt.Errorf("record %s/%s not found", parent.col.ID, key)
+			}
+			for _, parentKey := range keys {
+				inst := *sub // repoint DirPath at this record's instance without touching the definition
+				inst.ID = parent.col.ID + "/" + parentKey + "/" + subID
+				inst.DirPath = subCollectionInstanceDir(parent.col, parentKey, subID)
+				fields := maps.Clone(parent.parentFields)
+				if key == collectionPathWildcard {
+					if fields == nil {
+						fields = make(map[string]any)
+					}
+					fields[parentKeyField(segments[i-1])] = parentKey
+				}
+				next = append(next, collectionTarget{col: &inst, parentFields: fields})
+			}
+		}
+		targets = next
+	}
+	return targets, nil
+}
+
+// targetDefinition returns a shallow copy of def that also declares the
+// instance col under its path ID, so a database opened with it reads and
+// writes the records of that instance like those of any other collection.
+func targetDefinition(def *ingitdb.Definition, col *ingitdb.CollectionDef) *ingitdb.Definition {
+	out := *def
+	out.Collections = maps.Clone(def.Collections)
+	out.Collections[col.ID] = col
+	return &out
+}
+
+// resolveCollectionContexts resolves a --from value to the collections a
+// set-mode verb works on: the collection itself for a collection ID, every
+// matched instance for a subcollection path.
+func resolveCollectionContexts(
+	ctx context.Context,
+	cmd *cobra.Command,
+	path string,
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+) ([]insertContext, error) {
+	if !isSubCollectionPath(path) {
+		ictx, err := resolveInsertContext(ctx, cmd, path, homeDir, getWd, readDefinition, newDB)
+		if err != nil {
+			return nil, err
+		}
+		return []insertContext{ictx}, nil
+	}
+	return resolveSubCollectionContexts(cmd, path, homeDir, getWd, readDefinition, newDB)
+}
+
+// resolveSubCollectionContexts opens a database for every instance a
+// subcollection path matches. Subcollection paths address local databases
+// only.
+func resolveSubCollectionContexts(
+	cmd *cobra.Command,
+	path string,
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+) ([]insertContext, error) {
+	if remoteValue, _ := cmd.Flags().GetString("remote"); remoteValue != "" {
+		return nil, fmt.Errorf("subcollection path %q is not supported with --remote", path)
+	}
+	dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+	if err != nil {
+		return nil, err
+	}
+	def, err := readDefinition(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database definition: %w", err)
+	}
+	targets, err := resolveCollectionPath(def, path)
+	if err != nil {
+		return nil, err
+	}
+	ictxs := make([]insertContext, 0, len(targets))
+	for _, target := range targets {
+		targetDef := targetDefinition(def, target.col)
+		db, dbErr := newDB(dirPath, targetDef)
+		if dbErr != nil {
+			return nil, fmt.Errorf("failed to open database: %w", dbErr)
+		}
+		ictxs = append(ictxs, insertContext{
+			db:           db,
+			colDef:       target.col,
+			dirPath:      dirPath,
+			def:          targetDef,
+			parentFields: target.parentFields,
+		})
+	}
+	return ictxs, nil
+}
+
+// splitRecordPath splits an --id value that addresses a record of a
+// subcollection instance into the instance path and the record key. It
+// returns ok=false for <collection>/<key> IDs, which keep the
+// longest-prefix resolution of top-level collections.
+func splitRecordPath(id string) (collectionPath, key string, ok bool) {
+	i := strings.LastIndex(id, "/")
+	if i <= 0 || !isSubCollectionPath(id[:i]) {
+		return "", "", false
+	}
+	return id[:i], id[i+1:], true
+}
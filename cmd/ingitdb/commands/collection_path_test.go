@@ -0,0 +1,285 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dal-go/dalgo/dal"
+
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// collectionPathTestFiles is a customers collection with an orders
+// subcollection; customer c3 has no orders.
+var collectionPathTestFiles = map[string]string{
+	".ingitdb/root-collections.yaml": "customers: customers\n",
+	"customers/.collection/definition.yaml": `record_file:
+  name: "{key}.yaml"
+  format: yaml
+  type: map[string]any
+columns:
+  name:
+    type: string
+`,
+	"customers/.collection/subcollections/orders/definition.yaml": `record_file:
+  name: "{key}.yaml"
+  format: yaml
+  type: map[string]any
+columns:
+  total:
+    type: int
+`,
+	"customers/$records/c1.yaml":                    "name: Ann\n",
+	"customers/$records/c2.yaml":                    "name: Bob\n",
+	"customers/$records/c3.yaml":                    "name: Cid\n",
+	"customers/$records/c1/orders/$records/o1.yaml": "total: 10\n",
+	"customers/$records/c1/orders/$records/o2.yaml": "total: 25\n",
+	"customers/$records/c2/orders/$records/o1.yaml": "total: 40\n",
+}
+
+func writeCollectionPathTestDB(t *testing.T) (string, *ingitdb.Definition) {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range collectionPathTestFiles {
+		writeFixTestFile(t, dir, rel, content)
+	}
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	return dir, def
+}
+
+// collectionPathTestDeps returns a DI set for the record commands over the
+// database writeCollectionPathTestDB seeds.
+func collectionPathTestDeps(t *testing.T) (
+	dir string,
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDef func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) {
+	t.Helper()
+	dir, def := writeCollectionPathTestDB(t)
+	homeDir = func() (string, error) { return "/tmp/home", nil }
+	getWd = func() (string, error) { return dir, nil }
+	readDef = func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+	newDB = func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	logf = func(...any) {}
+	return
+}
+
+// orderFile returns the path of order key of customer customerKey.
+func orderFile(dir, customerKey, key string) string {
+	return filepath.Join(dir, "customers", "$records", customerKey, "orders", "$records", key+".yaml")
+}
+
+func TestResolveCollectionPath(t *testing.T) {
+	t.Parallel()
+	_, def := writeCollectionPathTestDB(t)
+	tests := []struct {
+		path    string
+		want    []string
+		parents []any
+		wantErr string
+	}{
+		{path: "customers/c1/orders", want: []string{"customers/c1/orders"}, parents: []any{nil}},
+		{path: "customers/*/orders", want: []string{"customers/c1/orders", "customers/c2/orders", "customers/c3/orders"}, parents: []any{"c1", "c2", "c3"}},
+		{path: "customers/c9/orders", wantErr: "record customers/c9 not found"},
+		{path: "customers/c1/invoices", wantErr: `has no subcollection "invoices"`},
+		{path: "customers/c1", wantErr: "expected <collection>/<key>/<subcollection>"},
+		{path: "customers//orders", wantErr: "empty record key"},
+		{path: "suppliers/s1/orders", wantErr: `collection "suppliers" not found`},
+	}
+	for _, tt := range tests {
+		targets, err := resolveCollectionPath(def, tt.path)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error %q, got %v", tt.path, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if len(targets) != len(tt.want) {
+			t.Errorf("%s: got %d targets, want %d", tt.path, len(targets), len(tt.want))
+			continue
+		}
+		for i, target := range targets {
+			if target.col.ID != tt.want[i] {
+				t.Errorf("%s: target %d is %s, want %s", tt.path, i, target.col.ID, tt.want[i])
+			}
+			if got := target.parentFields["$customers_key"]; got != tt.parents[i] {
+				t.Errorf("%s: target %d has $customers_key %v, want %v", tt.path, i, got, tt.parents[i])
+			}
+		}
+	}
+	if def.Collections["customers"].SubCollections["orders"].ID != "orders" {
+		t.Error("resolveCollectionPath changed the subcollection definition")
+	}
+}
+
+func TestSplitRecordPath(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		id       string
+		wantPath string
+		wantKey  string
+		wantOK   bool
+	}{
+		{id: "customers/c1/orders/o1", wantPath: "customers/c1/orders", wantKey: "o1", wantOK: true},
+		{id: "customers/c1", wantOK: false},
+		{id: "geo.nations/ie", wantOK: false},
+	}
+	for _, tt := range tests {
+		path, key, ok := splitRecordPath(tt.id)
+		if path != tt.wantPath || key != tt.wantKey || ok != tt.wantOK {
+			t.Errorf("splitRecordPath(%q) = %q, %q, %v; want %q, %q, %v", tt.id, path, key, ok, tt.wantPath, tt.wantKey, tt.wantOK)
+		}
+	}
+}
+
+func TestSelect_SubCollectionPath(t *testing.T) {
+	t.Parallel()
+	dir, def := writeCollectionPathTestDB(t)
+	homeDir := func() (string, error) { return "/tmp/home", nil }
+	getWd := func() (string, error) { return dir, nil }
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+	newDB := func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	logf := func(...any) {}
+
+	out, err := runSelectCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--from=customers/*/orders", "--where=total>=20", "--order-by=total", "--fields=$customers_key,$id,total")
+	if err != nil {
+		t.Fatalf("select wildcard: %v", err)
+	}
+	want := "$customers_key,$id,total\nc1,o2,25\nc2,o1,40\n"
+	if out != want {
+		t.Errorf("select wildcard:\ngot:\n%s\nwant:\n%s", out, want)
+	}
+
+	out, err = runSelectCmd(t, homeDir, getWd, readDef, newDB, logf, "--id=customers/c1/orders/o1", "--format=json")
+	if err != nil {
+		t.Fatalf("select --id: %v", err)
+	}
+	if !strings.Contains(out, `"total": 10`) {
+		t.Errorf("select --id = %s; want order o1 of c1", out)
+	}
+
+	if _, err = runSelectCmd(t, homeDir, getWd, readDef, newDB, logf, "--id=customers/*/orders/o1"); err == nil || !strings.Contains(err.Error(), "wildcard") {
+		t.Errorf("select --id with a wildcard: expected a wildcard error, got %v", err)
+	}
+}
+
+func TestDelete_SubCollectionPath(t *testing.T) {
+	t.Parallel()
+	dir, homeDir, getWd, readDef, newDB, logf := collectionPathTestDeps(t)
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--from=customers/*/orders", "--where=total>=20")
+	if err != nil {
+		t.Fatalf("delete wildcard: %v", err)
+	}
+	for _, tt := range []struct {
+		customer, key string
+		kept          bool
+	}{
+		{"c1", "o1", true},
+		{"c1", "o2", false},
+		{"c2", "o1", false},
+	} {
+		if _, statErr := os.Stat(orderFile(dir, tt.customer, tt.key)); (statErr == nil) != tt.kept {
+			t.Errorf("order %s of %s: kept=%v, want %v", tt.key, tt.customer, statErr == nil, tt.kept)
+		}
+	}
+}
+
+func TestDelete_SubCollectionPath_ParentKeyField(t *testing.T) {
+	t.Parallel()
+	dir, homeDir, getWd, readDef, newDB, logf := collectionPathTestDeps(t)
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--from=customers/*/orders", "--where=$customers_key==c2")
+	if err != nil {
+		t.Fatalf("delete by parent key: %v", err)
+	}
+	if _, statErr := os.Stat(orderFile(dir, "c2", "o1")); !os.IsNotExist(statErr) {
+		t.Errorf("order o1 of c2 must be deleted: %v", statErr)
+	}
+	for _, key := range []string{"o1", "o2"} {
+		if _, statErr := os.Stat(orderFile(dir, "c1", key)); statErr != nil {
+			t.Errorf("order %s of c1 must be kept: %v", key, statErr)
+		}
+	}
+}
+
+func TestUpdate_SubCollectionRecordID(t *testing.T) {
+	t.Parallel()
+	dir, homeDir, getWd, readDef, newDB, logf := collectionPathTestDeps(t)
+
+	if _, err := runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--id=customers/c1/orders/o1", "--set=total=11"); err != nil {
+		t.Fatalf("update --id: %v", err)
+	}
+	content, err := os.ReadFile(orderFile(dir, "c1", "o1"))
+	if err != nil || !strings.Contains(string(content), "total: 11") {
+		t.Errorf("order o1 of c1: %v\n%s", err, content)
+	}
+	if other, _ := os.ReadFile(orderFile(dir, "c2", "o1")); !strings.Contains(string(other), "total: 40") {
+		t.Errorf("order o1 of c2 must be untouched:\n%s", other)
+	}
+}
+
+func TestInsert_SubCollectionPath(t *testing.T) {
+	t.Parallel()
+	dir, homeDir, getWd, readDef, newDB, logf := collectionPathTestDeps(t)
+
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, false, nil,
+		"--into=customers/c1/orders", "--key=o3", "--data={total: 5}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	content, err := os.ReadFile(orderFile(dir, "c1", "o3"))
+	if err != nil || !strings.Contains(string(content), "total: 5") {
+		t.Errorf("order o3 of c1: %v\n%s", err, content)
+	}
+
+	_, err = runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, false, nil,
+		"--into=customers/*/orders", "--key=o4", "--data={total: 5}")
+	if err == nil || !strings.Contains(err.Error(), "wildcard") {
+		t.Errorf("insert into a wildcard path: expected an error, got %v", err)
+	}
+}
+
+func TestRecordCommands_MissingParentRecord(t *testing.T) {
+	t.Parallel()
+	_, homeDir, getWd, readDef, newDB, logf := collectionPathTestDeps(t)
+	const want = "record customers/c9 not found"
+
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, false, nil,
+		"--into=customers/c9/orders", "--key=o1", "--data={total: 5}")
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("insert: expected %q, got %v", want, err)
+	}
+	_, err = runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--id=customers/c9/orders/o1", "--set=total=1")
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("update: expected %q, got %v", want, err)
+	}
+	_, err = runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--from=customers/c9/orders", "--all")
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("delete: expected %q, got %v", want, err)
+	}
+}
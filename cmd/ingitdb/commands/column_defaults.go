@@ -0,0 +1,255 @@
+package commands
+
+// specscore: feature/column-defaults
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// Kinds of auto-managed columns a column can declare in the auto key of its
+// constraints.yaml entry.
+const (
+	autoCreatedAt = "created_at"
+	autoUpdatedAt = "updated_at"
+	autoCreatedBy = "created_by"
+	autoUpdatedBy = "updated_by"
+)
+
+// nowUTC returns the time auto-managed timestamp columns are set to.
+// Tests replace it; tests that do MUST NOT run in parallel.
+var nowUTC = func() time.Time { return time.Now().UTC() }
+
+// validateManaged checks the default and auto keys of column name.
+func (c columnConstraints) validateManaged(name string, colDef *ingitdb.ColumnDef) error {
+	if c.Auto == "" {
+		return nil
+	}
+	if c.Default != nil {
+		return fmt.Errorf("column %q: default is not allowed on an auto column", name)
+	}
+	var types []ingitdb.ColumnType
+	switch c.Auto {
+	case autoCreatedAt, autoUpdatedAt:
+		types = []ingitdb.ColumnType{ingitdb.ColumnTypeDateTime, ingitdb.ColumnTypeString, ingitdb.ColumnTypeAny}
+	case autoCreatedBy, autoUpdatedBy:
+		types = []ingitdb.ColumnType{ingitdb.ColumnTypeString, ingitdb.ColumnTypeAny}
+	default:
+		return fmt.Errorf("column %q: invalid auto %q (must be created_at, updated_at, created_by, or updated_by)", name, c.Auto)
+	}
+	if !slices.Contains(types, colDef.Type) {
+		return fmt.Errorf("column %q: auto %s requires a column of type %s, not %s", name, c.Auto, types[0], colDef.Type)
+	}
+	if colDef.Formula != "" {
+		return fmt.Errorf("column %q: auto is not allowed on a computed column", name)
+	}
+	return nil
+}
+
+// autoColumns returns the auto-managed columns of the collection keyed by
+// name, with their kind.
+func (c *collectionConstraints) autoColumns() map[string]string {
+	if c == nil {
+		return nil
+	}
+	auto := make(map[string]string)
+	for name, cc := range c.Columns {
+		if cc.Auto != "" {
+			auto[name] = cc.Auto
+		}
+	}
+	return auto
+}
+
+// applyColumnDefaults fills in the defaults and auto-managed columns of the
+// records insert and update are about to write. Inserted records get every
+// default and auto column they do not carry; updated records get their
+// updated_at and updated_by columns refreshed. Remote sources (empty
+// dirPath) are left alone: their constraints are not on disk.
+func applyColumnDefaults(ctx context.Context, dirPath string, col *ingitdb.CollectionDef, writes map[string]map[string]any, inserting bool) error {
+	if dirPath == "" || len(writes) == 0 {
+		return nil
+	}
+	cons, err := readCollectionConstraints(col)
+	if err != nil || cons == nil {
+		return err
+	}
+	now := nowUTC().Format(time.RFC3339)
+	author := ""
+	for _, name := range slices.Sorted(maps.Keys(cons.Columns)) {
+		cc := cons.Columns[name]
+		if cc.Default == nil && cc.Auto == "" {
+			continue
+		}
+		if !inserting && cc.Auto != autoUpdatedAt && cc.Auto != autoUpdatedBy {
+			continue
+		}
+		for _, data := range writes {
+			if inserting && data[name] != nil {
+				continue
+			}
+			var value any
+			switch cc.Auto {
+			case "":
+				value = cc.Default
+			case autoCreatedAt, autoUpdatedAt:
+				value = now
+			case autoCreatedBy, autoUpdatedBy:
+				if author == "" {
+					if author, err = gitAuthor(ctx, dirPath); err != nil {
+						return fmt.Errorf("column %q: %w", name, err)
+					}
+				}
+				value = author
+			}
+			data[name] = value
+		}
+	}
+	return nil
+}
+
+// gitAuthor returns the git author identity, "Name <email>", that commits
+// made in dirPath would carry.
+func gitAuthor(ctx context.Context, dirPath string) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dirPath, "var", "GIT_AUTHOR_IDENT").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the git author identity: %w", err)
+	}
+	// The identity ends with the timestamp and time zone of the commit.
+	ident := strings.TrimSpace(string(out))
+	end := strings.LastIndex(ident, ">")
+	if end < 0 {
+		return "", fmt.Errorf("unexpected git author identity %q", ident)
+	}
+	return ident[:end+1], nil
+}
+
+// mergeWithAutoColumns runs the three-way merge of a conflicted record file
+// with the auto-managed columns of col taken out, so that two branches
+// touching the same record do not conflict over its timestamps and authors,
+// and then puts them back: created_* columns keep their original value and
+// updated_* columns take the values of the side updated last.
+func mergeWithAutoColumns(base, ours, theirs []byte, col *ingitdb.CollectionDef, opts recordmerge.Options, auto map[string]string) recordmerge.Outcome {
+	var sides [3][]recordmerge.Record
+	for i, content := range [][]byte{base, ours, theirs} {
+		records, err := parseMergeStage(content, col)
+		if err != nil {
+			return recordmerge.Outcome{Escalate: true, Reason: fmt.Sprintf("failed to parse a conflict side: %v", err)}
+		}
+		sides[i] = records
+	}
+	stripped := func(records []recordmerge.Record) []recordmerge.Record {
+		out := make([]recordmerge.Record, len(records))
+		for i, r := range records {
+			fields := maps.Clone(r.Fields)
+			for name := range auto {
+				delete(fields, name)
+			}
+			out[i] = recordmerge.Record{Key: r.Key, Fields: fields}
+		}
+		return out
+	}
+	outcome := recordmerge.Merge(stripped(sides[0]), stripped(sides[1]), stripped(sides[2]), opts)
+	if outcome.Escalate {
+		return outcome
+	}
+	baseIdx, oursIdx, theirsIdx := indexMergeRecords(sides[0]), indexMergeRecords(sides[1]), indexMergeRecords(sides[2])
+	for _, r := range outcome.Merged {
+		b, o, t := baseIdx[r.Key], oursIdx[r.Key], theirsIdx[r.Key]
+		// The side updated last is the one whose updated_at is greater;
+		// ours when they tie or the collection has no updated_at column.
+		latest := o
+		if o == nil || (t != nil && updatedAt(t, auto) > updatedAt(o, auto)) {
+			latest = t
+		}
+		for name, kind := range auto {
+			var v any
+			switch kind {
+			case autoCreatedAt, autoCreatedBy:
+				for _, side := range []map[string]any{b, o, t} {
+					if v = side[name]; v != nil {
+						break
+					}
+				}
+			default:
+				v = latest[name]
+			}
+			if v != nil {
+				r.Fields[name] = v
+			}
+		}
+	}
+	return outcome
+}
+
+// updatedAt returns the value of the updated_at column of fields as text.
+func updatedAt(fields map[string]any, auto map[string]string) string {
+	for name, kind := range auto {
+		if kind == autoUpdatedAt && fields[name] != nil {
+			return fmt.Sprint(fields[name])
+		}
+	}
+	return ""
+}
+
+// parseMergeStage parses one stage of a conflicted record file into its
+// records, in file order. A single record is keyed by the empty string, as
+// in recordmerge.
+func parseMergeStage(content []byte, col *ingitdb.CollectionDef) ([]recordmerge.Record, error) {
+	if len(content) == 0 {
+		return nil, nil
+	}
+	if col.RecordFile == nil {
+		return nil, fmt.Errorf("collection has no record-file definition")
+	}
+	switch col.RecordFile.RecordType {
+	case ingitdb.SingleRecord:
+		data, err := ingitdb.ParseRecordContentForCollection(content, col)
+		if err != nil {
+			return nil, err
+		}
+		return []recordmerge.Record{{Fields: data}}, nil
+	case ingitdb.MapOfRecords:
+		m, err := ingitdb.ParseMapOfRecordsContent(content, col.RecordFile.Format)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]recordmerge.Record, 0, len(m))
+		for _, key := range sortedRecordKeys(m) {
+			records = append(records, recordmerge.Record{Key: key, Fields: m[key]})
+		}
+		return records, nil
+	case ingitdb.ListOfRecords:
+		keys, rows, err := readListRecords(content, col)
+		if err != nil {
+			return nil, err
+		}
+		records := make([]recordmerge.Record, len(rows))
+		for i, row := range rows {
+			if keys[i] == "" {
+				return nil, fmt.Errorf("row %d has no record key", i+1)
+			}
+			records[i] = recordmerge.Record{Key: keys[i], Fields: row}
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("record layout %q is not auto-mergeable yet", col.RecordFile.RecordType)
+	}
+}
+
+// indexMergeRecords maps the records of a conflict side by key.
+func indexMergeRecords(records []recordmerge.Record) map[string]map[string]any {
+	idx := make(map[string]map[string]any, len(records))
+	for _, r := range records {
+		idx[r.Key] = r.Fields
+	}
+	return idx
+}
@@ -0,0 +1,171 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// managedTestConstraints gives test.items a default status and the four
+// auto-managed columns.
+const managedTestConstraints = `columns:
+  status:
+    default: draft
+  created_at:
+    auto: created_at
+  updated_at:
+    auto: updated_at
+  created_by:
+    auto: created_by
+  updated_by:
+    auto: updated_by
+`
+
+// managedTestDef is testDef with the columns of managedTestConstraints.
+func managedTestDef(dir string) *ingitdb.Definition {
+	def := testDef(dir)
+	cols := def.Collections["test.items"].Columns
+	cols["status"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	cols["created_at"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeDateTime}
+	cols["updated_at"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeDateTime}
+	cols["created_by"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	cols["updated_by"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	return def
+}
+
+// setupManagedTestDB creates a git repository at a temp dir holding the
+// managedTestDef database and stubs the auto-managed timestamp with at.
+func setupManagedTestDB(t *testing.T, at string) string {
+	t.Helper()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", managedTestConstraints)
+	now, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		t.Fatalf("parse %s: %v", at, err)
+	}
+	prev := nowUTC
+	nowUTC = func() time.Time { return now }
+	t.Cleanup(func() { nowUTC = prev })
+	return dir
+}
+
+// unquoteYAML drops the quotes around YAML scalars, so assertions do not
+// depend on which timestamps and identities the encoder quotes.
+func unquoteYAML(s string) string {
+	return strings.NewReplacer(`"`, "", "'", "").Replace(s)
+}
+
+func managedTestReadDef(dir string) func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+	def := managedTestDef(dir)
+	return func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+}
+
+func managedTestNewDB(root string, d *ingitdb.Definition) (dal.DB, error) {
+	return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+}
+
+// TestInsert_AppliesDefaultsAndAutoColumns replaces nowUTC, so it does not
+// run in parallel.
+func TestInsert_AppliesDefaultsAndAutoColumns(t *testing.T) {
+	dir := setupManagedTestDB(t, "2026-03-01T10:00:00Z")
+	homeDir, getWd, _, _, logf := insertTestDeps(t, dir)
+
+	_, err := runInsertCmd(t, homeDir, getWd, managedTestReadDef(dir), managedTestNewDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--key=a", "--data={name: A, status: live}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	stdin := strings.NewReader("{\"$id\":\"b\",\"name\":\"B\",\"created_at\":\"2020-01-01T00:00:00Z\"}\n")
+	_, err = runInsertCmd(t, homeDir, getWd, managedTestReadDef(dir), managedTestNewDB, logf, stdin, false, nil,
+		"--path="+dir, "--into=test.items", "--format=jsonl")
+	if err != nil {
+		t.Fatalf("batch insert: %v", err)
+	}
+
+	for key, want := range map[string][]string{
+		"a": {"status: live", "created_at: 2026-03-01T10:00:00Z", "updated_at: 2026-03-01T10:00:00Z", "created_by: Test User <test@example.com>"},
+		"b": {"status: draft", "created_at: 2020-01-01T00:00:00Z", "updated_by: Test User <test@example.com>"},
+	} {
+		got := unquoteYAML(readItem(t, dir, key))
+		for _, w := range want {
+			if !strings.Contains(got, w) {
+				t.Errorf("record %s lacks %q:\n%s", key, w, got)
+			}
+		}
+	}
+}
+
+// TestUpdate_RefreshesUpdatedColumns replaces nowUTC, so it does not run in
+// parallel.
+func TestUpdate_RefreshesUpdatedColumns(t *testing.T) {
+	dir := setupManagedTestDB(t, "2026-03-02T08:30:00Z")
+	homeDir, getWd, _, _, logf := updateTestDeps(t, dir)
+	writeFixTestFile(t, dir, "$records/a.yaml",
+		"name: A\nstatus: live\ncreated_at: \"2020-01-01T00:00:00Z\"\nupdated_at: \"2020-01-01T00:00:00Z\"\nupdated_by: Someone <s@example.com>\n")
+
+	_, err := runUpdateCmd(t, homeDir, getWd, managedTestReadDef(dir), managedTestNewDB, logf,
+		"--path="+dir, "--id=test.items/a", "--set=name=Alpha")
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got := unquoteYAML(readItem(t, dir, "a"))
+	for _, w := range []string{"name: Alpha", "status: live", "created_at: 2020-01-01T00:00:00Z", "updated_at: 2026-03-02T08:30:00Z", "updated_by: Test User <test@example.com>"} {
+		if !strings.Contains(got, w) {
+			t.Errorf("record lacks %q:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, "created_by") {
+		t.Errorf("update must not add created_by:\n%s", got)
+	}
+}
+
+func TestMergeAndSerialize_AutoColumnsDoNotConflict(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", managedTestConstraints)
+	col := managedTestDef(dir).Collections["test.items"]
+	base := []byte("name: A\nstatus: draft\ncreated_at: \"2020-01-01T00:00:00Z\"\nupdated_at: \"2020-01-01T00:00:00Z\"\nupdated_by: Ann <a@example.com>\n")
+	ours := []byte("name: Alpha\nstatus: draft\ncreated_at: \"2020-01-01T00:00:00Z\"\nupdated_at: \"2026-03-01T00:00:00Z\"\nupdated_by: Bob <b@example.com>\n")
+	theirs := []byte("name: A\nstatus: live\ncreated_at: \"2020-01-01T00:00:00Z\"\nupdated_at: \"2026-03-02T00:00:00Z\"\nupdated_by: Cat <c@example.com>\n")
+
+	merged, ok := mergeAndSerialize(base, ours, theirs, col, recordmerge.Options{SameRecord: true})
+	if !ok {
+		t.Fatal("expected the merge to succeed")
+	}
+	for _, w := range []string{"name: Alpha", "status: live", "created_at: 2020-01-01T00:00:00Z", "updated_at: 2026-03-02T00:00:00Z", "updated_by: Cat <c@example.com>"} {
+		if !strings.Contains(unquoteYAML(string(merged)), w) {
+			t.Errorf("merged record lacks %q:\n%s", w, merged)
+		}
+	}
+
+	// A field both sides changed differently still escalates.
+	theirs = []byte("name: Beta\nstatus: draft\nupdated_at: \"2026-03-02T00:00:00Z\"\n")
+	if _, ok = mergeAndSerialize(base, ours, theirs, col, recordmerge.Options{SameRecord: true}); ok {
+		t.Error("expected a contested name to escalate")
+	}
+}
+
+func TestReadCollectionConstraints_InvalidAutoColumn(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct{ name, content, want string }{
+		{"unknown kind", "columns:\n  created_at:\n    auto: deleted_at\n", `invalid auto "deleted_at"`},
+		{"default on auto column", "columns:\n  created_at:\n    auto: created_at\n    default: now\n", "default is not allowed on an auto column"},
+		{"author in datetime column", "columns:\n  created_at:\n    auto: updated_by\n", "requires a column of type string"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeFixTestFile(t, dir, ".collection/constraints.yaml", tc.content)
+			_, err := readCollectionConstraints(managedTestDef(dir).Collections["test.items"])
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
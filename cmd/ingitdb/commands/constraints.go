@@ -0,0 +1,402 @@
+package commands
+
+// specscore: feature/unique-constraints
+// specscore: feature/foreign-key-enforcement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// collectionConstraintsFileName is the CLI-level schema sidecar that sits
+// next to a collection's definition.yaml. The library decodes definition.yaml
+// strictly (unknown keys are an error), so constraints the library does not
+// model are declared here instead.
+const collectionConstraintsFileName = "constraints.yaml"
+
+// uniqueViolationPrefix starts every unique-constraint message; the validate
+// report classifies findings by it.
+const uniqueViolationPrefix = "duplicate value for unique key"
+
+// collectionConstraints is the content of a constraints.yaml file.
+type collectionConstraints struct {
+	// Columns holds per-column constraints keyed by column name.
+	Columns map[string]columnConstraints `yaml:"columns,omitempty"`
+
+	// UniqueKeys lists composite unique keys: no two records may share the
+	// same combination of values in the listed columns.
+	UniqueKeys [][]string `yaml:"unique_keys,omitempty"`
+
+	// Key declares how insert generates the key of a record that comes
+	// without one.
+	Key *keyGeneration `yaml:"key,omitempty"`
+}
+
+// columnConstraints holds the constraints of a single column.
+type columnConstraints struct {
+	// Unique rejects two records holding the same value in the column.
+	Unique bool `yaml:"unique,omitempty"`
+
+	// OnDelete is the referential action for a foreign_key column when the
+	// referenced record is deleted: restrict (the default), cascade, or
+	// set_null.
+	OnDelete string `yaml:"on_delete,omitempty"`
+
+	// Default is the value insert gives the column when a record comes
+	// without one.
+	Default any `yaml:"default,omitempty"`
+
+	// Auto makes the column auto-managed: created_at and updated_at hold
+	// timestamps, created_by and updated_by the git author identity.
+	Auto string `yaml:"auto,omitempty"`
+}
+
+// Referential actions a foreign_key column can declare in on_delete.
+const (
+	onDeleteRestrict = "restrict"
+	onDeleteCascade  = "cascade"
+	onDeleteSetNull  = "set_null"
+)
+
+// onDelete returns the referential action of column, restrict when none is
+// declared.
+func (c *collectionConstraints) onDelete(column string) string {
+	if c == nil || c.Columns[column].OnDelete == "" {
+		return onDeleteRestrict
+	}
+	return c.Columns[column].OnDelete
+}
+
+// uniqueKeys returns every unique key of the collection: the unique columns
+// in alphabetical order, then the composite keys in declaration order.
+func (c *collectionConstraints) uniqueKeys() [][]string {
+	if c == nil {
+		return nil
+	}
+	var keys [][]string
+	for _, name := range slices.Sorted(maps.Keys(c.Columns)) {
+		if c.Columns[name].Unique {
+			keys = append(keys, []string{name})
+		}
+	}
+	return append(keys, c.UniqueKeys...)
+}
+
+// collectionSchemaDir returns the directory holding a collection's
+// definition.yaml: the collection directory itself in the shared layout, the
+// .collection/ directory otherwise (see collectionViewsDir).
+func collectionSchemaDir(colDir string) string {
+	if _, err := os.Stat(filepath.Join(colDir, ingitdb.CollectionDefFileName)); err == nil {
+		return colDir
+	}
+	return filepath.Join(colDir, ingitdb.SchemaDir)
+}
+
+// readCollectionConstraints reads the constraints.yaml of col. It returns nil
+// when the collection declares no constraints. Unknown keys and references to
+// undeclared columns are errors, like in definition.yaml.
+func readCollectionConstraints(col *ingitdb.CollectionDef) (*collectionConstraints, error) {
+	if col == nil || col.DirPath == "" {
+		return nil, nil
+	}
+	path := filepath.Join(collectionSchemaDir(col.DirPath), collectionConstraintsFileName)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints of collection %s: %w", col.ID, err)
+	}
+	cons := new(collectionConstraints)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err = dec.Decode(cons); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err = cons.validate(col); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return cons, nil
+}
+
+// validate checks that every constrained column is declared by col, that
+// on_delete is only declared, with a known action, on foreign_key columns,
+// that auto columns have a known kind and a fitting type, and that the key
+// generator is valid.
+func (c *collectionConstraints) validate(col *ingitdb.CollectionDef) error {
+	if c.Key != nil {
+		if err := c.Key.validate(col); err != nil {
+			return err
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Columns)) {
+		colDef, ok := col.Columns[name]
+		if !ok {
+			return fmt.Errorf("column %q is not declared in collection %s", name, col.ID)
+		}
+		if err := c.Columns[name].validateManaged(name, colDef); err != nil {
+			return err
+		}
+		switch c.Columns[name].OnDelete {
+		case "":
+			continue
+		case onDeleteRestrict, onDeleteCascade, onDeleteSetNull:
+		default:
+			return fmt.Errorf("column %q: invalid on_delete %q (must be restrict, cascade, or set_null)", name, c.Columns[name].OnDelete)
+		}
+		if colDef.ForeignKey == "" {
+			return fmt.Errorf("column %q: on_delete requires a foreign_key column", name)
+		}
+		if c.Columns[name].OnDelete == onDeleteSetNull && colDef.Required {
+			return fmt.Errorf("column %q: on_delete set_null is not allowed on a required column", name)
+		}
+	}
+	for i, key := range c.UniqueKeys {
+		if len(key) == 0 {
+			return fmt.Errorf("unique_keys[%d] lists no columns", i)
+		}
+		seen := make(map[string]bool, len(key))
+		for _, name := range key {
+			if _, ok := col.Columns[name]; !ok {
+				return fmt.Errorf("unique_keys[%d]: column %q is not declared in collection %s", i, name, col.ID)
+			}
+			if seen[name] {
+				return fmt.Errorf("unique_keys[%d]: column %q is listed twice", i, name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// uniqueTuple encodes the values of columns in data. ok is false when any of
+// the values is missing, nil, or empty: like SQL NULLs, such records never
+// collide.
+func uniqueTuple(data map[string]any, columns []string) (tuple string, ok bool) {
+	parts := make([]string, len(columns))
+	for i, name := range columns {
+		v, present := data[name]
+		if !present || v == nil || v == "" {
+			return "", false
+		}
+		parts[i] = fmt.Sprintf("%T:%v", normalizeUniqueValue(v), v)
+	}
+	return strings.Join(parts, "\x00"), true
+}
+
+// normalizeUniqueValue maps the numeric types the record decoders produce to
+// one type so 1 in a JSON file equals 1 in a YAML file.
+func normalizeUniqueValue(v any) any {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	}
+	return v
+}
+
+// describeUniqueKey renders a unique key as "email" or "(first, last)".
+func describeUniqueKey(columns []string) string {
+	if len(columns) == 1 {
+		return columns[0]
+	}
+	return "(" + strings.Join(columns, ", ") + ")"
+}
+
+// describeUniqueValues renders the values of a unique key as
+// `first="Ann", last="Lee"`.
+func describeUniqueValues(data map[string]any, columns []string) string {
+	parts := make([]string, len(columns))
+	for i, name := range columns {
+		if s, ok := data[name].(string); ok {
+			parts[i] = fmt.Sprintf("%s=%q", name, s)
+		} else {
+			parts[i] = fmt.Sprintf("%s=%v", name, data[name])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// uniqueViolationMessage describes a record whose unique key values are
+// already held by the record other.
+func uniqueViolationMessage(data map[string]any, columns []string, other string) string {
+	return fmt.Sprintf("%s %s: %s is already used by record %q",
+		uniqueViolationPrefix, describeUniqueKey(columns), describeUniqueValues(data, columns), other)
+}
+
+// indexUniqueKey maps each value tuple of columns to the keys of the records
+// holding it, in key order.
+func indexUniqueKey(records map[string]map[string]any, columns []string) map[string][]string {
+	index := make(map[string][]string)
+	for _, key := range slices.Sorted(maps.Keys(records)) {
+		if tuple, ok := uniqueTuple(records[key], columns); ok {
+			index[tuple] = append(index[tuple], key)
+		}
+	}
+	return index
+}
+
+// loadCollectionRecords reads every record of col from disk, keyed by record
+// key, together with the file each record lives in. Unparseable files are
+// skipped; record validation reports them.
+func loadCollectionRecords(col *ingitdb.CollectionDef) (records map[string]map[string]any, files map[string]string, err error) {
+	paths, err := collectionRecordFiles(col)
+	if err != nil {
+		return nil, nil, err
+	}
+	records = make(map[string]map[string]any)
+	files = make(map[string]string)
+	for _, path := range paths {
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+		for key, data := range parseKeyedRecords(content, col, path) {
+			records[key] = data
+			files[key] = path
+		}
+	}
+	return records, files, nil
+}
+
+// validateUniqueConstraints checks the records of every collection in def
+// that declares unique keys. For each group of records sharing a key's
+// values, every record but the first (in key order) is reported.
+func validateUniqueConstraints(def *ingitdb.Definition) ([]ingitdb.ValidationError, error) {
+	cols := eachCollection(def.Collections)
+	sort.Slice(cols, func(i, j int) bool { return cols[i].DirPath < cols[j].DirPath })
+	var violations []ingitdb.ValidationError
+	for _, col := range cols {
+		cons, err := readCollectionConstraints(col)
+		if err != nil {
+			return nil, err
+		}
+		keys := cons.uniqueKeys()
+		if len(keys) == 0 {
+			continue
+		}
+		records, files, err := loadCollectionRecords(col)
+		if err != nil {
+			return nil, err
+		}
+		for _, columns := range keys {
+			index := indexUniqueKey(records, columns)
+			for _, tuple := range slices.Sorted(maps.Keys(index)) {
+				holders := index[tuple]
+				for _, key := range holders[1:] {
+					violations = append(violations, ingitdb.ValidationError{
+						Severity:     ingitdb.SeverityError,
+						CollectionID: col.ID,
+						FilePath:     files[key],
+						RecordKey:    key,
+						FieldName:    columns[0],
+						Message:      uniqueViolationMessage(records[key], columns, holders[0]),
+					})
+				}
+			}
+		}
+	}
+	return violations, nil
+}
+
+// writeChecker runs the write-time integrity checks of one command. It loads
+// the stored records of each collection once, however many writes it checks,
+// so every check must run before the command writes anything.
+type writeChecker struct {
+	records map[string]map[string]map[string]any
+}
+
+func newWriteChecker() *writeChecker {
+	return &writeChecker{records: make(map[string]map[string]map[string]any)}
+}
+
+// collectionRecords returns the stored records of col, keyed by record key.
+// The map is shared between checks and must not be modified.
+func (c *writeChecker) collectionRecords(col *ingitdb.CollectionDef) (map[string]map[string]any, error) {
+	if records, ok := c.records[col.DirPath]; ok {
+		return records, nil
+	}
+	records, _, err := loadCollectionRecords(col)
+	if err != nil {
+		return nil, err
+	}
+	c.records[col.DirPath] = records
+	return records, nil
+}
+
+// checkUnique verifies that writing the records in writes (keyed by record
+// key, replacing any stored record with the same key) keeps every unique key
+// of col unique. It reads the stored records from disk, so it is only
+// meaningful for local databases.
+func (c *writeChecker) checkUnique(col *ingitdb.CollectionDef, writes map[string]map[string]any) error {
+	cons, err := readCollectionConstraints(col)
+	if err != nil {
+		return err
+	}
+	keys := cons.uniqueKeys()
+	if len(keys) == 0 || len(writes) == 0 {
+		return nil
+	}
+	stored, err := c.collectionRecords(col)
+	if err != nil {
+		return err
+	}
+	records := maps.Clone(stored)
+	if records == nil {
+		records = make(map[string]map[string]any, len(writes))
+	}
+	for key, data := range writes {
+		records[key] = data
+	}
+	for _, columns := range keys {
+		index := indexUniqueKey(records, columns)
+		for _, key := range slices.Sorted(maps.Keys(writes)) {
+			tuple, ok := uniqueTuple(writes[key], columns)
+			if !ok {
+				continue
+			}
+			for _, other := range index[tuple] {
+				if other != key {
+					return fmt.Errorf("record %q in collection %s: %s", key, col.ID, uniqueViolationMessage(writes[key], columns, other))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// check runs the write-time integrity checks — foreign keys, then unique
+// keys — on the records insert and update are about to write. Remote sources
+// (empty dirPath) are not checked: their stored records are not on disk.
+func (c *writeChecker) check(dirPath string, def *ingitdb.Definition, col *ingitdb.CollectionDef, writes map[string]map[string]any) error {
+	if dirPath == "" {
+		return nil
+	}
+	if err := c.checkForeignKeys(def, col, writes); err != nil {
+		return err
+	}
+	return c.checkUnique(col, writes)
+}
+
+// checkLocalWrite runs the write-time integrity checks of a command that
+// writes a single batch of records.
+func checkLocalWrite(dirPath string, def *ingitdb.Definition, col *ingitdb.CollectionDef, writes map[string]map[string]any) error {
+	return newWriteChecker().check(dirPath, def, col, writes)
+}
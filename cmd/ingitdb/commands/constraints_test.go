@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// uniqueTestConstraints makes the cities name unique and (population, area)
+// a composite unique key.
+const uniqueTestConstraints = "columns:\n  name:\n    unique: true\nunique_keys:\n  - [population, area]\n"
+
+// runValidateUnique runs validate against the fixTestDefinition database at
+// dir with a record validator that finds nothing.
+func runValidateUnique(t *testing.T, dir string, args ...string) (*bytes.Buffer, error) {
+	t.Helper()
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return fixTestDefinition(p), nil },
+		&mockDataValidator{result: &ingitdb.ValidationResult{}}, nil, func(...any) {},
+	)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	err := runCobraCommand(cmd, append([]string{"--path=" + dir}, args...)...)
+	return &out, err
+}
+
+func TestValidate_UniqueConstraints(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", uniqueTestConstraints)
+	writeFixTestFile(t, dir, "cities/$records/a.yaml", "name: Oslo\npopulation: 1\narea: 2\n")
+	writeFixTestFile(t, dir, "cities/$records/b.yaml", "name: Oslo\n")
+	writeFixTestFile(t, dir, "cities/$records/c.yaml", "name: Bergen\npopulation: 1\narea: 2.0\n")
+	writeFixTestFile(t, dir, "cities/$records/d.yaml", "name: \"\"\npopulation: 1\n")
+	writeFixTestFile(t, dir, "cities/$records/e.yaml", "name: \"\"\npopulation: 1\n")
+
+	out, err := runValidateUnique(t, dir, "--format=json")
+	if err == nil || !strings.Contains(err.Error(), "2 error(s)") {
+		t.Fatalf("expected 2 unique violations, got %v\n%s", err, out.String())
+	}
+	var doc struct {
+		Errors []validationFinding `json:"errors"`
+	}
+	if err = json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	want := []validationFinding{
+		{RuleID: "unique", Severity: "error", Collection: "cities", RecordKey: "b", File: "cities/$records/b.yaml", Line: 1, Column: 1, Field: "name",
+			Message: `duplicate value for unique key name: name="Oslo" is already used by record "a"`},
+		{RuleID: "unique", Severity: "error", Collection: "cities", RecordKey: "c", File: "cities/$records/c.yaml", Line: 2, Column: 1, Field: "population",
+			Message: `duplicate value for unique key (population, area): population=1, area=2 is already used by record "a"`},
+	}
+	if len(doc.Errors) != len(want) {
+		t.Fatalf("got %d findings, want %d: %+v", len(doc.Errors), len(want), doc.Errors)
+	}
+	for i := range want {
+		if doc.Errors[i] != want[i] {
+			t.Errorf("finding %d:\n got %+v\nwant %+v", i, doc.Errors[i], want[i])
+		}
+	}
+}
+
+func TestValidate_UniqueConstraintsText(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", uniqueTestConstraints)
+	writeFixTestFile(t, dir, "cities/$records/a.yaml", "name: Oslo\n")
+	writeFixTestFile(t, dir, "cities/$records/b.yaml", "name: Oslo\n")
+	_, err := runValidateUnique(t, dir)
+	if err == nil || !strings.Contains(err.Error(), `name="Oslo" is already used by record "a"`) {
+		t.Fatalf("expected a unique violation, got %v", err)
+	}
+}
+
+func TestCheckUniqueOnWrite(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", uniqueTestConstraints)
+	writeFixTestFile(t, dir, "cities/$records/oslo.yaml", "name: Oslo\npopulation: 709037\narea: 454\n")
+	col := fixTestDefinition(dir).Collections["cities"]
+
+	cases := []struct {
+		name   string
+		writes map[string]map[string]any
+		want   string
+	}{
+		{"new value", map[string]map[string]any{"bergen": {"name": "Bergen"}}, ""},
+		{"taken value", map[string]map[string]any{"oslo2": {"name": "Oslo"}},
+			`record "oslo2" in collection cities: duplicate value for unique key name: name="Oslo" is already used by record "oslo"`},
+		{"rewrite own record", map[string]map[string]any{"oslo": {"name": "Oslo", "population": 710000}}, ""},
+		{"taken composite", map[string]map[string]any{"x": {"name": "X", "population": 709037, "area": 454.0}},
+			`duplicate value for unique key (population, area): population=709037, area=454 is already used by record "oslo"`},
+		{"partial composite", map[string]map[string]any{"y": {"name": "Y", "population": 709037}}, ""},
+		{"empty value", map[string]map[string]any{"z": {"name": ""}}, ""},
+		{"clash within the writes", map[string]map[string]any{"p": {"name": "P"}, "q": {"name": "P"}},
+			`record "p" in collection cities: duplicate value for unique key name: name="P" is already used by record "q"`},
+	}
+	for _, tc := range cases {
+		err := newWriteChecker().checkUnique(col, tc.writes)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestWriteChecker_LoadsRecordsOnce(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", uniqueTestConstraints)
+	writeFixTestFile(t, dir, "cities/$records/oslo.yaml", "name: Oslo\n")
+	col := fixTestDefinition(dir).Collections["cities"]
+
+	checker := newWriteChecker()
+	if err := checker.checkUnique(col, map[string]map[string]any{"bergen": {"name": "Bergen"}}); err != nil {
+		t.Fatalf("first check: %v", err)
+	}
+	if _, leaked := checker.records[col.DirPath]["bergen"]; leaked {
+		t.Error("checked writes must not leak into the loaded records")
+	}
+	// A record stored after the first check is not seen by the same checker.
+	writeFixTestFile(t, dir, "cities/$records/trondheim.yaml", "name: Trondheim\n")
+	writes := map[string]map[string]any{"x": {"name": "Trondheim"}}
+	if err := checker.checkUnique(col, writes); err != nil {
+		t.Errorf("the checker reloaded the records: %v", err)
+	}
+	if err := newWriteChecker().checkUnique(col, writes); err == nil {
+		t.Error("a new checker must see the stored record")
+	}
+}
+
+func TestReadCollectionConstraints(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "columns:\n  name:\n    uniq: true\n", "field uniq not found"},
+		{"undeclared column", "columns:\n  email:\n    unique: true\n", `column "email" is not declared`},
+		{"empty unique key", "unique_keys:\n  - []\n", "unique_keys[0] lists no columns"},
+		{"repeated column", "unique_keys:\n  - [name, name]\n", `column "name" is listed twice`},
+	}
+	for _, tc := range cases {
+		dir := t.TempDir()
+		writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", tc.content)
+		_, err := readCollectionConstraints(fixTestDefinition(dir).Collections["cities"])
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	// The shared layout keeps constraints.yaml next to a definition.yaml in
+	// the collection directory.
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/definition.yaml", "")
+	writeFixTestFile(t, dir, "cities/constraints.yaml", uniqueTestConstraints)
+	cons, err := readCollectionConstraints(fixTestDefinition(dir).Collections["cities"])
+	if err != nil {
+		t.Fatalf("read constraints: %v", err)
+	}
+	if got := cons.uniqueKeys(); len(got) != 2 || got[0][0] != "name" || len(got[1]) != 2 {
+		t.Errorf("unexpected unique keys: %v", got)
+	}
+	if cons, err = readCollectionConstraints(&ingitdb.CollectionDef{ID: "none", DirPath: filepath.Join(dir, "none")}); cons != nil || err != nil {
+		t.Errorf("expected no constraints, got %+v, %v", cons, err)
+	}
+}
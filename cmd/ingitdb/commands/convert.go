@@ -0,0 +1,278 @@
+package commands
+
+// specscore: feature/cli/convert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// Convert returns the convert command, which changes how the records of a
+// schema object are stored on disk.
+func Convert(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert <kind> <name>",
+		Short: "Change the record file format or layout of a collection",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return fmt.Errorf("convert requires a kind: collection")
+		},
+	}
+	cmd.PersistentFlags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.PersistentFlags().Bool("dry-run", false, "list the files that would change without changing anything")
+
+	cmd.AddCommand(
+		convertCollection(homeDir, getWd, readDefinition, newDB, logf),
+	)
+	return cmd
+}
+
+func convertCollection(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "collection <name>",
+		Short: "Re-encode every record of a collection in another format or layout",
+		Long: "Re-encodes every record of a root collection as --format and --record-type, " +
+			"updates record_file in the collection definition and removes the old record files. The collection must return the same select output afterwards; " +
+			"otherwise every change is rolled back.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			format, _ := cmd.Flags().GetString("format")
+			rawType, _ := cmd.Flags().GetString("record-type")
+			name, _ := cmd.Flags().GetString("name")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			var recordType ingitdb.RecordType
+			if rawType != "" {
+				var ok bool
+				if recordType, ok = parseRecordType(rawType); !ok {
+					return fmt.Errorf("invalid --record-type %q (valid values: single, list, map)", rawType)
+				}
+			}
+			if format == "" && recordType == "" && name == "" {
+				return fmt.Errorf("nothing to convert: set --format, --record-type or --name")
+			}
+			conv := collectionConversion{
+				dbDir:      dirPath,
+				id:         args[0],
+				format:     ingitdb.RecordFormat(format),
+				recordType: recordType,
+				name:       name,
+			}
+			if dryRun {
+				return conv.preview(cmd.OutOrStdout(), readDefinition)
+			}
+			plan, err := conv.run(cmd.Context(), readDefinition, newDB)
+			if err != nil {
+				return err
+			}
+			if plan == nil {
+				logf(fmt.Sprintf("collection %s is already stored that way", conv.id))
+				return nil
+			}
+			logf(fmt.Sprintf("converted collection %s: wrote %d file(s), removed %d, moved %d director(ies)",
+				conv.id, len(plan.writes), len(plan.removes), len(plan.moves)))
+			return nil
+		},
+	}
+	cmd.Flags().String("format", "", "record file format: yaml, yml, json, jsonl, toml, ingr, csv or markdown (default: unchanged)")
+	cmd.Flags().String("record-type", "", "record file layout: single (a file per record), list or map (default: unchanged)")
+	cmd.Flags().String("name", "", "record file name, with {key} for single records (default: derived from the current name)")
+	return cmd
+}
+
+// collectionConversion is a request to store the records of a root
+// collection in another way; empty fields keep the current value.
+type collectionConversion struct {
+	dbDir      string
+	id         string
+	format     ingitdb.RecordFormat
+	recordType ingitdb.RecordType
+	name       string
+}
+
+// plan returns the definition file of the collection, its new record_file
+// and the record file changes. The plan is nil when nothing changes.
+func (c collectionConversion) plan(def *ingitdb.Definition) (string, *ingitdb.RecordFileDef, *recordConversion, error) {
+	col, ok := def.Collections[c.id]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("collection %q not found", c.id)
+	}
+	if col.RecordFile == nil {
+		return "", nil, nil, fmt.Errorf("collection %s has no record_file", c.id)
+	}
+	rf, err := convertedRecordFile(col, c.format, c.recordType, c.name)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	current := col.RecordFile
+	if rf.Name == current.Name && rf.Format == current.Format && rf.RecordType == current.RecordType {
+		return "", rf, nil, nil
+	}
+	roots, err := readRootCollections(c.dbDir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	rel, ok := roots[c.id]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("collection %q is not a root collection", c.id)
+	}
+	plan, err := planRecordConversion(col, map[*ingitdb.CollectionDef]*ingitdb.RecordFileDef{col: rf})
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return collectionDefinitionFile(c.dbDir, rel, c.id), rf, plan, nil
+}
+
+// preview writes what run would change, one line per file.
+func (c collectionConversion) preview(w io.Writer, readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error)) error {
+	def, err := readDefinition(c.dbDir)
+	if err != nil {
+		return fmt.Errorf("failed to read database definition: %w", err)
+	}
+	defFile, rf, plan, err := c.plan(def)
+	if err != nil || plan == nil {
+		return err
+	}
+	rel := func(path string) string {
+		if r, relErr := filepath.Rel(c.dbDir, path); relErr == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+	lines := []string{fmt.Sprintf("update %s: record_file %s, %s, %s", rel(defFile), rf.Name, rf.Format, rf.RecordType)}
+	for _, write := range plan.writes {
+		lines = append(lines, "write "+rel(write.path))
+	}
+	for _, path := range plan.removes {
+		lines = append(lines, "remove "+rel(path))
+	}
+	for _, mv := range plan.moves {
+		lines = append(lines, fmt.Sprintf("move %s -> %s", rel(mv[0]), rel(mv[1])))
+	}
+	for _, line := range lines {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run converts the collection and checks that select returns the same
+// records before and after; when it does not, or any step fails, the record
+// files and the definition are restored. It returns the applied plan, or
+// nil when nothing had to change.
+func (c collectionConversion) run(
+	ctx context.Context,
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+) (*recordConversion, error) {
+	def, err := readDefinition(c.dbDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read database definition: %w", err)
+	}
+	defFile, rf, plan, err := c.plan(def)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	before, err := c.selectRows(ctx, def, newDB)
+	if err != nil {
+		return nil, err
+	}
+	defContent, err := os.ReadFile(defFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", defFile, err)
+	}
+	undo, err := plan.apply()
+	if err != nil {
+		return nil, err
+	}
+	rollback := func(cause error) error {
+		undo()
+		if writeErr := os.WriteFile(defFile, defContent, 0o644); writeErr != nil {
+			return fmt.Errorf("%w; restoring %s also failed: %v", cause, defFile, writeErr)
+		}
+		return fmt.Errorf("%w; the conversion was rolled back", cause)
+	}
+	if err = writeRecordFileDefinition(defFile, rf); err != nil {
+		return nil, rollback(err)
+	}
+	if def, err = readDefinition(c.dbDir); err != nil {
+		return nil, rollback(fmt.Errorf("failed to read the converted definition: %w", err))
+	}
+	after, err := c.selectRows(ctx, def, newDB)
+	if err != nil {
+		return nil, rollback(err)
+	}
+	if err = compareSelectRows(before, after); err != nil {
+		return nil, rollback(fmt.Errorf("collection %s: %w", c.id, err))
+	}
+	return plan, nil
+}
+
+// selectRows returns every record of the collection as select reads it.
+func (c collectionConversion) selectRows(ctx context.Context, def *ingitdb.Definition, newDB func(string, *ingitdb.Definition) (dal.DB, error)) ([]map[string]any, error) {
+	db, err := newDB(c.dbDir, def)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return readSelectRows(ctx, db, c.id, def.Collections[c.id], nil, nil, nil)
+}
+
+// compareSelectRows reports the first record that differs between two
+// select results, ignoring record order. Values are compared by their JSON
+// encoding, so an int read from YAML equals the same number read from JSON.
+func compareSelectRows(before, after []map[string]any) error {
+	encode := func(rows []map[string]any) (map[string]string, error) {
+		encoded := make(map[string]string, len(rows))
+		for _, row := range rows {
+			b, err := json.Marshal(row)
+			if err != nil {
+				return nil, err
+			}
+			encoded[fmt.Sprint(row["$id"])] = string(b)
+		}
+		return encoded, nil
+	}
+	was, err := encode(before)
+	if err != nil {
+		return err
+	}
+	is, err := encode(after)
+	if err != nil {
+		return err
+	}
+	if len(before) != len(after) {
+		return fmt.Errorf("select returns %d record(s) after the conversion, %d before", len(after), len(before))
+	}
+	for _, key := range slices.Sorted(maps.Keys(was)) {
+		if is[key] != was[key] {
+			return fmt.Errorf("record %s reads differently after the conversion: %s, was %s", key, is[key], was[key])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dal-go/dalgo/dal"
+
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// convertTestFiles is a collection of single YAML records with a
+// subcollection instance under one of them.
+var convertTestFiles = map[string]string{
+	".ingitdb/root-collections.yaml": "countries: countries\n",
+	"countries/.collection/definition.yaml": `record_file:
+  name: "{key}.yaml"
+  format: yaml
+  type: map[string]any
+primary_key: [code]
+columns:
+  code:
+    type: string
+  title:
+    type: string
+  population:
+    type: int
+columns_order: [code, title, population]
+`,
+	"countries/.collection/subcollections/regions/definition.yaml": `record_file:
+  name: regions.yaml
+  format: yaml
+  type: map[$record_id]map[$field_name]any
+columns:
+  name:
+    type: string
+`,
+	"countries/$records/IE.yaml":                 "code: IE\ntitle: Ireland\npopulation: 5100000\n",
+	"countries/$records/NZ.yaml":                 "code: NZ\ntitle: New Zealand\n",
+	"countries/$records/IE/regions/regions.yaml": "leinster:\n  name: Leinster\n",
+}
+
+func writeConvertTestDB(t *testing.T) (string, *ingitdb.Definition) {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range convertTestFiles {
+		writeFixTestFile(t, dir, rel, content)
+	}
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	return dir, def
+}
+
+func TestConvertedRecordFile(t *testing.T) {
+	t.Parallel()
+	col := &ingitdb.CollectionDef{
+		ID:         "countries",
+		RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+	}
+	tests := []struct {
+		format     ingitdb.RecordFormat
+		recordType ingitdb.RecordType
+		name       string
+		want       string
+		wantErr    string
+	}{
+		{format: ingitdb.RecordFormatJSON, want: "{key}.json"},
+		{format: ingitdb.RecordFormatJSON, recordType: ingitdb.ListOfRecords, want: "countries.json"},
+		{recordType: ingitdb.MapOfRecords, want: "countries.yaml"},
+		{format: ingitdb.RecordFormatJSONL, recordType: ingitdb.ListOfRecords, name: "all.jsonl", want: "all.jsonl"},
+		{format: ingitdb.RecordFormatCSV, wantErr: "cannot be stored as csv"},
+	}
+	for _, tt := range tests {
+		rf, err := convertedRecordFile(col, tt.format, tt.recordType, tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s %s: expected error %q, got %v", tt.format, tt.recordType, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil || rf.Name != tt.want {
+			t.Errorf("%s %s: got %+v, %v; want name %s", tt.format, tt.recordType, rf, err, tt.want)
+		}
+	}
+}
+
+func TestPlanRecordConversion_SingleToList(t *testing.T) {
+	t.Parallel()
+	dir, def := writeConvertTestDB(t)
+	col := def.Collections["countries"]
+	rf, err := convertedRecordFile(col, ingitdb.RecordFormatJSON, ingitdb.ListOfRecords, "")
+	if err != nil {
+		t.Fatalf("convertedRecordFile: %v", err)
+	}
+	plan, err := planRecordConversion(col, map[*ingitdb.CollectionDef]*ingitdb.RecordFileDef{col: rf})
+	if err != nil {
+		t.Fatalf("planRecordConversion: %v", err)
+	}
+	if len(plan.writes) != 1 || len(plan.removes) != 2 || len(plan.moves) != 1 {
+		t.Fatalf("plan has %d writes, %d removes and %d moves; want 1, 2 and 1", len(plan.writes), len(plan.removes), len(plan.moves))
+	}
+	undo, err := plan.apply()
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	for rel, want := range map[string]string{
+		"countries/countries.json":          `"title": "New Zealand"`,
+		"countries/IE/regions/regions.yaml": "Leinster",
+	} {
+		content, readErr := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if readErr != nil || !strings.Contains(string(content), want) {
+			t.Errorf("%s = %q, %v; want it to contain %q", rel, content, readErr, want)
+		}
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "countries", "$records")); statErr == nil {
+		t.Error("the emptied $records directory was not removed")
+	}
+
+	undo()
+	for rel, content := range convertTestFiles {
+		got, readErr := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if readErr != nil || string(got) != content {
+			t.Errorf("after undo %s = %q, %v; want %q", rel, got, readErr, content)
+		}
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "countries", "countries.json")); statErr == nil {
+		t.Error("undo left countries.json behind")
+	}
+}
+
+func TestPlanRecordConversion_ListKeyError(t *testing.T) {
+	t.Parallel()
+	_, def := writeConvertTestDB(t)
+	col := *def.Collections["countries"]
+	col.PrimaryKey = nil
+	rf, err := convertedRecordFile(&col, ingitdb.RecordFormatJSON, ingitdb.ListOfRecords, "")
+	if err != nil {
+		t.Fatalf("convertedRecordFile: %v", err)
+	}
+	_, err = planRecordConversion(&col, map[*ingitdb.CollectionDef]*ingitdb.RecordFileDef{&col: rf})
+	if err == nil || !strings.Contains(err.Error(), "would lose its key") {
+		t.Errorf("expected a key error, got %v", err)
+	}
+}
+
+func TestCompareSelectRows(t *testing.T) {
+	t.Parallel()
+	before := []map[string]any{{"$id": "IE", "population": 5100000}, {"$id": "NZ", "title": "New Zealand"}}
+	same := []map[string]any{{"$id": "NZ", "title": "New Zealand"}, {"$id": "IE", "population": 5100000.0}}
+	if err := compareSelectRows(before, same); err != nil {
+		t.Errorf("compareSelectRows: %v", err)
+	}
+	changed := []map[string]any{{"$id": "IE", "population": "5100000"}, {"$id": "NZ", "title": "New Zealand"}}
+	if err := compareSelectRows(before, changed); err == nil || !strings.Contains(err.Error(), "record IE reads differently") {
+		t.Errorf("expected a difference for IE, got %v", err)
+	}
+	if err := compareSelectRows(before, same[:1]); err == nil || !strings.Contains(err.Error(), "1 record(s) after the conversion, 2 before") {
+		t.Errorf("expected a count difference, got %v", err)
+	}
+}
+
+func TestConvertCollection(t *testing.T) {
+	t.Parallel()
+	dir, _ := writeConvertTestDB(t)
+	newDB := func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	conv := collectionConversion{dbDir: dir, id: "countries", format: ingitdb.RecordFormatJSON, recordType: ingitdb.ListOfRecords}
+	plan, err := conv.run(context.Background(), validator.ReadDefinition, newDB)
+	if err != nil || plan == nil {
+		t.Fatalf("run: %v, %v", plan, err)
+	}
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	if rf := def.Collections["countries"].RecordFile; rf.Name != "countries.json" || rf.Format != ingitdb.RecordFormatJSON || rf.RecordType != ingitdb.ListOfRecords {
+		t.Errorf("record_file = %+v", rf)
+	}
+	if plan, err = conv.run(context.Background(), validator.ReadDefinition, newDB); err != nil || plan != nil {
+		t.Errorf("second run = %v, %v; want nothing to do", plan, err)
+	}
+}
+
+func TestConvertCollection_DryRun(t *testing.T) {
+	t.Parallel()
+	dir, _ := writeConvertTestDB(t)
+	var out strings.Builder
+	conv := collectionConversion{dbDir: dir, id: "countries", recordType: ingitdb.MapOfRecords}
+	if err := conv.preview(&out, validator.ReadDefinition); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	want := `update countries/.collection/definition.yaml: record_file countries.yaml, yaml, map[$record_id]map[$field_name]any
+write countries/countries.yaml
+remove countries/$records/IE.yaml
+remove countries/$records/NZ.yaml
+move countries/$records/IE/regions -> countries/IE/regions
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "countries", "$records", "IE.yaml")); err != nil {
+		t.Errorf("a dry run changed the records: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package commands
+
+// specscore: feature/cli/copy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/dal-go/record"
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/dalgo2ingitdb"
+	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// Conflict policies of `copy --on-conflict` for a record whose key the
+// target collection already holds.
+const (
+	copyConflictFail      = "fail"
+	copyConflictSkip      = "skip"
+	copyConflictOverwrite = "overwrite"
+)
+
+// Copy returns the `ingitdb copy` command. It copies the records of --from
+// that match --where (or --all) into the collection --to. The source is the
+// database --path or --remote names; the target is --to-path or --to-remote,
+// and the source database when neither is given. Every record is checked
+// against the target schema, and keys the target already holds are handled
+// by --on-conflict, before anything is written.
+func Copy(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy records to another collection, branch or database",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_ = logf
+			from, _ := cmd.Flags().GetString("from")
+			to, _ := cmd.Flags().GetString("to")
+			if from == "" {
+				return fmt.Errorf("--from is required")
+			}
+			if to == "" {
+				return fmt.Errorf("--to is required")
+			}
+			onConflict, _ := cmd.Flags().GetString("on-conflict")
+			if !slices.Contains([]string{copyConflictFail, copyConflictSkip, copyConflictOverwrite}, onConflict) {
+				return fmt.Errorf("invalid --on-conflict %q (must be fail, skip, or overwrite)", onConflict)
+			}
+			whereExprs, _ := cmd.Flags().GetStringArray("where")
+			allFlag, _ := cmd.Flags().GetBool("all")
+			if len(whereExprs) > 0 && allFlag {
+				return fmt.Errorf("--where and --all are mutually exclusive")
+			}
+			if len(whereExprs) == 0 && !allFlag {
+				return fmt.Errorf("copy requires one of --where or --all")
+			}
+			conds := make([]sqlflags.Condition, 0, len(whereExprs))
+			for _, e := range whereExprs {
+				c, parseErr := sqlflags.ParseWhere(e)
+				if parseErr != nil {
+					return fmt.Errorf("invalid --where %q: %w", e, parseErr)
+				}
+				conds = append(conds, c)
+			}
+			return runCopy(cmd.Context(), cmd, from, to, conds, allFlag, onConflict, homeDir, getWd, readDefinition, newDB)
+		},
+	}
+	addPathFlag(cmd)
+	addRemoteFlags(cmd)
+	sqlflags.RegisterFromFlag(cmd)
+	sqlflags.RegisterWhereFlag(cmd)
+	sqlflags.RegisterAllFlag(cmd)
+	cmd.Flags().String("to", "", "target collection ID or subcollection path")
+	cmd.Flags().String("to-path", "", "target database directory (default: the source database)")
+	cmd.Flags().String("to-remote", "", "target remote repository, in the --remote form (default: the source database)")
+	cmd.Flags().String("on-conflict", copyConflictFail, "what to do with a record whose key the target holds: fail, skip, or overwrite")
+	return cmd
+}
+
+// runCopy reads the matching records of every collection instance from
+// names, checks them against the target collection and writes them in a
+// single read-write transaction.
+func runCopy(
+	ctx context.Context,
+	cmd *cobra.Command,
+	from, to string,
+	conds []sqlflags.Condition,
+	all bool,
+	onConflict string,
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+) error {
+	targetCmd, err := copyTargetCommand(cmd)
+	if err != nil {
+		return err
+	}
+	if err = requireRemoteWriteToken(targetCmd); err != nil {
+		return err
+	}
+	sources, err := resolveCollectionContexts(ctx, cmd, from, homeDir, getWd, readDefinition, newDB)
+	if err != nil {
+		return err
+	}
+	target, err := resolveInsertContext(ctx, targetCmd, to, homeDir, getWd, readDefinition, newDB)
+	if err != nil {
+		return fmt.Errorf("invalid --to: %w", err)
+	}
+	if sameCopyDatabase(cmd, targetCmd) && slices.ContainsFunc(sources, func(src insertContext) bool {
+		return src.colDef.ID == target.colDef.ID && src.colDef.DirPath == target.colDef.DirPath
+	}) {
+		return fmt.Errorf("--to names the --from collection")
+	}
+
+	// Read-only pass: the matching records of every source instance.
+	var records []patchTarget
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		matches, readErr := readPatchTargets(ctx, src, conds, all)
+		if readErr != nil {
+			return readErr
+		}
+		for _, m := range matches {
+			if seen[m.key] {
+				return fmt.Errorf("record key %q is matched in more than one instance of %s", m.key, from)
+			}
+			seen[m.key] = true
+			records = append(records, m)
+		}
+	}
+
+	existing, err := readDeleteKeys(ctx, target, nil, true)
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, key := range existing {
+		taken[key] = true
+	}
+	var skipped []string
+	writes := make(map[string]map[string]any, len(records))
+	records = slices.DeleteFunc(records, func(m patchTarget) bool {
+		if taken[m.key] && onConflict == copyConflictSkip {
+			skipped = append(skipped, m.key)
+			return true
+		}
+		return false
+	})
+	for _, m := range records {
+		if taken[m.key] && onConflict == copyConflictFail {
+			return fmt.Errorf("record %q already exists in collection %s (use --on-conflict=skip or --on-conflict=overwrite)", m.key, target.colDef.ID)
+		}
+		if err = checkRecordFits(target.colDef, m.key, m.data); err != nil {
+			return err
+		}
+		writes[m.key] = m.data
+	}
+	if err = checkLocalWrite(target.dirPath, target.def, target.colDef, writes); err != nil {
+		return err
+	}
+
+	if len(records) > 0 {
+		if err = writeCopiedRecords(ctx, targetCmd, target, records, taken); err != nil {
+			return err
+		}
+	}
+	if len(skipped) > 0 {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "skipped %d records already in %s: %s\n",
+			len(skipped), target.colDef.ID, strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
+// writeCopiedRecords writes records to the target collection in a single
+// read-write transaction — a single commit for a remote target — and
+// materializes its local views. Keys in taken are overwritten; the others
+// are inserted.
+func writeCopiedRecords(ctx context.Context, targetCmd *cobra.Command, target insertContext, records []patchTarget, taken map[string]bool) error {
+	to := target.colDef.ID
+	if isLocalListCollection(target.dirPath, target.colDef) {
+		if err := writeCopiedListRecords(target.colDef, records, taken); err != nil {
+			return err
+		}
+		return buildLocalViews(ctx, target.toRecordContext())
+	}
+	writeDB, _ := maybeWrapWithBatching(targetCmd, target.db, target.def,
+		fmt.Sprintf("ingitdb: copy into %s (batch)", to))
+	err := writeDB.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		for _, m := range records {
+			rec := record.NewRecordWithData(record.NewKeyWithID(to, m.key), m.data)
+			var writeErr error
+			if taken[m.key] {
+				writeErr = tx.Set(ctx, rec)
+			} else {
+				writeErr = tx.Insert(ctx, rec)
+			}
+			if writeErr != nil {
+				return fmt.Errorf("failed to write record %q to collection %s: %w", m.key, to, writeErr)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return buildLocalViews(ctx, target.toRecordContext())
+}
+
+// writeCopiedListRecords writes records to a local list collection the way
+// insert and update do: new keys are appended to its list file and keys in
+// taken are patched in place. When the patch fails, the list file is put
+// back as it was before the append.
+func writeCopiedListRecords(col *ingitdb.CollectionDef, records []patchTarget, taken map[string]bool) error {
+	path, previous, _, err := readListFile(col)
+	if err != nil {
+		return err
+	}
+	var inserts []dalgo2ingitdb.ParsedRecord
+	writes := make(map[string]map[string]any)
+	for i, m := range records {
+		if !taken[m.key] {
+			inserts = append(inserts, dalgo2ingitdb.ParsedRecord{Key: m.key, Data: m.data, Position: i + 1})
+			continue
+		}
+		data, ok := listRowWithKey(col, m.key, m.data)
+		if !ok {
+			return fmt.Errorf("record %q: list collection %s has a composite primary key, so the record must carry its key columns", m.key, col.ID)
+		}
+		writes[m.key] = data
+	}
+	if len(inserts) > 0 {
+		if err = appendListRecords(col, inserts); err != nil {
+			return err
+		}
+	}
+	if err = patchListRecords(col, writes); err != nil {
+		if len(inserts) > 0 {
+			if restoreErr := os.WriteFile(path, previous, 0o644); restoreErr != nil {
+				return fmt.Errorf("%w (restoring %s also failed: %v)", err, path, restoreErr)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// copyTargetCommand returns a command holding the --path, --remote, --token
+// and --provider values the target of cmd resolves with, so the resolvers
+// shared by the other verbs serve the target too: --to-path or --to-remote
+// when given, the source database otherwise.
+func copyTargetCommand(cmd *cobra.Command) (*cobra.Command, error) {
+	toPath, _ := cmd.Flags().GetString("to-path")
+	toRemote, _ := cmd.Flags().GetString("to-remote")
+	if toPath != "" && toRemote != "" {
+		return nil, fmt.Errorf("--to-path with --to-remote is not supported")
+	}
+	if toPath == "" && toRemote == "" {
+		toPath, _ = cmd.Flags().GetString("path")
+		toRemote, _ = cmd.Flags().GetString("remote")
+	}
+	token, _ := cmd.Flags().GetString("token")
+	provider, _ := cmd.Flags().GetString("provider")
+	target := &cobra.Command{Use: cmd.Use}
+	addPathFlag(target)
+	addRemoteFlags(target)
+	for _, flag := range []struct{ name, value string }{
+		{"path", toPath}, {"remote", toRemote}, {"token", token}, {"provider", provider},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		if err := target.Flags().Set(flag.name, flag.value); err != nil {
+			return nil, err
+		}
+	}
+	return target, nil
+}
+
+// sameCopyDatabase reports whether the source and target commands address
+// the same database.
+func sameCopyDatabase(source, target *cobra.Command) bool {
+	for _, name := range []string{"path", "remote"} {
+		s, _ := source.Flags().GetString(name)
+		t, _ := target.Flags().GetString(name)
+		if s != t {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// runCopyCmd invokes the Copy command with the working directory dir and
+// renameTestDefinition for every database path, and returns captured
+// stderr + any error.
+func runCopyCmd(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	return runCopyCmdWithDef(t, dir, renameTestDefinition, args...)
+}
+
+// runCopyCmdWithDef is runCopyCmd with the definition readDef builds for
+// each database path.
+func runCopyCmdWithDef(t *testing.T, dir string, readDef func(string) *ingitdb.Definition, args ...string) (string, error) {
+	t.Helper()
+	cmd := Copy(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+			return readDef(p), nil
+		},
+		func(root string, d *ingitdb.Definition) (dal.DB, error) {
+			return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+		},
+		func(...any) {},
+	)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestCopy_BetweenCollections(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+
+	if _, err := runCopyCmd(t, dir, "--path="+dir, "--from=countries", "--where=name==France", "--to=towns"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "towns", "$records", "fr.yaml"))
+	if err != nil || !strings.Contains(string(content), "name: France") {
+		t.Errorf("copied record: %v\n%s", err, content)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "towns", "$records", "ie.yaml")); !os.IsNotExist(err) {
+		t.Errorf("a record not matching --where must not be copied: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "countries", "$records", "fr.yaml")); err != nil {
+		t.Errorf("the source record must be kept: %v", err)
+	}
+
+	_, err = runCopyCmd(t, dir, "--path="+dir, "--from=cities", "--all", "--to=towns")
+	if err == nil || !strings.Contains(err.Error(), `does not declare column "country"`) {
+		t.Errorf("expected an incompatible schema error, got %v", err)
+	}
+}
+
+func TestCopy_ConflictPolicies(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	writeFixTestFile(t, dir, "towns/$records/ie.yaml", "name: Old Ireland\n")
+	townIE := filepath.Join(dir, "towns", "$records", "ie.yaml")
+
+	_, err := runCopyCmd(t, dir, "--path="+dir, "--from=countries", "--all", "--to=towns")
+	if err == nil || !strings.Contains(err.Error(), `record "ie" already exists in collection towns`) {
+		t.Fatalf("expected a conflict error, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "towns", "$records", "fr.yaml")); !os.IsNotExist(err) {
+		t.Errorf("a failed copy must write nothing: %v", err)
+	}
+
+	out, err := runCopyCmd(t, dir, "--path="+dir, "--from=countries", "--all", "--to=towns", "--on-conflict=skip")
+	if err != nil {
+		t.Fatalf("copy with skip: %v", err)
+	}
+	if content, _ := os.ReadFile(townIE); !strings.Contains(string(content), "Old Ireland") || !strings.Contains(out, "skipped 1 records already in towns: ie") {
+		t.Errorf("skip must keep the existing record and report it; record:\n%s\noutput: %s", content, out)
+	}
+
+	if _, err = runCopyCmd(t, dir, "--path="+dir, "--from=countries", "--all", "--to=towns", "--on-conflict=overwrite"); err != nil {
+		t.Fatalf("copy with overwrite: %v", err)
+	}
+	if content, _ := os.ReadFile(townIE); !strings.Contains(string(content), "name: Ireland") || strings.Contains(string(content), "Old") {
+		t.Errorf("overwrite must replace the existing record:\n%s", content)
+	}
+}
+
+func TestCopy_IntoListCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	listDef := func(p string) *ingitdb.Definition {
+		def := renameTestDefinition(p)
+		towns := def.Collections["towns"]
+		towns.RecordFile = &ingitdb.RecordFileDef{Name: "towns.yaml", Format: "yaml", RecordType: ingitdb.ListOfRecords}
+		towns.Columns["id"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+		towns.ColumnsOrder = []string{"id", "name"}
+		return def
+	}
+	towns := writeFixTestFile(t, dir, "towns/towns.yaml", "- id: ie\n  name: Old Ireland\n- id: es\n  name: Spain\n")
+
+	_, err := runCopyCmdWithDef(t, dir, listDef, "--path="+dir, "--from=countries", "--all", "--to=towns", "--on-conflict=overwrite")
+	if err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	want := "- id: ie\n  name: Ireland\n- id: es\n  name: Spain\n- id: fr\n  name: France\n"
+	if got := readFixTestFile(t, towns); got != want {
+		t.Errorf("towns.yaml:\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestCopy_ToOtherDatabase(t *testing.T) {
+	t.Parallel()
+	staging, production := t.TempDir(), t.TempDir()
+	seedFKTestRecords(t, staging)
+
+	_, err := runCopyCmd(t, staging, "--from=cities", "--where=name==Dublin", "--to=cities", "--to-path="+production)
+	if err == nil || !strings.Contains(err.Error(), `foreign key "country" = "ie" has no matching record`) {
+		t.Errorf("expected a dangling reference error, got %v", err)
+	}
+
+	if _, err = runCopyCmd(t, staging, "--from=countries", "--all", "--to=countries", "--to-path="+production); err != nil {
+		t.Fatalf("copy countries: %v", err)
+	}
+	if _, err = runCopyCmd(t, staging, "--from=cities", "--where=name==Dublin", "--to=cities", "--to-path="+production); err != nil {
+		t.Fatalf("copy cities: %v", err)
+	}
+	for _, rel := range []string{"countries/$records/ie.yaml", "countries/$records/fr.yaml", "cities/$records/dublin.yaml"} {
+		if _, statErr := os.Stat(filepath.Join(production, rel)); statErr != nil {
+			t.Errorf("copied record %s: %v", rel, statErr)
+		}
+	}
+
+	_, err = runCopyCmd(t, staging, "--from=countries", "--all", "--to=countries")
+	if err == nil || !strings.Contains(err.Error(), "--to names the --from collection") {
+		t.Errorf("expected a same collection error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package commands
+
+// specscore: feature/cli/create
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitrepo"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// Create returns the `ingitdb create` command. One kind is supported today:
+// `create view <name> --in=<collection>`, which writes a view definition,
+// validates it against the collection schema and materializes it.
+func Create(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	viewBuilder materializer.ViewBuilder,
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <kind> <name>",
+		Short: "Create a schema object (view)",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return fmt.Errorf("create requires a kind: view")
+		},
+	}
+	cmd.PersistentFlags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.AddCommand(createView(homeDir, getWd, readDefinition, viewBuilder, logf))
+	return cmd
+}
+
+// createView returns the `create view <name>` subcommand.
+func createView(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	viewBuilder materializer.ViewBuilder,
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view <name>",
+		Short: "Create a view definition and materialize it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWriteView(cmd, args[0], false, homeDir, getWd, readDefinition, viewBuilder, logf)
+		},
+	}
+	addViewDefFlags(cmd)
+	cmd.Flags().Bool("if-not-exists", false, "do not fail when a view with this name already exists")
+	return cmd
+}
+
+// runWriteView is the shared body of `create view` and `alter view`. With
+// alter=false the view must not exist yet and is built from the flags alone;
+// with alter=true it must exist and only the supplied flags are changed. The
+// resulting definition is validated before it is written, and the view is
+// materialized right away so its output never lags its definition.
+func runWriteView(
+	cmd *cobra.Command,
+	name string,
+	alter bool,
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	viewBuilder materializer.ViewBuilder,
+	logf func(...any),
+) error {
+	colID, _ := cmd.Flags().GetString("in")
+	if colID == "" {
+		return fmt.Errorf("--in=<collection> is required")
+	}
+	dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+	if err != nil {
+		return err
+	}
+	entries, err := readRootCollections(dirPath)
+	if err != nil {
+		return err
+	}
+	rel, ok := entries[colID]
+	if !ok {
+		return fmt.Errorf("collection %q (from --in) not found", colID)
+	}
+	def, err := readDefinition(dirPath)
+	if err != nil {
+		return fmt.Errorf("failed to read database definition: %w", err)
+	}
+	colDef, ok := def.Collections[colID]
+	if !ok {
+		return fmt.Errorf("collection %q not found in definition", colID)
+	}
+
+	viewPath := filepath.Join(collectionViewsDir(dirPath, rel), name+".yaml")
+	_, statErr := os.Stat(viewPath)
+	exists := statErr == nil
+
+	view := &viewFile{ViewDef: ingitdb.ViewDef{ID: name}}
+	switch {
+	case alter && !exists:
+		return fmt.Errorf("view %q not found in collection %q", name, colID)
+	case alter:
+		if view, err = readViewDefFile(viewPath); err != nil {
+			return err
+		}
+	case exists:
+		if ifNotExists, _ := cmd.Flags().GetBool("if-not-exists"); ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("view %q already exists in collection %q; use `ingitdb alter view` to change it", name, colID)
+	}
+
+	if err = applyViewDefFlags(cmd, view); err != nil {
+		return err
+	}
+	if !alter && view.Template == "" && !cmd.Flags().Changed("format") {
+		// Without a template, a new view renders as a Markdown table.
+		view.Formats = []string{viewFormatMarkdown}
+	}
+	if err = validateViewAgainstCollection(view, colDef); err != nil {
+		return err
+	}
+	if err = writeViewDefFile(viewPath, view); err != nil {
+		return err
+	}
+	logf(fmt.Sprintf("view %s/%s written to %s", colID, name, viewPath))
+
+	if colDef.Views == nil {
+		colDef.Views = make(map[string]*ingitdb.ViewDef)
+	}
+	colDef.Views[name] = &view.ViewDef
+	return materializeSingleView(cmd.Context(), viewBuilder, def, dirPath, colDef, &view.ViewDef, logf)
+}
+
+// materializeSingleView builds one view of one collection and reports the
+// outcome through logf. It requires a builder exposing the per-view BuildView
+// entry point.
+func materializeSingleView(
+	ctx context.Context,
+	viewBuilder materializer.ViewBuilder,
+	def *ingitdb.Definition,
+	dirPath string,
+	colDef *ingitdb.CollectionDef,
+	view *ingitdb.ViewDef,
+	logf func(...any),
+) error {
+	if viewBuilder == nil {
+		return nil
+	}
+	builder, ok := viewBuilder.(singleViewBuilder)
+	if !ok {
+		return fmt.Errorf("materializing a single view requires the standard view builder")
+	}
+	repoRoot, err := gitrepo.FindRepoRoot(dirPath)
+	if err != nil {
+		repoRoot = ""
+	}
+	result, err := builder.BuildView(ctx, dirPath, repoRoot, colDef, def, view)
+	if err != nil {
+		return fmt.Errorf("failed to materialize view %s/%s: %w", colDef.ID, view.ID, err)
+	}
+	logf(materializeSummary(result))
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			logf(fmt.Sprintf("error: %v", e))
+		}
+		return fmt.Errorf("%d view error(s)", len(result.Errors))
+	}
+	return nil
+}
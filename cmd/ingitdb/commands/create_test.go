@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// viewCmdFixture is a shared-layout database with one root collection
+// ("cities", two records) used by the create/alter view tests.
+type viewCmdFixture struct {
+	dir    string
+	colDir string
+	def    *ingitdb.Definition
+}
+
+func newViewCmdFixture(t *testing.T) *viewCmdFixture {
+	t.Helper()
+	dir := t.TempDir()
+	// A .git directory makes gitrepo.FindRepoRoot resolve to dir, so view
+	// output lands under <dir>/$ingitdb/ rather than the process cwd.
+	if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir .git: %v", err)
+	}
+	colDir := seedCollection(t, dir, "cities")
+	recordsDir := filepath.Join(colDir, "$records")
+	if err := os.MkdirAll(recordsDir, 0o755); err != nil {
+		t.Fatalf("mkdir $records: %v", err)
+	}
+	for key, body := range map[string]string{
+		"sf": "name: San Francisco\npopulation: 800000\n",
+		"la": "name: Los Angeles\npopulation: 4000000\n",
+	} {
+		if err := os.WriteFile(filepath.Join(recordsDir, key+".yaml"), []byte(body), 0o644); err != nil {
+			t.Fatalf("write record %s: %v", key, err)
+		}
+	}
+	def := &ingitdb.Definition{
+		Collections: map[string]*ingitdb.CollectionDef{
+			"cities": {
+				ID:      "cities",
+				DirPath: colDir,
+				RecordFile: &ingitdb.RecordFileDef{
+					Name:       "{key}.yaml",
+					Format:     "yaml",
+					RecordType: ingitdb.SingleRecord,
+				},
+				Columns: map[string]*ingitdb.ColumnDef{
+					"name":       {Type: ingitdb.ColumnTypeString},
+					"population": {Type: ingitdb.ColumnTypeInt},
+				},
+			},
+		},
+	}
+	return &viewCmdFixture{dir: dir, colDir: colDir, def: def}
+}
+
+func (f *viewCmdFixture) viewPath(name string) string {
+	return filepath.Join(f.colDir, "$views", name+".yaml")
+}
+
+func runCreateCmd(t *testing.T, f *viewCmdFixture, vb materializer.ViewBuilder, args ...string) error {
+	t.Helper()
+	homeDir := func() (string, error) { return "/tmp/home", nil }
+	getWd := func() (string, error) { return f.dir, nil }
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil }
+	cmd := Create(homeDir, getWd, readDef, vb, func(...any) {})
+	return runCobraCommand(cmd, append([]string{"--path=" + f.dir}, args...)...)
+}
+
+func TestCreate_RequiresKind(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	if err := runCreateCmd(t, f, nil); err == nil {
+		t.Fatal("expected error when no kind is given")
+	}
+}
+
+func TestCreateView_WritesDefinition(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	err := runCreateCmd(t, f, nil, "view", "large_cities", "--in=cities",
+		"--columns=name,population", "--order-by=-population", "--top=10",
+		"--where=population>1000000", "--format=MD")
+	if err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	view, err := readViewDefFile(f.viewPath("large_cities"))
+	if err != nil {
+		t.Fatalf("read written view: %v", err)
+	}
+	if strings.Join(view.Columns, ",") != "name,population" {
+		t.Errorf("columns: got %v", view.Columns)
+	}
+	if view.OrderBy != "population desc" {
+		t.Errorf("order_by: got %q, want %q", view.OrderBy, "population desc")
+	}
+	if view.Top != 10 {
+		t.Errorf("top: got %d, want 10", view.Top)
+	}
+	if view.Where != "population>1000000" {
+		t.Errorf("where: got %q", view.Where)
+	}
+	if strings.Join(view.Formats, ",") != "md" {
+		t.Errorf("formats: got %v, want [md]", view.Formats)
+	}
+}
+
+func TestCreateView_Materializes(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	vb := materializer.NewViewBuilder(materializer.NewFileRecordsReader(), nil)
+	err := runCreateCmd(t, f, vb, "view", "all_cities", "--in=cities",
+		"--columns=name", "--file-name=all_cities.md")
+	if err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	out := filepath.Join(f.dir, ingitdb.IngitdbDir, ".collections", "cities", "all_cities.md")
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected materialized output at %s: %v", out, err)
+	}
+	if !strings.Contains(string(content), "San Francisco") {
+		t.Errorf("materialized output misses records:\n%s", content)
+	}
+}
+
+func TestCreateView_Rejections(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing --in", args: []string{"view", "v"}, wantErr: "--in"},
+		{name: "unknown collection", args: []string{"view", "v", "--in=nope"}, wantErr: "nope"},
+		{name: "unknown column", args: []string{"view", "v", "--in=cities", "--columns=name,area"}, wantErr: "area"},
+		{name: "unknown order-by column", args: []string{"view", "v", "--in=cities", "--order-by=area"}, wantErr: "area"},
+		{name: "multi-field order-by", args: []string{"view", "v", "--in=cities", "--order-by=name,population"}, wantErr: "single field"},
+		{name: "bad where", args: []string{"view", "v", "--in=cities", "--where=name=x"}, wantErr: "--where"},
+		{name: "unknown where column", args: []string{"view", "v", "--in=cities", "--where=area>1"}, wantErr: "area"},
+		{name: "unquoted where separator", args: []string{"view", "v", "--in=cities", "--where=name==R&&D"}, wantErr: "quote"},
+		{name: "negative top", args: []string{"view", "v", "--in=cities", "--top=-1"}, wantErr: "--top"},
+		{name: "bad format", args: []string{"view", "v", "--in=cities", "--format=json"}, wantErr: "--format"},
+		{name: "nothing to render", args: []string{"view", "v", "--in=cities", "--format="}, wantErr: "nothing to render"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := newViewCmdFixture(t)
+			err := runCreateCmd(t, f, nil, tc.args...)
+			if err == nil {
+				t.Fatalf("expected error for %v", tc.args)
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error %q should mention %q", err, tc.wantErr)
+			}
+			if _, statErr := os.Stat(f.viewPath("v")); !os.IsNotExist(statErr) {
+				t.Errorf("no view file must be written on error, stat: %v", statErr)
+			}
+		})
+	}
+}
+
+func TestCreateView_DefaultsToMarkdown(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	if err := runCreateCmd(t, f, nil, "view", "plain", "--in=cities"); err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	view, err := readViewDefFile(f.viewPath("plain"))
+	if err != nil {
+		t.Fatalf("read view: %v", err)
+	}
+	if strings.Join(view.Formats, ",") != "md" {
+		t.Errorf("formats: got %v, want [md]", view.Formats)
+	}
+}
+
+func TestCreateView_AlreadyExists(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	seedView(t, f.colDir, "existing", "", "")
+
+	err := runCreateCmd(t, f, nil, "view", "existing", "--in=cities")
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected already-exists error, got %v", err)
+	}
+	if err = runCreateCmd(t, f, nil, "view", "existing", "--in=cities", "--if-not-exists"); err != nil {
+		t.Fatalf("--if-not-exists should succeed, got %v", err)
+	}
+}
+
+func TestCollectionViewsDir_LegacyLayout(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	got := collectionViewsDir(dir, "cities")
+	want := filepath.Join(dir, "cities", ingitdb.SchemaDir, "views")
+	if got != want {
+		t.Errorf("collectionViewsDir: got %s, want %s", got, want)
+	}
+}
+
+func TestParseViewWhere(t *testing.T) {
+	t.Parallel()
+	conds, err := parseViewWhere("population > 1000 && active == true")
+	if err != nil {
+		t.Fatalf("parseViewWhere: %v", err)
+	}
+	if len(conds) != 2 || conds[0].Field != "population" || conds[1].Field != "active" {
+		t.Errorf("unexpected conditions: %+v", conds)
+	}
+	if conds, err = parseViewWhere("  "); err != nil || conds != nil {
+		t.Errorf("blank where: got %v, %v", conds, err)
+	}
+	if _, err = parseViewWhere("population"); err == nil {
+		t.Error("expected error for a condition without operator")
+	}
+	conds, err = parseViewWhere(`name == "R&&D" && note != 'a && b'`)
+	if err != nil {
+		t.Fatalf("parseViewWhere quoted: %v", err)
+	}
+	if len(conds) != 2 || conds[0].Value != "R&&D" || conds[1].Value != "a && b" {
+		t.Errorf("quoted separators split a value: %+v", conds)
+	}
+}
@@ -5,6 +5,8 @@ package commands
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 
 	"github.com/dal-go/dalgo/dal"
 	"github.com/dal-go/record"
@@ -13,6 +15,7 @@ import (
 	"github.com/ingitdb/dalgo2ingitdb"
 	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
 	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
 )
 
 // Delete returns the `ingitdb delete` command. Two modes inherited
@@ -112,16 +115,69 @@ func runDeleteFromSet(
 		conds = append(conds, c)
 	}
 
-	// Resolve collection (local or GitHub).
-	ictx, err := resolveInsertContext(ctx, cmd, from, homeDir, getWd, readDefinition, newDB)
+	// Resolve the collection (local or GitHub), or every instance a
+	// subcollection path matches.
+	ictxs, err := resolveCollectionContexts(ctx, cmd, from, homeDir, getWd, readDefinition, newDB)
 	if err != nil {
 		return err
 	}
 
 	// Read-only pass: collect matching keys.
+	matchedKeys := make([][]string, len(ictxs))
+	matched := 0
+	for i, ictx := range ictxs {
+		if matchedKeys[i], err = readDeleteKeys(ctx, ictx, conds, allFlag); err != nil {
+			return err
+		}
+		matched += len(matchedKeys[i])
+	}
+
+	// --min-affected pre-flight check. If the matched count is below
+	// the threshold, fail BEFORE opening the write transaction.
+	// Destructive atomicity: no record is deleted when below
+	// threshold.
+	if n, supplied, mErr := sqlflags.MinAffectedFromCmd(cmd); mErr != nil {
+		return mErr
+	} else if supplied && matched < n {
+		return fmt.Errorf("matched %d records, required at least %d", matched, n)
+	}
+
+	// Apply the on_delete actions of the foreign keys referencing the
+	// matched records. The plans are computed before any write
+	// transaction, so a restricted reference deletes nothing.
+	plans := make([]*deletePlan, len(ictxs))
+	for i, ictx := range ictxs {
+		if plans[i], err = planLocalDelete(ictx.dirPath, ictx.def, ictx.colDef.ID, matchedKeys[i]); err != nil {
+			return err
+		}
+	}
+
+	for i, ictx := range ictxs {
+		if err = deleteMatchedKeys(ctx, cmd, ictx, matchedKeys[i], plans[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDeleteKeys returns the keys of the records of the collection that
+// match conds, or of every record when all is set.
+func readDeleteKeys(ctx context.Context, ictx insertContext, conds []sqlflags.Condition, all bool) ([]string, error) {
+	if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+		rows, err := matchListRecords(ictx, conds, all)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, len(rows))
+		for i, r := range rows {
+			keys[i] = r.Key
+		}
+		return keys, nil
+	}
+	from := ictx.colDef.ID
 	q := newQueryForCollection(from)
 	var matchedKeys []string
-	err = ictx.db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
+	err := ictx.db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
 		reader, qerr := tx.ExecuteQueryToRecordsetReader(ctx, q)
 		if qerr != nil {
 			return qerr
@@ -134,7 +190,7 @@ func runDeleteFromSet(
 				break
 			}
 			recKey := dalgo2ingitdb.RowKey(row, rs)
-			if !allFlag {
+			if !all {
 				if whereNames == nil {
 					whereNames = whereColumnNames(rs, conds)
 				}
@@ -142,7 +198,7 @@ func runDeleteFromSet(
 				if derr != nil {
 					return derr
 				}
-				if match, _ := evalAllWhere(data, recKey, conds); !match {
+				if match, _ := evalAllWhere(withParentFields(data, ictx.parentFields), recKey, conds); !match {
 					continue
 				}
 			}
@@ -151,17 +207,25 @@ func runDeleteFromSet(
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("query failed: %w", err)
+		return nil, fmt.Errorf("query failed: %w", err)
 	}
+	return matchedKeys, nil
+}
 
-	// --min-affected pre-flight check. If the matched count is below
-	// the threshold, fail BEFORE opening the write transaction.
-	// Destructive atomicity: no record is deleted when below
-	// threshold.
-	if n, supplied, mErr := sqlflags.MinAffectedFromCmd(cmd); mErr != nil {
-		return mErr
-	} else if supplied && len(matchedKeys) < n {
-		return fmt.Errorf("matched %d records, required at least %d", len(matchedKeys), n)
+// deleteMatchedKeys deletes the matched records of one collection, and
+// the on_delete changes of plan, in a single read-write transaction and
+// materializes the affected local views.
+func deleteMatchedKeys(ctx context.Context, cmd *cobra.Command, ictx insertContext, matchedKeys []string, plan *deletePlan) error {
+	from := ictx.colDef.ID
+	if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+		rctx := ictx.toRecordContext()
+		if err := deleteListRecordsWithPlan(ctx, rctx, matchedKeys, plan); err != nil {
+			return err
+		}
+		if err := buildDeletePlanViews(ctx, rctx, plan); err != nil {
+			return err
+		}
+		return buildLocalViews(ctx, rctx)
 	}
 
 	// For --remote, wrap the db with a batching variant so the worker's
@@ -171,29 +235,28 @@ func runDeleteFromSet(
 		fmt.Sprintf("ingitdb: delete from %s (batch)", from))
 
 	// Read-write pass: delete each matching key.
-	err = writeDB.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+	err := writeDB.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
 		for _, k := range matchedKeys {
 			key := record.NewKeyWithID(from, k)
 			if delErr := tx.Delete(ctx, key); delErr != nil {
 				return delErr
 			}
 		}
-		return nil
+		return applyDeletePlanChildren(ctx, tx, plan)
 	})
 	if err != nil {
-		return err
+		return plan.restoreLists(err)
 	}
 
 	// Materialize local views (no-op when source is GitHub).
 	if ictx.dirPath == "" {
 		return nil
 	}
-	return buildLocalViews(ctx, recordContext{
-		db:      ictx.db,
-		colDef:  ictx.colDef,
-		dirPath: ictx.dirPath,
-		def:     ictx.def,
-	})
+	rctx := ictx.toRecordContext()
+	if err = buildDeletePlanViews(ctx, rctx, plan); err != nil {
+		return err
+	}
+	return buildLocalViews(ctx, rctx)
 }
 
 // runDeleteByID handles --id mode: fetch one record to confirm it
@@ -223,8 +286,12 @@ func runDeleteByID(
 	if err != nil {
 		return err
 	}
+	if isLocalListCollection(rctx.dirPath, rctx.colDef) {
+		return deleteListRecordByID(ctx, rctx, id)
+	}
 
 	key := record.NewKeyWithID(rctx.colDef.ID, rctx.recordKey)
+	var plan *deletePlan
 	err = rctx.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
 		// Pre-flight existence check. tx.Delete may or may not error
 		// on missing keys depending on the backend; we want an
@@ -236,10 +303,70 @@ func runDeleteByID(
 		if !probe.Exists() {
 			return fmt.Errorf("record not found: %s", id)
 		}
-		return tx.Delete(ctx, key)
+		// Apply the on_delete actions of the foreign keys referencing the
+		// record; a restricted reference fails before anything is deleted.
+		var planErr error
+		if plan, planErr = planLocalDelete(rctx.dirPath, rctx.def, rctx.colDef.ID, []string{rctx.recordKey}); planErr != nil {
+			return planErr
+		}
+		if delErr := tx.Delete(ctx, key); delErr != nil {
+			return delErr
+		}
+		return applyDeletePlanChildren(ctx, tx, plan)
 	})
 	if err != nil {
+		return plan.restoreLists(err)
+	}
+	if err = buildDeletePlanViews(ctx, rctx, plan); err != nil {
+		return err
+	}
+	return buildLocalViews(ctx, rctx)
+}
+
+// deleteListRecordByID is the --id mode of a local list collection: the
+// row is removed from the collection's list file.
+func deleteListRecordByID(ctx context.Context, rctx recordContext, id string) error {
+	_, _, records, err := readListFile(rctx.colDef)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(records, func(r recordmerge.Record) bool { return r.Key == rctx.recordKey }) {
+		return fmt.Errorf("record not found: %s", id)
+	}
+	plan, err := planLocalDelete(rctx.dirPath, rctx.def, rctx.colDef.ID, []string{rctx.recordKey})
+	if err != nil {
+		return err
+	}
+	if err = deleteListRecordsWithPlan(ctx, rctx, []string{rctx.recordKey}, plan); err != nil {
+		return err
+	}
+	if err = buildDeletePlanViews(ctx, rctx, plan); err != nil {
 		return err
 	}
 	return buildLocalViews(ctx, rctx)
 }
+
+// deleteListRecordsWithPlan removes keys from the list file of a local list
+// collection and then applies the on_delete changes of plan to the
+// referencing records in one read-write transaction. When that transaction
+// fails, the list file is put back as it was.
+func deleteListRecordsWithPlan(ctx context.Context, rctx recordContext, keys []string, plan *deletePlan) error {
+	previous, err := deleteListRecords(rctx.colDef, keys)
+	if err != nil {
+		return err
+	}
+	err = rctx.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		return applyDeletePlanChildren(ctx, tx, plan)
+	})
+	if err == nil {
+		return nil
+	}
+	err = plan.restoreLists(err)
+	if len(keys) > 0 {
+		path := listRecordFilePath(rctx.colDef)
+		if restoreErr := os.WriteFile(path, previous, 0o644); restoreErr != nil {
+			return fmt.Errorf("%w (restoring %s also failed: %v)", err, path, restoreErr)
+		}
+	}
+	return err
+}
@@ -393,3 +393,127 @@ func TestDelete_MinAffected_WithAll(t *testing.T) {
 		t.Fatal("expected error when collection (0) < threshold (4)")
 	}
 }
+
+// fkDeleteDeps is deleteTestDeps over fkTestDefinition.
+func fkDeleteDeps(dir string) (
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDef func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) {
+	def := fkTestDefinition(dir)
+	homeDir = func() (string, error) { return "/tmp/home", nil }
+	getWd = func() (string, error) { return dir, nil }
+	readDef = func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+	newDB = func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	logf = func(...any) {}
+	return
+}
+
+func TestDelete_ForeignKeyRestrict(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	homeDir, getWd, readDef, newDB, logf := fkDeleteDeps(dir)
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=countries/ie",
+	)
+	if err == nil || !strings.Contains(err.Error(), "(on_delete: restrict)") {
+		t.Fatalf("expected a restricted delete, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "countries", "$records", "ie.yaml")); statErr != nil {
+		t.Errorf("a restricted record MUST NOT be deleted: %v", statErr)
+	}
+}
+
+func TestDelete_ForeignKeyCascade(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  country:\n    on_delete: cascade\n")
+	writeFixTestFile(t, dir, "streets/.collection/constraints.yaml", "columns:\n  city:\n    on_delete: set_null\n")
+	homeDir, getWd, readDef, newDB, logf := fkDeleteDeps(dir)
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--from=countries", "--where=name==Ireland",
+	)
+	if err != nil {
+		t.Fatalf("expected success: %v", err)
+	}
+	for _, rel := range []string{"countries/$records/ie.yaml", "cities/$records/dublin.yaml", "cities/$records/cork.yaml"} {
+		if _, statErr := os.Stat(filepath.Join(dir, filepath.FromSlash(rel))); !os.IsNotExist(statErr) {
+			t.Errorf("%s should be deleted, stat: %v", rel, statErr)
+		}
+	}
+	street := readFixTestFile(t, filepath.Join(dir, "streets", "$records", "oconnell.yaml"))
+	if strings.Contains(street, "city") || !strings.Contains(street, "O'Connell Street") {
+		t.Errorf("on_delete set_null should only drop the city field, got:\n%s", street)
+	}
+}
+
+func TestDelete_ForeignKeyCascadeIntoListCollections(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	def := fkTestDefinition(dir)
+	for _, id := range []string{"cities", "streets"} {
+		def.Collections[id].RecordFile = &ingitdb.RecordFileDef{Name: id + ".yaml", Format: "yaml", RecordType: ingitdb.ListOfRecords}
+		def.Collections[id].Columns["id"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	}
+	writeFixTestFile(t, dir, "countries/$records/ie.yaml", "name: Ireland\n")
+	writeFixTestFile(t, dir, "countries/$records/fr.yaml", "name: France\n")
+	cities := writeFixTestFile(t, dir, "cities/cities.yaml",
+		"- id: dublin\n  country: ie\n- id: paris\n  country: fr\n- id: cork\n  country: ie\n")
+	streets := writeFixTestFile(t, dir, "streets/streets.yaml",
+		"- id: oconnell\n  city: dublin\n  name: O'Connell Street\n- id: rivoli\n  city: paris\n  name: Rue de Rivoli\n")
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  country:\n    on_delete: cascade\n")
+	writeFixTestFile(t, dir, "streets/.collection/constraints.yaml", "columns:\n  city:\n    on_delete: set_null\n")
+	homeDir, getWd, _, newDB, logf := fkDeleteDeps(dir)
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf, "--path="+dir, "--id=countries/ie")
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got, want := readFixTestFile(t, cities), "- country: fr\n  id: paris\n"; got != want {
+		t.Errorf("cities.yaml:\n got %q\nwant %q", got, want)
+	}
+	wantStreets := "- id: oconnell\n  name: O'Connell Street\n- city: paris\n  id: rivoli\n  name: Rue de Rivoli\n"
+	if got := readFixTestFile(t, streets); got != wantStreets {
+		t.Errorf("streets.yaml:\n got %q\nwant %q", got, wantStreets)
+	}
+}
+
+func TestDelete_ListCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := listCmdDeps(t, dir,
+		"- id: ie\n  region: west\n- id: fr\n  region: west\n- id: pl\n  region: east\n- id: cz\n  region: east\n")
+
+	_, err := runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--from=test.countries", "--where=region==west",
+	)
+	if err != nil {
+		t.Fatalf("set mode: %v", err)
+	}
+	_, err = runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.countries/cz",
+	)
+	if err != nil {
+		t.Fatalf("--id mode: %v", err)
+	}
+	want := "- id: pl\n  region: east\n"
+	if got := readListFixture(t, dir); got != want {
+		t.Errorf("countries.yaml:\n%s\nwant:\n%s", got, want)
+	}
+
+	_, err = runDeleteCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.countries/cz",
+	)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+}
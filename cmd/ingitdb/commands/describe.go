@@ -48,6 +48,8 @@ func Describe(
 		"explicit provider id (github, gitlab, bitbucket)")
 	cmd.PersistentFlags().String("format", "",
 		"output format: yaml (default), json, native, sql")
+	cmd.PersistentFlags().String("dialect", "",
+		"SQL dialect of --format=sql: postgres (default), sqlite, mysql")
 
 	cmd.AddCommand(
 		describeCollectionCmd(homeDir, getWd, readDefinition),
@@ -169,6 +171,19 @@ func describeCollectionFromDef(
 	if !ok {
 		return fmt.Errorf("collection %q not found in database at %s", name, dirPath)
 	}
+	if format == "sql" {
+		rawDialect, _ := cmd.Flags().GetString("dialect")
+		dialect, dialectErr := resolveDialect(rawDialect)
+		if dialectErr != nil {
+			return dialectErr
+		}
+		ddl, ddlErr := collectionDDL(def, col, dialect)
+		if ddlErr != nil {
+			return ddlErr
+		}
+		_, err = fmt.Fprint(cmd.OutOrStdout(), ddl)
+		return err
+	}
 	views, subcols, _ := discoverCollectionChildren(dirPath, name)
 	node, _ := buildCollectionPayload(col, collectionOutputCtx{
 		relPath:            name,
@@ -280,6 +295,9 @@ func describeViewFromMatches(cmd *cobra.Command, name, scopeCol string, matches
 	if err != nil {
 		return err
 	}
+	if format == "sql" {
+		return fmt.Errorf("--format=sql is only supported for collections")
+	}
 	if len(matches) == 0 {
 		if scopeCol != "" {
 			return fmt.Errorf("view %q not found in collection %q", name, scopeCol)
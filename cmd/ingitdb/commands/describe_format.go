@@ -10,7 +10,7 @@ import (
 const (
 	// engineIngitDB is the engine identifier the CLI passes when the
 	// describe command runs against an ingitdb project. resolveFormat
-	// uses it to determine that "sql" is not a supported native output.
+	// uses it to resolve "native" to yaml rather than sql.
 	engineIngitDB = "ingitdb"
 )
 
@@ -22,8 +22,8 @@ const (
 //   - "json"  → "json"
 //   - "native"→ the engine's canonical format ("yaml" for ingitdb;
 //     "sql" for any non-ingitdb engine)
-//   - "sql"/"SQL" → routes to native; for the ingitdb engine this is
-//     reported as an error so the caller surfaces it to the user.
+//   - "sql"/"SQL" → "sql"; for the ingitdb engine that is DDL mirroring
+//     the collection (see collectionDDL), not the native format.
 //
 // Any other value produces an error listing the accepted values.
 func resolveFormat(raw, engine string) (string, error) {
@@ -40,9 +40,6 @@ func resolveFormat(raw, engine string) (string, error) {
 		}
 		return "sql", nil
 	case "sql":
-		if engine == engineIngitDB {
-			return "", fmt.Errorf(`engine %q native format is "yaml"; use --format=yaml or --format=native`, engine)
-		}
 		return "sql", nil
 	default:
 		return "", fmt.Errorf("invalid --format value %q (valid values: yaml, json, native, sql)", raw)
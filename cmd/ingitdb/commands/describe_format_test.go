@@ -20,10 +20,8 @@ func TestResolveFormat(t *testing.T) {
 		{name: "json", raw: "json", engine: "ingitdb", want: "json"},
 		{name: "native_on_ingitdb_is_yaml", raw: "native", engine: "ingitdb", want: "yaml"},
 		{name: "native_on_sql_engine_is_sql", raw: "native", engine: "sqlite", want: "sql"},
-		{name: "sql_on_ingitdb_errors", raw: "sql", engine: "ingitdb", wantErr: true,
-			errSubstr: `engine "ingitdb" native format is "yaml"; use --format=yaml or --format=native`},
-		{name: "SQL_case_insensitive_on_ingitdb_errors", raw: "SQL", engine: "ingitdb", wantErr: true,
-			errSubstr: `engine "ingitdb" native format is "yaml"; use --format=yaml or --format=native`},
+		{name: "sql_on_ingitdb_is_ddl", raw: "sql", engine: "ingitdb", want: "sql"},
+		{name: "SQL_case_insensitive_on_ingitdb", raw: "SQL", engine: "ingitdb", want: "sql"},
 		{name: "sql_on_sql_engine_passes", raw: "sql", engine: "sqlite", want: "sql"},
 		{name: "unknown_value_lists_options", raw: "xml", engine: "ingitdb", wantErr: true,
 			errSubstr: "valid values: yaml, json, native, sql"},
@@ -0,0 +1,204 @@
+package commands
+
+// specscore: feature/cli/describe
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// SQL dialects `describe --format=sql` can emit DDL for.
+const (
+	sqlDialectPostgres = "postgres"
+	sqlDialectSQLite   = "sqlite"
+	sqlDialectMySQL    = "mysql"
+)
+
+// sqlKeyColumn is the column a table gets for the record key when the
+// collection declares no primary_key. It is the key column the list-record
+// reader falls back to, so collections that already store it keep it.
+const sqlKeyColumn = "id"
+
+// resolveDialect normalises --dialect: empty is postgres; anything but
+// postgres, sqlite and mysql is an error.
+func resolveDialect(raw string) (string, error) {
+	switch strings.ToLower(raw) {
+	case "", sqlDialectPostgres, "postgresql":
+		return sqlDialectPostgres, nil
+	case sqlDialectSQLite:
+		return sqlDialectSQLite, nil
+	case sqlDialectMySQL:
+		return sqlDialectMySQL, nil
+	}
+	return "", fmt.Errorf("invalid --dialect value %q (valid values: postgres, sqlite, mysql)", raw)
+}
+
+// sqlQuote quotes an identifier for dialect: backticks for MySQL, double
+// quotes otherwise.
+func sqlQuote(dialect, name string) string {
+	if dialect == sqlDialectMySQL {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqlColumnType maps a column to a SQL type of dialect. Strings with a
+// declared length become VARCHAR; maps, lists and any become JSON where the
+// dialect has a JSON type and TEXT where it does not. indexed marks columns
+// of the primary, a unique or a foreign key, which MySQL cannot index as TEXT.
+func sqlColumnType(dialect string, col *ingitdb.ColumnDef, indexed bool) string {
+	switch col.Type {
+	case ingitdb.ColumnTypeString:
+		if dialect == sqlDialectSQLite {
+			return "TEXT"
+		}
+		switch {
+		case col.MaxLength != nil:
+			return fmt.Sprintf("VARCHAR(%d)", *col.MaxLength)
+		case col.Length != nil:
+			return fmt.Sprintf("VARCHAR(%d)", *col.Length)
+		case indexed && dialect == sqlDialectMySQL:
+			return "VARCHAR(255)"
+		}
+		return "TEXT"
+	case ingitdb.ColumnTypeInt:
+		if dialect == sqlDialectSQLite {
+			return "INTEGER"
+		}
+		return "BIGINT"
+	case ingitdb.ColumnTypeFloat:
+		switch dialect {
+		case sqlDialectSQLite:
+			return "REAL"
+		case sqlDialectMySQL:
+			return "DOUBLE"
+		}
+		return "DOUBLE PRECISION"
+	case ingitdb.ColumnTypeBool:
+		if dialect == sqlDialectSQLite {
+			return "INTEGER"
+		}
+		return "BOOLEAN"
+	case ingitdb.ColumnTypeDate, ingitdb.ColumnTypeTime, ingitdb.ColumnTypeDateTime:
+		switch {
+		case dialect == sqlDialectSQLite:
+			return "TEXT"
+		case col.Type == ingitdb.ColumnTypeDate:
+			return "DATE"
+		case col.Type == ingitdb.ColumnTypeTime:
+			return "TIME"
+		case dialect == sqlDialectMySQL:
+			return "DATETIME"
+		}
+		return "TIMESTAMP"
+	}
+	switch dialect {
+	case sqlDialectPostgres:
+		return "JSONB"
+	case sqlDialectMySQL:
+		return "JSON"
+	}
+	return "TEXT"
+}
+
+// sqlPrimaryKey returns the primary key columns of col: its primary_key, or
+// sqlKeyColumn for the record key.
+func sqlPrimaryKey(col *ingitdb.CollectionDef) []string {
+	if len(col.PrimaryKey) > 0 {
+		return col.PrimaryKey
+	}
+	return []string{sqlKeyColumn}
+}
+
+// sqlKeyColumnDef returns the column a foreign key into col references: its
+// single primary_key column, or a string for the record key. It returns nil
+// for a composite primary key.
+func sqlKeyColumnDef(col *ingitdb.CollectionDef) *ingitdb.ColumnDef {
+	switch len(col.PrimaryKey) {
+	case 0:
+		return &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	case 1:
+		return col.Columns[col.PrimaryKey[0]]
+	}
+	return nil
+}
+
+// collectionDDL renders a CREATE TABLE statement mirroring col: the stored
+// columns (computed ones are left out), NOT NULL for required and key
+// columns, the primary key, the unique keys of constraints.yaml and the
+// foreign keys with their on_delete action.
+func collectionDDL(def *ingitdb.Definition, col *ingitdb.CollectionDef, dialect string) (string, error) {
+	cons, err := readCollectionConstraints(col)
+	if err != nil {
+		return "", err
+	}
+	pk := sqlPrimaryKey(col)
+	names := slices.Clone(col.ColumnsOrder)
+	for _, name := range slices.Sorted(maps.Keys(col.Columns)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	uniqueKeys := cons.uniqueKeys()
+	fks := foreignKeyColumns(col, def)
+	var lines []string
+	if col.Columns[sqlKeyColumn] == nil && len(col.PrimaryKey) == 0 {
+		keyDef := &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+		lines = append(lines, fmt.Sprintf("%s %s NOT NULL", sqlQuote(dialect, sqlKeyColumn), sqlColumnType(dialect, keyDef, true)))
+	}
+	for _, name := range names {
+		c := col.Columns[name]
+		if c == nil || c.Formula != "" {
+			continue
+		}
+		keyPart := slices.Contains(pk, name)
+		indexed := keyPart || slices.ContainsFunc(uniqueKeys, func(key []string) bool { return slices.Contains(key, name) })
+		typeDef := c
+		if target, ok := fks[name]; ok {
+			indexed = true
+			// A foreign key column takes the type of the key it references,
+			// so both sides of the constraint compare alike.
+			if keyDef := sqlKeyColumnDef(def.Collections[target]); keyDef != nil {
+				typeDef = keyDef
+			}
+		}
+		line := sqlQuote(dialect, name) + " " + sqlColumnType(dialect, typeDef, indexed)
+		if c.Required || keyPart {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "PRIMARY KEY ("+sqlQuoteList(dialect, pk)+")")
+	for _, key := range uniqueKeys {
+		lines = append(lines, "UNIQUE ("+sqlQuoteList(dialect, key)+")")
+	}
+	for _, name := range slices.Sorted(maps.Keys(fks)) {
+		if c := col.Columns[name]; c == nil || c.Formula != "" {
+			continue
+		}
+		target := fks[name]
+		line := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+			sqlQuote(dialect, name), sqlQuote(dialect, target), sqlQuoteList(dialect, sqlPrimaryKey(def.Collections[target])))
+		switch cons.onDelete(name) {
+		case onDeleteCascade:
+			line += " ON DELETE CASCADE"
+		case onDeleteSetNull:
+			line += " ON DELETE SET NULL"
+		}
+		lines = append(lines, line)
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n);\n", sqlQuote(dialect, col.ID), strings.Join(lines, ",\n  ")), nil
+}
+
+// sqlQuoteList quotes and comma-joins a list of column names.
+func sqlQuoteList(dialect string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = sqlQuote(dialect, name)
+	}
+	return strings.Join(quoted, ", ")
+}
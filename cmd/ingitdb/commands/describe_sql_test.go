@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestCollectionDDL(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml",
+		"columns:\n  country:\n    on_delete: cascade\n  name:\n    unique: true\n")
+	maxCode := 2
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID:         "countries",
+			PrimaryKey: []string{"code"},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"code":   {Type: ingitdb.ColumnTypeString, MaxLength: &maxCode},
+				"titles": {Type: ingitdb.ColumnTypeL10N, Required: true},
+			},
+		},
+		"cities": {
+			ID:           "cities",
+			DirPath:      filepath.Join(dir, "cities"),
+			ColumnsOrder: []string{"name"},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"name":       {Type: ingitdb.ColumnTypeString, Required: true},
+				"country":    {Type: ingitdb.ColumnTypeString, ForeignKey: "countries"},
+				"population": {Type: ingitdb.ColumnTypeInt},
+				"area":       {Type: ingitdb.ColumnTypeFloat},
+				"capital":    {Type: ingitdb.ColumnTypeBool},
+				"founded":    {Type: ingitdb.ColumnTypeDateTime},
+				"density":    {Type: ingitdb.ColumnTypeFloat, Formula: "population / area"},
+			},
+		},
+	}}
+	tests := []struct {
+		dialect    string
+		collection string
+		want       string
+	}{
+		{
+			dialect:    sqlDialectPostgres,
+			collection: "cities",
+			want: `CREATE TABLE "cities" (
+  "id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "area" DOUBLE PRECISION,
+  "capital" BOOLEAN,
+  "country" VARCHAR(2),
+  "founded" TIMESTAMP,
+  "population" BIGINT,
+  PRIMARY KEY ("id"),
+  UNIQUE ("name"),
+  FOREIGN KEY ("country") REFERENCES "countries" ("code") ON DELETE CASCADE
+);
+`,
+		},
+		{
+			dialect:    sqlDialectSQLite,
+			collection: "cities",
+			want: `CREATE TABLE "cities" (
+  "id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "area" REAL,
+  "capital" INTEGER,
+  "country" TEXT,
+  "founded" TEXT,
+  "population" INTEGER,
+  PRIMARY KEY ("id"),
+  UNIQUE ("name"),
+  FOREIGN KEY ("country") REFERENCES "countries" ("code") ON DELETE CASCADE
+);
+`,
+		},
+		{
+			dialect:    sqlDialectMySQL,
+			collection: "countries",
+			want: "CREATE TABLE `countries` (\n" +
+				"  `code` VARCHAR(2) NOT NULL,\n" +
+				"  `titles` JSON NOT NULL,\n" +
+				"  PRIMARY KEY (`code`)\n" +
+				");\n",
+		},
+		{
+			dialect:    sqlDialectMySQL,
+			collection: "cities",
+			want: "CREATE TABLE `cities` (\n" +
+				"  `id` VARCHAR(255) NOT NULL,\n" +
+				"  `name` VARCHAR(255) NOT NULL,\n" +
+				"  `area` DOUBLE,\n" +
+				"  `capital` BOOLEAN,\n" +
+				"  `country` VARCHAR(2),\n" +
+				"  `founded` DATETIME,\n" +
+				"  `population` BIGINT,\n" +
+				"  PRIMARY KEY (`id`),\n" +
+				"  UNIQUE (`name`),\n" +
+				"  FOREIGN KEY (`country`) REFERENCES `countries` (`code`) ON DELETE CASCADE\n" +
+				");\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := collectionDDL(def, def.Collections[tt.collection], tt.dialect)
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.dialect, tt.collection, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %s:\n got:\n%s\nwant:\n%s", tt.dialect, tt.collection, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDialect(t *testing.T) {
+	t.Parallel()
+	for raw, want := range map[string]string{"": "postgres", "PostgreSQL": "postgres", "sqlite": "sqlite", "MySQL": "mysql"} {
+		if got, err := resolveDialect(raw); err != nil || got != want {
+			t.Errorf("resolveDialect(%q) = %q, %v; want %q", raw, got, err, want)
+		}
+	}
+	if _, err := resolveDialect("oracle"); err == nil {
+		t.Error("expected an error for an unknown dialect")
+	}
+}
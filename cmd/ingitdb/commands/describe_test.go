@@ -225,22 +225,29 @@ func TestDescribeCollection_JSONFormat(t *testing.T) {
 	}
 }
 
-func TestDescribeCollection_SQLFormatErrors(t *testing.T) {
+func TestDescribeCollection_SQLFormat(t *testing.T) {
 	t.Parallel()
 	dir := describeFixtureDB(t, map[string]*ingitdb.CollectionDef{
 		"users": {
 			ID:         "users",
 			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: "yaml", RecordType: ingitdb.SingleRecord},
-			Columns:    map[string]*ingitdb.ColumnDef{"id": {Type: ingitdb.ColumnTypeString}},
+			Columns:    map[string]*ingitdb.ColumnDef{"email": {Type: ingitdb.ColumnTypeString, Required: true}},
 		},
 	}, nil)
 	cmd := Describe(func() (string, error) { return "/tmp", nil },
 		func() (string, error) { return dir, nil },
 		ingitdbValidatorReadDef)
-	err := runCobraCommand(cmd, "collection", "users", "--path="+dir, "--format=sql")
-	if err == nil || !strings.Contains(err.Error(),
-		`engine "ingitdb" native format is "yaml"; use --format=yaml or --format=native`) {
-		t.Fatalf("want SQL-on-ingitdb error; got: %v", err)
+	out, err := captureStdout(t, cmd, "collection", "users", "--path="+dir, "--format=SQL", "--dialect=mysql")
+	if err != nil {
+		t.Fatalf("describe --format=sql: %v", err)
+	}
+	want := "CREATE TABLE `users` (\n  `id` VARCHAR(255) NOT NULL,\n  `email` TEXT NOT NULL,\n  PRIMARY KEY (`id`)\n);\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	err = runCobraCommand(cmd, "collection", "users", "--path="+dir, "--format=sql", "--dialect=oracle")
+	if err == nil || !strings.Contains(err.Error(), "valid values: postgres, sqlite, mysql") {
+		t.Errorf("want an invalid-dialect error; got: %v", err)
 	}
 }
 
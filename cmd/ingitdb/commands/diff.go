@@ -220,13 +220,13 @@ func parseKeyedRecords(content []byte, colDef *ingitdb.CollectionDef, relPath st
 			out[k] = v
 		}
 	case ingitdb.ListOfRecords:
-		rows, err := ingitdb.ParseListOfRecordsContent(content, colDef.RecordFile.Format)
+		keys, rows, err := readListRecords(content, colDef)
 		if err != nil {
 			return out
 		}
-		for _, row := range rows {
-			if key, ok := ingitdb.ResolveListRecordKey(row, colDef); ok {
-				out[key] = row
+		for i, row := range rows {
+			if keys[i] != "" {
+				out[keys[i]] = row
 			}
 		}
 	}
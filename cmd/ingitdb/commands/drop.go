@@ -4,8 +4,10 @@ package commands
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/dal-go/dalgo/dal"
 	"github.com/spf13/cobra"
@@ -16,9 +18,10 @@ import (
 
 // Drop returns the `ingitdb drop` command. Two kinds are supported:
 // `drop collection <name>` and `drop view <name>`. The flags
-// --if-exists (idempotent on missing target) and --cascade (no-op in
-// the current data model; reserved for future cross-object dependency
-// graphs) are inherited by both subcommands.
+// --if-exists (idempotent on missing target), --cascade (also drop the
+// dependents found in the schema dependency graph, see drop_graph.go) and
+// --dry-run (list what would be dropped, change nothing) are inherited by
+// both subcommands.
 func Drop(
 	homeDir func() (string, error),
 	getWd func() (string, error),
@@ -43,7 +46,9 @@ func Drop(
 	cmd.PersistentFlags().String("provider", "",
 		"explicit provider id (github, gitlab, bitbucket) — required for unknown hosts")
 	cmd.PersistentFlags().Bool("if-exists", false, "do not fail when the target does not exist")
-	cmd.PersistentFlags().Bool("cascade", false, "drop dependents along with the target (no-op in the current data model)")
+	cmd.PersistentFlags().Bool("cascade", false,
+		"also drop dependents: views, subcollections, and foreign keys of other collections referencing the target")
+	cmd.PersistentFlags().Bool("dry-run", false, "list what would be dropped without changing anything")
 
 	cmd.AddCommand(
 		dropCollection(homeDir, getWd, readDefinition, newDB, logf),
@@ -67,7 +72,8 @@ func dropCollection(
 		RunE: func(cmd *cobra.Command, args []string) error {
 			_, _, _ = readDefinition, newDB, logf
 			ifExists, _ := cmd.Flags().GetBool("if-exists")
-			_, _ = cmd.Flags().GetBool("cascade") // accepted, no-op
+			cascade, _ := cmd.Flags().GetBool("cascade")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			name := args[0]
 
 			remoteVal, _ := cmd.Flags().GetString("remote")
@@ -79,7 +85,7 @@ func dropCollection(
 				return err
 			}
 			if remoteVal != "" {
-				return dropCollectionRemote(cmd.Context(), cmd, name, ifExists)
+				return dropCollectionRemote(cmd.Context(), cmd, name, ifExists, cascade, dryRun)
 			}
 
 			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
@@ -99,6 +105,28 @@ func dropCollection(
 				return fmt.Errorf("collection %q not found", name)
 			}
 
+			src := localDropSource(dirPath)
+			graph, err := readDropGraph(src, entries)
+			if err != nil {
+				return err
+			}
+			plan, err := planCollectionDrop(graph, name, rel, cascade)
+			if err != nil {
+				return err
+			}
+			if dryRun {
+				return plan.writePreview(cmd.OutOrStdout())
+			}
+			edits, err := plan.foreignKeyEdits(src)
+			if err != nil {
+				return err
+			}
+			for _, file := range slices.Sorted(maps.Keys(edits)) {
+				if writeErr := os.WriteFile(filepath.Join(dirPath, filepath.FromSlash(file)), edits[file], 0o644); writeErr != nil {
+					return fmt.Errorf("drop foreign keys referencing %s from %s: %w", name, file, writeErr)
+				}
+			}
+
 			absCol := filepath.Join(dirPath, rel)
 			if rmErr := os.RemoveAll(absCol); rmErr != nil {
 				return fmt.Errorf("remove collection directory %s: %w", rel, rmErr)
@@ -129,7 +157,8 @@ func dropView(
 		RunE: func(cmd *cobra.Command, args []string) error {
 			_, _, _ = readDefinition, newDB, logf
 			ifExists, _ := cmd.Flags().GetBool("if-exists")
-			_, _ = cmd.Flags().GetBool("cascade") // accepted, no-op
+			_, _ = cmd.Flags().GetBool("cascade") // accepted; nothing depends on a view
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
 			scopeCol, _ := cmd.Flags().GetString("in")
 			name := args[0]
 
@@ -142,7 +171,7 @@ func dropView(
 				return err
 			}
 			if remoteVal != "" {
-				return dropViewRemote(cmd.Context(), cmd, name, scopeCol, ifExists)
+				return dropViewRemote(cmd.Context(), cmd, name, scopeCol, ifExists, dryRun)
 			}
 
 			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
@@ -189,6 +218,10 @@ func dropView(
 				}
 				return fmt.Errorf("view %q not found in any collection", name)
 			case 1:
+				if dryRun {
+					_, err = fmt.Fprintf(cmd.OutOrStdout(), "would drop view %s/%s\n", matches[0].collection, name)
+					return err
+				}
 				return removeViewFiles(matches[0].viewPath, matches[0].colDir)
 			default:
 				cols := make([]string, 0, len(matches))
@@ -0,0 +1,366 @@
+package commands
+
+// specscore: feature/cli/drop
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// Kinds of schema objects that depend on a root collection.
+const (
+	dropKindView          = "view"
+	dropKindSubcollection = "subcollection"
+	dropKindForeignKey    = "foreign key"
+)
+
+// dropKindOrder is the order dependents are listed in.
+var dropKindOrder = []string{dropKindView, dropKindSubcollection, dropKindForeignKey}
+
+// dropDependent is a schema object that depends on a root collection. Views
+// and subcollections live inside the collection directory and go away with
+// it; a foreign key lives in another collection's definition.yaml, where
+// --cascade removes the foreign_key attribute of the column.
+type dropDependent struct {
+	kind string
+
+	// name identifies the object: "cities/active" for view active of
+	// cities, "cities/streets" for a subcollection, "streets.city" for the
+	// city column of streets.
+	name string
+
+	// file is the database-relative path of the file declaring the object.
+	file string
+
+	// column is the referencing column of a foreign key.
+	column string
+
+	// target is the root collection a foreign key references.
+	target string
+}
+
+// String renders the dependent the way previews and errors list it.
+func (d dropDependent) String() string {
+	if d.kind == dropKindForeignKey {
+		return fmt.Sprintf("%s %s (references %s)", d.kind, d.name, d.target)
+	}
+	return d.kind + " " + d.name
+}
+
+// dropSource reads the schema files of a database, local or remote. Paths
+// are slash-separated and relative to the database root.
+type dropSource struct {
+	// listFiles returns every file under the directory dir.
+	listFiles func(dir string) ([]string, error)
+
+	// readFile returns the content of a file; found is false when the file
+	// does not exist.
+	readFile func(file string) (content []byte, found bool, err error)
+}
+
+// localDropSource reads the schema files of the database at dbDir.
+func localDropSource(dbDir string) dropSource {
+	return dropSource{
+		listFiles: func(dir string) ([]string, error) {
+			var files []string
+			root := filepath.Join(dbDir, filepath.FromSlash(dir))
+			err := filepath.WalkDir(root, func(p string, entry fs.DirEntry, walkErr error) error {
+				if walkErr != nil {
+					if p == root && errors.Is(walkErr, fs.ErrNotExist) {
+						return filepath.SkipDir
+					}
+					return walkErr
+				}
+				if entry.IsDir() {
+					return nil
+				}
+				rel, relErr := filepath.Rel(dbDir, p)
+				if relErr != nil {
+					return relErr
+				}
+				files = append(files, filepath.ToSlash(rel))
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("enumerate files under %s: %w", dir, err)
+			}
+			return files, nil
+		},
+		readFile: func(file string) ([]byte, bool, error) {
+			content, err := os.ReadFile(filepath.Join(dbDir, filepath.FromSlash(file)))
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, false, nil
+			}
+			if err != nil {
+				return nil, false, fmt.Errorf("read %s: %w", file, err)
+			}
+			return content, true, nil
+		},
+	}
+}
+
+// dropGraph is the dependency graph of a database's schema: for every root
+// collection, the views and subcollections nested in it and the foreign keys
+// of other collections that reference it.
+type dropGraph struct {
+	dependents map[string][]dropDependent
+}
+
+// readDropGraph builds the dependency graph of the root collections in
+// entries (name → database-relative directory). Both definition layouts are
+// understood: the shared one, with definition.yaml in the collection
+// directory, views in $views/ and subcollections in sibling directories, and
+// the legacy one under .collection/ with views/ and subcollections/.
+func readDropGraph(src dropSource, entries map[string]string) (*dropGraph, error) {
+	roots := make(map[string]*ingitdb.CollectionDef, len(entries))
+	for id := range entries {
+		roots[id] = &ingitdb.CollectionDef{ID: id}
+	}
+	g := &dropGraph{dependents: make(map[string][]dropDependent)}
+	for _, id := range slices.Sorted(maps.Keys(entries)) {
+		rel := path.Clean(entries[id])
+		listed, err := src.listFiles(rel)
+		if err != nil {
+			return nil, err
+		}
+		files := make(map[string]bool, len(listed))
+		for _, f := range listed {
+			if strings.HasPrefix(f, rel+"/") {
+				files[f] = true
+			}
+		}
+		schemaDir, shared := rel, true
+		if !files[path.Join(rel, ingitdb.CollectionDefFileName)] {
+			schemaDir, shared = path.Join(rel, ingitdb.SchemaDir), false
+		}
+		if err = g.addCollection(src, roots, files, id, id, schemaDir, shared); err != nil {
+			return nil, err
+		}
+	}
+	for id := range g.dependents {
+		slices.SortFunc(g.dependents[id], func(a, b dropDependent) int {
+			return cmp.Or(
+				cmp.Compare(slices.Index(dropKindOrder, a.kind), slices.Index(dropKindOrder, b.kind)),
+				cmp.Compare(a.name, b.name))
+		})
+	}
+	return g, nil
+}
+
+// addCollection records the dependents declared by the collection (root
+// collection rootID, or one of its subcollections) whose definition lives in
+// schemaDir.
+func (g *dropGraph) addCollection(src dropSource, roots map[string]*ingitdb.CollectionDef, files map[string]bool, rootID, fullPath, schemaDir string, shared bool) error {
+	viewsDir := path.Join(schemaDir, "views")
+	subDir := path.Join(schemaDir, "subcollections")
+	if shared {
+		viewsDir = path.Join(schemaDir, ingitdb.SharedViewsDir)
+		subDir = schemaDir
+	}
+	var subs []string
+	for _, f := range slices.Sorted(maps.Keys(files)) {
+		if dir, base := path.Split(f); path.Clean(dir) == viewsDir && path.Ext(base) == ".yaml" {
+			g.dependents[rootID] = append(g.dependents[rootID], dropDependent{
+				kind: dropKindView,
+				name: fullPath + "/" + strings.TrimSuffix(base, ".yaml"),
+				file: f,
+			})
+			continue
+		}
+		rest, ok := strings.CutPrefix(f, subDir+"/")
+		if !ok {
+			continue
+		}
+		sub, file, nested := strings.Cut(rest, "/")
+		if nested && file == ingitdb.CollectionDefFileName && !strings.HasPrefix(sub, "$") {
+			subs = append(subs, sub)
+		}
+	}
+
+	defFile := path.Join(schemaDir, ingitdb.CollectionDefFileName)
+	content, found, err := src.readFile(defFile)
+	if err != nil {
+		return err
+	}
+	if found {
+		var def struct {
+			Columns map[string]struct {
+				ForeignKey string `yaml:"foreign_key"`
+			} `yaml:"columns"`
+		}
+		if err = yaml.Unmarshal(content, &def); err != nil {
+			return fmt.Errorf("parse %s: %w", defFile, err)
+		}
+		for _, column := range slices.Sorted(maps.Keys(def.Columns)) {
+			fk := def.Columns[column].ForeignKey
+			if fk == "" {
+				continue
+			}
+			target, ok := ingitdb.ResolveForeignKey(rootID, fk, roots)
+			if !ok || target == rootID {
+				continue
+			}
+			g.dependents[target] = append(g.dependents[target], dropDependent{
+				kind:   dropKindForeignKey,
+				name:   fullPath + "." + column,
+				file:   defFile,
+				column: column,
+				target: target,
+			})
+		}
+	}
+
+	for _, sub := range subs {
+		g.dependents[rootID] = append(g.dependents[rootID], dropDependent{
+			kind: dropKindSubcollection,
+			name: fullPath + "/" + sub,
+			file: path.Join(subDir, sub, ingitdb.CollectionDefFileName),
+		})
+		if err = g.addCollection(src, roots, files, rootID, fullPath+"/"+sub, path.Join(subDir, sub), shared); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dependentsOf returns the dependents of the root collection id: views
+// first, then subcollections, then foreign keys, each sorted by name.
+func (g *dropGraph) dependentsOf(id string) []dropDependent {
+	return g.dependents[id]
+}
+
+// collectionDrop is everything `drop collection` removes: the collection
+// directory and its registry entry, plus, with --cascade, the foreign keys
+// other collections declare on it.
+type collectionDrop struct {
+	name       string
+	rel        string
+	dependents []dropDependent
+}
+
+// planCollectionDrop collects the dependents of the root collection name.
+// Without cascade, any dependent fails the drop with an error listing them
+// all, before anything is written.
+func planCollectionDrop(g *dropGraph, name, rel string, cascade bool) (*collectionDrop, error) {
+	d := &collectionDrop{name: name, rel: path.Clean(rel), dependents: g.dependentsOf(name)}
+	if len(d.dependents) > 0 && !cascade {
+		listed := make([]string, len(d.dependents))
+		for i, dep := range d.dependents {
+			listed[i] = dep.String()
+		}
+		return nil, fmt.Errorf("cannot drop collection %q: %d dependent object(s) — %s; use --cascade to drop them too",
+			name, len(d.dependents), strings.Join(listed, ", "))
+	}
+	return d, nil
+}
+
+// writePreview lists, one per line, every object the drop removes.
+func (d *collectionDrop) writePreview(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "would drop collection %s (%s)\n", d.name, d.rel); err != nil {
+		return err
+	}
+	for _, dep := range d.dependents {
+		if _, err := fmt.Fprintf(w, "would drop %s\n", dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foreignKeyEdits returns the new content of the definition.yaml and
+// constraints.yaml files that declare a foreign key on the dropped
+// collection, keyed by database-relative path: the foreign_key attribute of
+// each referencing column is removed, and so is its on_delete action. The
+// columns themselves and their values are kept.
+func (d *collectionDrop) foreignKeyEdits(src dropSource) (map[string][]byte, error) {
+	edits := make(map[string][]byte)
+	edit := func(file string, keyPath ...string) error {
+		content, ok := edits[file]
+		if !ok {
+			var found bool
+			var err error
+			if content, found, err = src.readFile(file); err != nil || !found {
+				return err
+			}
+		}
+		updated, changed, err := removeYAMLKey(content, keyPath...)
+		if err != nil {
+			return fmt.Errorf("update %s: %w", file, err)
+		}
+		if changed {
+			edits[file] = updated
+		}
+		return nil
+	}
+	for _, dep := range d.dependents {
+		if dep.kind != dropKindForeignKey {
+			continue
+		}
+		if err := edit(dep.file, "columns", dep.column, "foreign_key"); err != nil {
+			return nil, err
+		}
+		constraintsFile := path.Join(path.Dir(dep.file), collectionConstraintsFileName)
+		if err := edit(constraintsFile, "columns", dep.column, "on_delete"); err != nil {
+			return nil, err
+		}
+	}
+	return edits, nil
+}
+
+// removeYAMLKey removes the key at keyPath (a chain of mapping keys) from a
+// YAML document, keeping the order of the other keys and their comments.
+// changed is false when the document does not hold the key.
+func removeYAMLKey(content []byte, keyPath ...string) (updated []byte, changed bool, err error) {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(content, &doc); err != nil {
+		return nil, false, err
+	}
+	if len(doc.Content) == 0 {
+		return content, false, nil
+	}
+	node := doc.Content[0]
+	for i, key := range keyPath {
+		if node.Kind != yaml.MappingNode {
+			return content, false, nil
+		}
+		idx := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return content, false, nil
+		}
+		if i == len(keyPath)-1 {
+			node.Content = slices.Delete(node.Content, idx, idx+2)
+			break
+		}
+		node = node.Content[idx+1]
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return nil, false, err
+	}
+	if err = enc.Close(); err != nil {
+		return nil, false, err
+	}
+	return buf.Bytes(), true, nil
+}
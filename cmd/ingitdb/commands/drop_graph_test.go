@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// seedDropGraphDB writes a database where countries uses the shared layout
+// with a view and a subcollection (regions, itself with a view), cities uses
+// the legacy layout with a view and a foreign key to countries, and streets
+// references both cities and, through its districts subcollection, countries.
+func seedDropGraphDB(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	writeFixTestFile(t, dir, ".collections/countries/definition.yaml", "columns:\n  name: {type: string}\n")
+	writeFixTestFile(t, dir, ".collections/countries/$views/by_name.yaml", "order_by: name\n")
+	writeFixTestFile(t, dir, ".collections/countries/$records/ie.yaml", "name: Ireland\n")
+	writeFixTestFile(t, dir, ".collections/countries/regions/definition.yaml", "columns:\n  country: {type: string, foreign_key: countries}\n")
+	writeFixTestFile(t, dir, ".collections/countries/regions/$views/all.yaml", "order_by: country\n")
+	writeFixTestFile(t, dir, "cities/.collection/definition.yaml",
+		"# Cities of the world.\ncolumns:\n  name:\n    type: string\n  country:\n    type: string\n    foreign_key: countries # the country\n")
+	writeFixTestFile(t, dir, "cities/.collection/views/big.yaml", "where: population > 1000000\n")
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  country:\n    on_delete: cascade\n  name:\n    unique: true\n")
+	writeFixTestFile(t, dir, "streets/.collection/definition.yaml", "columns:\n  city: {type: string, foreign_key: cities}\n")
+	writeFixTestFile(t, dir, "streets/.collection/subcollections/districts/definition.yaml", "columns:\n  country: {type: string, foreign_key: countries}\n")
+	return map[string]string{"countries": ".collections/countries", "cities": "cities", "streets": "streets"}
+}
+
+func TestReadDropGraph(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	entries := seedDropGraphDB(t, dir)
+	graph, err := readDropGraph(localDropSource(dir), entries)
+	if err != nil {
+		t.Fatalf("read graph: %v", err)
+	}
+	cases := map[string][]string{
+		"countries": {
+			"view countries/by_name",
+			"view countries/regions/all",
+			"subcollection countries/regions",
+			"foreign key cities.country (references countries)",
+			"foreign key streets/districts.country (references countries)",
+		},
+		"cities":  {"view cities/big", "foreign key streets.city (references cities)"},
+		"streets": {"subcollection streets/districts"},
+	}
+	for id, want := range cases {
+		var got []string
+		for _, dep := range graph.dependentsOf(id) {
+			got = append(got, dep.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("dependents of %s:\n got %q\nwant %q", id, got, want)
+		}
+	}
+}
+
+func TestPlanCollectionDrop(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	entries := seedDropGraphDB(t, dir)
+	src := localDropSource(dir)
+	graph, err := readDropGraph(src, entries)
+	if err != nil {
+		t.Fatalf("read graph: %v", err)
+	}
+
+	_, err = planCollectionDrop(graph, "cities", "cities", false)
+	want := `cannot drop collection "cities": 2 dependent object(s) — view cities/big, foreign key streets.city (references cities); use --cascade to drop them too`
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+
+	plan, err := planCollectionDrop(graph, "countries", entries["countries"], true)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	var preview bytes.Buffer
+	if err = plan.writePreview(&preview); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	wantPreview := "would drop collection countries (.collections/countries)\n" +
+		"would drop view countries/by_name\n" +
+		"would drop view countries/regions/all\n" +
+		"would drop subcollection countries/regions\n" +
+		"would drop foreign key cities.country (references countries)\n" +
+		"would drop foreign key streets/districts.country (references countries)\n"
+	if preview.String() != wantPreview {
+		t.Errorf("preview:\n%s\nwant:\n%s", preview.String(), wantPreview)
+	}
+
+	edits, err := plan.foreignKeyEdits(src)
+	if err != nil {
+		t.Fatalf("edits: %v", err)
+	}
+	wantEdits := map[string]string{
+		"cities/.collection/definition.yaml":                           "# Cities of the world.\ncolumns:\n  name:\n    type: string\n  country:\n    type: string\n",
+		"cities/.collection/constraints.yaml":                          "columns:\n  country: {}\n  name:\n    unique: true\n",
+		"streets/.collection/subcollections/districts/definition.yaml": "columns:\n  country: {type: string}\n",
+	}
+	if len(edits) != len(wantEdits) {
+		t.Errorf("edited %d files, want %d: %v", len(edits), len(wantEdits), edits)
+	}
+	for file, content := range wantEdits {
+		if string(edits[file]) != content {
+			t.Errorf("%s:\n got %q\nwant %q", file, edits[file], content)
+		}
+	}
+
+	if plan, err = planCollectionDrop(graph, "streets", "streets", false); err == nil {
+		t.Errorf("a subcollection must block the drop, got plan %+v", plan)
+	}
+}
@@ -5,7 +5,9 @@ package commands
 import (
 	"context"
 	"fmt"
+	"maps"
 	"path"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -18,9 +20,9 @@ import (
 // dropCollectionRemote drops a collection from a remote repository in a
 // single atomic commit (per spec REQ:one-commit-per-write). It enumerates
 // every file under the collection's data directory via the Git Data API
-// and bundles the deletions plus the root-collections.yaml update into one
-// commit.
-func dropCollectionRemote(ctx context.Context, cmd *cobra.Command, name string, ifExists bool) error {
+// and bundles the deletions, the root-collections.yaml update and, with
+// cascade, the definitions losing a foreign key into one commit.
+func dropCollectionRemote(ctx context.Context, cmd *cobra.Command, name string, ifExists, cascade, dryRun bool) error {
 	spec, cfg, err := remoteConfigFromCmd(cmd)
 	if err != nil {
 		return err
@@ -43,27 +45,52 @@ func dropCollectionRemote(ctx context.Context, cmd *cobra.Command, name string,
 	}
 	_ = rootContent // currently we re-marshal from the map; keeping the var for symmetry with future preservation of comments.
 
-	// 2. List every blob under the collection's directory.
+	// 2. Check the dependents of the collection.
 	writer, err := treeWriterFactory.NewTreeWriter(cfg)
 	if err != nil {
 		return fmt.Errorf("init remote writer: %w", err)
 	}
+	reader, err := gitHubFileReaderFactory.NewGitHubFileReader(cfg)
+	if err != nil {
+		return fmt.Errorf("init remote reader: %w", err)
+	}
+	src := remoteDropSource(ctx, reader, writer)
+	graph, err := readDropGraph(src, rootEntries)
+	if err != nil {
+		return err
+	}
+	plan, err := planCollectionDrop(graph, name, colDir, cascade)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return plan.writePreview(cmd.OutOrStdout())
+	}
+	edits, err := plan.foreignKeyEdits(src)
+	if err != nil {
+		return err
+	}
+
+	// 3. List every blob under the collection's directory.
 	cleanColDir := path.Clean(colDir)
 	files, err := writer.ListFilesUnder(ctx, cleanColDir)
 	if err != nil {
 		return fmt.Errorf("enumerate files under %s: %w", cleanColDir, err)
 	}
 
-	// 3. Build the new root-collections.yaml content with the entry removed.
+	// 4. Build the new root-collections.yaml content with the entry removed.
 	delete(rootEntries, name)
 	newRoot, _ := yaml.Marshal(rootEntries)
 
-	// 4. Assemble changes and commit in one shot.
-	changes := make([]dalgo2ghingitdb.TreeChange, 0, len(files)+1)
+	// 5. Assemble changes and commit in one shot.
+	changes := make([]dalgo2ghingitdb.TreeChange, 0, len(files)+len(edits)+1)
 	changes = append(changes, dalgo2ghingitdb.TreeChange{
 		Path:    remoteRootCollectionsPath(),
 		Content: newRoot,
 	})
+	for _, file := range slices.Sorted(maps.Keys(edits)) {
+		changes = append(changes, dalgo2ghingitdb.TreeChange{Path: file, Content: edits[file]})
+	}
 	for _, f := range files {
 		changes = append(changes, dalgo2ghingitdb.TreeChange{Path: f})
 	}
@@ -83,7 +110,7 @@ func dropCollectionRemote(ctx context.Context, cmd *cobra.Command, name string,
 // If scopeCol is non-empty, only that collection is searched. Otherwise
 // every collection is scanned and the call fails if the view name is
 // ambiguous (exists in more than one collection).
-func dropViewRemote(ctx context.Context, cmd *cobra.Command, name, scopeCol string, ifExists bool) error {
+func dropViewRemote(ctx context.Context, cmd *cobra.Command, name, scopeCol string, ifExists, dryRun bool) error {
 	_, cfg, err := remoteConfigFromCmd(cmd)
 	if err != nil {
 		return err
@@ -148,6 +175,10 @@ func dropViewRemote(ctx context.Context, cmd *cobra.Command, name, scopeCol stri
 		}
 		return fmt.Errorf("view %q not found in any collection", name)
 	case 1:
+		if dryRun {
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "would drop view %s/%s\n", matchedCollections[0], name)
+			return err
+		}
 		// Build one commit deleting view file + any materialized output.
 		writer, err := treeWriterFactory.NewTreeWriter(cfg)
 		if err != nil {
@@ -168,6 +199,27 @@ func dropViewRemote(ctx context.Context, cmd *cobra.Command, name, scopeCol stri
 	}
 }
 
+// remoteDropSource reads the schema files of a remote repository for the
+// drop dependency graph.
+func remoteDropSource(ctx context.Context, reader dalgo2ghingitdb.FileReader, writer treeWriter) dropSource {
+	return dropSource{
+		listFiles: func(dir string) ([]string, error) {
+			files, err := writer.ListFilesUnder(ctx, dir)
+			if err != nil {
+				return nil, fmt.Errorf("enumerate files under %s: %w", dir, err)
+			}
+			return files, nil
+		},
+		readFile: func(file string) ([]byte, bool, error) {
+			content, found, err := reader.ReadFile(ctx, file)
+			if err != nil {
+				return nil, false, fmt.Errorf("read %s: %w", file, err)
+			}
+			return content, found, nil
+		},
+	}
+}
+
 // remoteConfigFromCmd parses --remote, validates the provider, and assembles
 // a dalgo2ghingitdb.Config for the resolved host + token. Errors fire before
 // any I/O.
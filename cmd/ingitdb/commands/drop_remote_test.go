@@ -173,6 +173,57 @@ func TestDropCollection_Remote_HappyPath(t *testing.T) {
 	}
 }
 
+// TestDropCollection_Remote_Cascade verifies that a collection referenced by
+// a foreign key is only dropped with --cascade, and that the referencing
+// definition is rewritten in the same commit as the deletions.
+//
+// Modifies package-level variables — must not run in parallel.
+func TestDropCollection_Remote_Cascade(t *testing.T) {
+	files := map[string][]byte{
+		".ingitdb/root-collections.yaml":          []byte("countries: data/countries\ncities: data/cities\n"),
+		"data/cities/.collection/definition.yaml": []byte("columns:\n  country:\n    foreign_key: countries\n"),
+	}
+	enumFiles := []string{
+		"data/countries/definition.yaml",
+		"data/countries/$records/ie.yaml",
+	}
+	fw, cleanup := withFakeRemote(t, files, enumFiles)
+	defer cleanup()
+
+	homeDir, getWd, readDef, newDB, logf := emptyDropDeps(t)
+	cmd := Drop(homeDir, getWd, readDef, newDB, logf)
+	cmd.SetArgs([]string{"collection", "countries", "--remote=github.com/owner/repo", "--token=test-token"})
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "foreign key cities.country") {
+		t.Fatalf("expected the foreign key to block the drop, got: %v", err)
+	}
+	if fw.commitCalls != 0 {
+		t.Fatalf("expected no commit for a blocked drop, got %d", fw.commitCalls)
+	}
+
+	cmd = Drop(homeDir, getWd, readDef, newDB, logf)
+	cmd.SetArgs([]string{"collection", "countries", "--cascade", "--remote=github.com/owner/repo", "--token=test-token"})
+	if err = cmd.Execute(); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if fw.commitCalls != 1 {
+		t.Fatalf("expected exactly 1 commit, got %d", fw.commitCalls)
+	}
+	// root-collections.yaml + the rewritten definition + 2 deletions.
+	if len(fw.gotChanges) != 4 {
+		t.Fatalf("expected 4 changes, got %d: %+v", len(fw.gotChanges), fw.gotChanges)
+	}
+	for _, ch := range fw.gotChanges {
+		if ch.Path == "data/cities/.collection/definition.yaml" {
+			if got := string(ch.Content); got != "columns:\n  country: {}\n" {
+				t.Errorf("unexpected rewritten definition: %q", got)
+			}
+			return
+		}
+	}
+	t.Error("expected the cities definition to be rewritten")
+}
+
 // TestDropCollection_Remote_IfExistsMissing verifies that --if-exists turns
 // "collection not found" into a silent success on the remote path, with no
 // commit attempted.
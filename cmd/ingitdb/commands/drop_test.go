@@ -396,19 +396,130 @@ func TestDrop_View_IfExists_AbsentSucceeds(t *testing.T) {
 	}
 }
 
-func TestDrop_CascadeFlagAccepted(t *testing.T) {
+func TestDrop_Collection_CascadeWithoutDependents(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
 	homeDir, getWd, readDef, newDB, logf := dropTestDeps(t, dir)
-	seedCollection(t, dir, "cities")
+	colDir := seedCollection(t, dir, "cities")
 
-	// --cascade is a no-op in this data model; ensure it does not
-	// produce an unknown-flag error.
+	// With nothing to cascade, --cascade behaves like a plain drop.
 	_, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
 		"--path="+dir, "collection", "cities", "--cascade",
 	)
 	if err != nil {
-		t.Errorf("--cascade should be accepted as a no-op, got: %v", err)
+		t.Errorf("--cascade without dependents should succeed, got: %v", err)
+	}
+	if _, statErr := os.Stat(colDir); !os.IsNotExist(statErr) {
+		t.Errorf("collection directory should be gone")
+	}
+}
+
+func TestDrop_Collection_DependentBlocksDrop(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := dropTestDeps(t, dir)
+	colDir := seedCollection(t, dir, "cities")
+	viewPath := seedView(t, colDir, "active_cities", "", "")
+
+	_, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "collection", "cities",
+	)
+	if err == nil || !strings.Contains(err.Error(), "view cities/active_cities") || !strings.Contains(err.Error(), "--cascade") {
+		t.Fatalf("expected the view to block the drop, got: %v", err)
+	}
+	if _, statErr := os.Stat(viewPath); statErr != nil {
+		t.Errorf("nothing should be removed when the drop is blocked: %v", statErr)
+	}
+	root, _ := os.ReadFile(filepath.Join(dir, ".ingitdb", "root-collections.yaml"))
+	if !strings.Contains(string(root), "cities") {
+		t.Errorf("root-collections.yaml should be untouched, got:\n%s", root)
+	}
+}
+
+func TestDrop_Collection_CascadeDropsForeignKeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := dropTestDeps(t, dir)
+	seedCollection(t, dir, "countries")
+	citiesDir := seedCollection(t, dir, "cities")
+	defPath := filepath.Join(citiesDir, "definition.yaml")
+	if err := os.WriteFile(defPath, []byte("columns:\n  country:\n    foreign_key: countries\n"), 0o644); err != nil {
+		t.Fatalf("write definition.yaml: %v", err)
+	}
+	consPath := filepath.Join(citiesDir, "constraints.yaml")
+	if err := os.WriteFile(consPath, []byte("columns:\n  country:\n    on_delete: cascade\n"), 0o644); err != nil {
+		t.Fatalf("write constraints.yaml: %v", err)
+	}
+
+	_, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "collection", "countries",
+	)
+	if err == nil || !strings.Contains(err.Error(), "foreign key cities.country (references countries)") {
+		t.Fatalf("expected the foreign key to block the drop, got: %v", err)
+	}
+
+	if _, err = runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "collection", "countries", "--cascade",
+	); err != nil {
+		t.Fatalf("drop --cascade: %v", err)
+	}
+	def, _ := os.ReadFile(defPath)
+	if strings.Contains(string(def), "foreign_key") || !strings.Contains(string(def), "country") {
+		t.Errorf("expected the column kept without its foreign_key, got:\n%s", def)
+	}
+	cons, _ := os.ReadFile(consPath)
+	if strings.Contains(string(cons), "on_delete") {
+		t.Errorf("expected on_delete removed, got:\n%s", cons)
+	}
+}
+
+func TestDrop_Collection_DryRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := dropTestDeps(t, dir)
+	colDir := seedCollection(t, dir, "cities")
+	seedView(t, colDir, "active_cities", "", "")
+
+	stdout, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "collection", "cities", "--cascade", "--dry-run",
+	)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := "would drop collection cities (.collections/cities)\nwould drop view cities/active_cities\n"
+	if stdout != want {
+		t.Errorf("preview:\n%s\nwant:\n%s", stdout, want)
+	}
+	if _, statErr := os.Stat(colDir); statErr != nil {
+		t.Errorf("a dry run must not remove anything: %v", statErr)
+	}
+
+	// Without --cascade the dry run reports the same error as the drop.
+	if _, err = runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "collection", "cities", "--dry-run",
+	); err == nil {
+		t.Error("expected the dry run to fail without --cascade")
+	}
+}
+
+func TestDrop_View_DryRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := dropTestDeps(t, dir)
+	colDir := seedCollection(t, dir, "cities")
+	viewPath := seedView(t, colDir, "active_cities", "", "")
+
+	stdout, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "view", "active_cities", "--dry-run",
+	)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if stdout != "would drop view cities/active_cities\n" {
+		t.Errorf("unexpected preview: %q", stdout)
+	}
+	if _, statErr := os.Stat(viewPath); statErr != nil {
+		t.Errorf("a dry run must not remove the view: %v", statErr)
 	}
 }
 
@@ -419,9 +530,9 @@ func TestDrop_EndToEnd_CollectionWithView(t *testing.T) {
 	colDir := seedCollection(t, dir, "cities")
 	viewPath := seedView(t, colDir, "active_cities", "active.csv", "name\nDublin\n")
 
-	// dropping the collection should transitively remove its nested view.
+	// dropping the collection with --cascade removes its nested view.
 	_, err := runDropCmd(t, homeDir, getWd, readDef, newDB, logf,
-		"--path="+dir, "collection", "cities",
+		"--path="+dir, "collection", "cities", "--cascade",
 	)
 	if err != nil {
 		t.Fatalf("drop collection: %v", err)
@@ -0,0 +1,441 @@
+package commands
+
+// specscore: feature/cli/dump
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// dumpManifestName is the first entry of a dump bundle; the database files
+// follow under dumpFilesDir.
+const (
+	dumpManifestName = "ingitdb-dump.yaml"
+	dumpFilesDir     = "db/"
+	dumpVersion      = 1
+)
+
+// dumpManifest describes a dump bundle.
+type dumpManifest struct {
+	Version     int      `yaml:"version"`
+	Collections []string `yaml:"collections"`
+	Files       int      `yaml:"files"`
+}
+
+// Dump returns the `ingitdb dump` command. It writes the database settings,
+// the collection definitions (with their subcollections, views and
+// constraints) and every record file to a single tar bundle.
+func Dump(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Write the schema and all records to a single bundle",
+		Long: "Writes a tar bundle holding the database settings, every collection definition " +
+			"(subcollections, views and constraints included) and every record file as it is on disk. " +
+			"The bundle is gzip-compressed when --out ends in .gz or .tgz. Restore it with restore-dump.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			def, err := readDefinition(dirPath)
+			if err != nil {
+				return fmt.Errorf("failed to read database definition: %w", err)
+			}
+			files, err := dumpFiles(dirPath, def)
+			if err != nil {
+				return err
+			}
+			out, _ := cmd.Flags().GetString("out")
+			w := cmd.OutOrStdout()
+			if out != "" && out != "-" {
+				f, createErr := os.Create(out)
+				if createErr != nil {
+					return fmt.Errorf("failed to create %s: %w", out, createErr)
+				}
+				defer func() { _ = f.Close() }()
+				w = f
+			}
+			manifest := dumpManifest{Version: dumpVersion, Files: len(files)}
+			for _, e := range exportedCollections(def.Collections, nil, "") {
+				manifest.Collections = append(manifest.Collections, e.fullID)
+			}
+			compress := strings.HasSuffix(out, ".gz") || strings.HasSuffix(out, ".tgz")
+			if err = writeDumpBundle(w, dirPath, manifest, files, compress); err != nil {
+				return err
+			}
+			if out != "" && out != "-" {
+				logf(fmt.Sprintf("dumped %d collection(s) in %d file(s) to %s", len(manifest.Collections), len(files), out))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.Flags().String("out", "", "bundle file to write (default: stdout); .gz and .tgz are gzip-compressed")
+	return cmd
+}
+
+// RestoreDump returns the `ingitdb restore-dump` command. It recreates a
+// dumped database in an empty directory, optionally re-encoding the records
+// in another format.
+func RestoreDump(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore-dump",
+		Short: "Recreate a database from a dump bundle",
+		Long: "Extracts a bundle written by dump into an empty directory. Files are restored byte for byte; " +
+			"with --record-format, the records of every collection stored in another format are re-encoded " +
+			"and the collection definitions updated.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, _ := cmd.Flags().GetString("from")
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			var format ingitdb.RecordFormat
+			if raw, _ := cmd.Flags().GetString("record-format"); raw != "" {
+				format = ingitdb.RecordFormat(raw)
+				if recordFileExtension(format) == "" {
+					return fmt.Errorf("invalid --record-format %q (valid values: yaml, yml, json, jsonl, toml, markdown, ingr, csv)", raw)
+				}
+			}
+			r := cmd.InOrStdin()
+			if from != "-" {
+				f, openErr := os.Open(from)
+				if openErr != nil {
+					return fmt.Errorf("failed to open %s: %w", from, openErr)
+				}
+				defer func() { _ = f.Close() }()
+				r = f
+			}
+			manifest, err := runRestoreDump(r, dirPath, format, readDefinition)
+			if err != nil {
+				return err
+			}
+			logf(fmt.Sprintf("restored %d collection(s) in %d file(s) to %s", len(manifest.Collections), manifest.Files, dirPath))
+			return nil
+		},
+	}
+	cmd.Flags().String("path", "", "empty or new directory to restore into (default: current directory)")
+	cmd.Flags().String("from", "", "bundle file written by dump ('-' for stdin)")
+	cmd.Flags().String("record-format", "",
+		"re-encode the records as yaml, yml, json, jsonl, toml, markdown, ingr or csv (default: keep each collection's format)")
+	_ = cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+// dumpFiles returns the database-relative paths of the files a dump holds:
+// everything under .ingitdb/, the schema files of every root collection —
+// the .collection/ and .collections/ trees, $views/, definition.yaml and
+// constraints.yaml — and the record files of every collection instance.
+func dumpFiles(dbDir string, def *ingitdb.Definition) ([]string, error) {
+	files := make(map[string]bool)
+	add := func(abs string) error {
+		rel, err := filepath.Rel(dbDir, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s is outside the database directory", abs)
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	}
+	settingsDir := filepath.Join(dbDir, filepath.FromSlash(path.Dir(rootCollectionsRelPath)))
+	if err := walkDumpFiles(settingsDir, func(string) bool { return true }, add); err != nil {
+		return nil, err
+	}
+	roots, err := readRootCollections(dbDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range slices.Sorted(maps.Keys(roots)) {
+		colDir := filepath.Join(dbDir, filepath.FromSlash(roots[id]))
+		if err = walkDumpFiles(colDir, isSchemaFile, add); err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		err = walkCollectionInstances(def.Collections[id], func(_, inst *ingitdb.CollectionDef, _ []string, _ map[string]map[string]any) error {
+			recordFiles, listErr := collectionRecordFiles(inst)
+			if listErr != nil {
+				return listErr
+			}
+			for _, file := range recordFiles {
+				if err := add(file); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.Sorted(maps.Keys(files)), nil
+}
+
+// isSchemaFile reports whether rel, a slash-separated path inside a
+// collection directory, belongs to a collection definition rather than to
+// its records or generated files.
+func isSchemaFile(rel string) bool {
+	for _, part := range strings.Split(path.Dir(rel), "/") {
+		if part == ingitdb.SchemaDir || part == ingitdb.CollectionsDir || part == ingitdb.SharedViewsDir {
+			return true
+		}
+		if part == "$records" {
+			return false
+		}
+	}
+	base := path.Base(rel)
+	return base == ingitdb.CollectionDefFileName || base == collectionConstraintsFileName
+}
+
+// walkDumpFiles calls add for every regular file under dir whose path
+// relative to dir satisfies keep. A missing dir holds no files.
+func walkDumpFiles(dir string, keep func(rel string) bool, add func(abs string) error) error {
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if !keep(filepath.ToSlash(rel)) {
+			return nil
+		}
+		return add(p)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// writeDumpBundle writes the manifest and then each file, unchanged, to w as
+// a tar archive. Entries carry no timestamps or owners, so dumping the same
+// database twice yields the same bundle.
+func writeDumpBundle(w io.Writer, dbDir string, manifest dumpManifest, files []string, compress bool) error {
+	if compress {
+		gz := gzip.NewWriter(w)
+		if err := writeDumpBundle(gz, dbDir, manifest, files, false); err != nil {
+			return err
+		}
+		return gz.Close()
+	}
+	tw := tar.NewWriter(w)
+	header, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err = writeTarEntry(tw, dumpManifestName, header); err != nil {
+		return err
+	}
+	for _, file := range files {
+		content, readErr := os.ReadFile(filepath.Join(dbDir, filepath.FromSlash(file)))
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s: %w", file, readErr)
+		}
+		if err = writeTarEntry(tw, dumpFilesDir+file, content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// writeTarEntry adds a regular file to tw.
+func writeTarEntry(tw *tar.Writer, name string, content []byte) error {
+	hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(content)), Format: tar.FormatPAX}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s to the bundle: %w", name, err)
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// runRestoreDump extracts the bundle read from r into dbDir, which must be
+// empty or not exist, and re-encodes the records as format when it is set.
+// On failure the restored files are removed again.
+func runRestoreDump(
+	r io.Reader,
+	dbDir string,
+	format ingitdb.RecordFormat,
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+) (manifest *dumpManifest, err error) {
+	entries, err := os.ReadDir(dbDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case len(entries) > 0:
+		return nil, fmt.Errorf("%s is not empty; restore-dump only restores into an empty directory", dbDir)
+	}
+	if err = os.MkdirAll(dbDir, 0o755); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			entries, _ := os.ReadDir(dbDir)
+			for _, e := range entries {
+				_ = os.RemoveAll(filepath.Join(dbDir, e.Name()))
+			}
+		}
+	}()
+	if manifest, err = extractDumpBundle(r, dbDir); err != nil {
+		return nil, err
+	}
+	if format == "" {
+		return manifest, nil
+	}
+	def, err := readDefinition(dbDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the restored database definition: %w", err)
+	}
+	if err = convertDatabaseFormat(dbDir, def, format); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// extractDumpBundle checks the manifest of a bundle and writes its files
+// under dbDir. Gzip-compressed bundles are recognised by their magic bytes.
+func extractDumpBundle(r io.Reader, dbDir string) (*dumpManifest, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bundle: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	} else {
+		r = br
+	}
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != dumpManifestName {
+		return nil, errors.New("not an inGitDB dump bundle: the manifest is missing")
+	}
+	content, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the bundle: %w", err)
+	}
+	manifest := new(dumpManifest)
+	if err = yaml.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", dumpManifestName, err)
+	}
+	if manifest.Version != dumpVersion {
+		return nil, fmt.Errorf("unsupported dump version %d (this ingitdb reads version %d)", manifest.Version, dumpVersion)
+	}
+	files := 0
+	for {
+		hdr, err = tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the bundle: %w", err)
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, dumpFilesDir)
+		target := filepath.Join(dbDir, filepath.FromSlash(rel))
+		if !ok || hdr.Typeflag != tar.TypeReg || !strings.HasPrefix(target, filepath.Clean(dbDir)+string(filepath.Separator)) {
+			return nil, fmt.Errorf("unexpected bundle entry %s", hdr.Name)
+		}
+		if err = extractTarFile(tr, target); err != nil {
+			return nil, err
+		}
+		files++
+	}
+	if files != manifest.Files {
+		return nil, fmt.Errorf("the bundle holds %d file(s), its manifest lists %d", files, manifest.Files)
+	}
+	return manifest, nil
+}
+
+// convertDatabaseFormat re-encodes the records of every collection not
+// stored as format and updates their definitions. Every root collection is
+// planned before anything is written, and a failure rolls back whatever was
+// already converted.
+func convertDatabaseFormat(dbDir string, def *ingitdb.Definition, format ingitdb.RecordFormat) (err error) {
+	roots, err := readRootCollections(dbDir)
+	if err != nil {
+		return err
+	}
+	formats := make(map[*ingitdb.CollectionDef]*ingitdb.RecordFileDef)
+	defFiles := make(map[*ingitdb.CollectionDef]string)
+	for _, e := range exportedCollections(def.Collections, nil, "") {
+		if e.col.RecordFile == nil || e.col.RecordFile.Format == format {
+			continue
+		}
+		rf, convErr := convertedRecordFile(e.col, format, "", "")
+		if convErr != nil {
+			return convErr
+		}
+		rootID, _, _ := strings.Cut(e.fullID, "/")
+		formats[e.col] = rf
+		defFiles[e.col] = collectionDefinitionFile(dbDir, roots[rootID], e.fullID)
+	}
+	var plans []*recordConversion
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		plan, planErr := planRecordConversion(def.Collections[id], formats)
+		if planErr != nil {
+			return planErr
+		}
+		plans = append(plans, plan)
+	}
+	var undos []func()
+	defer func() {
+		if err != nil {
+			for i := len(undos) - 1; i >= 0; i-- {
+				undos[i]()
+			}
+		}
+	}()
+	for _, plan := range plans {
+		undo, applyErr := plan.apply()
+		if applyErr != nil {
+			return applyErr
+		}
+		undos = append(undos, undo)
+	}
+	for col, rf := range formats {
+		defFile := defFiles[col]
+		content, readErr := os.ReadFile(defFile)
+		if readErr != nil {
+			return fmt.Errorf("failed to read %s: %w", defFile, readErr)
+		}
+		undos = append(undos, func() { _ = os.WriteFile(defFile, content, 0o644) })
+		if err = writeRecordFileDefinition(defFile, rf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
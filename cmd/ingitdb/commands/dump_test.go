@@ -0,0 +1,221 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/validator"
+)
+
+// dumpTestFiles is a database with a subcollection, a CSV list collection
+// and a generated README that is not part of a dump.
+var dumpTestFiles = map[string]string{
+	".ingitdb/root-collections.yaml": "countries: countries\ncities: cities\n",
+	"countries/.collection/definition.yaml": `record_file:
+  name: "{key}.yaml"
+  format: yaml
+  type: map[string]any
+columns:
+  title:
+    type: string
+`,
+	"countries/.collection/subcollections/regions/definition.yaml": `record_file:
+  name: regions.yaml
+  format: yaml
+  type: map[$record_id]map[$field_name]any
+columns:
+  name:
+    type: string
+`,
+	"countries/$records/IE.yaml":                 "# kept as is\ntitle: Ireland\n",
+	"countries/$records/IE/regions/regions.yaml": "leinster:\n  name: Leinster\nmunster:\n  name: Munster\n",
+	"cities/.collection/definition.yaml": `record_file:
+  name: cities.csv
+  format: csv
+  type: '[]map[string]any'
+columns:
+  id:
+    type: string
+  name:
+    type: string
+columns_order:
+  - id
+  - name
+`,
+	"cities/cities.csv": "id,name\ndub,Dublin\ncrk,Cork\n",
+	"cities/README.md":  "generated\n",
+}
+
+func writeDumpTestDB(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for rel, content := range dumpTestFiles {
+		writeFixTestFile(t, dir, rel, content)
+	}
+	return dir
+}
+
+func dumpTestBundle(t *testing.T, dir string, compress bool) []byte {
+	t.Helper()
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	files, err := dumpFiles(dir, def)
+	if err != nil {
+		t.Fatalf("dumpFiles: %v", err)
+	}
+	var bundle bytes.Buffer
+	manifest := dumpManifest{Version: dumpVersion, Collections: []string{"cities", "countries", "countries/regions"}, Files: len(files)}
+	if err = writeDumpBundle(&bundle, dir, manifest, files, compress); err != nil {
+		t.Fatalf("writeDumpBundle: %v", err)
+	}
+	return bundle.Bytes()
+}
+
+func TestDumpFiles(t *testing.T) {
+	t.Parallel()
+	dir := writeDumpTestDB(t)
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	files, err := dumpFiles(dir, def)
+	if err != nil {
+		t.Fatalf("dumpFiles: %v", err)
+	}
+	want := []string{
+		".ingitdb/root-collections.yaml",
+		"cities/.collection/definition.yaml",
+		"cities/cities.csv",
+		"countries/$records/IE.yaml",
+		"countries/$records/IE/regions/regions.yaml",
+		"countries/.collection/definition.yaml",
+		"countries/.collection/subcollections/regions/definition.yaml",
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("dumpFiles = %v, want %v", files, want)
+	}
+}
+
+func TestRestoreDump_ByteForByte(t *testing.T) {
+	t.Parallel()
+	for _, compress := range []bool{false, true} {
+		bundle := dumpTestBundle(t, writeDumpTestDB(t), compress)
+		target := filepath.Join(t.TempDir(), "restored")
+		manifest, err := runRestoreDump(bytes.NewReader(bundle), target, "", nil)
+		if err != nil {
+			t.Fatalf("runRestoreDump(compress=%v): %v", compress, err)
+		}
+		if manifest.Files != 7 {
+			t.Errorf("manifest lists %d files, want 7", manifest.Files)
+		}
+		for rel, content := range dumpTestFiles {
+			got, readErr := os.ReadFile(filepath.Join(target, filepath.FromSlash(rel)))
+			if rel == "cities/README.md" {
+				if readErr == nil {
+					t.Errorf("%s was restored", rel)
+				}
+				continue
+			}
+			if readErr != nil || string(got) != content {
+				t.Errorf("%s = %q, %v; want %q", rel, got, readErr, content)
+			}
+		}
+	}
+}
+
+func TestRestoreDump_RecordFormat(t *testing.T) {
+	t.Parallel()
+	bundle := dumpTestBundle(t, writeDumpTestDB(t), false)
+	target := t.TempDir()
+	if _, err := runRestoreDump(bytes.NewReader(bundle), target, ingitdb.RecordFormatJSON, validator.ReadDefinition); err != nil {
+		t.Fatalf("runRestoreDump: %v", err)
+	}
+	def, err := validator.ReadDefinition(target)
+	if err != nil {
+		t.Fatalf("ReadDefinition of the converted database: %v", err)
+	}
+	cities := def.Collections["cities"]
+	if rf := cities.RecordFile; rf.Name != "cities.json" || rf.Format != ingitdb.RecordFormatJSON || rf.RecordType != ingitdb.ListOfRecords {
+		t.Errorf("cities record_file = %+v", rf)
+	}
+	records, _, err := loadCollectionRecords(cities)
+	if err != nil || len(records) != 2 || records["crk"]["name"] != "Cork" {
+		t.Errorf("cities records = %v, %v", records, err)
+	}
+	for rel, want := range map[string]string{
+		"countries/$records/IE.json":                 "title",
+		"countries/$records/IE/regions/regions.json": "Munster",
+	} {
+		content, readErr := os.ReadFile(filepath.Join(target, filepath.FromSlash(rel)))
+		if readErr != nil || !strings.Contains(string(content), want) {
+			t.Errorf("%s = %q, %v; want it to contain %q", rel, content, readErr, want)
+		}
+	}
+	for _, rel := range []string{"countries/$records/IE.yaml", "cities/cities.csv"} {
+		if _, statErr := os.Stat(filepath.Join(target, filepath.FromSlash(rel))); statErr == nil {
+			t.Errorf("%s was not removed", rel)
+		}
+	}
+}
+
+func TestRestoreDump_Errors(t *testing.T) {
+	t.Parallel()
+	bundle := dumpTestBundle(t, writeDumpTestDB(t), false)
+
+	notEmpty := t.TempDir()
+	writeFixTestFile(t, notEmpty, "keep.txt", "x")
+	if _, err := runRestoreDump(bytes.NewReader(bundle), notEmpty, "", nil); err == nil || !strings.Contains(err.Error(), "is not empty") {
+		t.Errorf("expected a not empty error, got %v", err)
+	}
+
+	if _, err := runRestoreDump(strings.NewReader("junk"), t.TempDir(), "", nil); err == nil || !strings.Contains(err.Error(), "not an inGitDB dump bundle") {
+		t.Errorf("expected a not a bundle error, got %v", err)
+	}
+
+	target := t.TempDir()
+	_, err := runRestoreDump(bytes.NewReader(bundle), target, ingitdb.RecordFormatMarkdown, validator.ReadDefinition)
+	if err == nil || !strings.Contains(err.Error(), "cannot be stored as markdown") {
+		t.Errorf("expected a record type error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(target); len(entries) != 0 {
+		t.Errorf("a failed restore left %d entries behind", len(entries))
+	}
+}
+
+func TestConvertDatabaseFormat_PlansEveryRootFirst(t *testing.T) {
+	t.Parallel()
+	dir := writeDumpTestDB(t)
+	// countries is planned after cities and cannot be parsed.
+	writeFixTestFile(t, dir, "countries/$records/IE.yaml", "title: [unclosed\n")
+	def, err := validator.ReadDefinition(dir)
+	if err != nil {
+		t.Fatalf("ReadDefinition: %v", err)
+	}
+	if err = convertDatabaseFormat(dir, def, ingitdb.RecordFormatJSON); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if got := readFixTestFile(t, filepath.Join(dir, "cities/cities.csv")); got != dumpTestFiles["cities/cities.csv"] {
+		t.Errorf("cities were converted before every root was planned:\n%s", got)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "cities/cities.json")); statErr == nil {
+		t.Error("cities/cities.json was written")
+	}
+}
+
+func TestSetYAMLKey(t *testing.T) {
+	t.Parallel()
+	got, err := setYAMLKey([]byte("# header\nrecord_file:\n  name: a.yaml # the file\n  format: yaml\ncolumns: {}\n"), "a.json", "record_file", "name")
+	if err != nil {
+		t.Fatalf("setYAMLKey: %v", err)
+	}
+	if want := "# header\nrecord_file:\n  name: a.json\n  format: yaml\ncolumns: {}\n"; string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
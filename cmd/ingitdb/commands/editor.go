@@ -61,16 +61,20 @@ func runWithEditor(colDef *ingitdb.CollectionDef, openEditor func(string) error)
 // buildRecordTemplate returns a byte slice pre-filled with an empty record
 // template for the given collection. For markdown the template has YAML
 // frontmatter delimiters; for other formats it is a bare YAML skeleton.
+// When the collection generates record keys, the template opens with a
+// comment saying how.
 func buildRecordTemplate(colDef *ingitdb.CollectionDef) []byte {
 	keys := orderedColumnKeys(colDef)
 	var buf bytes.Buffer
 	if colDef.RecordFile != nil && colDef.RecordFile.Format == ingitdb.RecordFormatMarkdown {
 		buf.WriteString("---\n")
+		writeKeyGenerationHint(&buf, colDef)
 		for _, k := range keys {
 			buf.WriteString(k + ": \n")
 		}
 		buf.WriteString("---\n\n")
 	} else {
+		writeKeyGenerationHint(&buf, colDef)
 		for _, k := range keys {
 			buf.WriteString(k + ": \n")
 		}
@@ -78,6 +82,20 @@ func buildRecordTemplate(colDef *ingitdb.CollectionDef) []byte {
 	return buf.Bytes()
 }
 
+// writeKeyGenerationHint writes a comment describing the key generator of
+// colDef, if it declares one. JSON has no comments, so JSON collections get
+// none.
+func writeKeyGenerationHint(buf *bytes.Buffer, colDef *ingitdb.CollectionDef) {
+	if colDef.RecordFile != nil && colDef.RecordFile.Format == ingitdb.RecordFormatJSON {
+		return
+	}
+	cons, err := readCollectionConstraints(colDef)
+	if err != nil || cons == nil || cons.Key == nil {
+		return
+	}
+	buf.WriteString("# $id: leave out to use " + cons.Key.describe() + " as the record key\n")
+}
+
 // orderedColumnKeys returns the column names in canonical order:
 // ColumnsOrder entries first (skipping absent columns), then remaining
 // columns alphabetically.
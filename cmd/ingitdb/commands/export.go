@@ -0,0 +1,631 @@
+package commands
+
+// specscore: feature/cli/export
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// Values of export --locales.
+const (
+	exportLocalesColumns = "columns"
+	exportLocalesTable   = "table"
+)
+
+// exportTableSeparator joins the IDs of a subcollection path into the name of
+// its table: countries/regions becomes countries__regions.
+const exportTableSeparator = "__"
+
+// Export returns the `ingitdb export` command. It writes every collection to
+// a SQLite database: one table per collection, typed from the column
+// definitions, with subcollections as child tables keyed by their parents.
+func Export(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the database to a SQLite file",
+		Long: "Creates one table per collection (columns typed from the column definitions, locale maps " +
+			"as a column per locale or as side tables, subcollections as child tables carrying the keys " +
+			"of their parent records) and loads every record. With --ref the records are read as of that " +
+			"git ref. The database file is written with the sqlite3 shell, which must be on PATH; " +
+			"--format=sql prints the SQLite script instead of running it and needs no shell.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			out, _ := cmd.Flags().GetString("out")
+			switch format {
+			case "sqlite":
+				if out == "" {
+					return errors.New("--out is required with --format=sqlite")
+				}
+			case "sql":
+			default:
+				return fmt.Errorf("invalid --format=%q (must be sqlite or sql)", format)
+			}
+			locales, _ := cmd.Flags().GetString("locales")
+			if locales != exportLocalesColumns && locales != exportLocalesTable {
+				return fmt.Errorf("invalid --locales=%q (must be %s or %s)", locales, exportLocalesColumns, exportLocalesTable)
+			}
+			force, _ := cmd.Flags().GetBool("force")
+			if format == "sqlite" && !force {
+				if _, err := os.Stat(out); err == nil {
+					return fmt.Errorf("%s already exists (use --force to overwrite it)", out)
+				}
+			}
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			if ref, _ := cmd.Flags().GetString("ref"); ref != "" {
+				treeDir, cleanup, treeErr := exportTreeAtRef(ctx, dirPath, ref)
+				if treeErr != nil {
+					return treeErr
+				}
+				defer cleanup()
+				dirPath = treeDir
+			}
+			def, err := readDefinition(dirPath)
+			if err != nil {
+				return fmt.Errorf("failed to read database definition: %w", err)
+			}
+			only, _ := cmd.Flags().GetString("collection")
+			tables, err := loadExportTables(def, only)
+			if err != nil {
+				return err
+			}
+			script, err := exportScript(def, tables, locales)
+			if err != nil {
+				return err
+			}
+			if format == "sql" {
+				if out == "" {
+					_, err = io.WriteString(cmd.OutOrStdout(), script)
+					return err
+				}
+				return os.WriteFile(out, []byte(script), 0o644)
+			}
+			if err = writeSQLiteFile(ctx, out, script); err != nil {
+				return err
+			}
+			rows := 0
+			for _, t := range tables {
+				rows += len(t.rows)
+			}
+			logf(fmt.Sprintf("exported %d records of %d collection(s) to %s", rows, len(tables), out))
+			return nil
+		},
+	}
+	cmd.Flags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.Flags().String("format", "sqlite", "output format: sqlite (a database file) or sql (the SQLite script)")
+	cmd.Flags().String("out", "", "file to write; required for sqlite, stdout for sql when omitted")
+	cmd.Flags().String("ref", "", "export the database as of this git ref instead of the working tree")
+	cmd.Flags().String("collection", "", "export only this root collection and its subcollections")
+	cmd.Flags().String("locales", exportLocalesColumns,
+		"how locale maps are stored: columns (a <column>_<locale> column per locale) or table (a side table per column)")
+	cmd.Flags().Bool("force", false, "overwrite --out when it exists")
+	return cmd
+}
+
+// exportTable is the table of one collection: a root collection, or a
+// subcollection whose records are gathered from every parent record.
+type exportTable struct {
+	name string
+	col  *ingitdb.CollectionDef
+	// parent is the table of the parent collection; nil for root collections.
+	parent *exportTable
+	// parentColumns name the key columns of the ancestor records, outermost
+	// first: <ancestor ID>_key.
+	parentColumns []string
+	rows          []exportRow
+}
+
+// exportRow is one record and the keys of the records it is nested in.
+type exportRow struct {
+	parentKeys []string
+	key        string
+	data       map[string]any
+}
+
+// primaryKey returns the primary key of the table: the parent key columns
+// followed by the collection's own key.
+func (t *exportTable) primaryKey() []string {
+	return append(slices.Clone(t.parentColumns), sqlPrimaryKey(t.col)...)
+}
+
+// columns returns the stored columns of the collection in columns_order,
+// then by name. Computed columns are left out.
+func (t *exportTable) columns() []string {
+	names := slices.Clone(t.col.ColumnsOrder)
+	for _, name := range slices.Sorted(maps.Keys(t.col.Columns)) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return slices.DeleteFunc(names, func(name string) bool {
+		c := t.col.Columns[name]
+		return c == nil || c.Formula != ""
+	})
+}
+
+// syntheticKey reports whether the table needs an id column for the record
+// key because the collection declares neither a primary_key nor an id column.
+func (t *exportTable) syntheticKey() bool {
+	return len(t.col.PrimaryKey) == 0 && t.col.Columns[sqlKeyColumn] == nil
+}
+
+// locales returns the locales found in the values of the locale map column,
+// sorted.
+func (t *exportTable) locales(column string) []string {
+	seen := make(map[string]bool)
+	for _, row := range t.rows {
+		if m, ok := row.data[column].(map[string]any); ok {
+			for locale := range m {
+				seen[locale] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// loadExportTables reads the records of every collection of def, or of only
+// one root collection and its subcollections, into tables ordered parents
+// first.
+func loadExportTables(def *ingitdb.Definition, only string) ([]*exportTable, error) {
+	var tables []*exportTable
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		if only != "" && id != only {
+			continue
+		}
+		col := def.Collections[id]
+		byDef := make(map[*ingitdb.CollectionDef]*exportTable)
+		tables = append(tables, exportTablesOf(col, id, nil, byDef)...)
+		if err := loadExportRows(col, byDef); err != nil {
+			return nil, err
+		}
+	}
+	if only != "" && len(tables) == 0 {
+		return nil, fmt.Errorf("collection %q not found", only)
+	}
+	return tables, nil
+}
+
+// exportTablesOf returns the table of col and, depth first, the tables of its
+// subcollections, recording each under its definition in byDef.
+func exportTablesOf(col *ingitdb.CollectionDef, name string, parent *exportTable, byDef map[*ingitdb.CollectionDef]*exportTable) []*exportTable {
+	t := &exportTable{name: name, col: col, parent: parent}
+	if parent != nil {
+		t.parentColumns = append(slices.Clone(parent.parentColumns), parent.col.ID+"_key")
+	}
+	byDef[col] = t
+	tables := []*exportTable{t}
+	for _, subID := range slices.Sorted(maps.Keys(col.SubCollections)) {
+		tables = append(tables, exportTablesOf(col.SubCollections[subID], name+exportTableSeparator+subID, t, byDef)...)
+	}
+	return tables
+}
+
+// loadExportRows adds the records of every instance of col and its
+// subcollections to their tables.
+func loadExportRows(col *ingitdb.CollectionDef, byDef map[*ingitdb.CollectionDef]*exportTable) error {
+	return walkCollectionInstances(col, func(def, _ *ingitdb.CollectionDef, parentKeys []string, records map[string]map[string]any) error {
+		t := byDef[def]
+		pk := sqlPrimaryKey(def)
+		for _, key := range slices.Sorted(maps.Keys(records)) {
+			data := records[key]
+			if _, ok := data[pk[0]]; len(pk) == 1 && !ok {
+				data[pk[0]] = key
+			}
+			t.rows = append(t.rows, exportRow{parentKeys: parentKeys, key: key, data: data})
+		}
+		return nil
+	})
+}
+
+// walkCollectionInstances calls fn with the records of the root collection
+// col and then, depth first, of every subcollection instance: def is the
+// declared collection, inst a copy of it repointed at the data directory of
+// one instance, and parentKeys the keys of the records the instance is
+// nested in, outermost first.
+func walkCollectionInstances(col *ingitdb.CollectionDef, fn func(def, inst *ingitdb.CollectionDef, parentKeys []string, records map[string]map[string]any) error) error {
+	return walkInstances(col, col, nil, fn)
+}
+
+func walkInstances(def, inst *ingitdb.CollectionDef, parentKeys []string, fn func(def, inst *ingitdb.CollectionDef, parentKeys []string, records map[string]map[string]any) error) error {
+	records, _, err := loadCollectionRecords(inst)
+	if err != nil {
+		return err
+	}
+	if err = fn(def, inst, parentKeys, records); err != nil {
+		return err
+	}
+	keys := slices.Sorted(maps.Keys(records))
+	for _, subID := range slices.Sorted(maps.Keys(def.SubCollections)) {
+		sub := def.SubCollections[subID]
+		for _, key := range keys {
+			subInst := *sub // repoint DirPath at this record's instance without touching the definition
+			subInst.DirPath = subCollectionInstanceDir(inst, key, subID)
+			if err = walkInstances(sub, &subInst, append(slices.Clone(parentKeys), key), fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// subCollectionInstanceDir returns the data directory of one parent record's
+// instance of a subcollection: <parent dir>/<parent records base>/<key>/<subID>.
+func subCollectionInstanceDir(parent *ingitdb.CollectionDef, parentKey, subID string) string {
+	base := parent.DirPath
+	if parent.RecordFile != nil {
+		base = filepath.Join(base, parent.RecordFile.RecordsBasePath())
+	}
+	return filepath.Join(base, parentKey, subID)
+}
+
+// exportScript renders the SQLite script that creates the tables and inserts
+// the rows. Foreign keys are declared but not enforced while loading, so
+// tables can be filled in any order.
+func exportScript(def *ingitdb.Definition, tables []*exportTable, locales string) (string, error) {
+	exported := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		exported[t.name] = true
+	}
+	var b strings.Builder
+	b.WriteString("PRAGMA foreign_keys = OFF;\nBEGIN TRANSACTION;\n")
+	for _, t := range tables {
+		ddl, err := exportTableDDL(def, t, locales, exported)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(ddl)
+	}
+	for _, t := range tables {
+		if err := writeExportRows(&b, t, locales); err != nil {
+			return "", err
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	return b.String(), nil
+}
+
+// exportKeyType returns the SQLite type of a primary key column of t: TEXT
+// for the parent keys and the synthetic id, the column's type otherwise.
+func exportKeyType(t *exportTable, name string) string {
+	if c := t.col.Columns[name]; c != nil && !slices.Contains(t.parentColumns, name) {
+		return sqlColumnType(sqlDialectSQLite, c, true)
+	}
+	return "TEXT"
+}
+
+// exportTableDDL renders the CREATE TABLE statements of t and, with
+// --locales=table, of the side tables of its locale map columns.
+func exportTableDDL(def *ingitdb.Definition, t *exportTable, locales string, exported map[string]bool) (string, error) {
+	cons, err := readCollectionConstraints(t.col)
+	if err != nil {
+		return "", err
+	}
+	q := func(name string) string { return sqlQuote(sqlDialectSQLite, name) }
+	pk := t.primaryKey()
+	var lines []string
+	for _, name := range t.parentColumns {
+		lines = append(lines, q(name)+" TEXT NOT NULL")
+	}
+	if t.syntheticKey() {
+		lines = append(lines, q(sqlKeyColumn)+" TEXT NOT NULL")
+	}
+	var sideTables []string
+	for _, name := range t.columns() {
+		c := t.col.Columns[name]
+		if c.Type == ingitdb.ColumnTypeL10N {
+			if locales == exportLocalesTable {
+				sideTables = append(sideTables, name)
+				continue
+			}
+			for _, locale := range t.locales(name) {
+				lines = append(lines, q(name+"_"+locale)+" TEXT")
+			}
+			continue
+		}
+		line := q(name) + " " + sqlColumnType(sqlDialectSQLite, c, slices.Contains(pk, name))
+		if c.Required || slices.Contains(pk, name) {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "PRIMARY KEY ("+sqlQuoteList(sqlDialectSQLite, pk)+")")
+	for _, key := range cons.uniqueKeys() {
+		lines = append(lines, "UNIQUE ("+sqlQuoteList(sqlDialectSQLite, append(slices.Clone(t.parentColumns), key...))+")")
+	}
+	if p := t.parent; p != nil && len(sqlPrimaryKey(p.col)) == 1 {
+		lines = append(lines, fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE CASCADE",
+			sqlQuoteList(sqlDialectSQLite, t.parentColumns), q(p.name), sqlQuoteList(sqlDialectSQLite, p.primaryKey())))
+	}
+	fks := foreignKeyColumns(t.col, def)
+	for _, name := range slices.Sorted(maps.Keys(fks)) {
+		target := fks[name]
+		c := t.col.Columns[name]
+		if c.Formula != "" || c.Type == ingitdb.ColumnTypeL10N || !exported[target] || len(sqlPrimaryKey(def.Collections[target])) != 1 {
+			continue
+		}
+		line := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)", q(name), q(target), sqlQuoteList(sqlDialectSQLite, sqlPrimaryKey(def.Collections[target])))
+		switch cons.onDelete(name) {
+		case onDeleteCascade:
+			line += " ON DELETE CASCADE"
+		case onDeleteSetNull:
+			line += " ON DELETE SET NULL"
+		}
+		lines = append(lines, line)
+	}
+	ddl := fmt.Sprintf("CREATE TABLE %s (\n  %s\n);\n", q(t.name), strings.Join(lines, ",\n  "))
+	for _, name := range sideTables {
+		lines = lines[:0]
+		for _, key := range pk {
+			lines = append(lines, q(key)+" "+exportKeyType(t, key)+" NOT NULL")
+		}
+		lines = append(lines,
+			`"locale" TEXT NOT NULL`,
+			`"value" TEXT`,
+			"PRIMARY KEY ("+sqlQuoteList(sqlDialectSQLite, append(slices.Clone(pk), "locale"))+")",
+			fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE CASCADE",
+				sqlQuoteList(sqlDialectSQLite, pk), q(t.name), sqlQuoteList(sqlDialectSQLite, pk)))
+		ddl += fmt.Sprintf("CREATE TABLE %s (\n  %s\n);\n", q(t.name+exportTableSeparator+name), strings.Join(lines, ",\n  "))
+	}
+	return ddl, nil
+}
+
+// writeExportRows writes an INSERT statement per row of t and, with
+// --locales=table, per locale value of its locale map columns.
+func writeExportRows(b *strings.Builder, t *exportTable, locales string) error {
+	q := func(name string) string { return sqlQuote(sqlDialectSQLite, name) }
+	pk := t.primaryKey()
+	var names []string
+	names = append(names, t.parentColumns...)
+	if t.syntheticKey() {
+		names = append(names, sqlKeyColumn)
+	}
+	var localeColumns, sideTables []string
+	for _, name := range t.columns() {
+		if t.col.Columns[name].Type != ingitdb.ColumnTypeL10N {
+			names = append(names, name)
+		} else if locales == exportLocalesTable {
+			sideTables = append(sideTables, name)
+		} else {
+			localeColumns = append(localeColumns, name)
+		}
+	}
+	header := names
+	localesOf := make(map[string][]string, len(localeColumns))
+	for _, name := range localeColumns {
+		localesOf[name] = t.locales(name)
+		for _, locale := range localesOf[name] {
+			header = append(header, name+"_"+locale)
+		}
+	}
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", q(t.name), sqlQuoteList(sqlDialectSQLite, header))
+	for _, row := range t.rows {
+		values := slices.Clone(row.parentKeys)
+		for i := range values {
+			values[i] = exportString(values[i])
+		}
+		if t.syntheticKey() {
+			values = append(values, exportString(row.key))
+		}
+		for _, name := range names[len(values):] {
+			v, err := exportValue(t.col.Columns[name].Type, row.data[name])
+			if err != nil {
+				return fmt.Errorf("record %s of %s, column %s: %w", row.key, t.name, name, err)
+			}
+			values = append(values, v)
+		}
+		for _, name := range localeColumns {
+			m, _ := row.data[name].(map[string]any)
+			for _, locale := range localesOf[name] {
+				v, err := exportValue(ingitdb.ColumnTypeString, m[locale])
+				if err != nil {
+					return fmt.Errorf("record %s of %s, column %s: %w", row.key, t.name, name, err)
+				}
+				values = append(values, v)
+			}
+		}
+		b.WriteString(insert + strings.Join(values, ", ") + ");\n")
+		for _, name := range sideTables {
+			m, _ := row.data[name].(map[string]any)
+			for _, locale := range slices.Sorted(maps.Keys(m)) {
+				values = values[:0]
+				for _, key := range pk {
+					values = append(values, exportKeyValue(t, row, key))
+				}
+				v, err := exportValue(ingitdb.ColumnTypeString, m[locale])
+				if err != nil {
+					return fmt.Errorf("record %s of %s, column %s: %w", row.key, t.name, name, err)
+				}
+				values = append(values, exportString(locale), v)
+				fmt.Fprintf(b, "INSERT INTO %s (%s) VALUES (%s);\n", q(t.name+exportTableSeparator+name),
+					sqlQuoteList(sqlDialectSQLite, append(slices.Clone(pk), "locale", "value")), strings.Join(values, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// exportKeyValue returns the literal of the primary key column name of row.
+func exportKeyValue(t *exportTable, row exportRow, name string) string {
+	if i := slices.Index(t.parentColumns, name); i >= 0 {
+		return exportString(row.parentKeys[i])
+	}
+	if c := t.col.Columns[name]; c != nil {
+		if v, err := exportValue(c.Type, row.data[name]); err == nil {
+			return v
+		}
+	}
+	return exportString(row.key)
+}
+
+// exportValue renders a record value as a SQLite literal: bools as 0 and 1,
+// dates and times as ISO 8601 text, maps and lists as JSON text and missing
+// values as NULL.
+func exportValue(columnType ingitdb.ColumnType, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return exportString(v), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case json.Number:
+		return v.String(), nil
+	case time.Time:
+		if columnType == ingitdb.ColumnTypeDate {
+			return exportString(v.Format(time.DateOnly)), nil
+		}
+		return exportString(v.Format(time.RFC3339Nano)), nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %T as JSON: %w", v, err)
+	}
+	return exportString(string(encoded)), nil
+}
+
+// exportString quotes s as a SQL string literal.
+func exportString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writeSQLiteFile runs script with the sqlite3 shell against a new database
+// next to out and moves it over out once every statement has succeeded.
+func writeSQLiteFile(ctx context.Context, out, script string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(out), "."+filepath.Base(out)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	_ = tmp.Close()
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	cmd := exec.CommandContext(ctx, sqlite3Command, "-batch", "-bail", tmp.Name())
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("exporting to SQLite needs the %s shell on PATH: %w", sqlite3Command, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w (%s)", sqlite3Command, err, strings.TrimSpace(stderr.String()))
+	}
+	if err = os.Rename(tmp.Name(), out); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	return nil
+}
+
+// exportTreeAtRef extracts the database directory as of ref into a temporary
+// directory with git archive. The returned function removes it.
+func exportTreeAtRef(ctx context.Context, dirPath, ref string) (string, func(), error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dirPath, "rev-parse", "--show-toplevel", "--show-prefix").Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("--ref needs the database in a git repository: %w", err)
+	}
+	topLevel, prefix, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	// git archive refuses to run in a subdirectory when given a tree, so the
+	// database directory is addressed as <ref>:<prefix> from the top level.
+	tree := ref + ":" + strings.TrimSuffix(prefix, "/")
+	archive := exec.CommandContext(ctx, "git", "-C", topLevel, "archive", "--format=tar", tree)
+	var stderr bytes.Buffer
+	archive.Stderr = &stderr
+	content, err := archive.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the database at %s: %w (%s)", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	dir, err := os.MkdirTemp("", "ingitdb-export-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	if err = extractTar(bytes.NewReader(content), dir); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to extract the database at %s: %w", ref, err)
+	}
+	return dir, cleanup, nil
+}
+
+// extractTar writes the directories and regular files of a tar stream under
+// dir. Other entries, such as symlinks, are skipped.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0o755)
+		case tar.TypeReg:
+			err = extractTarFile(tr, path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractTarFile writes the current tar entry to path.
+func extractTarFile(r io.Reader, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
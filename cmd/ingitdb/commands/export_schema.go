@@ -0,0 +1,364 @@
+package commands
+
+// specscore: feature/cli/export-schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// jsonSchemaDialect is the JSON Schema draft the exported schemas declare.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// defaultSchemaOutDir is where export-schema writes the schemas, relative to
+// the database directory.
+const defaultSchemaOutDir = ".ingitdb/schemas"
+
+// placeholderPattern matches the {key} and {fieldName} placeholders of a
+// record_file.name.
+var placeholderPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// localeKeyRegex matches a locale key: a language code, optionally followed
+// by a region ("en", "pt-BR").
+const localeKeyRegex = `^[a-z]{2,3}(-[A-Za-z]{2,4})?$`
+
+// mapKeyPatterns are the JSON Schema property-name patterns of the map key
+// types a column type may declare. A key type missing here accepts any name.
+var mapKeyPatterns = map[string]string{
+	"locale": localeKeyRegex,
+	"int":    `^-?[0-9]+$`,
+	"number": `^-?[0-9]+(\.[0-9]+)?$`,
+	"bool":   `^(true|false)$`,
+	"date":   `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`,
+}
+
+// ExportSchema returns the `ingitdb export-schema` command. It renders the
+// collection definitions in a format other tools understand; today that is
+// JSON Schema, one file per collection, for editors to validate record files
+// against.
+func ExportSchema(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-schema",
+		Short: "Export collection definitions as JSON Schema",
+		Long: "Writes one JSON Schema per collection (column types, required columns, enums, " +
+			"locale maps and foreign-key references) to --out, and with --settings prints the " +
+			"editor settings that map record-file globs to the schemas.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			format, _ := cmd.Flags().GetString("format")
+			if format != "jsonschema" {
+				return fmt.Errorf("invalid --format=%q (must be jsonschema)", format)
+			}
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			def, err := readDefinition(dirPath)
+			if err != nil {
+				return fmt.Errorf("failed to read database definition: %w", err)
+			}
+			only, _ := cmd.Flags().GetString("collection")
+			outDir, _ := cmd.Flags().GetString("out")
+			settings, _ := cmd.Flags().GetBool("settings")
+			return runExportSchema(cmd.OutOrStdout(), dirPath, def, only, outDir, settings, logf)
+		},
+	}
+	cmd.Flags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.Flags().String("format", "jsonschema", "schema format: jsonschema")
+	cmd.Flags().String("collection", "", "export only this root collection and its subcollections")
+	cmd.Flags().String("out", defaultSchemaOutDir, "directory to write the schemas to, relative to the database directory")
+	cmd.Flags().Bool("settings", false, "print the yaml.schemas and json.schemas editor settings for the exported schemas")
+	return cmd
+}
+
+// exportedCollection is a collection to export and where it sits in the
+// collection tree.
+type exportedCollection struct {
+	// fullID is the slash-separated path of the collection: "countries",
+	// "countries/regions".
+	fullID string
+	col    *ingitdb.CollectionDef
+	// parent is the collection a subcollection belongs to; nil for a root
+	// collection.
+	parent *exportedCollection
+}
+
+// schemaFileName returns the name of the schema file of the collection.
+func (e exportedCollection) schemaFileName() string {
+	return strings.ReplaceAll(e.fullID, "/", ".") + ".schema.json"
+}
+
+// exportedCollections flattens the collection tree under parent (nil for the
+// root collections) into a list sorted by full ID. When only is set, just that
+// root collection and its subcollections are kept.
+func exportedCollections(collections map[string]*ingitdb.CollectionDef, parent *exportedCollection, only string) []exportedCollection {
+	var out []exportedCollection
+	for _, id := range slices.Sorted(maps.Keys(collections)) {
+		if parent == nil && only != "" && id != only {
+			continue
+		}
+		e := &exportedCollection{fullID: id, col: collections[id], parent: parent}
+		if parent != nil {
+			e.fullID = parent.fullID + "/" + id
+		}
+		out = append(out, *e)
+		out = append(out, exportedCollections(e.col.SubCollections, e, "")...)
+	}
+	return out
+}
+
+// runExportSchema writes the JSON Schema of every collection of def to outDir
+// and, with settings, prints the editor settings mapping record files to them.
+func runExportSchema(w io.Writer, dbDir string, def *ingitdb.Definition, only, outDir string, settings bool, logf func(...any)) error {
+	cols := exportedCollections(def.Collections, nil, only)
+	if only != "" && len(cols) == 0 {
+		return fmt.Errorf("collection %q not found", only)
+	}
+	absOut := outDir
+	if !filepath.IsAbs(absOut) {
+		absOut = filepath.Join(dbDir, outDir)
+	}
+	if err := os.MkdirAll(absOut, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", outDir, err)
+	}
+	for _, e := range cols {
+		out, err := marshalJSONSchema(collectionJSONSchema(e))
+		if err != nil {
+			return fmt.Errorf("failed to encode schema of %s: %w", e.fullID, err)
+		}
+		file := filepath.Join(absOut, e.schemaFileName())
+		if err = os.WriteFile(file, out, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+	}
+	logf(fmt.Sprintf("exported %d JSON schema(s) to %s", len(cols), absOut))
+	if !settings {
+		return nil
+	}
+	out, err := marshalJSONSchema(editorSchemaSettings(dbDir, absOut, cols))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// marshalJSONSchema encodes v as indented JSON with a trailing newline.
+// Keys are sorted, so re-exporting an unchanged schema leaves the file as
+// it was.
+func marshalJSONSchema(v any) ([]byte, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// collectionJSONSchema returns the JSON Schema of a record file of the
+// collection: one record for a single-record file, an array of records for a
+// list, and an object of records for a map.
+func collectionJSONSchema(e exportedCollection) map[string]any {
+	record := recordJSONSchema(e.col)
+	schema := record
+	if rf := e.col.RecordFile; rf != nil {
+		switch rf.RecordType {
+		case ingitdb.ListOfRecords:
+			schema = map[string]any{"type": "array", "items": record}
+		case ingitdb.MapOfRecords:
+			schema = map[string]any{"type": "object", "additionalProperties": record}
+		}
+	}
+	schema["$schema"] = jsonSchemaDialect
+	schema["$id"] = e.schemaFileName()
+	schema["title"] = e.fullID
+	if title := e.col.Titles["en"]; title != "" {
+		schema["title"] = title
+	}
+	return schema
+}
+
+// recordJSONSchema returns the JSON Schema of one record. Computed columns
+// are not stored and are left out. A field that is not a column is rejected,
+// as validate does, except for the $-prefixed fields inGitDB itself injects.
+func recordJSONSchema(col *ingitdb.CollectionDef) map[string]any {
+	properties := make(map[string]any, len(col.Columns))
+	var required []string
+	for _, name := range slices.Sorted(maps.Keys(col.Columns)) {
+		column := col.Columns[name]
+		if column == nil || column.Formula != "" {
+			continue
+		}
+		properties[name] = columnJSONSchema(column)
+		if column.Required {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"patternProperties":    map[string]any{`^\$`: map[string]any{}},
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// columnJSONSchema returns the JSON Schema of a column's value.
+func columnJSONSchema(column *ingitdb.ColumnDef) map[string]any {
+	schema := typeJSONSchema(column.Type)
+	if column.Title != "" {
+		schema["title"] = column.Title
+	}
+	if len(column.Enum) > 0 {
+		schema["enum"] = column.Enum
+	}
+	if column.MinValue != nil {
+		schema["minimum"] = *column.MinValue
+	}
+	if column.MaxValue != nil {
+		schema["maximum"] = *column.MaxValue
+	}
+	minKey, maxKey := "minLength", "maxLength"
+	switch schema["type"] {
+	case "array":
+		minKey, maxKey = "minItems", "maxItems"
+	case "object":
+		minKey, maxKey = "minProperties", "maxProperties"
+	}
+	if column.Length != nil {
+		schema[minKey], schema[maxKey] = *column.Length, *column.Length
+	}
+	if column.MinLength != nil {
+		schema[minKey] = *column.MinLength
+	}
+	if column.MaxLength != nil {
+		schema[maxKey] = *column.MaxLength
+	}
+	if column.Format == "uri" || column.Format == "email" {
+		schema["format"] = column.Format
+	}
+	if column.ForeignKey != "" {
+		// JSON Schema cannot look into other files; the reference is kept for
+		// tooling and shown by editors as the column description.
+		schema["x-ingitdb-foreign-key"] = column.ForeignKey
+		schema["description"] = "Key of a record in collection " + column.ForeignKey
+	}
+	return schema
+}
+
+// typeJSONSchema maps a column type to JSON Schema.
+func typeJSONSchema(ct ingitdb.ColumnType) map[string]any {
+	switch ct {
+	case ingitdb.ColumnTypeString:
+		return map[string]any{"type": "string"}
+	case ingitdb.ColumnTypeInt:
+		return map[string]any{"type": "integer"}
+	case ingitdb.ColumnTypeFloat:
+		return map[string]any{"type": "number"}
+	case ingitdb.ColumnTypeBool:
+		return map[string]any{"type": "boolean"}
+	case ingitdb.ColumnTypeDate:
+		return map[string]any{"type": "string", "format": "date"}
+	case ingitdb.ColumnTypeTime:
+		return map[string]any{"type": "string", "format": "time"}
+	case ingitdb.ColumnTypeDateTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if elem, ok := ingitdb.ListElementType(ct); ok {
+		return map[string]any{"type": "array", "items": typeJSONSchema(elem)}
+	}
+	if rest, ok := strings.CutPrefix(string(ct), "map["); ok {
+		if keyType, valueType, found := strings.Cut(rest, "]"); found {
+			schema := map[string]any{"type": "object"}
+			if pattern, known := mapKeyPatterns[keyType]; known {
+				schema["propertyNames"] = map[string]any{"pattern": pattern}
+			}
+			if valueType != "" && valueType != string(ingitdb.ColumnTypeAny) {
+				schema["additionalProperties"] = typeJSONSchema(ingitdb.ColumnType(valueType))
+			}
+			return schema
+		}
+	}
+	return map[string]any{}
+}
+
+// editorSchemaSettings returns the editor settings that associate the record
+// files of each collection with its schema: yaml.schemas (VS Code YAML
+// extension) for YAML collections and json.schemas for JSON ones. Paths are
+// relative to the database directory, which is assumed to be the workspace
+// root. Collections in other formats are left out.
+func editorSchemaSettings(dbDir, absOut string, cols []exportedCollection) map[string]any {
+	yamlSchemas := make(map[string][]string)
+	var jsonSchemas []map[string]any
+	for _, e := range cols {
+		rf := e.col.RecordFile
+		if rf == nil || rf.Name == "" {
+			continue
+		}
+		schemaRel, err := filepath.Rel(dbDir, filepath.Join(absOut, e.schemaFileName()))
+		if err != nil {
+			continue
+		}
+		schemaPath := "./" + filepath.ToSlash(schemaRel)
+		glob := recordFileGlob(dbDir, e)
+		switch rf.Format {
+		case ingitdb.RecordFormatYAML, ingitdb.RecordFormatYML:
+			yamlSchemas[schemaPath] = append(yamlSchemas[schemaPath], glob)
+		case ingitdb.RecordFormatJSON:
+			jsonSchemas = append(jsonSchemas, map[string]any{"fileMatch": []string{glob}, "url": schemaPath})
+		}
+	}
+	settings := make(map[string]any)
+	if len(yamlSchemas) > 0 {
+		settings["yaml.schemas"] = yamlSchemas
+	}
+	if len(jsonSchemas) > 0 {
+		settings["json.schemas"] = jsonSchemas
+	}
+	return settings
+}
+
+// recordFileGlob returns the glob, relative to the database directory, that
+// matches the record files of e: the {key} and {field} placeholders of
+// record_file.name become wildcards.
+func recordFileGlob(dbDir string, e exportedCollection) string {
+	rf := e.col.RecordFile
+	return path.Join(collectionDataGlob(dbDir, e), rf.RecordsBasePath(), placeholderPattern.ReplaceAllString(rf.Name, "*"))
+}
+
+// collectionDataGlob returns the glob of the directories holding the records
+// of e, relative to the database directory. A subcollection's DirPath is its
+// schema directory; its data lives under every record of the parent, as
+// subCollectionInstanceDir lays it out, so the parent key becomes a wildcard.
+func collectionDataGlob(dbDir string, e exportedCollection) string {
+	if e.parent == nil {
+		if rel, err := filepath.Rel(dbDir, e.col.DirPath); err == nil {
+			return filepath.ToSlash(rel)
+		}
+		return filepath.ToSlash(e.col.DirPath)
+	}
+	dir := collectionDataGlob(dbDir, *e.parent)
+	if rf := e.parent.col.RecordFile; rf != nil {
+		dir = path.Join(dir, rf.RecordsBasePath())
+	}
+	return path.Join(dir, "*", e.col.ID)
+}
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func exportSchemaTestDef(dir string) *ingitdb.Definition {
+	minPop := 0.0
+	maxCode := 2
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID:      "countries",
+			DirPath: filepath.Join(dir, "countries"),
+			Titles:  map[string]string{"en": "Countries"},
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"code":       {Type: ingitdb.ColumnTypeString, Required: true, MaxLength: &maxCode},
+				"titles":     {Type: ingitdb.ColumnTypeL10N},
+				"population": {Type: ingitdb.ColumnTypeInt, MinValue: &minPop},
+				"continent":  {Type: ingitdb.ColumnTypeString, Enum: []any{"africa", "europe"}},
+				"capital":    {Type: ingitdb.ColumnTypeString, ForeignKey: "cities"},
+				"founded":    {Type: ingitdb.ColumnTypeDate},
+				"tags":       {Type: "[]string"},
+				"density":    {Type: ingitdb.ColumnTypeFloat, Formula: "population / 2"},
+			},
+		},
+		"regions": {
+			ID:      "regions",
+			DirPath: filepath.Join(dir, "geo", "regions"),
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "regions.json", Format: ingitdb.RecordFormatJSON, RecordType: ingitdb.ListOfRecords,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+		"cities": {
+			ID:      "cities",
+			DirPath: filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{
+				Name: "cities.csv", Format: ingitdb.RecordFormatCSV, RecordType: ingitdb.ListOfRecords,
+			},
+			Columns: map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		},
+	}}
+}
+
+func readJSONSchemaForTest(t *testing.T, file string) map[string]any {
+	t.Helper()
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("read %s: %v", file, err)
+	}
+	var got map[string]any
+	if err = json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("parse %s: %v", file, err)
+	}
+	return got
+}
+
+func TestRunExportSchema(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	var out bytes.Buffer
+	var logged []string
+	logf := func(args ...any) { logged = append(logged, args[0].(string)) }
+	if err := runExportSchema(&out, dir, exportSchemaTestDef(dir), "", defaultSchemaOutDir, true, logf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if len(logged) != 1 || !strings.HasPrefix(logged[0], "exported 3 JSON schema(s)") {
+		t.Errorf("unexpected log: %q", logged)
+	}
+
+	countries := readJSONSchemaForTest(t, filepath.Join(dir, ".ingitdb/schemas/countries.schema.json"))
+	wantCountries := map[string]any{
+		"$schema":              jsonSchemaDialect,
+		"$id":                  "countries.schema.json",
+		"title":                "Countries",
+		"type":                 "object",
+		"required":             []any{"code"},
+		"additionalProperties": false,
+		"patternProperties":    map[string]any{`^\$`: map[string]any{}},
+		"properties": map[string]any{
+			"code":       map[string]any{"type": "string", "maxLength": 2.0},
+			"titles":     map[string]any{"type": "object", "propertyNames": map[string]any{"pattern": mapKeyPatterns["locale"]}, "additionalProperties": map[string]any{"type": "string"}},
+			"population": map[string]any{"type": "integer", "minimum": 0.0},
+			"continent":  map[string]any{"type": "string", "enum": []any{"africa", "europe"}},
+			"capital":    map[string]any{"type": "string", "x-ingitdb-foreign-key": "cities", "description": "Key of a record in collection cities"},
+			"founded":    map[string]any{"type": "string", "format": "date"},
+			"tags":       map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	if !reflect.DeepEqual(countries, wantCountries) {
+		t.Errorf("countries schema:\n got %v\nwant %v", countries, wantCountries)
+	}
+
+	regions := readJSONSchemaForTest(t, filepath.Join(dir, ".ingitdb/schemas/regions.schema.json"))
+	if regions["type"] != "array" || regions["title"] != "regions" {
+		t.Errorf("a list-of-records file must be an array of records, got %v", regions)
+	}
+	if items, _ := regions["items"].(map[string]any); items["type"] != "object" {
+		t.Errorf("unexpected items: %v", regions["items"])
+	}
+
+	wantSettings := `{
+  "json.schemas": [
+    {
+      "fileMatch": [
+        "geo/regions/regions.json"
+      ],
+      "url": "./.ingitdb/schemas/regions.schema.json"
+    }
+  ],
+  "yaml.schemas": {
+    "./.ingitdb/schemas/countries.schema.json": [
+      "countries/$records/*.yaml"
+    ]
+  }
+}
+`
+	if out.String() != wantSettings {
+		t.Errorf("settings:\n%s\nwant:\n%s", out.String(), wantSettings)
+	}
+}
+
+func TestRunExportSchema_SubcollectionSettings(t *testing.T) {
+	t.Parallel()
+	// The orders subcollection is defined under customers/.collection/ but
+	// its records live under each customer record.
+	dir, def := writeCollectionPathTestDB(t)
+	var out bytes.Buffer
+	if err := runExportSchema(&out, dir, def, "", defaultSchemaOutDir, true, func(...any) {}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	wantSettings := `{
+  "yaml.schemas": {
+    "./.ingitdb/schemas/customers.orders.schema.json": [
+      "customers/$records/*/orders/$records/*.yaml"
+    ],
+    "./.ingitdb/schemas/customers.schema.json": [
+      "customers/$records/*.yaml"
+    ]
+  }
+}
+`
+	if out.String() != wantSettings {
+		t.Errorf("settings:\n%s\nwant:\n%s", out.String(), wantSettings)
+	}
+}
+
+func TestRunExportSchema_Collection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	def := exportSchemaTestDef(dir)
+	var out bytes.Buffer
+	if err := runExportSchema(&out, dir, def, "cities", "schemas", false, func(...any) {}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "schemas"))
+	if err != nil {
+		t.Fatalf("read out dir: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "cities.schema.json" {
+		t.Errorf("expected only cities.schema.json, got %v", entries)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no settings without --settings, got %q", out.String())
+	}
+	err = runExportSchema(&out, dir, def, "unknown", "schemas", false, func(...any) {})
+	if err == nil || err.Error() != `collection "unknown" not found` {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+}
+
+func TestTypeJSONSchema(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		ct   ingitdb.ColumnType
+		want map[string]any
+	}{
+		{ct: ingitdb.ColumnTypeBool, want: map[string]any{"type": "boolean"}},
+		{ct: ingitdb.ColumnTypeFloat, want: map[string]any{"type": "number"}},
+		{ct: ingitdb.ColumnTypeDateTime, want: map[string]any{"type": "string", "format": "date-time"}},
+		{ct: ingitdb.ColumnTypeAny, want: map[string]any{}},
+		{ct: "map[string]any", want: map[string]any{"type": "object"}},
+		{ct: "map[int]float", want: map[string]any{
+			"type":                 "object",
+			"propertyNames":        map[string]any{"pattern": mapKeyPatterns["int"]},
+			"additionalProperties": map[string]any{"type": "number"},
+		}},
+		{ct: "[]date", want: map[string]any{"type": "array", "items": map[string]any{"type": "string", "format": "date"}}},
+	}
+	for _, tt := range tests {
+		if got := typeJSONSchema(tt.ct); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("typeJSONSchema(%s) = %v, want %v", tt.ct, got, tt.want)
+		}
+	}
+}
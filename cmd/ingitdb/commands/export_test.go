@@ -0,0 +1,233 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// exportTestDefinition returns a database with a locale map column, a
+// subcollection, a list collection and a foreign key between them.
+func exportTestDefinition(t *testing.T) *ingitdb.Definition {
+	t.Helper()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "countries/$records/IE.yaml", "title:\n  en: Ireland\n  ga: Éire\npopulation: 5100000\n")
+	writeFixTestFile(t, dir, "countries/$records/NZ.yaml", "title:\n  en: New Zealand\n")
+	writeFixTestFile(t, dir, "countries/$records/IE/regions/regions.yaml", "leinster:\n  name: Leinster\n  capital: 1\n")
+	writeFixTestFile(t, dir, "cities/cities.json",
+		`[{"id": 1, "name": "Dublin", "tags": ["capital", "port"], "capital": true}, {"id": 2, "name": "O'Brien's", "capital": false}]`)
+	regions := &ingitdb.CollectionDef{
+		ID:         "regions",
+		DirPath:    filepath.Join(dir, "countries", "subcollections", "regions"),
+		RecordFile: &ingitdb.RecordFileDef{Name: "regions.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.MapOfRecords},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"name":    {Type: ingitdb.ColumnTypeString, Required: true},
+			"capital": {Type: ingitdb.ColumnTypeInt, ForeignKey: "cities"},
+		},
+		ColumnsOrder: []string{"name", "capital"},
+	}
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": {
+			ID:         "countries",
+			DirPath:    filepath.Join(dir, "countries"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: ingitdb.RecordFormatYAML, RecordType: ingitdb.SingleRecord},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"title":      {Type: ingitdb.ColumnTypeL10N},
+				"population": {Type: ingitdb.ColumnTypeInt},
+			},
+			ColumnsOrder:   []string{"title", "population"},
+			SubCollections: map[string]*ingitdb.CollectionDef{"regions": regions},
+		},
+		"cities": {
+			ID:         "cities",
+			DirPath:    filepath.Join(dir, "cities"),
+			RecordFile: &ingitdb.RecordFileDef{Name: "cities.json", Format: ingitdb.RecordFormatJSON, RecordType: ingitdb.ListOfRecords},
+			Columns: map[string]*ingitdb.ColumnDef{
+				"id":      {Type: ingitdb.ColumnTypeInt},
+				"name":    {Type: ingitdb.ColumnTypeString, Required: true},
+				"tags":    {Type: ingitdb.ColumnTypeAny},
+				"capital": {Type: ingitdb.ColumnTypeBool},
+			},
+			ColumnsOrder: []string{"id", "name", "tags", "capital"},
+		},
+	}}
+}
+
+func TestExportScript(t *testing.T) {
+	t.Parallel()
+	def := exportTestDefinition(t)
+	tables, err := loadExportTables(def, "")
+	if err != nil {
+		t.Fatalf("loadExportTables: %v", err)
+	}
+	script, err := exportScript(def, tables, exportLocalesColumns)
+	if err != nil {
+		t.Fatalf("exportScript: %v", err)
+	}
+	want := `PRAGMA foreign_keys = OFF;
+BEGIN TRANSACTION;
+CREATE TABLE "cities" (
+  "id" INTEGER NOT NULL,
+  "name" TEXT NOT NULL,
+  "tags" TEXT,
+  "capital" INTEGER,
+  PRIMARY KEY ("id")
+);
+CREATE TABLE "countries" (
+  "id" TEXT NOT NULL,
+  "title_en" TEXT,
+  "title_ga" TEXT,
+  "population" INTEGER,
+  PRIMARY KEY ("id")
+);
+CREATE TABLE "countries__regions" (
+  "countries_key" TEXT NOT NULL,
+  "id" TEXT NOT NULL,
+  "name" TEXT NOT NULL,
+  "capital" INTEGER,
+  PRIMARY KEY ("countries_key", "id"),
+  FOREIGN KEY ("countries_key") REFERENCES "countries" ("id") ON DELETE CASCADE,
+  FOREIGN KEY ("capital") REFERENCES "cities" ("id")
+);
+INSERT INTO "cities" ("id", "name", "tags", "capital") VALUES (1, 'Dublin', '["capital","port"]', 1);
+INSERT INTO "cities" ("id", "name", "tags", "capital") VALUES (2, 'O''Brien''s', NULL, 0);
+INSERT INTO "countries" ("id", "population", "title_en", "title_ga") VALUES ('IE', 5100000, 'Ireland', 'Éire');
+INSERT INTO "countries" ("id", "population", "title_en", "title_ga") VALUES ('NZ', NULL, 'New Zealand', NULL);
+INSERT INTO "countries__regions" ("countries_key", "id", "name", "capital") VALUES ('IE', 'leinster', 'Leinster', 1);
+COMMIT;
+`
+	if script != want {
+		t.Errorf("got:\n%s\nwant:\n%s", script, want)
+	}
+
+	script, err = exportScript(def, tables[1:2], exportLocalesTable)
+	if err != nil {
+		t.Fatalf("exportScript: %v", err)
+	}
+	for _, line := range []string{
+		`CREATE TABLE "countries__title" (`,
+		`PRIMARY KEY ("id", "locale"),`,
+		`FOREIGN KEY ("id") REFERENCES "countries" ("id") ON DELETE CASCADE`,
+		`INSERT INTO "countries" ("id", "population") VALUES ('IE', 5100000);`,
+		`INSERT INTO "countries__title" ("id", "locale", "value") VALUES ('IE', 'ga', 'Éire');`,
+	} {
+		if !strings.Contains(script, line) {
+			t.Errorf("--locales=table script lacks %q:\n%s", line, script)
+		}
+	}
+}
+
+func TestLoadExportTables_Only(t *testing.T) {
+	t.Parallel()
+	def := exportTestDefinition(t)
+	tables, err := loadExportTables(def, "countries")
+	if err != nil {
+		t.Fatalf("loadExportTables: %v", err)
+	}
+	if len(tables) != 2 || tables[0].name != "countries" || tables[1].name != "countries__regions" {
+		t.Errorf("got %d tables, want countries and countries__regions", len(tables))
+	}
+	if _, err = loadExportTables(def, "planets"); err == nil || !strings.Contains(err.Error(), `collection "planets" not found`) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestExportValue(t *testing.T) {
+	t.Parallel()
+	day := time.Date(2024, 2, 29, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		columnType ingitdb.ColumnType
+		value      any
+		want       string
+	}{
+		{ingitdb.ColumnTypeString, nil, "NULL"},
+		{ingitdb.ColumnTypeString, "it's", "'it''s'"},
+		{ingitdb.ColumnTypeBool, true, "1"},
+		{ingitdb.ColumnTypeInt, 42, "42"},
+		{ingitdb.ColumnTypeInt, int64(-7), "-7"},
+		{ingitdb.ColumnTypeFloat, 2.5, "2.5"},
+		{ingitdb.ColumnTypeDate, day, "'2024-02-29'"},
+		{ingitdb.ColumnTypeDateTime, day, "'2024-02-29T10:30:00Z'"},
+		{ingitdb.ColumnTypeAny, map[string]any{"a": []any{1}}, `'{"a":[1]}'`},
+	}
+	for _, tt := range tests {
+		if got, err := exportValue(tt.columnType, tt.value); err != nil || got != tt.want {
+			t.Errorf("exportValue(%s, %#v) = %s, %v; want %s", tt.columnType, tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestWriteSQLiteFile(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath(sqlite3Command); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	def := exportTestDefinition(t)
+	tables, err := loadExportTables(def, "")
+	if err != nil {
+		t.Fatalf("loadExportTables: %v", err)
+	}
+	script, err := exportScript(def, tables, exportLocalesTable)
+	if err != nil {
+		t.Fatalf("exportScript: %v", err)
+	}
+	out := filepath.Join(t.TempDir(), "db.sqlite")
+	if err = writeSQLiteFile(context.Background(), out, script); err != nil {
+		t.Fatalf("writeSQLiteFile: %v", err)
+	}
+	query := `SELECT c.name, r.name, t.value FROM countries__regions r
+JOIN cities c ON c.id = r.capital JOIN countries__title t ON t.id = r.countries_key AND t.locale = 'en'`
+	got, err := exec.Command(sqlite3Command, out, query).Output()
+	if err != nil {
+		t.Fatalf("sqlite3: %v", err)
+	}
+	if want := "Dublin|Leinster|Ireland\n"; string(got) != want {
+		t.Errorf("query returned %q, want %q", got, want)
+	}
+	if err = writeSQLiteFile(context.Background(), out, "CREATE TABLE broken (;"); err == nil {
+		t.Error("expected an error for an invalid script")
+	}
+	if _, err = exec.Command(sqlite3Command, out, "SELECT count(*) FROM cities").Output(); err != nil {
+		t.Errorf("a failed export replaced the previous file: %v", err)
+	}
+}
+
+func TestExportTreeAtRef(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	writeFixTestFile(t, repo, "db/countries/$records/IE.yaml", "title: Ireland\n")
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	writeFixTestFile(t, repo, "db/countries/$records/IE.yaml", "title: Éire\n")
+
+	dir, cleanup, err := exportTreeAtRef(context.Background(), filepath.Join(repo, "db"), "HEAD")
+	if err != nil {
+		t.Fatalf("exportTreeAtRef: %v", err)
+	}
+	defer cleanup()
+	content, err := os.ReadFile(filepath.Join(dir, "countries", "$records", "IE.yaml"))
+	if err != nil || string(content) != "title: Ireland\n" {
+		t.Errorf("IE.yaml at HEAD = %q, %v", content, err)
+	}
+	if _, _, err = exportTreeAtRef(context.Background(), repo, "no-such-ref"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
@@ -50,6 +50,10 @@ func addMaterializeCommandFlags(cmd *cobra.Command) {
 		"regenerate materialized views; bare flag = all, or =GLOB[,GLOB] (use '=', not a space)")
 	cmd.Flags().Int("records-delimiter", 0,
 		"write a '#-' delimiter after each record in INGR view output; 0=default (enabled), 1=enabled, -1=disabled")
+	cmd.Flags().String("since", "",
+		"rebuild only the views of collections changed since this git ref (compared with the working tree)")
+	cmd.Flags().Bool("check", false,
+		"write nothing; print a diff of every stale generated file and fail if any is out of date")
 	cmd.Flags().Lookup("collections").NoOptDefVal = materializeAllSentinel
 	cmd.Flags().Lookup("views").NoOptDefVal = materializeAllSentinel
 }
@@ -0,0 +1,404 @@
+package commands
+
+// specscore: feature/foreign-key-enforcement
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/dal-go/record"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// foreignKeyColumns maps each foreign_key column of col to the root
+// collection it references. Columns whose foreign_key resolves to no
+// collection are left out; definition validation reports them.
+func foreignKeyColumns(col *ingitdb.CollectionDef, def *ingitdb.Definition) map[string]string {
+	fks := make(map[string]string)
+	for name, colDef := range col.Columns {
+		if colDef.ForeignKey == "" {
+			continue
+		}
+		if target, ok := ingitdb.ResolveForeignKey(col.ID, colDef.ForeignKey, def.Collections); ok {
+			fks[name] = target
+		}
+	}
+	return fks
+}
+
+// foreignKeyValue returns the referenced key held in data[column], or "" when
+// the value is missing, nil, or empty — an absent reference is a required
+// concern, not an integrity one.
+func foreignKeyValue(data map[string]any, column string) string {
+	raw, ok := data[column]
+	if !ok || raw == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", raw)
+}
+
+// danglingReferenceMessage matches the message of the record validator, so
+// write-time and validation errors read the same and share the foreign-key
+// rule id.
+func danglingReferenceMessage(column, value, target string) string {
+	return fmt.Sprintf("foreign key %q = %q has no matching record in collection %q", column, value, target)
+}
+
+// collectionKeys returns the set of record keys stored in the collection id.
+func collectionKeys(def *ingitdb.Definition, id string) (map[string]bool, error) {
+	records, _, err := loadCollectionRecords(def.Collections[id])
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(records))
+	for key := range records {
+		keys[key] = true
+	}
+	return keys, nil
+}
+
+// checkForeignKeys verifies that every non-empty foreign_key value in writes
+// references an existing record. Records being written count as existing, so
+// a batch may reference its own records.
+func (c *writeChecker) checkForeignKeys(def *ingitdb.Definition, col *ingitdb.CollectionDef, writes map[string]map[string]any) error {
+	if def == nil {
+		return nil
+	}
+	fks := foreignKeyColumns(col, def)
+	for _, key := range slices.Sorted(maps.Keys(writes)) {
+		for _, column := range slices.Sorted(maps.Keys(fks)) {
+			value := foreignKeyValue(writes[key], column)
+			if value == "" {
+				continue
+			}
+			target := fks[column]
+			if target == col.ID {
+				if _, ok := writes[value]; ok {
+					continue
+				}
+			}
+			records, err := c.collectionRecords(def.Collections[target])
+			if err != nil {
+				return err
+			}
+			if _, ok := records[value]; !ok {
+				return fmt.Errorf("record %q in collection %s: %s", key, col.ID, danglingReferenceMessage(column, value, target))
+			}
+		}
+	}
+	return nil
+}
+
+// recordRef identifies a record of a root collection.
+type recordRef struct {
+	collection string
+	key        string
+}
+
+// deletePlan is the full effect of deleting a set of records once the
+// on_delete actions of the foreign keys referencing them are applied.
+type deletePlan struct {
+	// deletes lists the records to delete: the requested ones first, then
+	// the ones removed by on_delete cascade, in discovery order.
+	deletes []recordRef
+
+	// requested is the number of requested records at the head of deletes.
+	requested int
+
+	// nulls lists, per child record, the foreign_key fields on_delete
+	// set_null removes.
+	nulls map[recordRef][]string
+
+	// lists holds the child collections that keep their records in a local
+	// list file. Their changes go through the list encoder, one write per
+	// file, rather than through the transaction.
+	lists map[string]*ingitdb.CollectionDef
+
+	// listBackups holds the list files applyDeletePlanChildren rewrote, as
+	// they were before, for restoreLists.
+	listBackups []listFileBackup
+}
+
+// referencingColumn is a foreign_key column of a child collection and its
+// on_delete action.
+type referencingColumn struct {
+	collection string
+	column     string
+	onDelete   string
+}
+
+// planLocalDelete computes the deletePlan for removing keys from the root
+// collection colID of the local database at dirPath. A reference with
+// on_delete restrict (the default) fails the plan, naming the child record
+// that blocks it. It returns a nil plan for a remote source (empty dirPath),
+// whose records are not on disk. Subcollections are not scanned: foreign keys
+// resolve to root collections, and references held by subcollection records
+// are reported by validate.
+func planLocalDelete(dirPath string, def *ingitdb.Definition, colID string, keys []string) (*deletePlan, error) {
+	if dirPath == "" || def == nil {
+		return nil, nil
+	}
+	plan := &deletePlan{nulls: make(map[recordRef][]string), lists: make(map[string]*ingitdb.CollectionDef)}
+	deleting := make(map[recordRef]bool)
+	for _, key := range keys {
+		ref := recordRef{collection: colID, key: key}
+		if !deleting[ref] {
+			deleting[ref] = true
+			plan.deletes = append(plan.deletes, ref)
+		}
+	}
+	plan.requested = len(plan.deletes)
+
+	// referencing maps a referenced collection to the columns pointing at it.
+	referencing := make(map[string][]referencingColumn)
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		col := def.Collections[id]
+		fks := foreignKeyColumns(col, def)
+		if len(fks) == 0 {
+			continue
+		}
+		cons, err := readCollectionConstraints(col)
+		if err != nil {
+			return nil, err
+		}
+		for _, column := range slices.Sorted(maps.Keys(fks)) {
+			referencing[fks[column]] = append(referencing[fks[column]],
+				referencingColumn{collection: id, column: column, onDelete: cons.onDelete(column)})
+		}
+	}
+	records := make(map[string]map[string]map[string]any)
+	load := func(id string) (map[string]map[string]any, error) {
+		if recs, ok := records[id]; ok {
+			return recs, nil
+		}
+		recs, _, err := loadCollectionRecords(def.Collections[id])
+		if err != nil {
+			return nil, err
+		}
+		records[id] = recs
+		return recs, nil
+	}
+
+	for i := 0; i < len(plan.deletes); i++ {
+		parent := plan.deletes[i]
+		for _, ref := range referencing[parent.collection] {
+			children, err := load(ref.collection)
+			if err != nil {
+				return nil, err
+			}
+			for _, childKey := range slices.Sorted(maps.Keys(children)) {
+				child := recordRef{collection: ref.collection, key: childKey}
+				if deleting[child] || foreignKeyValue(children[childKey], ref.column) != parent.key {
+					continue
+				}
+				switch ref.onDelete {
+				case onDeleteCascade:
+					deleting[child] = true
+					delete(plan.nulls, child)
+					plan.deletes = append(plan.deletes, child)
+				case onDeleteSetNull:
+					if !slices.Contains(plan.nulls[child], ref.column) {
+						plan.nulls[child] = append(plan.nulls[child], ref.column)
+					}
+				default:
+					return nil, fmt.Errorf("cannot delete record %q from collection %s: record %q in collection %s references it through foreign key %q (on_delete: restrict)",
+						parent.key, parent.collection, childKey, ref.collection, ref.column)
+				}
+			}
+		}
+	}
+	for _, id := range plan.affectedCollections("") {
+		if col := def.Collections[id]; isLocalListCollection(dirPath, col) {
+			plan.lists[id] = col
+		}
+	}
+	return plan, nil
+}
+
+// applyDeletePlanChildren performs the on_delete side effects of plan inside
+// tx: it deletes the cascaded records and removes the set_null fields of the
+// child records. The requested records are deleted by the caller. Child rows
+// of local list collections are rewritten in their list files first; when
+// the transaction fails, the caller puts them back with restoreLists.
+func applyDeletePlanChildren(ctx context.Context, tx dal.ReadwriteTransaction, plan *deletePlan) error {
+	if plan == nil {
+		return nil
+	}
+	if err := plan.applyListChanges(); err != nil {
+		return err
+	}
+	for _, ref := range plan.deletes[plan.requested:] {
+		if plan.lists[ref.collection] != nil {
+			continue
+		}
+		if err := tx.Delete(ctx, record.NewKeyWithID(ref.collection, ref.key)); err != nil {
+			return fmt.Errorf("on_delete cascade: failed to delete record %q from collection %s: %w", ref.key, ref.collection, err)
+		}
+	}
+	refs := slices.SortedFunc(maps.Keys(plan.nulls), func(a, b recordRef) int {
+		return cmp.Or(cmp.Compare(a.collection, b.collection), cmp.Compare(a.key, b.key))
+	})
+	for _, ref := range refs {
+		if plan.lists[ref.collection] != nil {
+			continue
+		}
+		data := map[string]any{}
+		rec := record.NewRecordWithData(record.NewKeyWithID(ref.collection, ref.key), data)
+		if err := tx.Get(ctx, rec); err != nil {
+			return fmt.Errorf("on_delete set_null: failed to read record %q from collection %s: %w", ref.key, ref.collection, err)
+		}
+		for _, column := range plan.nulls[ref] {
+			delete(data, column)
+		}
+		if err := tx.Set(ctx, rec); err != nil {
+			return fmt.Errorf("on_delete set_null: failed to update record %q in collection %s: %w", ref.key, ref.collection, err)
+		}
+	}
+	return nil
+}
+
+// applyListChanges applies the cascaded deletes and set_null changes of p to
+// the child collections in p.lists, reading each list file with the CLI list
+// reader and writing it back through the list encoder in one write. Every
+// file is backed up before it is written.
+func (p *deletePlan) applyListChanges() error {
+	for _, id := range slices.Sorted(maps.Keys(p.lists)) {
+		col := p.lists[id]
+		remove := make(map[string]bool)
+		for _, ref := range p.deletes[p.requested:] {
+			if ref.collection == id {
+				remove[ref.key] = true
+			}
+		}
+		path, content, records, err := readListFile(col)
+		if err != nil {
+			return err
+		}
+		kept := make([]recordmerge.Record, 0, len(records))
+		changed := false
+		for _, r := range records {
+			if r.Key != "" && remove[r.Key] {
+				changed = true
+				continue
+			}
+			if columns := p.nulls[recordRef{collection: id, key: r.Key}]; r.Key != "" && len(columns) > 0 {
+				r.Fields = maps.Clone(r.Fields)
+				for _, column := range columns {
+					delete(r.Fields, column)
+				}
+				changed = true
+			}
+			kept = append(kept, r)
+		}
+		if !changed {
+			continue
+		}
+		p.listBackups = append(p.listBackups, listFileBackup{path: path, content: content})
+		if err = writeListFile(col, path, kept); err != nil {
+			return fmt.Errorf("on_delete: %w", err)
+		}
+	}
+	return nil
+}
+
+// restoreLists puts back, newest first, the list files p rewrote, after the
+// transaction they belong to failed with err. It returns err, noting any file
+// it could not restore.
+func (p *deletePlan) restoreLists(err error) error {
+	if p == nil {
+		return err
+	}
+	err = restoreListFiles(p.listBackups, err)
+	p.listBackups = nil
+	return err
+}
+
+// buildDeletePlanViews rebuilds the views of the collections plan touched
+// besides the one rctx targets; the caller rebuilds that one.
+func buildDeletePlanViews(ctx context.Context, rctx recordContext, plan *deletePlan) error {
+	if plan == nil {
+		return nil
+	}
+	for _, id := range plan.affectedCollections(rctx.colDef.ID) {
+		child := rctx
+		child.colDef = rctx.def.Collections[id]
+		if err := buildLocalViews(ctx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// affectedCollections returns the collections plan touches besides colID, so
+// their views can be rebuilt after the delete.
+func (p *deletePlan) affectedCollections(colID string) []string {
+	seen := map[string]bool{colID: true}
+	var ids []string
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, ref := range p.deletes {
+		add(ref.collection)
+	}
+	for ref := range p.nulls {
+		add(ref.collection)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// validateForeignKeyOrphans reports every record of a root collection whose
+// foreign_key value references a missing record. The record validator runs
+// the same check in a full pass; incremental validation only re-reads changed
+// records, so it misses children orphaned by a deleted parent.
+func validateForeignKeyOrphans(def *ingitdb.Definition) ([]ingitdb.ValidationError, error) {
+	var violations []ingitdb.ValidationError
+	targetKeys := make(map[string]map[string]bool)
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		col := def.Collections[id]
+		fks := foreignKeyColumns(col, def)
+		if len(fks) == 0 {
+			continue
+		}
+		records, files, err := loadCollectionRecords(col)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range slices.Sorted(maps.Keys(records)) {
+			for _, column := range slices.Sorted(maps.Keys(fks)) {
+				value := foreignKeyValue(records[key], column)
+				if value == "" {
+					continue
+				}
+				target := fks[column]
+				keys, loaded := targetKeys[target]
+				if !loaded {
+					if keys, err = collectionKeys(def, target); err != nil {
+						return nil, err
+					}
+					targetKeys[target] = keys
+				}
+				if !keys[value] {
+					violations = append(violations, ingitdb.ValidationError{
+						Severity:     ingitdb.SeverityError,
+						CollectionID: id,
+						FilePath:     files[key],
+						RecordKey:    key,
+						FieldName:    column,
+						Message:      danglingReferenceMessage(column, value, target),
+					})
+				}
+			}
+		}
+	}
+	return violations, nil
+}
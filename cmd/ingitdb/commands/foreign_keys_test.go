@@ -0,0 +1,187 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// fkTestDefinition describes a database at dir where cities reference
+// countries and streets reference cities, all single-record YAML collections.
+func fkTestDefinition(dir string) *ingitdb.Definition {
+	collection := func(id string, columns map[string]*ingitdb.ColumnDef) *ingitdb.CollectionDef {
+		return &ingitdb.CollectionDef{
+			ID:         id,
+			DirPath:    filepath.Join(dir, id),
+			Columns:    columns,
+			RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: "yaml", RecordType: ingitdb.SingleRecord},
+		}
+	}
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"countries": collection("countries", map[string]*ingitdb.ColumnDef{
+			"name": {Type: ingitdb.ColumnTypeString},
+		}),
+		"cities": collection("cities", map[string]*ingitdb.ColumnDef{
+			"name":    {Type: ingitdb.ColumnTypeString},
+			"country": {Type: ingitdb.ColumnTypeString, ForeignKey: "countries"},
+		}),
+		"streets": collection("streets", map[string]*ingitdb.ColumnDef{
+			"name": {Type: ingitdb.ColumnTypeString},
+			"city": {Type: ingitdb.ColumnTypeString, ForeignKey: "cities"},
+		}),
+	}}
+}
+
+// seedFKTestRecords writes countries ie and fr, cities dublin (ie) and cork
+// (ie), and street oconnell (dublin).
+func seedFKTestRecords(t *testing.T, dir string) {
+	t.Helper()
+	writeFixTestFile(t, dir, "countries/$records/ie.yaml", "name: Ireland\n")
+	writeFixTestFile(t, dir, "countries/$records/fr.yaml", "name: France\n")
+	writeFixTestFile(t, dir, "cities/$records/dublin.yaml", "name: Dublin\ncountry: ie\n")
+	writeFixTestFile(t, dir, "cities/$records/cork.yaml", "name: Cork\ncountry: ie\n")
+	writeFixTestFile(t, dir, "streets/$records/oconnell.yaml", "name: O'Connell Street\ncity: dublin\n")
+}
+
+func TestCheckForeignKeysOnWrite(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	def := fkTestDefinition(dir)
+
+	cases := []struct {
+		name   string
+		col    string
+		writes map[string]map[string]any
+		want   string
+	}{
+		{"existing parent", "cities", map[string]map[string]any{"paris": {"country": "fr"}}, ""},
+		{"dangling reference", "cities", map[string]map[string]any{"berlin": {"country": "de"}},
+			`record "berlin" in collection cities: foreign key "country" = "de" has no matching record in collection "countries"`},
+		{"empty reference", "cities", map[string]map[string]any{"nowhere": {"country": ""}}, ""},
+		{"missing reference", "streets", map[string]map[string]any{"main": {"name": "Main"}}, ""},
+		{"parent in the same batch", "countries", map[string]map[string]any{"de": {"name": "Germany"}}, ""},
+	}
+	for _, tc := range cases {
+		err := newWriteChecker().checkForeignKeys(def, def.Collections[tc.col], tc.writes)
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%s: expected %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestPlanLocalDelete_RestrictByDefault(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+
+	_, err := planLocalDelete(dir, fkTestDefinition(dir), "countries", []string{"ie"})
+	want := `cannot delete record "ie" from collection countries: record "cork" in collection cities references it through foreign key "country" (on_delete: restrict)`
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected %q, got %v", want, err)
+	}
+
+	plan, err := planLocalDelete(dir, fkTestDefinition(dir), "countries", []string{"fr"})
+	if err != nil {
+		t.Fatalf("an unreferenced record must be deletable: %v", err)
+	}
+	if len(plan.deletes) != 1 || len(plan.nulls) != 0 {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+}
+
+func TestPlanLocalDelete_CascadeAndSetNull(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  country:\n    on_delete: cascade\n")
+	writeFixTestFile(t, dir, "streets/.collection/constraints.yaml", "columns:\n  city:\n    on_delete: set_null\n")
+
+	plan, err := planLocalDelete(dir, fkTestDefinition(dir), "countries", []string{"ie"})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	wantDeletes := []recordRef{{"countries", "ie"}, {"cities", "cork"}, {"cities", "dublin"}}
+	if len(plan.deletes) != len(wantDeletes) {
+		t.Fatalf("deletes = %v, want %v", plan.deletes, wantDeletes)
+	}
+	for i := range wantDeletes {
+		if plan.deletes[i] != wantDeletes[i] {
+			t.Errorf("deletes[%d] = %v, want %v", i, plan.deletes[i], wantDeletes[i])
+		}
+	}
+	if got := plan.nulls[recordRef{"streets", "oconnell"}]; len(got) != 1 || got[0] != "city" {
+		t.Errorf("nulls = %v, want streets/oconnell city", plan.nulls)
+	}
+	if got := plan.affectedCollections("countries"); strings.Join(got, ",") != "cities,streets" {
+		t.Errorf("affected collections = %v", got)
+	}
+
+	if plan, err = planLocalDelete("", fkTestDefinition(dir), "countries", []string{"ie"}); plan != nil || err != nil {
+		t.Errorf("a remote source must not be planned, got %+v, %v", plan, err)
+	}
+}
+
+func TestReadCollectionConstraints_OnDelete(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		col, content, want string
+	}{
+		{"cities", "columns:\n  country:\n    on_delete: destroy\n", `invalid on_delete "destroy"`},
+		{"cities", "columns:\n  name:\n    on_delete: cascade\n", `column "name": on_delete requires a foreign_key column`},
+	}
+	for _, tc := range cases {
+		dir := t.TempDir()
+		writeFixTestFile(t, dir, tc.col+"/.collection/constraints.yaml", tc.content)
+		_, err := readCollectionConstraints(fkTestDefinition(dir).Collections[tc.col])
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: expected %q, got %v", tc.content, tc.want, err)
+		}
+	}
+
+	dir := t.TempDir()
+	def := fkTestDefinition(dir)
+	def.Collections["cities"].Columns["country"].Required = true
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  country:\n    on_delete: set_null\n")
+	if _, err := readCollectionConstraints(def.Collections["cities"]); err == nil || !strings.Contains(err.Error(), "not allowed on a required column") {
+		t.Errorf("expected set_null on a required column to be rejected, got %v", err)
+	}
+}
+
+func TestValidate_IncrementalReportsOrphans(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	writeFixTestFile(t, dir, "cities/$records/lyon.yaml", "name: Lyon\ncountry: fr\n")
+	writeFixTestFile(t, dir, "cities/$records/bonn.yaml", "name: Bonn\ncountry: de\n")
+
+	// The incremental validator already reported bonn; the pass over whole
+	// collections must not repeat it.
+	result := &ingitdb.ValidationResult{}
+	result.Append(ingitdb.ValidationError{
+		Severity: ingitdb.SeverityError, CollectionID: "cities", RecordKey: "bonn", FieldName: "country",
+		Message: `foreign key "country" = "de" has no matching record in collection "countries"`,
+	})
+	writeFixTestFile(t, dir, "streets/$records/rue.yaml", "name: Rue\ncity: nantes\n")
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return fkTestDefinition(p), nil },
+		nil, &mockIncrementalValidator{result: result}, func(...any) {},
+	)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	err := runCobraCommand(cmd, "--path="+dir, "--from-commit=HEAD~1", "--format=github")
+	if err == nil || !strings.Contains(err.Error(), "2 error(s)") {
+		t.Fatalf("expected 2 errors, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), `record "rue": field "city": foreign key "city" = "nantes" has no matching record`) {
+		t.Errorf("expected the orphaned street, got:\n%s", out.String())
+	}
+}
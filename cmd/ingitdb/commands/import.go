@@ -0,0 +1,670 @@
+package commands
+
+// specscore: feature/cli/import
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/dalgo2ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// importSources maps the scheme of --from to the reader of that source.
+var importSources = map[string]func(ctx context.Context, file string) (*sqlDump, error){
+	"sqlite":   readSQLiteDatabase,
+	"sql-dump": readSQLDumpFile,
+}
+
+// importFormatExtensions maps each --record-format value to the extension of
+// the record files import writes.
+var importFormatExtensions = map[ingitdb.RecordFormat]string{
+	ingitdb.RecordFormatYAML:  ".yaml",
+	ingitdb.RecordFormatYML:   ".yml",
+	ingitdb.RecordFormatJSON:  ".json",
+	ingitdb.RecordFormatJSONL: ".jsonl",
+	ingitdb.RecordFormatTOML:  ".toml",
+	ingitdb.RecordFormatINGR:  ".ingr",
+	ingitdb.RecordFormatCSV:   ".csv",
+}
+
+// sqlite3Command is the SQLite shell import runs to read a database file.
+var sqlite3Command = "sqlite3"
+
+// Import returns the `ingitdb import` command. It reads the tables of a
+// SQLite database or a SQL dump, creates one collection per table from its
+// DDL, and loads the rows through the batch insert pipeline.
+func Import(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Create collections from a SQLite database or a SQL dump and load their rows",
+		Long: "Creates one collection per table of --from — column types, NOT NULL, primary, unique and " +
+			"foreign keys are taken from the CREATE TABLE statements — and inserts the rows of each table " +
+			"in one batch. Tables are loaded so that referenced records exist before the records " +
+			"referencing them. Per-table options take table=value pairs; *=value applies to every table. " +
+			"--from=sqlite: reads the database with the sqlite3 shell, which must be on PATH.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			from, _ := cmd.Flags().GetString("from")
+			scheme, file, _ := strings.Cut(from, ":")
+			read, ok := importSources[scheme]
+			if !ok || file == "" {
+				return fmt.Errorf("invalid --from=%q (must be sqlite:<file> or sql-dump:<file>)", from)
+			}
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			var opts importOptions
+			opts.tables, _ = cmd.Flags().GetStringSlice("tables")
+			opts.keyColumns, _ = cmd.Flags().GetStringToString("key-column")
+			opts.recordFormats, _ = cmd.Flags().GetStringToString("record-format")
+			opts.recordTypes, _ = cmd.Flags().GetStringToString("record-type")
+			opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			dump, err := read(cmd.Context(), file)
+			if err != nil {
+				return err
+			}
+			return runImport(cmd.Context(), cmd.OutOrStdout(), dirPath, dump, opts, readDefinition, newDB, logf)
+		},
+	}
+	addPathFlag(cmd)
+	cmd.Flags().String("from", "", "source to import: sqlite:<file> or sql-dump:<file>")
+	_ = cmd.MarkFlagRequired("from")
+	cmd.Flags().StringSlice("tables", nil, "import only these tables (default: every table)")
+	cmd.Flags().StringToString("key-column", nil, "column holding the record key, as table=column (default: the primary key, else id)")
+	cmd.Flags().StringToString("record-format", nil, "record file format, as table=format: yaml (default), yml, json, jsonl, toml, ingr, csv")
+	cmd.Flags().StringToString("record-type", nil, "record file layout, as table=type: single (a file per record), list or map")
+	cmd.Flags().Bool("dry-run", false, "print the collection definitions without writing anything")
+	return cmd
+}
+
+// importOptions are the per-table choices of an import.
+type importOptions struct {
+	// tables restricts the import to these tables; empty imports all.
+	tables []string
+
+	// keyColumns, recordFormats and recordTypes map a table name, or "*"
+	// for every table, to the value of the matching flag.
+	keyColumns    map[string]string
+	recordFormats map[string]string
+	recordTypes   map[string]string
+
+	dryRun bool
+}
+
+// tableOption returns the value of a per-table option for table: its own
+// entry, else the "*" entry.
+func tableOption(values map[string]string, table string) string {
+	for name, value := range values {
+		if strings.EqualFold(name, table) {
+			return value
+		}
+	}
+	return values["*"]
+}
+
+// importedTable is a table together with the collection it becomes.
+type importedTable struct {
+	table     *sqlTable
+	keyColumn string
+	colDef    *ingitdb.CollectionDef
+	cons      *collectionConstraints
+}
+
+// runImport creates a collection per selected table of dump and loads its
+// rows. The collections are written first and read back through
+// readDefinition, so the rows are checked against the definitions the
+// database will use. A failure removes every collection the import created.
+func runImport(
+	ctx context.Context,
+	w io.Writer,
+	dbDir string,
+	dump *sqlDump,
+	opts importOptions,
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) error {
+	tables, err := planImport(dbDir, dump, opts, logf)
+	if err != nil {
+		return err
+	}
+	if opts.dryRun {
+		for _, it := range tables {
+			if err = writeImportedDefinition(w, it); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rootPath := filepath.Join(dbDir, rootCollectionsRelPath)
+	rootContent, rootErr := os.ReadFile(rootPath)
+	var created []string
+	rollback := func(cause error) error {
+		for _, dir := range created {
+			_ = os.RemoveAll(dir)
+		}
+		if rootErr == nil {
+			_ = os.WriteFile(rootPath, rootContent, 0o644)
+		} else {
+			_ = os.Remove(rootPath)
+		}
+		return cause
+	}
+	for _, it := range tables {
+		colDir := filepath.Join(dbDir, it.colDef.ID)
+		created = append(created, colDir)
+		if err = writeImportedCollection(colDir, it); err != nil {
+			return rollback(err)
+		}
+		if err = writeRootCollectionsWith(dbDir, it.colDef.ID, it.colDef.ID); err != nil {
+			return rollback(err)
+		}
+	}
+	def, err := readDefinition(dbDir)
+	if err != nil {
+		return rollback(fmt.Errorf("failed to read database definition: %w", err))
+	}
+	db, err := newDB(dbDir, def)
+	if err != nil {
+		return rollback(fmt.Errorf("failed to open database: %w", err))
+	}
+	for _, it := range tables {
+		records, err := importRecords(it)
+		if err != nil {
+			return rollback(err)
+		}
+		ictx := insertContext{db: db, colDef: def.Collections[it.colDef.ID], dirPath: dbDir, def: def}
+		if err = insertBatchRecords(ctx, records, ictx); err != nil {
+			return rollback(fmt.Errorf("table %s: %w", it.table.name, err))
+		}
+		logf(fmt.Sprintf("imported %d row(s) of table %s into collection %s", len(records), it.table.name, it.colDef.ID))
+	}
+	return nil
+}
+
+// planImport selects the tables to import, checks that none of them is a
+// collection already, and builds their definitions, ordered so that every
+// table comes after the tables it references.
+func planImport(dbDir string, dump *sqlDump, opts importOptions, logf func(...any)) ([]*importedTable, error) {
+	selected := dump.tables
+	if len(opts.tables) > 0 {
+		selected = nil
+		for _, name := range opts.tables {
+			t := dump.table(name)
+			if t == nil {
+				return nil, fmt.Errorf("table %q not found in the source", name)
+			}
+			selected = append(selected, t)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("the source has no tables to import")
+	}
+	existing, err := readRootCollections(dbDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	tables := make([]*importedTable, 0, len(selected))
+	for _, t := range selected {
+		if _, ok := existing[t.name]; ok {
+			return nil, fmt.Errorf("collection %q already exists", t.name)
+		}
+		if _, err = os.Stat(filepath.Join(dbDir, t.name)); err == nil {
+			return nil, fmt.Errorf("cannot import table %s: %s already exists", t.name, filepath.Join(dbDir, t.name))
+		}
+		it := &importedTable{table: t}
+		if it.keyColumn, err = importKeyColumn(t, tableOption(opts.keyColumns, t.name)); err != nil {
+			return nil, err
+		}
+		tables = append(tables, it)
+	}
+	for _, it := range tables {
+		rf, err := importRecordFile(it.table.name, tableOption(opts.recordFormats, it.table.name), tableOption(opts.recordTypes, it.table.name))
+		if err != nil {
+			return nil, err
+		}
+		importCollectionDef(it, rf, tables, logf)
+		if err = it.colDef.Validate(); err != nil {
+			return nil, fmt.Errorf("table %s: %w", it.table.name, err)
+		}
+		if err = it.cons.validate(it.colDef); err != nil {
+			return nil, fmt.Errorf("table %s: %w", it.table.name, err)
+		}
+	}
+	return importOrder(tables), nil
+}
+
+// importKeyColumn picks the column whose values become the record keys of
+// table: the --key-column of the table, its single-column primary key, or
+// its id column.
+func importKeyColumn(t *sqlTable, flagValue string) (string, error) {
+	switch {
+	case flagValue != "":
+		c := t.column(flagValue)
+		if c == nil {
+			return "", fmt.Errorf("table %s has no column %s (--key-column)", t.name, flagValue)
+		}
+		return c.name, nil
+	case len(t.primaryKey) == 1 && t.column(t.primaryKey[0]) != nil:
+		return t.column(t.primaryKey[0]).name, nil
+	case len(t.primaryKey) > 1:
+		return "", fmt.Errorf("table %s has a composite primary key (%s); choose the record key with --key-column %s=<column>",
+			t.name, strings.Join(t.primaryKey, ", "), t.name)
+	case t.column("id") != nil:
+		return t.column("id").name, nil
+	}
+	return "", fmt.Errorf("table %s has no primary key and no id column; choose the record key with --key-column %s=<column>", t.name, t.name)
+}
+
+// importRecordFile returns the record file of a table's collection. The
+// default layout is a file per record, except for the formats that only
+// hold lists of records.
+func importRecordFile(table, format, recordType string) (*ingitdb.RecordFileDef, error) {
+	if format == "" {
+		format = string(ingitdb.RecordFormatYAML)
+	}
+	ext, ok := importFormatExtensions[ingitdb.RecordFormat(format)]
+	if !ok {
+		return nil, fmt.Errorf("invalid --record-format %q for table %s (valid values: yaml, yml, json, jsonl, toml, ingr, csv)", format, table)
+	}
+	rf := &ingitdb.RecordFileDef{Format: ingitdb.RecordFormat(format)}
+	if recordType == "" {
+		rf.RecordType = ingitdb.SingleRecord
+		switch rf.Format {
+		case ingitdb.RecordFormatJSONL, ingitdb.RecordFormatCSV, ingitdb.RecordFormatINGR:
+			rf.RecordType = ingitdb.ListOfRecords
+		}
+	} else if rf.RecordType, ok = parseRecordType(recordType); !ok {
+		return nil, fmt.Errorf("invalid --record-type %q for table %s (valid values: single, list, map)", recordType, table)
+	}
+	rf.Name = table + ext
+	if rf.RecordType == ingitdb.SingleRecord {
+		rf.Name = "{key}" + ext
+	}
+	if err := rf.Validate(); err != nil {
+		return nil, fmt.Errorf("table %s: %w", table, err)
+	}
+	return rf, nil
+}
+
+// importCollectionDef builds the definition and constraints of a table's
+// collection. The key column stays a column and is declared as the
+// primary_key. Unique keys go to constraints.yaml, and so does the primary key
+// when another column holds the record key. Foreign keys are kept when they
+// reference the record key of another imported table; the others are
+// reported and dropped, as are ON DELETE actions other than CASCADE and
+// SET NULL.
+func importCollectionDef(it *importedTable, rf *ingitdb.RecordFileDef, tables []*importedTable, logf func(...any)) {
+	t := it.table
+	canonical := func(names []string) []string {
+		out := make([]string, len(names))
+		for i, name := range names {
+			out[i] = name
+			if c := t.column(name); c != nil {
+				out[i] = c.name
+			}
+		}
+		return out
+	}
+	pk := canonical(t.primaryKey)
+	it.colDef = &ingitdb.CollectionDef{
+		ID:         t.name,
+		RecordFile: rf,
+		Columns:    make(map[string]*ingitdb.ColumnDef, len(t.columns)),
+		PrimaryKey: []string{it.keyColumn},
+	}
+	for _, c := range t.columns {
+		colDef := c.columnDef()
+		colDef.Required = colDef.Required || c.name == it.keyColumn || slices.Contains(pk, c.name)
+		it.colDef.Columns[c.name] = colDef
+		it.colDef.ColumnsOrder = append(it.colDef.ColumnsOrder, c.name)
+	}
+
+	cons := &collectionConstraints{Columns: make(map[string]columnConstraints)}
+	uniqueKeys := slices.Clone(t.uniqueKeys)
+	if len(pk) > 0 && !slices.Equal(pk, []string{it.keyColumn}) {
+		uniqueKeys = append(uniqueKeys, pk)
+	}
+	for _, key := range uniqueKeys {
+		key = canonical(key)
+		switch {
+		case len(key) > 1:
+			cons.UniqueKeys = append(cons.UniqueKeys, key)
+		case key[0] != it.keyColumn:
+			cc := cons.Columns[key[0]]
+			cc.Unique = true
+			cons.Columns[key[0]] = cc
+		}
+	}
+	for _, fk := range t.foreignKeys {
+		var target *importedTable
+		for _, other := range tables {
+			if strings.EqualFold(other.table.name, fk.table) {
+				target = other
+			}
+		}
+		columns := canonical(fk.columns)
+		switch {
+		case len(columns) != 1:
+			logf(fmt.Sprintf("table %s: dropped the composite foreign key (%s) to %s", t.name, strings.Join(columns, ", "), fk.table))
+			continue
+		case target == nil:
+			logf(fmt.Sprintf("table %s: dropped the foreign key %s to %s, which is not imported", t.name, columns[0], fk.table))
+			continue
+		case len(fk.refColumns) == 1 && !strings.EqualFold(fk.refColumns[0], target.keyColumn):
+			logf(fmt.Sprintf("table %s: dropped the foreign key %s to %s.%s, which is not the record key of %s",
+				t.name, columns[0], fk.table, fk.refColumns[0], target.table.name))
+			continue
+		}
+		colDef := it.colDef.Columns[columns[0]]
+		colDef.ForeignKey = target.table.name
+		cc := cons.Columns[columns[0]]
+		switch {
+		case fk.onDelete == "CASCADE":
+			cc.OnDelete = onDeleteCascade
+		case fk.onDelete == "SET NULL" && !colDef.Required:
+			cc.OnDelete = onDeleteSetNull
+		case fk.onDelete != "" && fk.onDelete != "RESTRICT" && fk.onDelete != "NO ACTION":
+			logf(fmt.Sprintf("table %s: column %s keeps the default on_delete restrict instead of %s", t.name, columns[0], fk.onDelete))
+		}
+		if cc != (columnConstraints{}) {
+			cons.Columns[columns[0]] = cc
+		}
+	}
+	it.cons = cons
+}
+
+// importOrder sorts the tables so that every table follows the tables its
+// foreign keys reference. Tables in a reference cycle keep the source order.
+func importOrder(tables []*importedTable) []*importedTable {
+	ordered := make([]*importedTable, 0, len(tables))
+	placed := make(map[string]bool, len(tables))
+	for len(ordered) < len(tables) {
+		progress := false
+		for _, it := range tables {
+			if placed[it.colDef.ID] {
+				continue
+			}
+			ready := true
+			for _, col := range it.colDef.Columns {
+				if fk := col.ForeignKey; fk != "" && fk != it.colDef.ID && !placed[fk] {
+					ready = false
+				}
+			}
+			if ready {
+				ordered = append(ordered, it)
+				placed[it.colDef.ID] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, it := range tables {
+				if !placed[it.colDef.ID] {
+					ordered = append(ordered, it)
+					placed[it.colDef.ID] = true
+				}
+			}
+		}
+	}
+	return ordered
+}
+
+// encodeImportedDefinition renders the definition.yaml and, when the table
+// has any, the constraints.yaml of an imported collection.
+func encodeImportedDefinition(it *importedTable) (definition, constraints []byte, err error) {
+	encode := func(v any) ([]byte, error) {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	if definition, err = encode(it.colDef); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode the definition of %s: %w", it.colDef.ID, err)
+	}
+	if len(it.cons.Columns) > 0 || len(it.cons.UniqueKeys) > 0 {
+		if constraints, err = encode(it.cons); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode the constraints of %s: %w", it.colDef.ID, err)
+		}
+	}
+	return definition, constraints, nil
+}
+
+// writeImportedDefinition prints the files of an imported collection for
+// --dry-run, each under a comment naming its path.
+func writeImportedDefinition(w io.Writer, it *importedTable) error {
+	definition, constraints, err := encodeImportedDefinition(it)
+	if err != nil {
+		return err
+	}
+	schemaDir := filepath.ToSlash(filepath.Join(it.colDef.ID, ingitdb.SchemaDir))
+	if _, err = fmt.Fprintf(w, "# %s/%s\n%s", schemaDir, ingitdb.CollectionDefFileName, definition); err != nil {
+		return err
+	}
+	if constraints != nil {
+		_, err = fmt.Fprintf(w, "# %s/%s\n%s", schemaDir, collectionConstraintsFileName, constraints)
+	}
+	return err
+}
+
+// writeImportedCollection writes the schema files of an imported collection
+// under colDir.
+func writeImportedCollection(colDir string, it *importedTable) error {
+	definition, constraints, err := encodeImportedDefinition(it)
+	if err != nil {
+		return err
+	}
+	schemaDir := filepath.Join(colDir, ingitdb.SchemaDir)
+	if err = os.MkdirAll(schemaDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", schemaDir, err)
+	}
+	files := map[string][]byte{ingitdb.CollectionDefFileName: definition, collectionConstraintsFileName: constraints}
+	for name, content := range files {
+		if content == nil {
+			continue
+		}
+		path := filepath.Join(schemaDir, name)
+		if err = os.WriteFile(path, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// importRecords converts the rows of a table to batch records: every value
+// is converted to the type of its column, and the record key is the value of
+// the key column.
+func importRecords(it *importedTable) ([]dalgo2ingitdb.ParsedRecord, error) {
+	records := make([]dalgo2ingitdb.ParsedRecord, 0, len(it.table.rows))
+	for i, row := range it.table.rows {
+		data := make(map[string]any, len(row))
+		for name, value := range row {
+			converted, err := importValue(it.colDef.Columns[name].Type, value)
+			if err != nil {
+				return nil, fmt.Errorf("table %s, row %d, column %s: %w", it.table.name, i+1, name, err)
+			}
+			data[name] = converted
+		}
+		key, ok := data[it.keyColumn]
+		if !ok {
+			return nil, fmt.Errorf("table %s, row %d: the key column %s is NULL", it.table.name, i+1, it.keyColumn)
+		}
+		records = append(records, dalgo2ingitdb.ParsedRecord{Key: fmt.Sprint(key), Data: data, Position: i + 1})
+	}
+	return records, nil
+}
+
+// importValue converts a value read from SQL — a string, a sqlNumber or a
+// bool — to the type of its column. Integers and booleans written as text,
+// as COPY data and SQLite store them, are parsed; JSON columns are decoded.
+func importValue(columnType ingitdb.ColumnType, value any) (any, error) {
+	var text string
+	switch v := value.(type) {
+	case bool:
+		switch columnType {
+		case ingitdb.ColumnTypeBool, ingitdb.ColumnTypeAny:
+			return v, nil
+		case ingitdb.ColumnTypeInt:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return strconv.FormatBool(v), nil
+	case sqlNumber:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return nil, fmt.Errorf("unsupported value %v", value)
+	}
+	switch columnType {
+	case ingitdb.ColumnTypeInt:
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(text, 64); err == nil && f == math.Trunc(f) {
+			return int64(f), nil
+		}
+		return nil, fmt.Errorf("%q is not an integer", text)
+	case ingitdb.ColumnTypeFloat:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", text)
+		}
+		return f, nil
+	case ingitdb.ColumnTypeBool:
+		switch strings.ToLower(text) {
+		case "1", "t", "true", "y", "yes":
+			return true, nil
+		case "0", "f", "false", "n", "no":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean", text)
+	case ingitdb.ColumnTypeAny:
+		if _, isNumber := value.(sqlNumber); isNumber {
+			if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return n, nil
+			}
+			return importValue(ingitdb.ColumnTypeFloat, value)
+		}
+		var decoded any
+		if json.Unmarshal([]byte(text), &decoded) == nil {
+			return decoded, nil
+		}
+	}
+	return text, nil
+}
+
+// readSQLDumpFile reads a SQL dump file.
+func readSQLDumpFile(_ context.Context, file string) (*sqlDump, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	dump, err := parseSQLDump(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	return dump, nil
+}
+
+// readSQLiteDatabase reads the tables of a SQLite database with the sqlite3
+// shell: their DDL from sqlite_master, then the rows of each table as JSON,
+// which keeps numbers apart from text.
+func readSQLiteDatabase(ctx context.Context, file string) (*sqlDump, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file, err)
+	}
+	schema, err := runSQLite(ctx, file,
+		"SELECT sql || ';' FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY rowid", "-noheader")
+	if err != nil {
+		return nil, err
+	}
+	dump, err := parseSQLDump(string(schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the schema of %s: %w", file, err)
+	}
+	for _, t := range dump.tables {
+		out, err := runSQLite(ctx, file, "SELECT * FROM "+sqlQuote(sqlDialectSQLite, t.name), "-json")
+		if err != nil {
+			return nil, err
+		}
+		if t.rows, err = decodeSQLiteRows(out); err != nil {
+			return nil, fmt.Errorf("failed to read the rows of table %s: %w", t.name, err)
+		}
+	}
+	return dump, nil
+}
+
+// decodeSQLiteRows decodes the rows `sqlite3 -json` prints. Numbers keep
+// their text as sqlNumber, NULL columns are left out, and a table without
+// rows prints nothing.
+func decodeSQLiteRows(out []byte) ([]map[string]any, error) {
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.UseNumber()
+	var rows []map[string]any
+	if err := dec.Decode(&rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		for name, value := range row {
+			switch v := value.(type) {
+			case nil:
+				delete(row, name)
+			case json.Number:
+				row[name] = sqlNumber(v)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// runSQLite runs a query against a read-only SQLite database with the
+// sqlite3 shell and returns its output.
+func runSQLite(ctx context.Context, file, query string, options ...string) ([]byte, error) {
+	args := append([]string{"-batch", "-readonly"}, options...)
+	cmd := exec.CommandContext(ctx, sqlite3Command, append(args, file, query)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("importing a SQLite database needs the %s shell on PATH: %w", sqlite3Command, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w (%s)", sqlite3Command, file, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,851 @@
+package commands
+
+// specscore: feature/cli/import
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// sqlDump is what import reads from a SQL source: the tables of its
+// CREATE TABLE statements, in declaration order, with their rows.
+type sqlDump struct {
+	tables []*sqlTable
+}
+
+// table returns the table called name, matched case-insensitively like SQL
+// identifiers, or nil.
+func (d *sqlDump) table(name string) *sqlTable {
+	for _, t := range d.tables {
+		if strings.EqualFold(t.name, name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// sqlTable is a table declared by a CREATE TABLE statement. Constraints added
+// later by ALTER TABLE (as pg_dump writes them) are folded in.
+type sqlTable struct {
+	name        string
+	columns     []*sqlTableColumn
+	primaryKey  []string
+	uniqueKeys  [][]string
+	foreignKeys []sqlForeignKey
+
+	// rows are the values of the table's INSERT and COPY statements keyed by
+	// column name; NULL values are absent.
+	rows []map[string]any
+}
+
+// column returns the column called name, matched case-insensitively, or nil.
+func (t *sqlTable) column(name string) *sqlTableColumn {
+	for _, c := range t.columns {
+		if strings.EqualFold(c.name, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// sqlTableColumn is a column of a CREATE TABLE statement.
+type sqlTableColumn struct {
+	name string
+
+	// sqlType is the upper-cased type name without arguments, e.g.
+	// "CHARACTER VARYING" or "INTEGER"; array types end in "[]".
+	sqlType string
+
+	// length is the first type argument, as in VARCHAR(64); 0 when absent.
+	length int
+
+	notNull bool
+}
+
+// sqlForeignKey is a REFERENCES clause, inline or as a table constraint.
+type sqlForeignKey struct {
+	columns    []string
+	table      string
+	refColumns []string
+
+	// onDelete is the upper-cased ON DELETE action ("CASCADE", "SET NULL",
+	// ...), empty when none is declared.
+	onDelete string
+}
+
+// sqlNumber is a numeric literal of a dump. It keeps the literal text so the
+// value can be converted to the type of its column.
+type sqlNumber string
+
+// columnDef maps the column to an inGitDB column definition by the affinity
+// rules SQLite applies to type names, which also cover the Postgres and
+// MySQL spellings: integer types, floating-point and decimal types, booleans,
+// dates and times, JSON and arrays, and strings for everything else.
+func (c *sqlTableColumn) columnDef() *ingitdb.ColumnDef {
+	colDef := &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString, Required: c.notNull}
+	t := c.sqlType
+	switch {
+	case strings.HasSuffix(t, "[]"), strings.Contains(t, "JSON"):
+		colDef.Type = ingitdb.ColumnTypeAny
+	case strings.Contains(t, "BOOL"):
+		colDef.Type = ingitdb.ColumnTypeBool
+	case t == "TINYINT" && c.length == 1:
+		// MySQL spells BOOLEAN as TINYINT(1).
+		colDef.Type = ingitdb.ColumnTypeBool
+	case strings.Contains(t, "INT"), strings.Contains(t, "SERIAL"):
+		colDef.Type = ingitdb.ColumnTypeInt
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "TEXT"), strings.Contains(t, "CLOB"):
+		if c.length > 0 {
+			length := c.length
+			colDef.MaxLength = &length
+		}
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"),
+		strings.Contains(t, "NUMERIC"), strings.Contains(t, "DECIMAL"):
+		colDef.Type = ingitdb.ColumnTypeFloat
+	case strings.HasPrefix(t, "TIMESTAMP"), strings.HasPrefix(t, "DATETIME"):
+		colDef.Type = ingitdb.ColumnTypeDateTime
+	case t == "DATE":
+		colDef.Type = ingitdb.ColumnTypeDate
+	case strings.HasPrefix(t, "TIME"):
+		colDef.Type = ingitdb.ColumnTypeTime
+	}
+	return colDef
+}
+
+// sqlTokenKind classifies the tokens of a SQL dump.
+type sqlTokenKind int
+
+const (
+	// sqlWord is a bare identifier, keyword or number.
+	sqlWord sqlTokenKind = iota
+	// sqlIdent is a quoted identifier.
+	sqlIdent
+	// sqlString is a string literal, unescaped.
+	sqlString
+	// sqlPunct is any other single character: ( ) , ; . and operators.
+	sqlPunct
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// is reports whether the token is the bare word (case-insensitively) or the
+// punctuation text.
+func (t sqlToken) is(text string) bool {
+	switch t.kind {
+	case sqlWord:
+		return strings.EqualFold(t.text, text)
+	case sqlPunct:
+		return t.text == text
+	}
+	return false
+}
+
+// sqlScanner splits a SQL dump into statements of tokens.
+type sqlScanner struct {
+	src  string
+	pos  int
+	line int
+
+	// backslashEscapes makes backslashes escape characters in every string
+	// literal, as MySQL does. It is switched on by the first backtick-quoted
+	// identifier, which only MySQL dumps contain; elsewhere only E'...'
+	// strings take escapes.
+	backslashEscapes bool
+}
+
+// statement returns the tokens of the next statement, without its
+// terminating semicolon, and the line it starts on. It returns no tokens at
+// the end of the input.
+func (s *sqlScanner) statement() ([]sqlToken, int, error) {
+	var tokens []sqlToken
+	start := 0
+	for {
+		s.skipSpace()
+		if s.pos >= len(s.src) {
+			return tokens, start, nil
+		}
+		if len(tokens) == 0 {
+			start = s.line
+		}
+		tok, err := s.token()
+		if err != nil {
+			return nil, start, fmt.Errorf("line %d: %w", s.line, err)
+		}
+		if tok.is(";") {
+			if len(tokens) > 0 {
+				return tokens, start, nil
+			}
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// skipSpace skips whitespace and comments: -- and # to the end of the line,
+// and /* */, which includes MySQL's /*!...*/ version comments.
+func (s *sqlScanner) skipSpace() {
+	for s.pos < len(s.src) {
+		switch c := s.src[s.pos]; {
+		case c == '\n':
+			s.line++
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			s.pos++
+		case c == '#' || strings.HasPrefix(s.src[s.pos:], "--"):
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			end := strings.Index(s.src[s.pos+2:], "*/")
+			if end < 0 {
+				end = len(s.src) - s.pos - 4
+			}
+			s.line += strings.Count(s.src[s.pos:s.pos+end+4], "\n")
+			s.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+// token reads the token at the current position.
+func (s *sqlScanner) token() (sqlToken, error) {
+	c := s.src[s.pos]
+	switch {
+	case c == '\'':
+		text, err := s.quoted('\'', s.backslashEscapes)
+		return sqlToken{kind: sqlString, text: text}, err
+	case c == '"':
+		text, err := s.quoted('"', false)
+		return sqlToken{kind: sqlIdent, text: text}, err
+	case c == '`':
+		s.backslashEscapes = true
+		text, err := s.quoted('`', false)
+		return sqlToken{kind: sqlIdent, text: text}, err
+	case isSQLWordChar(c):
+		start := s.pos
+		number := c >= '0' && c <= '9'
+		for s.pos < len(s.src) {
+			c = s.src[s.pos]
+			// A number also takes its decimal point and exponent sign: 1.5e-3.
+			exponentSign := (c == '-' || c == '+') && (s.src[s.pos-1] == 'e' || s.src[s.pos-1] == 'E')
+			if !isSQLWordChar(c) && !(number && (c == '.' || exponentSign)) {
+				break
+			}
+			s.pos++
+		}
+		word := s.src[start:s.pos]
+		// E'...' takes backslash escapes; N'...' and X'...' are plain.
+		if len(word) == 1 && s.pos < len(s.src) && s.src[s.pos] == '\'' {
+			text, err := s.quoted('\'', s.backslashEscapes || word == "E" || word == "e")
+			return sqlToken{kind: sqlString, text: text}, err
+		}
+		return sqlToken{kind: sqlWord, text: word}, nil
+	}
+	s.pos++
+	return sqlToken{kind: sqlPunct, text: string(c)}, nil
+}
+
+// isSQLWordChar reports whether c continues a bare word or number.
+func isSQLWordChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' ||
+		c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// quoted reads a literal enclosed in quote, where a doubled quote stands for
+// itself and, with escapes, a backslash escapes the next character.
+func (s *sqlScanner) quoted(quote byte, escapes bool) (string, error) {
+	start := s.line
+	s.pos++
+	var b strings.Builder
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == quote && s.pos+1 < len(s.src) && s.src[s.pos+1] == quote:
+			b.WriteByte(quote)
+			s.pos += 2
+		case c == quote:
+			s.pos++
+			return b.String(), nil
+		case c == '\\' && escapes && s.pos+1 < len(s.src):
+			b.WriteString(unescapeSQLChar(s.src[s.pos+1]))
+			s.pos += 2
+		default:
+			if c == '\n' {
+				s.line++
+			}
+			b.WriteByte(c)
+			s.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated literal starting on line %d", start)
+}
+
+// unescapeSQLChar returns the character a backslash escape stands for.
+func unescapeSQLChar(c byte) string {
+	switch c {
+	case 'n':
+		return "\n"
+	case 't':
+		return "\t"
+	case 'r':
+		return "\r"
+	case '0':
+		return "\x00"
+	case 'Z':
+		return "\x1a"
+	}
+	return string(c)
+}
+
+// copyRows reads the tab-separated data lines that follow a Postgres
+// `COPY ... FROM stdin;` statement, up to the `\.` terminator. \N is NULL.
+func (s *sqlScanner) copyRows() ([][]any, error) {
+	if nl := strings.IndexByte(s.src[s.pos:], '\n'); nl >= 0 {
+		s.pos += nl + 1
+		s.line++
+	} else {
+		s.pos = len(s.src)
+	}
+	var rows [][]any
+	for s.pos < len(s.src) {
+		end := strings.IndexByte(s.src[s.pos:], '\n')
+		if end < 0 {
+			end = len(s.src) - s.pos
+		}
+		line := strings.TrimSuffix(s.src[s.pos:s.pos+end], "\r")
+		s.pos = min(s.pos+end+1, len(s.src))
+		s.line++
+		if line == `\.` {
+			return rows, nil
+		}
+		fields := strings.Split(line, "\t")
+		row := make([]any, len(fields))
+		for i, field := range fields {
+			if field == `\N` {
+				continue
+			}
+			var b strings.Builder
+			for j := 0; j < len(field); j++ {
+				if field[j] == '\\' && j+1 < len(field) {
+					j++
+					b.WriteString(unescapeSQLChar(field[j]))
+					continue
+				}
+				b.WriteByte(field[j])
+			}
+			row[i] = b.String()
+		}
+		rows = append(rows, row)
+	}
+	return nil, fmt.Errorf("COPY data is not terminated by \\.")
+}
+
+// sqlTokens walks the tokens of one statement.
+type sqlTokens struct {
+	toks []sqlToken
+	i    int
+}
+
+func (p *sqlTokens) done() bool {
+	return p.i >= len(p.toks)
+}
+
+func (p *sqlTokens) peek() sqlToken {
+	return p.at(0)
+}
+
+// at returns the token offset places ahead without consuming anything.
+func (p *sqlTokens) at(offset int) sqlToken {
+	if p.i+offset >= len(p.toks) {
+		return sqlToken{kind: sqlPunct}
+	}
+	return p.toks[p.i+offset]
+}
+
+// accept consumes the next tokens when they are the given words or
+// punctuation, in order.
+func (p *sqlTokens) accept(texts ...string) bool {
+	if p.i+len(texts) > len(p.toks) {
+		return false
+	}
+	for j, text := range texts {
+		if !p.toks[p.i+j].is(text) {
+			return false
+		}
+	}
+	p.i += len(texts)
+	return true
+}
+
+// skip consumes the next token, or the whole parenthesised group it opens.
+func (p *sqlTokens) skip() {
+	depth := 0
+	for !p.done() {
+		tok := p.toks[p.i]
+		p.i++
+		switch {
+		case tok.is("("):
+			depth++
+		case tok.is(")"):
+			depth--
+		}
+		if depth <= 0 {
+			return
+		}
+	}
+}
+
+// name reads a possibly schema-qualified name and returns its last part:
+// public.users and "public"."users" are users.
+func (p *sqlTokens) name() (string, error) {
+	var name string
+	for {
+		tok := p.peek()
+		if p.done() || tok.kind != sqlWord && tok.kind != sqlIdent {
+			return "", fmt.Errorf("expected a name, got %q", tok.text)
+		}
+		p.i++
+		name = tok.text
+		if !p.accept(".") {
+			return name, nil
+		}
+	}
+}
+
+// nameList reads a parenthesised list of column names. Index options of a
+// name, such as MySQL's prefix length or ASC, are skipped.
+func (p *sqlTokens) nameList() ([]string, error) {
+	if !p.accept("(") {
+		return nil, fmt.Errorf("expected a column list, got %q", p.peek().text)
+	}
+	var names []string
+	for {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		for !p.done() && !p.peek().is(",") && !p.peek().is(")") {
+			p.skip()
+		}
+		if p.accept(")") {
+			return names, nil
+		}
+		if !p.accept(",") {
+			return nil, fmt.Errorf("unterminated column list")
+		}
+	}
+}
+
+// parseSQLDump reads the tables and rows of a SQL dump: CREATE TABLE,
+// ALTER TABLE ... ADD constraints, INSERT (or REPLACE) ... VALUES and
+// Postgres COPY ... FROM stdin. Other statements are ignored.
+func parseSQLDump(src string) (*sqlDump, error) {
+	s := &sqlScanner{src: src, line: 1}
+	d := &sqlDump{}
+	for {
+		toks, line, err := s.statement()
+		if err != nil {
+			return nil, err
+		}
+		if len(toks) == 0 {
+			return d, nil
+		}
+		p := &sqlTokens{toks: toks}
+		if err = d.parseStatement(p, s); err != nil {
+			return nil, fmt.Errorf("statement on line %d: %w", line, err)
+		}
+	}
+}
+
+// parseStatement applies one statement to the dump.
+func (d *sqlDump) parseStatement(p *sqlTokens, s *sqlScanner) error {
+	switch {
+	case p.accept("CREATE"):
+		for _, modifier := range []string{"OR", "REPLACE", "GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED"} {
+			p.accept(modifier)
+		}
+		if !p.accept("TABLE") {
+			return nil
+		}
+		t, err := parseCreateTable(p)
+		if err != nil {
+			return err
+		}
+		if d.table(t.name) != nil {
+			return fmt.Errorf("table %s is created twice", t.name)
+		}
+		d.tables = append(d.tables, t)
+	case p.accept("ALTER", "TABLE"):
+		p.accept("ONLY")
+		p.accept("IF", "EXISTS")
+		name, err := p.name()
+		if err != nil {
+			return err
+		}
+		t := d.table(name)
+		if t == nil || !p.accept("ADD") {
+			return nil
+		}
+		if p.accept("CONSTRAINT") {
+			if _, err = p.name(); err != nil {
+				return err
+			}
+		}
+		_, err = t.parseTableConstraint(p)
+		return err
+	case p.accept("INSERT"), p.accept("REPLACE"):
+		return d.parseInsert(p)
+	case p.accept("COPY"):
+		return d.parseCopy(p, s)
+	}
+	return nil
+}
+
+// parseCreateTable reads a CREATE TABLE statement after its TABLE keyword.
+func parseCreateTable(p *sqlTokens) (*sqlTable, error) {
+	p.accept("IF", "NOT", "EXISTS")
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	t := &sqlTable{name: name}
+	if !p.accept("(") {
+		return nil, fmt.Errorf("CREATE TABLE %s: expected a column list", name)
+	}
+	for {
+		start := p.i
+		for depth := 0; !p.done(); p.i++ {
+			tok := p.toks[p.i]
+			if depth == 0 && (tok.is(",") || tok.is(")")) {
+				break
+			}
+			if tok.is("(") {
+				depth++
+			} else if tok.is(")") {
+				depth--
+			}
+		}
+		if p.done() {
+			return nil, fmt.Errorf("CREATE TABLE %s: unterminated column list", name)
+		}
+		if err = t.parseTableElement(&sqlTokens{toks: p.toks[start:p.i]}); err != nil {
+			return nil, fmt.Errorf("CREATE TABLE %s: %w", name, err)
+		}
+		if p.accept(")") {
+			// Table options (ENGINE=..., WITHOUT ROWID) are ignored.
+			return t, nil
+		}
+		p.accept(",")
+	}
+}
+
+// parseTableElement reads a column definition or table constraint of a
+// CREATE TABLE column list.
+func (t *sqlTable) parseTableElement(p *sqlTokens) error {
+	if p.accept("CONSTRAINT") {
+		if _, err := p.name(); err != nil {
+			return err
+		}
+	}
+	if ok, err := t.parseTableConstraint(p); ok || err != nil {
+		return err
+	}
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	c := &sqlTableColumn{name: name}
+	var typeWords []string
+	for !p.done() && p.peek().kind == sqlWord && !sqlColumnConstraintWords[strings.ToUpper(p.peek().text)] {
+		typeWords = append(typeWords, strings.ToUpper(p.peek().text))
+		p.i++
+		if p.peek().is("(") {
+			if n, err := strconv.Atoi(p.at(1).text); err == nil && c.length == 0 {
+				c.length = n
+			}
+			p.skip()
+		}
+	}
+	c.sqlType = strings.Join(typeWords, " ")
+	for p.accept("[") {
+		p.accept("]")
+		c.sqlType += "[]"
+	}
+	t.columns = append(t.columns, c)
+	for !p.done() {
+		switch {
+		case p.accept("NOT", "NULL"):
+			c.notNull = true
+		case p.accept("PRIMARY", "KEY"):
+			t.primaryKey = []string{name}
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			t.uniqueKeys = append(t.uniqueKeys, []string{name})
+		case p.accept("REFERENCES"):
+			fk, err := parseReferences(p, []string{name})
+			if err != nil {
+				return err
+			}
+			t.foreignKeys = append(t.foreignKeys, fk)
+		default:
+			p.skip() // DEFAULT, CHECK, COLLATE, AUTO_INCREMENT, ...
+		}
+	}
+	return nil
+}
+
+// sqlColumnConstraintWords end the type of a column definition.
+var sqlColumnConstraintWords = map[string]bool{
+	"NOT": true, "NULL": true, "PRIMARY": true, "UNIQUE": true, "REFERENCES": true, "DEFAULT": true,
+	"CHECK": true, "CONSTRAINT": true, "COLLATE": true, "AUTO_INCREMENT": true, "AUTOINCREMENT": true,
+	"GENERATED": true, "COMMENT": true, "ON": true, "AS": true,
+}
+
+// parseTableConstraint reads a PRIMARY KEY, UNIQUE or FOREIGN KEY table
+// constraint. Indexes and CHECK constraints are skipped. ok is false when the
+// tokens are not a table constraint.
+func (t *sqlTable) parseTableConstraint(p *sqlTokens) (ok bool, err error) {
+	switch {
+	case p.accept("PRIMARY", "KEY"):
+		t.primaryKey, err = p.nameList()
+	case p.accept("UNIQUE"):
+		if p.accept("KEY") || p.accept("INDEX") {
+			if !p.peek().is("(") {
+				p.i++ // index name
+			}
+		}
+		var key []string
+		if key, err = p.nameList(); err == nil {
+			t.uniqueKeys = append(t.uniqueKeys, key)
+		}
+	case p.accept("FOREIGN", "KEY"):
+		var columns []string
+		if columns, err = p.nameList(); err != nil {
+			return true, err
+		}
+		if !p.accept("REFERENCES") {
+			return true, fmt.Errorf("FOREIGN KEY without REFERENCES")
+		}
+		var fk sqlForeignKey
+		if fk, err = parseReferences(p, columns); err == nil {
+			t.foreignKeys = append(t.foreignKeys, fk)
+		}
+	case p.peek().is("CHECK") && p.at(1).is("("), p.accept("FULLTEXT"), p.accept("SPATIAL"), p.accept("EXCLUDE"),
+		// MySQL indexes; a column may be called key or index too.
+		(p.peek().is("KEY") || p.peek().is("INDEX")) && (p.at(1).is("(") || p.at(2).is("(")):
+		p.i = len(p.toks)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// parseReferences reads the target of a REFERENCES clause and its ON DELETE
+// action. ON UPDATE, MATCH and deferrability are skipped.
+func parseReferences(p *sqlTokens, columns []string) (sqlForeignKey, error) {
+	fk := sqlForeignKey{columns: columns}
+	var err error
+	if fk.table, err = p.name(); err != nil {
+		return fk, err
+	}
+	if p.peek().is("(") {
+		if fk.refColumns, err = p.nameList(); err != nil {
+			return fk, err
+		}
+	}
+	for {
+		switch {
+		case p.accept("ON", "DELETE"):
+			fk.onDelete = parseReferentialAction(p)
+		case p.accept("ON", "UPDATE"):
+			parseReferentialAction(p)
+		case p.accept("MATCH"), p.accept("INITIALLY"):
+			p.i++
+		case p.accept("DEFERRABLE"), p.accept("NOT", "DEFERRABLE"):
+		default:
+			return fk, nil
+		}
+	}
+}
+
+// parseReferentialAction reads the upper-cased action of an ON DELETE or ON
+// UPDATE clause.
+func parseReferentialAction(p *sqlTokens) string {
+	for _, action := range [][]string{{"SET", "NULL"}, {"SET", "DEFAULT"}, {"NO", "ACTION"}} {
+		if p.accept(action...) {
+			return strings.Join(action, " ")
+		}
+	}
+	action := strings.ToUpper(p.peek().text)
+	p.skip()
+	return action
+}
+
+// parseInsert reads an INSERT or REPLACE statement after its first keyword.
+// Rows without a column list follow the column order of the table.
+func (d *sqlDump) parseInsert(p *sqlTokens) error {
+	if p.accept("OR") {
+		p.i++ // INSERT OR REPLACE, INSERT OR IGNORE
+	}
+	p.accept("IGNORE")
+	p.accept("INTO")
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := d.table(name)
+	if t == nil {
+		return fmt.Errorf("INSERT INTO %s: the table is not created in the dump", name)
+	}
+	columns, err := t.insertColumns(p)
+	if err != nil {
+		return err
+	}
+	if !p.accept("VALUES") {
+		return fmt.Errorf("INSERT INTO %s: only INSERT ... VALUES is supported", name)
+	}
+	for {
+		if !p.accept("(") {
+			return fmt.Errorf("INSERT INTO %s: expected a row of values, got %q", name, p.peek().text)
+		}
+		var values []any
+		for {
+			value, err := parseSQLValue(p)
+			if err != nil {
+				return fmt.Errorf("INSERT INTO %s: %w", name, err)
+			}
+			values = append(values, value)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return fmt.Errorf("INSERT INTO %s: expected , or ) after a value, got %q", name, p.peek().text)
+			}
+		}
+		if err = t.addRow(columns, values); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			// ON CONFLICT / ON DUPLICATE KEY clauses are ignored.
+			return nil
+		}
+	}
+}
+
+// parseCopy reads a Postgres COPY ... FROM stdin statement and its data.
+func (d *sqlDump) parseCopy(p *sqlTokens, s *sqlScanner) error {
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	t := d.table(name)
+	if t == nil {
+		return fmt.Errorf("COPY %s: the table is not created in the dump", name)
+	}
+	columns, err := t.insertColumns(p)
+	if err != nil {
+		return err
+	}
+	if !p.accept("FROM", "stdin") {
+		return fmt.Errorf("COPY %s: only COPY ... FROM stdin is supported", name)
+	}
+	rows, err := s.copyRows()
+	if err != nil {
+		return fmt.Errorf("COPY %s: %w", name, err)
+	}
+	for _, row := range rows {
+		if err = t.addRow(columns, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertColumns reads the optional column list of an INSERT or COPY, and
+// defaults to every column of the table.
+func (t *sqlTable) insertColumns(p *sqlTokens) ([]string, error) {
+	if !p.peek().is("(") {
+		columns := make([]string, len(t.columns))
+		for i, c := range t.columns {
+			columns[i] = c.name
+		}
+		return columns, nil
+	}
+	columns, err := p.nameList()
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range columns {
+		c := t.column(name)
+		if c == nil {
+			return nil, fmt.Errorf("table %s has no column %s", t.name, name)
+		}
+		columns[i] = c.name
+	}
+	return columns, nil
+}
+
+// addRow adds the values of one row, leaving out NULLs.
+func (t *sqlTable) addRow(columns []string, values []any) error {
+	if len(values) != len(columns) {
+		return fmt.Errorf("table %s: row %d has %d values for %d columns", t.name, len(t.rows)+1, len(values), len(columns))
+	}
+	row := make(map[string]any, len(columns))
+	for i, name := range columns {
+		if values[i] != nil {
+			row[name] = values[i]
+		}
+	}
+	t.rows = append(t.rows, row)
+	return nil
+}
+
+// parseSQLValue reads a literal of a VALUES row: a string, a number, NULL,
+// TRUE or FALSE. A trailing Postgres cast such as '2024-01-01'::date is
+// skipped. Other expressions are an error.
+func parseSQLValue(p *sqlTokens) (any, error) {
+	sign := ""
+	if p.accept("-") {
+		sign = "-"
+	} else {
+		p.accept("+")
+	}
+	tok := p.peek()
+	if p.done() {
+		return nil, fmt.Errorf("expected a value")
+	}
+	p.i++
+	var value any
+	switch {
+	case tok.kind == sqlString && sign == "":
+		value = tok.text
+	case tok.kind == sqlWord && unicode.IsDigit(rune(tok.text[0])):
+		value = sqlNumber(sign + tok.text)
+	case tok.is("NULL") && sign == "", tok.is("DEFAULT") && sign == "":
+		value = nil
+	case tok.is("TRUE") && sign == "":
+		value = true
+	case tok.is("FALSE") && sign == "":
+		value = false
+	default:
+		return nil, fmt.Errorf("unsupported value %s%s; only literals can be imported", sign, tok.text)
+	}
+	for p.accept(":", ":") {
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+		if p.peek().is("(") {
+			p.skip()
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func TestParseSQLDump_Postgres(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump(`--
+-- PostgreSQL database dump
+--
+SET statement_timeout = 0;
+
+CREATE TABLE public.countries (
+    code character varying(2) NOT NULL,
+    title text
+);
+
+CREATE TABLE public.cities (
+    id integer NOT NULL,
+    name text NOT NULL,
+    country character varying(2),
+    population bigint,
+    capital boolean DEFAULT false,
+    founded date,
+    tags text[],
+    CONSTRAINT population_positive CHECK ((population > 0))
+);
+
+COPY public.countries (code, title) FROM stdin;
+IE	Ireland
+NZ	\N
+\.
+
+INSERT INTO public.cities VALUES (1, 'Dublin', 'IE', 1200000, true, '0841-01-01'::date, NULL);
+INSERT INTO public.cities (id, name, country) VALUES (2, 'O''Brien', 'IE'), (3, E'Cork\ncity', NULL);
+
+ALTER TABLE ONLY public.countries
+    ADD CONSTRAINT countries_pkey PRIMARY KEY (code);
+ALTER TABLE ONLY public.cities
+    ADD CONSTRAINT cities_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY public.cities
+    ADD CONSTRAINT cities_country_fkey FOREIGN KEY (country) REFERENCES public.countries(code) ON DELETE SET NULL;
+CREATE INDEX cities_name_idx ON public.cities USING btree (name);
+`)
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	if len(dump.tables) != 2 {
+		t.Fatalf("got %d tables, want 2", len(dump.tables))
+	}
+	countries, cities := dump.table("countries"), dump.table("CITIES")
+	if want := []map[string]any{{"code": "IE", "title": "Ireland"}, {"code": "NZ"}}; !reflect.DeepEqual(countries.rows, want) {
+		t.Errorf("countries rows = %v, want %v", countries.rows, want)
+	}
+	if !reflect.DeepEqual(countries.primaryKey, []string{"code"}) || countries.columns[0].length != 2 {
+		t.Errorf("countries: primary key %v, code length %d", countries.primaryKey, countries.columns[0].length)
+	}
+	wantRows := []map[string]any{
+		{"id": sqlNumber("1"), "name": "Dublin", "country": "IE", "population": sqlNumber("1200000"), "capital": true, "founded": "0841-01-01"},
+		{"id": sqlNumber("2"), "name": "O'Brien", "country": "IE"},
+		{"id": sqlNumber("3"), "name": "Cork\ncity"},
+	}
+	if !reflect.DeepEqual(cities.rows, wantRows) {
+		t.Errorf("cities rows = %v, want %v", cities.rows, wantRows)
+	}
+	wantFK := []sqlForeignKey{{columns: []string{"country"}, table: "countries", refColumns: []string{"code"}, onDelete: "SET NULL"}}
+	if !reflect.DeepEqual(cities.foreignKeys, wantFK) {
+		t.Errorf("cities foreign keys = %+v, want %+v", cities.foreignKeys, wantFK)
+	}
+	wantTypes := map[string]ingitdb.ColumnType{
+		"id": ingitdb.ColumnTypeInt, "name": ingitdb.ColumnTypeString, "country": ingitdb.ColumnTypeString,
+		"population": ingitdb.ColumnTypeInt, "capital": ingitdb.ColumnTypeBool, "founded": ingitdb.ColumnTypeDate,
+		"tags": ingitdb.ColumnTypeAny,
+	}
+	for _, c := range cities.columns {
+		if got := c.columnDef().Type; got != wantTypes[c.name] {
+			t.Errorf("column %s: type %s, want %s", c.name, got, wantTypes[c.name])
+		}
+	}
+	if !cities.column("name").notNull || cities.column("country").notNull {
+		t.Error("NOT NULL is not read from the column definitions")
+	}
+}
+
+func TestParseSQLDump_MySQL(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump("/*!40101 SET NAMES utf8mb4 */;\n" +
+		"DROP TABLE IF EXISTS `orders`;\n" +
+		"CREATE TABLE `orders` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `customer` varchar(20) COLLATE utf8mb4_bin DEFAULT NULL,\n" +
+		"  `total` decimal(10,2) NOT NULL,\n" +
+		"  `paid` tinyint(1) NOT NULL DEFAULT '0',\n" +
+		"  `key` varchar(8),\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `customer_key` (`customer`,`key`(4)),\n" +
+		"  KEY `total_idx` (`total`),\n" +
+		"  CONSTRAINT `fk_customer` FOREIGN KEY (`customer`) REFERENCES `customers` (`id`) ON DELETE CASCADE ON UPDATE CASCADE\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n" +
+		"INSERT INTO `orders` VALUES (1,'ann',-12.50,1,'it\\'s'),(2,NULL,1e3,0,NULL);\n")
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	orders := dump.table("orders")
+	if len(orders.columns) != 5 {
+		t.Fatalf("got %d columns, want 5", len(orders.columns))
+	}
+	if !reflect.DeepEqual(orders.uniqueKeys, [][]string{{"customer", "key"}}) {
+		t.Errorf("unique keys = %v", orders.uniqueKeys)
+	}
+	if fk := orders.foreignKeys; len(fk) != 1 || fk[0].table != "customers" || fk[0].onDelete != "CASCADE" {
+		t.Errorf("foreign keys = %+v", fk)
+	}
+	wantRows := []map[string]any{
+		{"id": sqlNumber("1"), "customer": "ann", "total": sqlNumber("-12.50"), "paid": sqlNumber("1"), "key": "it's"},
+		{"id": sqlNumber("2"), "total": sqlNumber("1e3"), "paid": sqlNumber("0")},
+	}
+	if !reflect.DeepEqual(orders.rows, wantRows) {
+		t.Errorf("rows = %v, want %v", orders.rows, wantRows)
+	}
+	if got := orders.column("paid").columnDef().Type; got != ingitdb.ColumnTypeBool {
+		t.Errorf("tinyint(1) maps to %s, want bool", got)
+	}
+	if got := orders.column("total").columnDef().Type; got != ingitdb.ColumnTypeFloat {
+		t.Errorf("decimal maps to %s, want float", got)
+	}
+	if got := orders.column("customer").columnDef().MaxLength; got == nil || *got != 20 {
+		t.Errorf("varchar(20) max_length = %v, want 20", got)
+	}
+}
+
+func TestParseSQLDump_Errors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]string{
+		"CREATE TABLE t (id int);\nINSERT INTO u VALUES (1);":             "the table is not created in the dump",
+		"CREATE TABLE t (id int);\nINSERT INTO t VALUES (now());":         "only literals can be imported",
+		"CREATE TABLE t (id int, n int);\nINSERT INTO t VALUES (1);":      "row 1 has 1 values for 2 columns",
+		"CREATE TABLE t (id int);\nINSERT INTO t VALUES ('open);":         "unterminated literal",
+		"CREATE TABLE t (id int);\nCOPY t (id) FROM stdin;\n1\n":          "not terminated",
+		"CREATE TABLE t (id int);\nCREATE TABLE IF NOT EXISTS t (a int);": "created twice",
+	}
+	for src, want := range tests {
+		_, err := parseSQLDump(src)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseSQLDump(%q) error = %v, want it to contain %q", src, err, want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+const importTestDump = `CREATE TABLE countries (
+  code VARCHAR(2) PRIMARY KEY,
+  title TEXT NOT NULL
+);
+CREATE TABLE cities (
+  id INTEGER PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  country VARCHAR(2) REFERENCES countries (code) ON DELETE CASCADE,
+  mayor TEXT REFERENCES people (id)
+);
+CREATE TABLE visits (
+  city INTEGER NOT NULL REFERENCES cities,
+  day DATE NOT NULL,
+  note TEXT,
+  PRIMARY KEY (city, day)
+);
+INSERT INTO countries VALUES ('IE', 'Ireland');
+INSERT INTO cities VALUES (1, 'Dublin', 'IE', NULL);
+`
+
+func TestRunImport_DryRun(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump(importTestDump)
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	var logged []string
+	logf := func(args ...any) { logged = append(logged, args[0].(string)) }
+	opts := importOptions{
+		tables:        []string{"cities", "countries"},
+		recordFormats: map[string]string{"*": "json"},
+		recordTypes:   map[string]string{"countries": "map"},
+		dryRun:        true,
+	}
+	var out bytes.Buffer
+	if err = runImport(context.Background(), &out, t.TempDir(), dump, opts, nil, nil, logf); err != nil {
+		t.Fatalf("runImport: %v", err)
+	}
+	want := `# countries/.collection/definition.yaml
+record_file:
+  name: countries.json
+  format: json
+  type: map[$record_id]map[$field_name]any
+columns:
+  code:
+    type: string
+    required: true
+    max_length: 2
+  title:
+    type: string
+    required: true
+columns_order:
+  - code
+  - title
+primary_key:
+  - code
+# cities/.collection/definition.yaml
+record_file:
+  name: '{key}.json'
+  format: json
+  type: map[string]any
+columns:
+  id:
+    type: int
+    required: true
+  name:
+    type: string
+    required: true
+  country:
+    type: string
+    max_length: 2
+    foreign_key: countries
+  mayor:
+    type: string
+columns_order:
+  - id
+  - name
+  - country
+  - mayor
+primary_key:
+  - id
+# cities/.collection/constraints.yaml
+columns:
+  country:
+    on_delete: cascade
+  name:
+    unique: true
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if len(logged) != 1 || !strings.Contains(logged[0], "dropped the foreign key mayor to people") {
+		t.Errorf("logged %q, want the dropped foreign key to people", logged)
+	}
+}
+
+func TestPlanImport_Errors(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump(importTestDump)
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, rootCollectionsRelPath, "countries: geo/countries\n")
+	tests := []struct {
+		opts importOptions
+		want string
+	}{
+		{importOptions{tables: []string{"planets"}}, `table "planets" not found`},
+		{importOptions{tables: []string{"countries"}}, `collection "countries" already exists`},
+		{importOptions{tables: []string{"visits"}}, "composite primary key (city, day)"},
+		{importOptions{tables: []string{"visits"}, keyColumns: map[string]string{"visits": "when"}}, "has no column when"},
+		{importOptions{tables: []string{"cities"}, recordFormats: map[string]string{"cities": "xml"}}, `invalid --record-format "xml"`},
+		{importOptions{tables: []string{"cities"}, recordTypes: map[string]string{"*": "tree"}}, `invalid --record-type "tree"`},
+		{importOptions{tables: []string{"cities"}, recordFormats: map[string]string{"cities": "csv"}, recordTypes: map[string]string{"cities": "single"}}, "requires record type"},
+	}
+	for _, tt := range tests {
+		_, err := planImport(dir, dump, tt.opts, func(...any) {})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("planImport(%+v) error = %v, want it to contain %q", tt.opts, err, tt.want)
+		}
+	}
+}
+
+func TestPlanImport_KeyColumnAndOrder(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump(importTestDump)
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	opts := importOptions{keyColumns: map[string]string{"VISITS": "note"}}
+	tables, err := planImport(t.TempDir(), dump, opts, func(...any) {})
+	if err != nil {
+		t.Fatalf("planImport: %v", err)
+	}
+	var order []string
+	for _, it := range tables {
+		order = append(order, it.colDef.ID)
+	}
+	if want := []string{"countries", "cities", "visits"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	visits := tables[2]
+	if !reflect.DeepEqual(visits.colDef.PrimaryKey, []string{"note"}) || !visits.colDef.Columns["note"].Required {
+		t.Errorf("visits: primary key %v, note required %v", visits.colDef.PrimaryKey, visits.colDef.Columns["note"].Required)
+	}
+	if !reflect.DeepEqual(visits.cons.UniqueKeys, [][]string{{"city", "day"}}) {
+		t.Errorf("visits unique keys = %v, want the replaced primary key", visits.cons.UniqueKeys)
+	}
+	if visits.colDef.Columns["city"].ForeignKey != "cities" {
+		t.Errorf("visits.city foreign key = %q, want cities", visits.colDef.Columns["city"].ForeignKey)
+	}
+}
+
+func TestImportValue(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		columnType ingitdb.ColumnType
+		value      any
+		want       any
+	}{
+		{ingitdb.ColumnTypeInt, sqlNumber("42"), int64(42)},
+		{ingitdb.ColumnTypeInt, "7", int64(7)},
+		{ingitdb.ColumnTypeInt, sqlNumber("3.0"), int64(3)},
+		{ingitdb.ColumnTypeInt, true, int64(1)},
+		{ingitdb.ColumnTypeFloat, sqlNumber("-1.5"), -1.5},
+		{ingitdb.ColumnTypeBool, "t", true},
+		{ingitdb.ColumnTypeBool, sqlNumber("0"), false},
+		{ingitdb.ColumnTypeString, sqlNumber("0042"), "0042"},
+		{ingitdb.ColumnTypeDate, "2024-02-29", "2024-02-29"},
+		{ingitdb.ColumnTypeAny, `{"a":[1]}`, map[string]any{"a": []any{float64(1)}}},
+		{ingitdb.ColumnTypeAny, "plain", "plain"},
+		{ingitdb.ColumnTypeAny, sqlNumber("5"), int64(5)},
+	}
+	for _, tt := range tests {
+		got, err := importValue(tt.columnType, tt.value)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("importValue(%s, %#v) = %#v, %v; want %#v", tt.columnType, tt.value, got, err, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		columnType ingitdb.ColumnType
+		value      any
+	}{
+		{ingitdb.ColumnTypeInt, "seven"},
+		{ingitdb.ColumnTypeFloat, "n/a"},
+		{ingitdb.ColumnTypeBool, "maybe"},
+	} {
+		if _, err := importValue(tt.columnType, tt.value); err == nil {
+			t.Errorf("importValue(%s, %#v): expected an error", tt.columnType, tt.value)
+		}
+	}
+}
+
+func TestImportRecords(t *testing.T) {
+	t.Parallel()
+	dump, err := parseSQLDump(importTestDump + "INSERT INTO cities VALUES (NULL, 'Nowhere', NULL, NULL);\n")
+	if err != nil {
+		t.Fatalf("parseSQLDump: %v", err)
+	}
+	tables, err := planImport(t.TempDir(), dump, importOptions{tables: []string{"countries", "cities"}}, func(...any) {})
+	if err != nil {
+		t.Fatalf("planImport: %v", err)
+	}
+	records, err := importRecords(tables[0])
+	if err != nil || len(records) != 1 || records[0].Key != "IE" || records[0].Data["title"] != "Ireland" {
+		t.Errorf("countries records = %+v, %v", records, err)
+	}
+	if _, err = importRecords(tables[1]); err == nil || !strings.Contains(err.Error(), "row 2: the key column id is NULL") {
+		t.Errorf("expected a NULL key error, got %v", err)
+	}
+}
+
+func TestDecodeSQLiteRows(t *testing.T) {
+	t.Parallel()
+	out := "[{\"sku\":\"a-1\",\"price\":9.5,\"qty\":3,\"note\":null},\n{\"sku\":\"b-2\",\"price\":10.0,\"qty\":0,\"note\":\"it's\"}]\n"
+	rows, err := decodeSQLiteRows([]byte(out))
+	if err != nil {
+		t.Fatalf("decodeSQLiteRows: %v", err)
+	}
+	want := []map[string]any{
+		{"sku": "a-1", "price": sqlNumber("9.5"), "qty": sqlNumber("3")},
+		{"sku": "b-2", "price": sqlNumber("10.0"), "qty": sqlNumber("0"), "note": "it's"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+	if rows, err = decodeSQLiteRows([]byte("\n")); err != nil || rows != nil {
+		t.Errorf("a table without rows = %v, %v; want no rows", rows, err)
+	}
+	if _, err = decodeSQLiteRows([]byte("Error: no such table")); err == nil {
+		t.Error("expected an error for output that is not JSON")
+	}
+}
+
+func TestReadSQLiteDatabase(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath(sqlite3Command); err != nil {
+		t.Skip("sqlite3 is not installed")
+	}
+	file := filepath.Join(t.TempDir(), "shop.db")
+	create := exec.Command(sqlite3Command, file,
+		"CREATE TABLE items (sku TEXT PRIMARY KEY, price REAL, qty INTEGER NOT NULL, note TEXT);"+
+			"INSERT INTO items VALUES ('a-1', 9.5, 3, NULL), ('b-2', 10, 0, 'it''s');"+
+			"CREATE TABLE empty (id INTEGER PRIMARY KEY);")
+	if out, err := create.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3: %v: %s", err, out)
+	}
+	dump, err := readSQLiteDatabase(context.Background(), file)
+	if err != nil {
+		t.Fatalf("readSQLiteDatabase: %v", err)
+	}
+	items := dump.table("items")
+	want := []map[string]any{
+		{"sku": "a-1", "price": sqlNumber("9.5"), "qty": sqlNumber("3")},
+		{"sku": "b-2", "price": sqlNumber("10.0"), "qty": sqlNumber("0"), "note": "it's"},
+	}
+	if items == nil || !reflect.DeepEqual(items.rows, want) {
+		t.Errorf("items = %+v, want rows %v", items, want)
+	}
+	if empty := dump.table("empty"); empty == nil || len(empty.rows) != 0 {
+		t.Errorf("empty = %+v, want a table without rows", empty)
+	}
+	if _, err = readSQLiteDatabase(context.Background(), filepath.Join(t.TempDir(), "missing.db")); err == nil {
+		t.Error("expected an error for a missing database file")
+	}
+	if err = os.WriteFile(filepath.Join(filepath.Dir(file), "junk.db"), []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readSQLiteDatabase(context.Background(), filepath.Join(filepath.Dir(file), "junk.db")); err == nil {
+		t.Error("expected an error for a file that is not a SQLite database")
+	}
+}
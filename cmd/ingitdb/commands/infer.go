@@ -0,0 +1,380 @@
+package commands
+
+// specscore: feature/cli/infer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// defaultInferMaxEnum is the most distinct values a string column may hold to
+// be reported as an enum candidate.
+const defaultInferMaxEnum = 10
+
+// inferFormats maps the record file extensions infer reads to their format.
+var inferFormats = map[string]ingitdb.RecordFormat{
+	".yaml":  ingitdb.RecordFormatYAML,
+	".yml":   ingitdb.RecordFormatYML,
+	".json":  ingitdb.RecordFormatJSON,
+	".jsonl": ingitdb.RecordFormatJSONL,
+}
+
+// Infer returns the `ingitdb infer <dir>` command. It scans a directory of
+// YAML or JSON record files, infers the record file layout and the column
+// types, and registers the directory as a collection with a draft
+// .collection/definition.yaml.
+func Infer(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "infer <dir>",
+		Short: "Infer a collection definition from existing record files",
+		Long: "Scans <dir> (relative to the database directory) for YAML, JSON or JSONL record files, " +
+			"infers the record format, record type, column types, required columns and enums, " +
+			"and writes a draft <dir>/.collection/definition.yaml plus a root-collections.yaml entry. " +
+			"An inferred enum lists only the values found and is marked for review.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dirPath, err := resolveDBPath(cmd, homeDir, getWd)
+			if err != nil {
+				return err
+			}
+			rel, err := inferCollectionDir(dirPath, args[0])
+			if err != nil {
+				return err
+			}
+			colID, _ := cmd.Flags().GetString("collection")
+			if colID == "" {
+				colID = filepath.Base(rel)
+			}
+			maxEnum, _ := cmd.Flags().GetInt("max-enum")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			force, _ := cmd.Flags().GetBool("force")
+			return runInfer(cmd.Context(), cmd.OutOrStdout(), dirPath, rel, colID, maxEnum, dryRun, force, logf)
+		},
+	}
+	cmd.Flags().String("path", "", "path to the database directory (default: current directory)")
+	cmd.Flags().String("collection", "", "ID of the new collection (default: the directory name)")
+	cmd.Flags().Int("max-enum", defaultInferMaxEnum, "most distinct values a string column may hold to get a draft enum (0 disables)")
+	cmd.Flags().Bool("dry-run", false, "print the draft definition without writing or moving anything")
+	cmd.Flags().Bool("force", false, "overwrite an existing definition.yaml")
+	return cmd
+}
+
+// inferCollectionDir returns the database-relative, slash-separated path of
+// dir, which may be absolute or relative to the database directory.
+func inferCollectionDir(dbDir, dir string) (string, error) {
+	abs := dir
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(dbDir, dir)
+	}
+	rel, err := filepath.Rel(dbDir, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not a directory inside the database at %s", dir, dbDir)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// inferredFiles is the result of scanning a collection directory: the record
+// files and where they sit.
+type inferredFiles struct {
+	format ingitdb.RecordFormat
+
+	// paths are the record files, sorted.
+	paths []string
+
+	// inRecordsDir reports whether the files sit in the $records/
+	// subdirectory, where one-record-per-file collections keep them.
+	inRecordsDir bool
+}
+
+// scanInferFiles lists the record files of colDir: the files of its $records/
+// subdirectory when it has one, its top-level files otherwise. Hidden files
+// and files of other types (README.md, ...) are ignored; record files of
+// different formats are an error.
+func scanInferFiles(colDir string) (*inferredFiles, error) {
+	found := &inferredFiles{}
+	dir := filepath.Join(colDir, "$records")
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		found.inRecordsDir = true
+	} else {
+		dir = colDir
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		format, ok := inferFormats[strings.ToLower(filepath.Ext(name))]
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !ok {
+			continue
+		}
+		if found.format != "" && found.format != format {
+			return nil, fmt.Errorf("%s mixes %s and %s record files; infer needs a single format", dir, found.format, format)
+		}
+		found.format = format
+		found.paths = append(found.paths, filepath.Join(dir, name))
+	}
+	if len(found.paths) == 0 {
+		return nil, fmt.Errorf("no YAML, JSON or JSONL record files found in %s", dir)
+	}
+	sort.Strings(found.paths)
+	return found, nil
+}
+
+// inferRecordFile decides the record type from the scanned files and folds
+// their records into s. Files in $records/, and several top-level files, hold
+// one record each. A single top-level file holds a list of records when it is
+// a sequence (or JSONL), a map of records when every value of its top-level
+// mapping is itself a mapping, and one record otherwise.
+func inferRecordFile(files *inferredFiles, s *inferredSchema) (*ingitdb.RecordFileDef, error) {
+	ext := filepath.Ext(files.paths[0])
+	rf := &ingitdb.RecordFileDef{Name: "{key}" + ext, Format: files.format, RecordType: ingitdb.SingleRecord}
+	if files.format == ingitdb.RecordFormatJSONL && !files.inRecordsDir && len(files.paths) > 1 {
+		return nil, fmt.Errorf("found %d JSONL files; a JSONL collection is a single list-of-records file", len(files.paths))
+	}
+	for _, path := range files.paths {
+		nodes, err := readInferNodes(path, files.format)
+		if err != nil {
+			return nil, err
+		}
+		single := files.inRecordsDir || len(files.paths) > 1
+		switch {
+		case files.format == ingitdb.RecordFormatJSONL && !single:
+			rf.Name, rf.RecordType = filepath.Base(path), ingitdb.ListOfRecords
+		case len(nodes) == 1 && nodes[0].Kind == yaml.SequenceNode && !single:
+			rf.Name, rf.RecordType = filepath.Base(path), ingitdb.ListOfRecords
+			nodes = nodes[0].Content
+		case len(nodes) == 1 && isMapOfRecordsNode(nodes[0]) && !single:
+			rf.Name, rf.RecordType = filepath.Base(path), ingitdb.MapOfRecords
+			var records []*yaml.Node
+			for i := 1; i < len(nodes[0].Content); i += 2 {
+				records = append(records, nodes[0].Content[i])
+			}
+			nodes = records
+		case len(nodes) != 1:
+			return nil, fmt.Errorf("%s: expected one record per file", path)
+		}
+		for _, node := range nodes {
+			if err = s.addRecord(node); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+	return rf, nil
+}
+
+// readInferNodes parses a record file into its top-level YAML nodes: one per
+// line for JSONL, one for the other formats. YAML is a superset of JSON, so
+// one parser serves both and keeps the key order of the files.
+func readInferNodes(path string, format ingitdb.RecordFormat) ([]*yaml.Node, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	docs := [][]byte{content}
+	if format == ingitdb.RecordFormatJSONL {
+		docs = bytes.Split(content, []byte("\n"))
+	}
+	var nodes []*yaml.Node
+	for i, doc := range docs {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		var root yaml.Node
+		if err = yaml.Unmarshal(doc, &root); err != nil {
+			if format == ingitdb.RecordFormatJSONL {
+				return nil, fmt.Errorf("failed to parse %s line %d: %w", path, i+1, err)
+			}
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if len(root.Content) > 0 {
+			nodes = append(nodes, root.Content[0])
+		}
+	}
+	return nodes, nil
+}
+
+// isMapOfRecordsNode reports whether node is a non-empty mapping whose values
+// are all mappings.
+func isMapOfRecordsNode(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return false
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		if node.Content[i].Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// runInfer infers the definition of the collection colID from the record
+// files under the database-relative directory rel. With dryRun it prints the
+// draft to w and changes nothing. Otherwise it writes the draft, registers
+// the collection in root-collections.yaml, and then moves top-level
+// one-record files into $records/ (where the definition reader looks for
+// them). When a move fails, the files already moved are moved back and the
+// definition and root-collections.yaml are restored.
+func runInfer(ctx context.Context, w io.Writer, dbDir, rel, colID string, maxEnum int, dryRun, force bool, logf func(...any)) error {
+	colDir := filepath.Join(dbDir, filepath.FromSlash(rel))
+	defPath := filepath.Join(colDir, ingitdb.SchemaDir, ingitdb.CollectionDefFileName)
+	if _, err := os.Stat(defPath); err == nil && !force && !dryRun {
+		return fmt.Errorf("%s already exists; use --force to overwrite it", defPath)
+	}
+	entries, err := readRootCollections(dbDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if existing, ok := entries[colID]; ok && existing != rel {
+		return fmt.Errorf("collection %q is already registered for directory %s", colID, existing)
+	}
+
+	files, err := scanInferFiles(colDir)
+	if err != nil {
+		return err
+	}
+	s := newInferredSchema()
+	rf, err := inferRecordFile(files, s)
+	if err != nil {
+		return err
+	}
+	colDef, err := s.collectionDef(colID, rf, maxEnum)
+	if err != nil {
+		return err
+	}
+	out, err := encodeInferredDefinition(colDef)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		_, err = w.Write(out)
+		return err
+	}
+
+	restore, err := snapshotFiles(defPath, filepath.Join(dbDir, rootCollectionsRelPath))
+	if err != nil {
+		return err
+	}
+	if err = writeInferredDefinition(defPath, out); err == nil {
+		err = writeRootCollectionsWith(dbDir, colID, rel)
+	}
+	if err == nil && rf.RecordType == ingitdb.SingleRecord && !files.inRecordsDir {
+		recordsDir := filepath.Join(colDir, "$records")
+		if err = moveInferredRecords(ctx, dbDir, recordsDir, files.paths); err == nil {
+			logf(fmt.Sprintf("moved %d record file(s) into %s", len(files.paths), recordsDir))
+		}
+	}
+	if err != nil {
+		return errors.Join(err, restore())
+	}
+	logf(fmt.Sprintf("inferred collection %s from %d record(s): %d column(s), definition written to %s",
+		colID, s.records, len(colDef.Columns), defPath))
+	return nil
+}
+
+// writeInferredDefinition writes the draft definition to defPath.
+func writeInferredDefinition(defPath string, out []byte) error {
+	if err := os.MkdirAll(filepath.Dir(defPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(defPath), err)
+	}
+	if err := os.WriteFile(defPath, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", defPath, err)
+	}
+	return nil
+}
+
+// moveInferredRecords moves the record files at paths into recordsDir, with
+// `git mv` for tracked files (see moveRecordPath). When a move fails, the
+// files already moved are moved back.
+func moveInferredRecords(ctx context.Context, dbDir, recordsDir string, paths []string) error {
+	for i, path := range paths {
+		err := moveRecordPath(ctx, dbDir, path, filepath.Join(recordsDir, filepath.Base(path)))
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("failed to move %s into $records/: %w", path, err)
+		for j := i - 1; j >= 0; j-- {
+			err = errors.Join(err, moveRecordPath(ctx, dbDir, filepath.Join(recordsDir, filepath.Base(paths[j])), paths[j]))
+		}
+		return err
+	}
+	return nil
+}
+
+// snapshotFiles records the content of paths and returns a function that
+// puts it back, removing the paths that did not exist.
+func snapshotFiles(paths ...string) (func() error, error) {
+	contents := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		contents[path] = content
+	}
+	return func() error {
+		var errs []error
+		for _, path := range paths {
+			content, existed := contents[path]
+			var err error
+			if existed {
+				err = os.WriteFile(path, content, 0o644)
+			} else if err = os.Remove(path); errors.Is(err, os.ErrNotExist) {
+				err = nil
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to restore %s: %w", path, err))
+			}
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+// writeRootCollectionsWith adds the collection name at the database-relative
+// directory rel to root-collections.yaml, creating the file when the
+// database has none yet.
+func writeRootCollectionsWith(dbDir, name, rel string) error {
+	entries, err := readRootCollections(dbDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if entries == nil {
+		entries = make(map[string]string)
+	}
+	entries[name] = rel
+	out, _ := yaml.Marshal(entries)
+	path := filepath.Join(dbDir, rootCollectionsRelPath)
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(rootCollectionsRelPath), err)
+	}
+	if err = os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", rootCollectionsRelPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package commands
+
+// specscore: feature/cli/infer
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// localeKeyPattern matches the keys of a map[locale]string value.
+var localeKeyPattern = regexp.MustCompile(localeKeyRegex)
+
+// listKeyColumns are the columns the list-of-records reader takes a record key
+// from when the collection declares no primary_key.
+var listKeyColumns = []string{"$ID", "$id", "id"}
+
+// inferredColumn accumulates what the scanned records hold in one column.
+type inferredColumn struct {
+	// kinds is the set of column types of the non-null values seen.
+	kinds map[ingitdb.ColumnType]bool
+
+	// present counts the records holding a non-null value.
+	present int
+
+	// values counts the records per distinct value, as long as every value
+	// is a string; it is nil otherwise.
+	values map[string]int
+}
+
+// inferredSchema accumulates the columns of the scanned records.
+type inferredSchema struct {
+	records int
+	columns map[string]*inferredColumn
+
+	// order lists the columns in order of first appearance.
+	order []string
+
+	// rows holds the decoded records, for primary-key inference on list
+	// files.
+	rows []map[string]any
+}
+
+func newInferredSchema() *inferredSchema {
+	return &inferredSchema{columns: make(map[string]*inferredColumn)}
+}
+
+// addRecord folds one record, a YAML mapping node, into the schema. Walking
+// the node instead of a decoded map keeps the field order of the file.
+func (s *inferredSchema) addRecord(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: record is not a mapping", node.Line)
+	}
+	s.records++
+	row := make(map[string]any, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name := node.Content[i].Value
+		var value any
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return fmt.Errorf("line %d: field %q: %w", node.Content[i].Line, name, err)
+		}
+		row[name] = value
+		col, ok := s.columns[name]
+		if !ok {
+			col = &inferredColumn{kinds: make(map[ingitdb.ColumnType]bool), values: make(map[string]int)}
+			s.columns[name] = col
+			s.order = append(s.order, name)
+		}
+		if value == nil {
+			continue
+		}
+		col.present++
+		kind := inferValueType(value)
+		col.kinds[kind] = true
+		if str, isString := value.(string); isString && col.values != nil {
+			col.values[str]++
+		} else {
+			col.values = nil
+		}
+	}
+	s.rows = append(s.rows, row)
+	return nil
+}
+
+// inferValueType maps a decoded YAML or JSON value to the column type that
+// holds it. Timestamps YAML decodes on its own are strings on disk.
+func inferValueType(value any) ingitdb.ColumnType {
+	switch v := value.(type) {
+	case bool:
+		return ingitdb.ColumnTypeBool
+	case int, int64, uint64:
+		return ingitdb.ColumnTypeInt
+	case float64:
+		return ingitdb.ColumnTypeFloat
+	case string, time.Time:
+		return ingitdb.ColumnTypeString
+	case map[string]any:
+		if len(v) == 0 {
+			return ingitdb.ColumnTypeAny
+		}
+		for key, item := range v {
+			if _, isString := item.(string); !isString || !localeKeyPattern.MatchString(key) {
+				return ingitdb.ColumnTypeAny
+			}
+		}
+		return ingitdb.ColumnTypeL10N
+	}
+	return ingitdb.ColumnTypeAny
+}
+
+// columnType resolves the observed kinds of a column to one type: a single
+// kind is kept, int and float widen to float, anything else is any.
+func (c *inferredColumn) columnType() ingitdb.ColumnType {
+	switch {
+	case len(c.kinds) == 1:
+		for kind := range c.kinds {
+			return kind
+		}
+	case len(c.kinds) == 2 && c.kinds[ingitdb.ColumnTypeInt] && c.kinds[ingitdb.ColumnTypeFloat]:
+		return ingitdb.ColumnTypeFloat
+	}
+	return ingitdb.ColumnTypeAny
+}
+
+// enumCandidates returns the distinct values of a string column when there
+// are at most maxValues of them and each is, on average, used by at least two
+// records — a column of mostly unique values is not an enum.
+func (c *inferredColumn) enumCandidates(maxValues int) []string {
+	if c.values == nil || len(c.values) < 2 || len(c.values) > maxValues || len(c.values)*2 > c.present {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(c.values))
+}
+
+// primaryKey returns the primary_key a list-of-records collection needs: none
+// when every record holds one of listKeyColumns, else the first column that
+// holds a unique scalar value in every record.
+func (s *inferredSchema) primaryKey() ([]string, error) {
+	hasKey := func(row map[string]any) bool {
+		for _, name := range listKeyColumns {
+			if row[name] != nil {
+				return true
+			}
+		}
+		return false
+	}
+	if !slices.ContainsFunc(s.rows, func(row map[string]any) bool { return !hasKey(row) }) {
+		return nil, nil
+	}
+	for _, name := range s.order {
+		col := s.columns[name]
+		if col.present != s.records {
+			continue
+		}
+		if kind := col.columnType(); kind != ingitdb.ColumnTypeString && kind != ingitdb.ColumnTypeInt {
+			continue
+		}
+		seen := make(map[string]bool, s.records)
+		for _, row := range s.rows {
+			seen[fmt.Sprintf("%v", row[name])] = true
+		}
+		if len(seen) == s.records {
+			return []string{name}, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot infer a record key: no id column, and no column holds a unique value in every record")
+}
+
+// collectionDef returns the draft definition of the scanned records. Every
+// column holding a value in all records is required, and a column with enum
+// candidates (see enumCandidates) gets them as a draft enum.
+func (s *inferredSchema) collectionDef(id string, recordFile *ingitdb.RecordFileDef, maxEnum int) (*ingitdb.CollectionDef, error) {
+	colDef := &ingitdb.CollectionDef{
+		ID:           id,
+		RecordFile:   recordFile,
+		Columns:      make(map[string]*ingitdb.ColumnDef, len(s.columns)),
+		ColumnsOrder: s.order,
+	}
+	for name, col := range s.columns {
+		colDef.Columns[name] = &ingitdb.ColumnDef{
+			Type:     col.columnType(),
+			Required: col.present == s.records,
+		}
+		for _, value := range col.enumCandidates(maxEnum) {
+			colDef.Columns[name].Enum = append(colDef.Columns[name].Enum, value)
+		}
+	}
+	if recordFile.RecordType == ingitdb.ListOfRecords {
+		var err error
+		if colDef.PrimaryKey, err = s.primaryKey(); err != nil {
+			return nil, err
+		}
+	}
+	if err := colDef.Validate(); err != nil {
+		return nil, fmt.Errorf("inferred definition is not valid: %w", err)
+	}
+	return colDef, nil
+}
+
+// inferredEnumComment flags a draft enum in the written definition: it only
+// lists the values the scanned records happen to hold.
+const inferredEnumComment = "draft enum: only the values found in the records; review it"
+
+// encodeInferredDefinition renders colDef as a definition.yaml draft. The
+// columns keep the order of first appearance, and a draft enum is flagged by
+// a comment above its column for the author to review.
+func encodeInferredDefinition(colDef *ingitdb.CollectionDef) ([]byte, error) {
+	columns := &yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range colDef.ColumnsOrder {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+		if len(colDef.Columns[name].Enum) > 0 {
+			key.HeadComment = inferredEnumComment
+		}
+		value := &yaml.Node{}
+		if err := value.Encode(colDef.Columns[name]); err != nil {
+			return nil, fmt.Errorf("failed to encode column %q: %w", name, err)
+		}
+		columns.Content = append(columns.Content, key, value)
+	}
+	draft := struct {
+		RecordFile   *ingitdb.RecordFileDef `yaml:"record_file"`
+		PrimaryKey   []string               `yaml:"primary_key,omitempty"`
+		Columns      *yaml.Node             `yaml:"columns"`
+		ColumnsOrder []string               `yaml:"columns_order,omitempty"`
+	}{colDef.RecordFile, colDef.PrimaryKey, columns, colDef.ColumnsOrder}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&draft); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunInfer_DryRun(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name: "one record per file",
+			files: map[string]string{
+				"ie.yaml":   "name: Ireland\npopulation: 5100000\nregion: europe\n",
+				"fr.yaml":   "name: France\npopulation: 68000000\nregion: europe\narea: 643801.5\n",
+				"br.yaml":   "name: Brazil\npopulation: 216000000\nregion: americas\n",
+				"us.yaml":   "name: USA\npopulation: 335000000\nregion: americas\narea: 9833520\n",
+				"README.md": "# Countries\n",
+			},
+			want: "record_file:\n" +
+				"  name: '{key}.yaml'\n" +
+				"  format: yaml\n" +
+				"  type: map[string]any\n" +
+				"columns:\n" +
+				"  name:\n" +
+				"    type: string\n" +
+				"    required: true\n" +
+				"  population:\n" +
+				"    type: int\n" +
+				"    required: true\n" +
+				"  # " + inferredEnumComment + "\n" +
+				"  region:\n" +
+				"    type: string\n" +
+				"    required: true\n" +
+				"    enum:\n" +
+				"      - americas\n" +
+				"      - europe\n" +
+				"  area:\n" +
+				"    type: float\n" +
+				"columns_order:\n" +
+				"  - name\n" +
+				"  - population\n" +
+				"  - region\n" +
+				"  - area\n",
+		},
+		{
+			name: "list of records with a unique column",
+			files: map[string]string{
+				"tags.json": `[{"code": "go", "title": {"en": "Go", "fr": "Go"}, "active": true},` +
+					`{"code": "ts", "title": {"en": "TypeScript"}, "active": false, "meta": {"x": 1}}]`,
+			},
+			want: "record_file:\n" +
+				"  name: tags.json\n" +
+				"  format: json\n" +
+				"  type: '[]map[string]any'\n" +
+				"primary_key:\n" +
+				"  - code\n" +
+				"columns:\n" +
+				"  code:\n" +
+				"    type: string\n" +
+				"    required: true\n" +
+				"  title:\n" +
+				"    type: map[locale]string\n" +
+				"    required: true\n" +
+				"  active:\n" +
+				"    type: bool\n" +
+				"    required: true\n" +
+				"  meta:\n" +
+				"    type: any\n" +
+				"columns_order:\n" +
+				"  - code\n" +
+				"  - title\n" +
+				"  - active\n" +
+				"  - meta\n",
+		},
+		{
+			name: "map of records",
+			files: map[string]string{
+				"statuses.yaml": "open:\n  title: Open\n  rank: 1\nclosed:\n  title: Closed\n  rank: 2.5\n",
+			},
+			want: "record_file:\n" +
+				"  name: statuses.yaml\n" +
+				"  format: yaml\n" +
+				"  type: map[$record_id]map[$field_name]any\n" +
+				"columns:\n" +
+				"  title:\n" +
+				"    type: string\n" +
+				"    required: true\n" +
+				"  rank:\n" +
+				"    type: float\n" +
+				"    required: true\n" +
+				"columns_order:\n" +
+				"  - title\n" +
+				"  - rank\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFixTestFile(t, dir, "data/"+name, content)
+			}
+			var out bytes.Buffer
+			if err := runInfer(t.Context(), &out, dir, "data", "data", defaultInferMaxEnum, true, false, func(...any) {}); err != nil {
+				t.Fatalf("infer: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("draft:\n%s\nwant:\n%s", out.String(), tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "data", ".collection")); !os.IsNotExist(err) {
+				t.Errorf("dry run must not write the definition, stat: %v", err)
+			}
+		})
+	}
+}
+
+func TestRunInfer_Writes(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, ".ingitdb/root-collections.yaml", "cities: cities\n")
+	writeFixTestFile(t, dir, "data/countries/ie.json", `{"name": "Ireland"}`)
+	writeFixTestFile(t, dir, "data/countries/fr.json", `{"name": "France"}`)
+	var logged []string
+	logf := func(args ...any) { logged = append(logged, args[0].(string)) }
+
+	if err := runInfer(t.Context(), nil, dir, "data/countries", "countries", defaultInferMaxEnum, false, false, logf); err != nil {
+		t.Fatalf("infer: %v", err)
+	}
+	for _, file := range []string{"$records/ie.json", "$records/fr.json", ".collection/definition.yaml"} {
+		if _, err := os.Stat(filepath.Join(dir, "data/countries", file)); err != nil {
+			t.Errorf("expected %s: %v", file, err)
+		}
+	}
+	entries, err := readRootCollections(dir)
+	if err != nil {
+		t.Fatalf("read root collections: %v", err)
+	}
+	if entries["countries"] != "data/countries" || entries["cities"] != "cities" {
+		t.Errorf("unexpected root collections: %v", entries)
+	}
+	if len(logged) != 2 || !strings.HasPrefix(logged[1], "inferred collection countries from 2 record(s)") {
+		t.Errorf("unexpected log: %q", logged)
+	}
+
+	err = runInfer(t.Context(), nil, dir, "data/countries", "countries", defaultInferMaxEnum, false, false, logf)
+	if err == nil || !strings.Contains(err.Error(), "use --force") {
+		t.Errorf("expected an existing-definition error, got %v", err)
+	}
+	if err = runInfer(t.Context(), nil, dir, "data/countries", "countries", defaultInferMaxEnum, false, true, logf); err != nil {
+		t.Errorf("infer --force: %v", err)
+	}
+	err = runInfer(t.Context(), nil, dir, "data/countries", "cities", defaultInferMaxEnum, false, true, logf)
+	if err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected an already-registered error, got %v", err)
+	}
+}
+
+func TestRunInfer_MovesTrackedRecordsWithGit(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	disableGitBackgroundMaintenance(t, dir)
+	writeFixTestFile(t, dir, "countries/ie.yaml", "name: Ireland\n")
+	writeFixTestFile(t, dir, "countries/fr.yaml", "name: France\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-m", "countries")
+
+	if err := runInfer(t.Context(), nil, dir, "countries", "countries", defaultInferMaxEnum, false, false, func(...any) {}); err != nil {
+		t.Fatalf("infer: %v", err)
+	}
+	status := string(runGit(t, dir, "status", "--porcelain"))
+	for _, want := range []string{"R  countries/fr.yaml -> countries/$records/fr.yaml", "R  countries/ie.yaml -> countries/$records/ie.yaml"} {
+		if !strings.Contains(status, want) {
+			t.Errorf("git status misses %q:\n%s", want, status)
+		}
+	}
+}
+
+func TestRunInfer_UndoesFailedMove(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	rootCollections := "cities: cities\n"
+	writeFixTestFile(t, dir, ".ingitdb/root-collections.yaml", rootCollections)
+	writeFixTestFile(t, dir, "countries/ie.yaml", "name: Ireland\n")
+	// A $records file, not a directory, leaves the records nowhere to go.
+	writeFixTestFile(t, dir, "countries/$records", "")
+
+	err := runInfer(t.Context(), nil, dir, "countries", "countries", defaultInferMaxEnum, false, false, func(...any) {})
+	if err == nil || !strings.Contains(err.Error(), "failed to move") {
+		t.Fatalf("expected a move error, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "countries/ie.yaml")); err != nil {
+		t.Errorf("record file was not left in place: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "countries/.collection/definition.yaml")); !os.IsNotExist(err) {
+		t.Errorf("definition was not removed: %v", err)
+	}
+	if got := readFixTestFile(t, filepath.Join(dir, ".ingitdb/root-collections.yaml")); got != rootCollections {
+		t.Errorf("root-collections.yaml was not restored:\n%s", got)
+	}
+}
+
+func TestRunInfer_Errors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "no record files",
+			files:   map[string]string{"README.md": "# Empty\n"},
+			wantErr: "no YAML, JSON or JSONL record files",
+		},
+		{
+			name:    "mixed formats",
+			files:   map[string]string{"a.yaml": "x: 1\n", "b.json": `{"x": 1}`},
+			wantErr: "mixes",
+		},
+		{
+			name:    "no record key",
+			files:   map[string]string{"items.jsonl": "{\"kind\": \"a\"}\n{\"kind\": \"a\"}\n"},
+			wantErr: "cannot infer a record key",
+		},
+		{
+			name:    "not a mapping",
+			files:   map[string]string{"a.yaml": "- 1\n", "b.yaml": "- 2\n"},
+			wantErr: "record is not a mapping",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				writeFixTestFile(t, dir, "data/"+name, content)
+			}
+			err := runInfer(t.Context(), nil, dir, "data", "data", defaultInferMaxEnum, true, false, func(...any) {})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
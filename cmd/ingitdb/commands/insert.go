@@ -13,6 +13,7 @@ import (
 	"github.com/dal-go/record"
 	"github.com/spf13/cobra"
 
+	"github.com/ingitdb/dalgo2ingitdb"
 	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
 	"github.com/ingitdb/ingitdb-go/ingitdb"
 )
@@ -127,13 +128,39 @@ func Insert(
 				return err
 			}
 
+			// A collection with a key generator supplies the key when
+			// neither --key nor $id does.
+			generated, err := generateInsertKey(ctx, cmd, ictx, data)
+			if err != nil {
+				return err
+			}
+
 			// Resolve the record key. Either --key, a top-level $id in
 			// the data, or both consistently supplied.
 			recordKey, data, err := resolveInsertKey(cmd, data)
 			if err != nil {
 				return err
 			}
+			if generated {
+				// The caller has no other way to learn the new key.
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), recordKey)
+			}
 
+			writes := map[string]map[string]any{recordKey: data}
+			if err = applyColumnDefaults(ctx, ictx.dirPath, ictx.colDef, writes, true); err != nil {
+				return err
+			}
+			if err = checkLocalWrite(ictx.dirPath, ictx.def, ictx.colDef, writes); err != nil {
+				return err
+			}
+			if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+				// A list row is appended to the collection's list file.
+				rows := []dalgo2ingitdb.ParsedRecord{{Key: recordKey, Data: data, Position: 1}}
+				if err = appendListRecords(ictx.colDef, rows); err != nil {
+					return err
+				}
+				return buildLocalViews(ctx, ictx.toRecordContext())
+			}
 			// Insert the record (collision check added in Task 5).
 			key := record.NewKeyWithID(ictx.colDef.ID, recordKey)
 			record := record.NewRecordWithData(key, data)
@@ -171,6 +198,28 @@ func Insert(
 	return cmd
 }
 
+// generateInsertKey sets $id in data from the key generator of the target
+// collection when neither --key nor $id supplies the key, and reports
+// whether it did.
+func generateInsertKey(ctx context.Context, cmd *cobra.Command, ictx insertContext, data map[string]any) (bool, error) {
+	if flagKey, _ := cmd.Flags().GetString("key"); flagKey != "" || data == nil {
+		return false, nil
+	}
+	if _, ok := data["$id"]; ok {
+		return false, nil
+	}
+	gen, err := newKeyGenerator(ctx, ictx.dirPath, ictx.colDef)
+	if err != nil || gen == nil {
+		return false, err
+	}
+	key, err := gen.generate(data)
+	if err != nil {
+		return false, err
+	}
+	data["$id"] = key
+	return true, nil
+}
+
 // resolveInsertKey returns the record key derived from --key and/or a
 // top-level $id field in data, plus the data map with $id stripped.
 // Rules:
@@ -49,23 +49,67 @@ func runBatchInsert(
 	// either dedupe the shared path (correct, but only by accident) or,
 	// for untracked files, os.Remove the shared file — destroying
 	// pre-existing records in the collection. Refuse batch mode for
-	// these collections until a proper SetMulti-based path exists.
+	// these collections until a proper SetMulti-based path exists; list
+	// collections take the single-write path of insertBatchRecords.
 	if ictx.colDef.RecordFile != nil && ictx.colDef.RecordFile.RecordType == ingitdb.MapOfRecords {
-		return fmt.Errorf("batch mode does not yet support collections with record_type=%s; only single-record collections are supported (collection: %s)", ictx.colDef.RecordFile.RecordType, ictx.colDef.ID)
+		return fmt.Errorf("batch mode does not yet support collections with record_type=%s; only single-record and list collections are supported (collection: %s)", ictx.colDef.RecordFile.RecordType, ictx.colDef.ID)
+	}
+	// Records without a key get one from the collection's key generator.
+	stdin, fields, err := generateBatchKeys(ctx, format, keyColumn, fields, stdin, ictx)
+	if err != nil {
+		return err
 	}
 	records, err := parseBatchStream(format, keyColumn, fields, stdin)
 	if err != nil {
 		return err
 	}
+	if err = insertBatchRecords(ctx, records, ictx); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stderr, "%d records inserted\n", len(records))
+	return nil
+}
+
+// insertBatchRecords is the write half of the batch pipeline: it checks the
+// parsed records for duplicate keys, foreign keys and unique constraints,
+// inserts them in one transaction (rolling back every written file on
+// failure), and materializes local views once after commit. import feeds it
+// the rows of each table it creates; those collections are new, so removing
+// a shared map or list file on rollback cannot destroy older records.
+func insertBatchRecords(ctx context.Context, records []dalgo2ingitdb.ParsedRecord, ictx insertContext) error {
 	if len(records) == 0 {
-		_, _ = fmt.Fprintln(stderr, "0 records inserted")
 		return nil
 	}
 	// Pre-commit intra-batch duplicate check.
-	err = rejectIntraBatchDuplicates(records)
+	err := rejectIntraBatchDuplicates(records)
 	if err != nil {
 		return err
 	}
+	// Pre-commit defaults, then foreign-key and unique-constraint checks
+	// against the stored records and the rest of the batch.
+	writes := make(map[string]map[string]any, len(records))
+	for i, rec := range records {
+		if rec.Data == nil {
+			records[i].Data = make(map[string]any)
+		}
+		writes[rec.Key] = records[i].Data
+	}
+	if err = applyColumnDefaults(ctx, ictx.dirPath, ictx.colDef, writes, true); err != nil {
+		return err
+	}
+	err = checkLocalWrite(ictx.dirPath, ictx.def, ictx.colDef, writes)
+	if err != nil {
+		return err
+	}
+	// A list-of-records collection keeps every record in one file, so the
+	// batch is appended to it in a single write that either happens in full
+	// or not at all.
+	if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+		if err = appendListRecords(ictx.colDef, records); err != nil {
+			return err
+		}
+		return materializeAfterBatch(ctx, ictx)
+	}
 	// Atomic insert. Any individual failure aborts the whole batch.
 	var writtenPaths []string
 	commitErr := ictx.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
@@ -100,8 +144,8 @@ func runBatchInsert(
 	// materialization). Failures here cannot be rolled back — records
 	// are on disk — and MUST be reported with a diagnostic distinct from
 	// a pre-commit rollback (req:batch-post-commit-failure). The error
-	// wrap on line below ("records inserted but view materialization
-	// failed") is the contract.
+	// wrap in materializeAfterBatch ("records inserted but view
+	// materialization failed") is the contract.
 	//
 	// AC: batch-view-materialization-once and AC: batch-post-commit-
 	// view-failure are verified by code inspection of THIS call site —
@@ -110,12 +154,17 @@ func runBatchInsert(
 	// write-target that is read-only). That fixture infrastructure is
 	// out of MVP scope; revisit when batch update/delete arrive and the
 	// view path needs end-to-end coverage anyway.
+	return materializeAfterBatch(ctx, ictx)
+}
+
+// materializeAfterBatch rebuilds the local views once the records of a batch
+// are on disk.
+func materializeAfterBatch(ctx context.Context, ictx insertContext) error {
 	rctx := ictx.toRecordContext()
 	viewErr := buildLocalViews(ctx, rctx)
 	if viewErr != nil {
 		return fmt.Errorf("records inserted but view materialization failed: %w", viewErr)
 	}
-	_, _ = fmt.Fprintf(stderr, "%d records inserted\n", len(records))
 	return nil
 }
 
@@ -218,6 +218,28 @@ func TestInsertBatch_JSONL_CollisionWithExistingRecord(t *testing.T) {
 	}
 }
 
+func TestInsertBatch_JSONL_UniqueViolationWritesNothing(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", "columns:\n  name:\n    unique: true\n")
+	stdin := strings.NewReader(`{"$id":"ie","name":"Ireland"}
+{"$id":"eire","name":"Ireland"}
+`)
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf,
+		stdin, false, nil,
+		"--path="+dir, "--into=test.items", "--format=jsonl",
+	)
+	if err == nil || !strings.Contains(err.Error(), "duplicate value for unique key name") {
+		t.Fatalf("expected a unique violation, got %v", err)
+	}
+	for _, key := range []string{"ie", "eire"} {
+		if _, statErr := os.Stat(filepath.Join(dir, "$records", key+".yaml")); !os.IsNotExist(statErr) {
+			t.Errorf("%s.yaml MUST NOT exist after a failed batch, stat: %v", key, statErr)
+		}
+	}
+}
+
 func TestInsertBatch_YAML_HappyPath(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
@@ -535,3 +557,67 @@ func TestInsertBatch_CSV_ToMarkdownStorage(t *testing.T) {
 		t.Errorf("hello.md missing body; got:\n%s", helloStr)
 	}
 }
+
+func TestInsertBatch_JSONL_ToYAMLListStorage(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir := func() (string, error) { return "/tmp/home", nil }
+	getWd := func() (string, error) { return dir, nil }
+	col := &ingitdb.CollectionDef{
+		ID:      "test.countries",
+		DirPath: dir,
+		RecordFile: &ingitdb.RecordFileDef{
+			Name:       "countries.yaml",
+			Format:     ingitdb.RecordFormatYAML,
+			RecordType: ingitdb.ListOfRecords,
+		},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"id":   {Type: ingitdb.ColumnTypeString},
+			"name": {Type: ingitdb.ColumnTypeString},
+		},
+		ColumnsOrder: []string{"id", "name"},
+	}
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{col.ID: col}}
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+	newDB := func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	logf := func(...any) {}
+	path := filepath.Join(dir, "countries.yaml")
+	if err := os.WriteFile(path, []byte("- id: ie\n  name: Ireland\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin := strings.NewReader(`{"$id":"fr","name":"France"}
+{"$id":"de","name":"Germany"}
+`)
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf,
+		stdin, false, nil,
+		"--path="+dir, "--into=test.countries", "--format=jsonl",
+	)
+	if err != nil {
+		t.Fatalf("expected success, got: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("countries.yaml not on disk: %v", err)
+	}
+	keys, rows, err := readListRecords(content, col)
+	if err != nil {
+		t.Fatalf("readListRecords: %v", err)
+	}
+	if strings.Join(keys, ",") != "ie,fr,de" || rows[2]["name"] != "Germany" {
+		t.Errorf("countries.yaml holds keys %v, want ie,fr,de appended in order:\n%s", keys, content)
+	}
+
+	_, err = runInsertCmd(t, homeDir, getWd, readDef, newDB, logf,
+		strings.NewReader(`{"$id":"es","name":"Spain"}`+"\n"+`{"$id":"ie","name":"Éire"}`+"\n"), false, nil,
+		"--path="+dir, "--into=test.countries", "--format=jsonl",
+	)
+	if err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected a collision error, got %v", err)
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, content) {
+		t.Errorf("a failed batch changed countries.yaml:\n%s", after)
+	}
+}
@@ -5,6 +5,8 @@ package commands
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/dal-go/dalgo/dal"
 	"github.com/spf13/cobra"
@@ -21,6 +23,9 @@ type insertContext struct {
 	colDef  *ingitdb.CollectionDef
 	dirPath string // empty when source is GitHub
 	def     *ingitdb.Definition
+	// parentFields holds the parent key pseudo-fields of a subcollection
+	// instance matched through a wildcard path; nil otherwise.
+	parentFields map[string]any
 }
 
 // toRecordContext converts an insertContext into a recordContext
@@ -40,7 +45,8 @@ func (ictx insertContext) toRecordContext() recordContext {
 // and returns the assembled insertContext.
 //
 // The caller supplies the collection ID directly (from --into) rather
-// than parsing it out of an --id value.
+// than parsing it out of an --id value. A subcollection path must name
+// its parent records; a wildcard would match more than one instance.
 func resolveInsertContext(
 	ctx context.Context,
 	cmd *cobra.Command,
@@ -55,6 +61,16 @@ func resolveInsertContext(
 	if remoteVal != "" && pathVal != "" {
 		return insertContext{}, fmt.Errorf("--path with --remote is not supported")
 	}
+	if isSubCollectionPath(collectionID) {
+		if slices.Contains(strings.Split(collectionID, "/"), collectionPathWildcard) {
+			return insertContext{}, fmt.Errorf("collection path %q has a wildcard parent; name the parent record", collectionID)
+		}
+		ictxs, err := resolveSubCollectionContexts(cmd, collectionID, homeDir, getWd, readDefinition, newDB)
+		if err != nil {
+			return insertContext{}, err
+		}
+		return ictxs[0], nil
+	}
 	if remoteVal != "" {
 		return resolveInsertContextRemote(ctx, cmd, collectionID, remoteVal)
 	}
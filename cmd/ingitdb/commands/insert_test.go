@@ -351,6 +351,54 @@ func TestInsert_RejectsExistingKey(t *testing.T) {
 	}
 }
 
+func TestInsert_RejectsUniqueViolation(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", "columns:\n  name:\n    unique: true\n")
+
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--key=first", "--data={name: Taken}",
+	)
+	if err != nil {
+		t.Fatalf("first insert should succeed: %v", err)
+	}
+	_, err = runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--key=second", "--data={name: Taken}",
+	)
+	if err == nil || !strings.Contains(err.Error(), `name="Taken" is already used by record "first"`) {
+		t.Fatalf("expected a unique violation, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "$records", "second.yaml")); !os.IsNotExist(statErr) {
+		t.Errorf("second.yaml MUST NOT be written, stat: %v", statErr)
+	}
+}
+
+func TestInsert_RejectsDanglingForeignKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	def := fkTestDefinition(dir)
+	homeDir, getWd, _, newDB, logf := insertTestDeps(t, dir)
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=cities", "--key=berlin", "--data={name: Berlin, country: de}",
+	)
+	if err == nil || !strings.Contains(err.Error(), `foreign key "country" = "de" has no matching record in collection "countries"`) {
+		t.Fatalf("expected a dangling-reference error, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "cities", "$records", "berlin.yaml")); !os.IsNotExist(statErr) {
+		t.Errorf("berlin.yaml MUST NOT be written, stat: %v", statErr)
+	}
+	_, err = runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=cities", "--key=paris", "--data={name: Paris, country: fr}",
+	)
+	if err != nil {
+		t.Fatalf("a valid reference should insert: %v", err)
+	}
+}
+
 // insertMarkdownTestDeps is like insertTestDeps but uses testMarkdownDef
 // so the test can target the test.notes markdown collection.
 func insertMarkdownTestDeps(t *testing.T, dir string) (
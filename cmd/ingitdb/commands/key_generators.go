@@ -0,0 +1,533 @@
+package commands
+
+// specscore: feature/key-generators
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"gopkg.in/yaml.v3"
+)
+
+// Key generators a collection can declare in the key block of its
+// constraints.yaml.
+const (
+	keyGenUUIDv7   = "uuidv7"
+	keyGenULID     = "ulid"
+	keyGenSequence = "sequence"
+	keyGenSlug     = "slug"
+	keyGenTemplate = "template"
+)
+
+// keyGeneration is the key block of a constraints.yaml file: how insert
+// generates the key of a record that comes without --key or $id.
+type keyGeneration struct {
+	// Generate names the generator: uuidv7, ulid, sequence, slug or template.
+	Generate string `yaml:"generate"`
+
+	// Field is the column the slug generator derives the key from.
+	Field string `yaml:"field,omitempty"`
+
+	// Template is the key of the template generator, with a {column}
+	// placeholder for every column value it is built from.
+	Template string `yaml:"template,omitempty"`
+}
+
+// keyTemplatePlaceholder matches a {column} placeholder of a key template.
+var keyTemplatePlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// validate checks that the generator is known and that the columns it
+// derives keys from are declared by col.
+func (k *keyGeneration) validate(col *ingitdb.CollectionDef) error {
+	switch k.Generate {
+	case keyGenUUIDv7, keyGenULID, keyGenSequence:
+		if k.Field != "" || k.Template != "" {
+			return fmt.Errorf("key: generator %s takes neither field nor template", k.Generate)
+		}
+	case keyGenSlug:
+		if k.Field == "" {
+			return fmt.Errorf("key: generator slug requires a field")
+		}
+		if _, ok := col.Columns[k.Field]; !ok {
+			return fmt.Errorf("key: column %q is not declared in collection %s", k.Field, col.ID)
+		}
+	case keyGenTemplate:
+		placeholders := keyTemplatePlaceholder.FindAllStringSubmatch(k.Template, -1)
+		if len(placeholders) == 0 {
+			return fmt.Errorf("key: template %q has no {column} placeholder", k.Template)
+		}
+		for _, m := range placeholders {
+			if _, ok := col.Columns[m[1]]; !ok {
+				return fmt.Errorf("key: template column %q is not declared in collection %s", m[1], col.ID)
+			}
+		}
+	default:
+		return fmt.Errorf("key: invalid generate %q (must be uuidv7, ulid, sequence, slug, or template)", k.Generate)
+	}
+	return nil
+}
+
+// describe renders the generator for the --edit template.
+func (k *keyGeneration) describe() string {
+	switch k.Generate {
+	case keyGenSlug:
+		return "a slug of " + k.Field
+	case keyGenTemplate:
+		return k.Template
+	case keyGenSequence:
+		return "the next number"
+	}
+	return "a new " + strings.ToUpper(k.Generate)
+}
+
+// keyGenerator hands out the keys of records inserted without one. It
+// remembers every key it returned, so the records of a batch get distinct
+// keys.
+type keyGenerator struct {
+	spec  *keyGeneration
+	taken map[string]bool
+	next  int // the next number of the sequence generator
+	now   func() time.Time
+	rand  io.Reader
+}
+
+// newKeyGenerator returns the key generator col declares, or nil when it
+// declares none or the database is remote. The sequence generator starts
+// after the highest numeric key in the working tree and on every local and
+// remote-tracking git branch, so inserts on different branches do not hand
+// out the same number.
+func newKeyGenerator(ctx context.Context, dirPath string, col *ingitdb.CollectionDef) (*keyGenerator, error) {
+	if dirPath == "" {
+		return nil, nil
+	}
+	cons, err := readCollectionConstraints(col)
+	if err != nil || cons == nil || cons.Key == nil {
+		return nil, err
+	}
+	g := &keyGenerator{spec: cons.Key, taken: make(map[string]bool), next: 1, now: time.Now, rand: rand.Reader}
+	switch cons.Key.Generate {
+	case keyGenUUIDv7, keyGenULID:
+		return g, nil
+	}
+	keys, err := collectionRecordKeys(col)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(g.taken, keys)
+	if cons.Key.Generate == keyGenSequence {
+		if keys, err = branchRecordKeys(ctx, dirPath, col); err != nil {
+			return nil, err
+		}
+		maps.Copy(g.taken, keys)
+		for key := range g.taken {
+			if n, convErr := strconv.Atoi(key); convErr == nil && n >= g.next {
+				g.next = n + 1
+			}
+		}
+	}
+	return g, nil
+}
+
+// generate returns a new key for a record holding data.
+func (g *keyGenerator) generate(data map[string]any) (string, error) {
+	var key string
+	switch g.spec.Generate {
+	case keyGenUUIDv7:
+		b, err := g.timeOrderedBytes()
+		if err != nil {
+			return "", err
+		}
+		b[6] = b[6]&0x0f | 0x70 // version 7
+		b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+		h := hex.EncodeToString(b)
+		key = h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	case keyGenULID:
+		b, err := g.timeOrderedBytes()
+		if err != nil {
+			return "", err
+		}
+		key = encodeULID(b)
+	case keyGenSequence:
+		for g.taken[strconv.Itoa(g.next)] {
+			g.next++
+		}
+		key = strconv.Itoa(g.next)
+		g.next++
+	case keyGenSlug:
+		value, err := keyFieldValue(data, g.spec.Field)
+		if err != nil {
+			return "", err
+		}
+		base := slugify(value)
+		if base == "" {
+			return "", fmt.Errorf("cannot generate a key: %s %q has no letters or digits to slug", g.spec.Field, value)
+		}
+		key = base
+		for n := 2; g.taken[key]; n++ {
+			key = base + "-" + strconv.Itoa(n)
+		}
+	case keyGenTemplate:
+		var err error
+		key = keyTemplatePlaceholder.ReplaceAllStringFunc(g.spec.Template, func(m string) string {
+			value, valueErr := keyFieldValue(data, m[1:len(m)-1])
+			if valueErr != nil && err == nil {
+				err = valueErr
+			}
+			return value
+		})
+		if err != nil {
+			return "", err
+		}
+		// Column values may hold anything, so the key is checked before it
+		// becomes a file name. Unlike a slug, a template key is not varied to
+		// dodge a collision: the same values mean the same record.
+		if strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+			return "", fmt.Errorf("cannot generate a key: template %q gives %q, and a key may not contain /, \\ or ..", g.spec.Template, key)
+		}
+		if g.taken[key] {
+			return "", fmt.Errorf("cannot generate a key: template %q gives %q, which is already taken", g.spec.Template, key)
+		}
+	}
+	g.taken[key] = true
+	return key, nil
+}
+
+// timeOrderedBytes returns 16 bytes starting with the current Unix time in
+// milliseconds, big-endian, and ending with 80 random bits.
+func (g *keyGenerator) timeOrderedBytes() ([]byte, error) {
+	b := make([]byte, 16)
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(g.now().UnixMilli()))
+	copy(b, ms[2:])
+	if _, err := io.ReadFull(g.rand, b[6:]); err != nil {
+		return nil, fmt.Errorf("failed to generate a key: %w", err)
+	}
+	return b, nil
+}
+
+// crockfordBase32 is the ULID alphabet.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeULID renders 16 bytes as the 26 characters of a ULID.
+func encodeULID(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	mask := big.NewInt(31)
+	out := make([]byte, 26)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordBase32[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(out)
+}
+
+// keyFieldValue returns the value of field in data as key text. A missing
+// or empty value cannot make a key.
+func keyFieldValue(data map[string]any, field string) (string, error) {
+	v, ok := data[field]
+	if !ok || v == nil || v == "" {
+		return "", fmt.Errorf("cannot generate a key: the record has no %s value", field)
+	}
+	return fmt.Sprint(v), nil
+}
+
+// slugify lower-cases s and joins its runs of letters and digits with
+// single hyphens.
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// collectionRecordKeys returns the record keys of col in the working tree.
+// The keys of one-record files come from their paths, which also covers
+// layouts with the file below a per-key directory ({key}/record.yaml).
+func collectionRecordKeys(col *ingitdb.CollectionDef) (map[string]bool, error) {
+	rf := col.RecordFile
+	if rf == nil || rf.RecordType != ingitdb.SingleRecord || !strings.Contains(rf.Name, "{key}") {
+		records, _, err := loadCollectionRecords(col)
+		return keySet(records), err
+	}
+	files, err := collectionRecordFiles(col)
+	if err != nil {
+		return nil, err
+	}
+	baseDir := filepath.Join(col.DirPath, rf.RecordsBasePath())
+	keys := make(map[string]bool, len(files))
+	for _, file := range files {
+		rel, relErr := filepath.Rel(baseDir, file)
+		if relErr != nil {
+			continue
+		}
+		if key, ok := recordFileKey(rf, filepath.ToSlash(rel)); ok {
+			keys[key] = true
+		}
+	}
+	return keys, nil
+}
+
+// recordFileKey returns the key of the one-record file at name, a slash
+// path relative to the records directory, or false when name is not a
+// record file of rf. A key never spans directories, so the files of
+// subcollections below a {key}/record.yaml record do not match.
+func recordFileKey(rf *ingitdb.RecordFileDef, name string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(rf.Name, "{key}")
+	if len(name) <= len(prefix)+len(suffix) || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return "", false
+	}
+	key := name[len(prefix) : len(name)-len(suffix)]
+	return key, !strings.Contains(key, "/")
+}
+
+// branchRecordKeys returns the record keys of col on every local and
+// remote-tracking branch of the git repository holding dirPath. Outside a
+// git working tree it returns nothing. A failing git command is an error:
+// the keys it would have found could otherwise be handed out again.
+func branchRecordKeys(ctx context.Context, dirPath string, col *ingitdb.CollectionDef) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	rf := col.RecordFile
+	if rf == nil || !isGitWorkingTree(ctx, dirPath) {
+		return keys, nil
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", dirPath, "for-each-ref", "--format=%(objectname)", "refs/heads", "refs/remotes").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git branches: %w", err)
+	}
+	rel, err := filepath.Rel(dirPath, filepath.Join(col.DirPath, rf.RecordsBasePath()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate the records of collection %s: %w", col.ID, err)
+	}
+	rel = filepath.ToSlash(rel)
+	commits := strings.Fields(string(out))
+	slices.Sort(commits)
+	for _, commit := range slices.Compact(commits) {
+		if rf.RecordType != ingitdb.SingleRecord || !strings.Contains(rf.Name, "{key}") {
+			relPath := path.Join(rel, rf.Name)
+			names, lsErr := gitTreeNames(ctx, dirPath, commit, relPath, false)
+			if lsErr != nil {
+				return nil, lsErr
+			}
+			if len(names) == 0 {
+				continue
+			}
+			content, showErr := exec.CommandContext(ctx, "git", "-C", dirPath, "show", commit+":./"+relPath).Output()
+			if showErr != nil {
+				return nil, fmt.Errorf("failed to read %s at %s: %w", relPath, commit, showErr)
+			}
+			maps.Copy(keys, keySet(parseKeyedRecords(content, col, relPath)))
+			continue
+		}
+		// A {key}/record.yaml layout keeps the record files a level down.
+		names, lsErr := gitTreeNames(ctx, dirPath, commit, rel+"/", strings.Contains(rf.Name, "/"))
+		if lsErr != nil {
+			return nil, lsErr
+		}
+		for _, name := range names {
+			if key, ok := recordFileKey(rf, strings.TrimPrefix(name, rel+"/")); ok {
+				keys[key] = true
+			}
+		}
+	}
+	return keys, nil
+}
+
+// gitTreeNames lists the paths matching pathspec in commit, relative to
+// dirPath, descending into subdirectories when recursive.
+func gitTreeNames(ctx context.Context, dirPath, commit, pathspec string, recursive bool) ([]string, error) {
+	args := []string{"-C", dirPath, "ls-tree", "-z", "--name-only"}
+	if recursive {
+		args = append(args, "-r")
+	}
+	out, err := exec.CommandContext(ctx, "git", append(args, commit, "--", pathspec)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s at %s: %w", pathspec, commit, err)
+	}
+	var names []string
+	for name := range strings.SplitSeq(string(out), "\x00") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// keySet returns the keys of records.
+func keySet(records map[string]map[string]any) map[string]bool {
+	keys := make(map[string]bool, len(records))
+	for key := range records {
+		keys[key] = true
+	}
+	return keys
+}
+
+// generateBatchKeys returns the batch stream with a generated $id added to
+// every record that comes without a key, plus the --fields list to parse it
+// with. Without a key generator the stream is returned untouched. Records
+// that do not parse are passed through for the batch parser to report.
+func generateBatchKeys(ctx context.Context, format, keyColumn string, fields []string, stdin io.Reader, ictx insertContext) (io.Reader, []string, error) {
+	gen, err := newKeyGenerator(ctx, ictx.dirPath, ictx.colDef)
+	if err != nil || gen == nil {
+		return stdin, fields, err
+	}
+	if format == "ingr" {
+		return nil, nil, fmt.Errorf("collection %s generates record keys, which --format=ingr does not support: INGR records always carry their key", ictx.colDef.ID)
+	}
+	content, err := io.ReadAll(stdin)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	switch format {
+	case "jsonl":
+		content, err = generateJSONLKeys(content, gen)
+	case "yaml":
+		content, err = generateYAMLKeys(content, gen)
+	case "csv":
+		if keyColumn != "" {
+			break
+		}
+		content, fields, err = generateCSVKeys(content, fields, gen)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return bytes.NewReader(content), fields, nil
+}
+
+// generateJSONLKeys adds a generated $id to every JSON object line of
+// content that has none. Blank lines are kept, so line numbers in batch
+// diagnostics still point at the input.
+func generateJSONLKeys(content []byte, gen *keyGenerator) ([]byte, error) {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		var data map[string]any
+		if dec.Decode(&data) != nil || data == nil {
+			continue
+		}
+		if _, ok := data["$id"]; ok {
+			continue
+		}
+		key, err := gen.generate(data)
+		if err != nil {
+			return nil, fmt.Errorf("record at line %d: %w", i+1, err)
+		}
+		data["$id"] = key
+		if lines[i], err = json.Marshal(data); err != nil {
+			return nil, fmt.Errorf("record at line %d: %w", i+1, err)
+		}
+	}
+	return bytes.Join(lines, []byte("\n")), nil
+}
+
+// generateYAMLKeys adds a generated $id to every document of a YAML stream
+// that has none.
+func generateYAMLKeys(content []byte, gen *keyGenerator) ([]byte, error) {
+	var docs []map[string]any
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var data map[string]any
+		err := dec.Decode(&data)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return content, nil
+		}
+		docs = append(docs, data)
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	for i, data := range docs {
+		if data == nil {
+			data = make(map[string]any)
+		}
+		if _, ok := data["$id"]; !ok {
+			key, err := gen.generate(data)
+			if err != nil {
+				return nil, fmt.Errorf("record at document %d: %w", i+1, err)
+			}
+			data["$id"] = key
+		}
+		if err := enc.Encode(data); err != nil {
+			return nil, fmt.Errorf("record at document %d: %w", i+1, err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// generateCSVKeys adds a generated $id column to a CSV stream whose header,
+// or --fields list, has neither a $id nor an id column.
+func generateCSVKeys(content []byte, fields []string, gen *keyGenerator) ([]byte, []string, error) {
+	rows, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return content, fields, nil
+	}
+	header, data, line := fields, rows, 1
+	if len(fields) == 0 {
+		header, data, line = rows[0], rows[1:], 2
+	}
+	if slices.Contains(header, "$id") || slices.Contains(header, "id") {
+		return content, fields, nil
+	}
+	for i, row := range data {
+		values := make(map[string]any, len(header))
+		for j, name := range header {
+			if j < len(row) {
+				values[name] = row[j]
+			}
+		}
+		key, genErr := gen.generate(values)
+		if genErr != nil {
+			return nil, nil, fmt.Errorf("record at line %d: %w", line+i, genErr)
+		}
+		data[i] = append(row, key)
+	}
+	if len(fields) == 0 {
+		rows[0] = append(rows[0], "$id")
+	} else {
+		fields = append(slices.Clone(fields), "$id")
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err = w.WriteAll(rows); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), fields, nil
+}
@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// writeKeyGeneration declares the key block of the test.items collection
+// that insertTestDeps serves from dir.
+func writeKeyGeneration(t *testing.T, dir, key string) {
+	t.Helper()
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", "key:\n"+key)
+}
+
+func TestInsert_GeneratesUUIDv7Key(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: uuidv7\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	out, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--data={name: Oslo}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	key := strings.TrimSpace(out)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(key) {
+		t.Fatalf("printed key %q is not a UUIDv7", key)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "$records", key+".yaml")); err != nil {
+		t.Errorf("record file of generated key: %v", err)
+	}
+}
+
+func TestInsert_KeyFlagWinsOverGenerator(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: ulid\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	out, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--key=oslo", "--data={name: Oslo}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if out != "" {
+		t.Errorf("insert with --key printed %q, want nothing", out)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "$records", "oslo.yaml")); err != nil {
+		t.Errorf("record file: %v", err)
+	}
+}
+
+func TestInsert_GeneratesSlugKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: slug\n  field: name\n")
+	writeFixTestFile(t, dir, "$records/new-york.yaml", "name: New York\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	out, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--data={name: New  York!}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if got := strings.TrimSpace(out); got != "new-york-2" {
+		t.Errorf("key = %q, want new-york-2", got)
+	}
+
+	_, err = runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--empty")
+	if err == nil || !strings.Contains(err.Error(), "has no name value") {
+		t.Errorf("expected missing name error, got %v", err)
+	}
+}
+
+func TestInsert_GeneratesTemplateKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: template\n  template: city-{name}\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	out, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+		"--path="+dir, "--into=test.items", "--data={name: oslo}")
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if got := strings.TrimSpace(out); got != "city-oslo" {
+		t.Errorf("key = %q, want city-oslo", got)
+	}
+}
+
+func TestInsert_TemplateKeyRejectsUnsafeAndTakenKeys(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name, data, wantErr string
+	}{
+		{"slash", "{name: a/b}", "may not contain"},
+		{"dot-dot", "{name: ..}", "may not contain"},
+		{"taken", "{name: oslo}", "already taken"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeKeyGeneration(t, dir, "  generate: template\n  template: \"{name}\"\n")
+			writeFixTestFile(t, dir, "$records/oslo.yaml", "name: oslo\n")
+			homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+			_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, nil, true, nil,
+				"--path="+dir, "--into=test.items", "--data="+tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want it to mention %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestInsertBatch_GeneratesSequenceKeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: sequence\n")
+	writeFixTestFile(t, dir, "$records/2.yaml", "name: Two\n")
+	writeFixTestFile(t, dir, "$records/oslo.yaml", "name: Oslo\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	stdin := strings.NewReader("{\"name\":\"Three\"}\n{\"$id\":\"bergen\",\"name\":\"Bergen\"}\n{\"name\":\"Four\"}\n")
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, stdin, false, nil,
+		"--path="+dir, "--into=test.items", "--format=jsonl")
+	if err != nil {
+		t.Fatalf("batch insert: %v", err)
+	}
+	for key, name := range map[string]string{"3": "Three", "4": "Four", "bergen": "Bergen"} {
+		content, readErr := os.ReadFile(filepath.Join(dir, "$records", key+".yaml"))
+		if readErr != nil || !strings.Contains(string(content), name) {
+			t.Errorf("record %s: %v\n%s", key, readErr, content)
+		}
+	}
+}
+
+func TestInsertBatch_CSVGeneratesKeyColumn(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: slug\n  field: name\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	stdin := strings.NewReader("name\nSan Francisco\nSan Francisco\n")
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, stdin, false, nil,
+		"--path="+dir, "--into=test.items", "--format=csv")
+	if err != nil {
+		t.Fatalf("batch insert: %v", err)
+	}
+	for _, key := range []string{"san-francisco", "san-francisco-2"} {
+		if _, statErr := os.Stat(filepath.Join(dir, "$records", key+".yaml")); statErr != nil {
+			t.Errorf("record %s: %v", key, statErr)
+		}
+	}
+}
+
+func TestInsertBatch_YAMLGeneratesKeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: template\n  template: \"{name}\"\n")
+	homeDir, getWd, readDef, newDB, logf := insertTestDeps(t, dir)
+
+	stdin := strings.NewReader("name: ie\n---\nname: fr\n")
+	_, err := runInsertCmd(t, homeDir, getWd, readDef, newDB, logf, stdin, false, nil,
+		"--path="+dir, "--into=test.items", "--format=yaml")
+	if err != nil {
+		t.Fatalf("batch insert: %v", err)
+	}
+	for _, key := range []string{"ie", "fr"} {
+		if _, statErr := os.Stat(filepath.Join(dir, "$records", key+".yaml")); statErr != nil {
+			t.Errorf("record %s: %v", key, statErr)
+		}
+	}
+}
+
+func TestKeyGenerator_SequenceSkipsKeysOnOtherBranches(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	disableGitBackgroundMaintenance(t, dir)
+	writeKeyGeneration(t, dir, "  generate: sequence\n")
+	writeFixTestFile(t, dir, "$records/1.yaml", "name: One\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "one")
+	runGit(t, dir, "checkout", "-b", "feature")
+	writeFixTestFile(t, dir, "$records/7.yaml", "name: Seven\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "seven")
+	runGit(t, dir, "checkout", "-")
+
+	gen, err := newKeyGenerator(t.Context(), dir, testDef(dir).Collections["test.items"])
+	if err != nil {
+		t.Fatalf("newKeyGenerator: %v", err)
+	}
+	key, err := gen.generate(map[string]any{})
+	if err != nil || key != "8" {
+		t.Errorf("generate() = %q, %v; want 8", key, err)
+	}
+}
+
+func TestKeyGenerator_SequenceWithDirectoryPerKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	disableGitBackgroundMaintenance(t, dir)
+	writeKeyGeneration(t, dir, "  generate: sequence\n")
+	writeFixTestFile(t, dir, "$records/3/record.yaml", "name: Three\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "three")
+	runGit(t, dir, "checkout", "-b", "feature")
+	writeFixTestFile(t, dir, "$records/7/record.yaml", "name: Seven\n")
+	// A subcollection record below a record is not a key of the collection.
+	writeFixTestFile(t, dir, "$records/7/orders/$records/99/record.yaml", "name: Order\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "seven")
+	runGit(t, dir, "checkout", "-")
+
+	col := testDef(dir).Collections["test.items"]
+	col.RecordFile.Name = "{key}/record.yaml"
+	gen, err := newKeyGenerator(t.Context(), dir, col)
+	if err != nil {
+		t.Fatalf("newKeyGenerator: %v", err)
+	}
+	key, err := gen.generate(map[string]any{})
+	if err != nil || key != "8" {
+		t.Errorf("generate() = %q, %v; want 8", key, err)
+	}
+}
+
+func TestKeyGenerator_SequenceReportsGitFailures(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	disableGitBackgroundMaintenance(t, dir)
+	writeKeyGeneration(t, dir, "  generate: sequence\n")
+	writeFixTestFile(t, dir, "$records/1.yaml", "name: One\n")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "one")
+	// A remote-tracking branch whose commit is missing, as in a partial clone.
+	writeFixTestFile(t, dir, ".git/refs/remotes/origin/gone", strings.Repeat("1234567890", 4)+"\n")
+
+	_, err := newKeyGenerator(t.Context(), dir, testDef(dir).Collections["test.items"])
+	if err == nil || !strings.Contains(err.Error(), "failed to list") {
+		t.Errorf("expected a git listing error, got %v", err)
+	}
+}
+
+func TestKeyGenerator_TimeOrderedKeys(t *testing.T) {
+	t.Parallel()
+	now := time.UnixMilli(0x0123456789ab)
+	zeros := func() *keyGenerator {
+		return &keyGenerator{taken: make(map[string]bool), now: func() time.Time { return now }, rand: bytes.NewReader(make([]byte, 10))}
+	}
+	gen := zeros()
+	gen.spec = &keyGeneration{Generate: keyGenULID}
+	if key, err := gen.generate(nil); err != nil || key != "014D2PF2DB0000000000000000" {
+		t.Errorf("ulid = %q, %v", key, err)
+	}
+	gen = zeros()
+	gen.spec = &keyGeneration{Generate: keyGenUUIDv7}
+	if key, err := gen.generate(nil); err != nil || key != "01234567-89ab-7000-8000-000000000000" {
+		t.Errorf("uuidv7 = %q, %v", key, err)
+	}
+}
+
+func TestReadCollectionConstraints_InvalidKey(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct{ name, key, want string }{
+		{"unknown generator", "  generate: random\n", `invalid generate "random"`},
+		{"slug without field", "  generate: slug\n", "requires a field"},
+		{"slug of undeclared column", "  generate: slug\n  field: title\n", `column "title" is not declared`},
+		{"template without placeholder", "  generate: template\n  template: fixed\n", "has no {column} placeholder"},
+		{"uuidv7 with field", "  generate: uuidv7\n  field: name\n", "takes neither field nor template"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			writeKeyGeneration(t, dir, tc.key)
+			_, err := readCollectionConstraints(testDef(dir).Collections["test.items"])
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("expected error containing %q, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestBuildRecordTemplate_DescribesKeyGenerator(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeKeyGeneration(t, dir, "  generate: slug\n  field: name\n")
+	col := testDef(dir).Collections["test.items"]
+	want := "# $id: leave out to use a slug of name as the record key\nname: \n"
+	if got := string(buildRecordTemplate(col)); got != want {
+		t.Errorf("template = %q, want %q", got, want)
+	}
+	col.RecordFile = &ingitdb.RecordFileDef{Name: "{key}.json", Format: ingitdb.RecordFormatJSON, RecordType: ingitdb.SingleRecord}
+	if got := string(buildRecordTemplate(col)); strings.Contains(got, "#") {
+		t.Errorf("JSON template has a comment: %q", got)
+	}
+}
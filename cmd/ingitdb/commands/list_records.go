@@ -0,0 +1,353 @@
+package commands
+
+// specscore: feature/record-format/list-of-records
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/ingitdb/dalgo2ingitdb"
+	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// readListRecords parses a list-of-records file of any list format into its
+// rows in file order, with the record key of each row; a row without a
+// resolvable key has an empty key. YAML, JSON and JSONL go through the
+// library list parser; CSV and INGR keep their own parsers, and INGR rows
+// come keyed by their $ID column in key order.
+func readListRecords(content []byte, col *ingitdb.CollectionDef) (keys []string, rows []map[string]any, err error) {
+	switch col.RecordFile.Format {
+	case ingitdb.RecordFormatINGR:
+		records, parseErr := ingitdb.ParseMapOfRecordsContent(content, col.RecordFile.Format)
+		if parseErr != nil {
+			return nil, nil, parseErr
+		}
+		keys = sortedRecordKeys(records)
+		for _, key := range keys {
+			rows = append(rows, records[key])
+		}
+		return keys, rows, nil
+	case ingitdb.RecordFormatCSV:
+		data, parseErr := ingitdb.ParseRecordContentForCollection(content, col)
+		if parseErr != nil {
+			return nil, nil, parseErr
+		}
+		rows, _ = data["$records"].([]map[string]any)
+	default:
+		if rows, err = ingitdb.ParseListOfRecordsContent(content, col.RecordFile.Format); err != nil {
+			return nil, nil, err
+		}
+	}
+	keys = make([]string, len(rows))
+	for i, row := range rows {
+		keys[i], _ = ingitdb.ResolveListRecordKey(row, col)
+	}
+	return keys, rows, nil
+}
+
+// listRecordFilePath returns the file that holds the records of a
+// list-of-records collection.
+func listRecordFilePath(col *ingitdb.CollectionDef) string {
+	return filepath.Join(col.DirPath, col.RecordFile.RecordsBasePath(), col.RecordFile.Name)
+}
+
+// isLocalListCollection reports whether col is a local collection that keeps
+// its records in one list file. Such collections are read and written
+// through readListRecords and the list encoder rather than the dalgo
+// record path.
+func isLocalListCollection(dirPath string, col *ingitdb.CollectionDef) bool {
+	return dirPath != "" && col.RecordFile != nil && col.RecordFile.RecordType == ingitdb.ListOfRecords
+}
+
+// readListFile reads the list file of col into keyed rows in file order. A
+// missing file holds no rows. content is the file as read, so a caller can
+// put it back.
+func readListFile(col *ingitdb.CollectionDef) (path string, content []byte, records []recordmerge.Record, err error) {
+	path = listRecordFilePath(col)
+	content, err = os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return path, nil, nil, nil
+		}
+		return path, nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	keys, rows, err := readListRecords(content, col)
+	if err != nil {
+		return path, nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	records = make([]recordmerge.Record, len(rows))
+	for i, row := range rows {
+		records[i] = recordmerge.Record{Key: keys[i], Fields: row}
+	}
+	return path, content, records, nil
+}
+
+// writeListFile encodes records with the list encoder and writes them to
+// path in one write.
+func writeListFile(col *ingitdb.CollectionDef, path string, records []recordmerge.Record) error {
+	out, err := serializeListRecords(records, col)
+	if err != nil {
+		return fmt.Errorf("failed to encode the records of %s: %w", col.ID, err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// listFileBackup is the content of a list file before a rewrite.
+type listFileBackup struct {
+	path    string
+	content []byte
+}
+
+// restoreListFiles puts back, newest first, the list files of backups after
+// a later step failed with err. It returns err, noting any file it could not
+// restore.
+func restoreListFiles(backups []listFileBackup, err error) error {
+	for _, b := range slices.Backward(backups) {
+		if restoreErr := os.WriteFile(b.path, b.content, 0o644); restoreErr != nil {
+			err = fmt.Errorf("%w (restoring %s also failed: %v)", err, b.path, restoreErr)
+		}
+	}
+	return err
+}
+
+// appendListRecords appends records to the file of a list-of-records
+// collection in one write, after the rows already in it. A row that does
+// not carry its key gets it in the column listKeyColumn names. It fails,
+// writing nothing, when a row would resolve to another key or its key is
+// already taken.
+func appendListRecords(col *ingitdb.CollectionDef, records []dalgo2ingitdb.ParsedRecord) error {
+	path, _, merged, err := readListFile(col)
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(merged)+len(records))
+	for _, r := range merged {
+		taken[r.Key] = true
+	}
+	for _, rec := range records {
+		data, ok := listRowWithKey(col, rec.Key, rec.Data)
+		if !ok {
+			return fmt.Errorf("record at position %d (key=%q): list collection %s has a composite primary key, so the record must carry its key columns", rec.Position, rec.Key, col.ID)
+		}
+		if key, _ := ingitdb.ResolveListRecordKey(data, col); key != rec.Key {
+			return fmt.Errorf("record at position %d (key=%q): its fields resolve to key %q in list collection %s", rec.Position, rec.Key, key, col.ID)
+		}
+		if taken[rec.Key] {
+			return fmt.Errorf("record at position %d (key=%q): record already exists in %s", rec.Position, rec.Key, col.ID)
+		}
+		taken[rec.Key] = true
+		merged = append(merged, recordmerge.Record{Key: rec.Key, Fields: data})
+	}
+	return writeListFile(col, path, merged)
+}
+
+// listRowWithKey returns a copy of data that holds key in the column
+// listKeyColumn names when data does not carry its key already. ok is false
+// for a composite primary key, whose columns data must carry itself.
+func listRowWithKey(col *ingitdb.CollectionDef, key string, data map[string]any) (row map[string]any, ok bool) {
+	row = maps.Clone(data)
+	if row == nil {
+		row = make(map[string]any)
+	}
+	if carriesListKey(row, col) {
+		return row, true
+	}
+	column := listKeyColumn(col)
+	if column == "" {
+		return nil, false
+	}
+	row[column] = key
+	return row, true
+}
+
+// matchListRecords returns the keyed rows of a local list collection that
+// match conds, or every keyed row when all is set. As in the dalgo read
+// path, conds also see the parent key pseudo-fields of ictx.
+func matchListRecords(ictx insertContext, conds []sqlflags.Condition, all bool) ([]recordmerge.Record, error) {
+	_, _, records, err := readListFile(ictx.colDef)
+	if err != nil {
+		return nil, err
+	}
+	var matches []recordmerge.Record
+	for _, r := range records {
+		if r.Key == "" {
+			continue
+		}
+		if !all {
+			if matched, _ := evalAllWhere(withParentFields(r.Fields, ictx.parentFields), r.Key, conds); !matched {
+				continue
+			}
+		}
+		matches = append(matches, r)
+	}
+	return matches, nil
+}
+
+// patchListRecords replaces the fields of the rows named by writes in one
+// write, keeping the order of the rows. It fails, writing nothing, when a
+// key has no row or the new fields resolve to another key.
+func patchListRecords(col *ingitdb.CollectionDef, writes map[string]map[string]any) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	path, _, records, err := readListFile(col)
+	if err != nil {
+		return err
+	}
+	patched := make(map[string]bool, len(writes))
+	for i, r := range records {
+		data, ok := writes[r.Key]
+		if !ok || r.Key == "" {
+			continue
+		}
+		if key, _ := ingitdb.ResolveListRecordKey(data, col); key != r.Key {
+			return fmt.Errorf("record %q: its fields resolve to key %q in list collection %s", r.Key, key, col.ID)
+		}
+		records[i].Fields = data
+		patched[r.Key] = true
+	}
+	for _, key := range slices.Sorted(maps.Keys(writes)) {
+		if !patched[key] {
+			return fmt.Errorf("record not found: %s/%s", col.ID, key)
+		}
+	}
+	return writeListFile(col, path, records)
+}
+
+// deleteListRecords removes the rows with the given keys in one write and
+// returns the file content from before the write, so a caller whose
+// follow-up write fails can put it back. It fails, writing nothing, when a
+// key has no row; with no keys it writes nothing.
+func deleteListRecords(col *ingitdb.CollectionDef, keys []string) (previous []byte, err error) {
+	path, content, records, err := readListFile(col)
+	if err != nil || len(keys) == 0 {
+		return content, err
+	}
+	remove := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		remove[key] = true
+	}
+	kept := make([]recordmerge.Record, 0, len(records))
+	for _, r := range records {
+		if r.Key != "" && remove[r.Key] {
+			delete(remove, r.Key)
+			continue
+		}
+		kept = append(kept, r)
+	}
+	for _, key := range keys {
+		if remove[key] {
+			return nil, fmt.Errorf("record not found: %s/%s", col.ID, key)
+		}
+	}
+	if err = writeListFile(col, path, kept); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// carriesListKey reports whether a list row holds its own key: every
+// primary_key column, or one of the fields ResolveListRecordKey falls back to.
+func carriesListKey(data map[string]any, col *ingitdb.CollectionDef) bool {
+	if len(col.PrimaryKey) > 0 {
+		for _, column := range col.PrimaryKey {
+			if data[column] == nil {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := ingitdb.ResolveListRecordKey(data, col)
+	return ok
+}
+
+// listKeyColumn returns the field a list row stores its key in when the row
+// has none: the single primary_key column, else a declared "id" column, else
+// the reserved "$ID" field. A composite key cannot be split back into its
+// columns, so those rows must carry the key columns themselves.
+func listKeyColumn(col *ingitdb.CollectionDef) string {
+	switch {
+	case len(col.PrimaryKey) == 1:
+		return col.PrimaryKey[0]
+	case len(col.PrimaryKey) > 1:
+		return ""
+	case col.Columns["id"] != nil:
+		return "id"
+	}
+	return "$ID"
+}
+
+// listRowKeyField returns the field data holds its list key in: the single
+// primary_key column, else the first of the fields ResolveListRecordKey
+// falls back to. It is empty for a composite primary key.
+func listRowKeyField(col *ingitdb.CollectionDef, data map[string]any) string {
+	if len(col.PrimaryKey) > 0 {
+		return listKeyColumn(col)
+	}
+	for _, field := range []string{"$ID", "$id", "id"} {
+		if _, ok := data[field]; ok {
+			return field
+		}
+	}
+	return listKeyColumn(col)
+}
+
+// validateListRecordKeys reports every row of a list-of-records file whose
+// key an earlier row of the same file already holds; such a row can be
+// neither addressed nor merged. Rows without a key are left to the
+// record validator.
+func validateListRecordKeys(def *ingitdb.Definition) ([]ingitdb.ValidationError, error) {
+	cols := eachCollection(def.Collections)
+	sort.Slice(cols, func(i, j int) bool { return cols[i].DirPath < cols[j].DirPath })
+	var violations []ingitdb.ValidationError
+	for _, col := range cols {
+		if col.RecordFile == nil || col.RecordFile.RecordType != ingitdb.ListOfRecords {
+			continue
+		}
+		paths, err := collectionRecordFiles(col)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			content, readErr := os.ReadFile(path)
+			if readErr != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, readErr)
+			}
+			keys, _, parseErr := readListRecords(content, col)
+			if parseErr != nil {
+				continue
+			}
+			seen := make(map[string]int, len(keys))
+			for i, key := range keys {
+				if key == "" {
+					continue
+				}
+				if first, dup := seen[key]; dup {
+					violations = append(violations, ingitdb.ValidationError{
+						Severity:     ingitdb.SeverityError,
+						CollectionID: col.ID,
+						FilePath:     path,
+						RecordKey:    key,
+						Message:      fmt.Sprintf("list rows %d and %d share the record key %q", first+1, i+1, key),
+					})
+					continue
+				}
+				seen[key] = i
+			}
+		}
+	}
+	return violations, nil
+}
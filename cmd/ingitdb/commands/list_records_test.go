@@ -0,0 +1,246 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dal-go/dalgo/dal"
+
+	"github.com/ingitdb/dalgo2ingitdb"
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func listTestCollection(dir string, format ingitdb.RecordFormat, name string, primaryKey ...string) *ingitdb.CollectionDef {
+	return &ingitdb.CollectionDef{
+		ID:         "cities",
+		DirPath:    dir,
+		RecordFile: &ingitdb.RecordFileDef{Name: name, Format: format, RecordType: ingitdb.ListOfRecords},
+		PrimaryKey: primaryKey,
+		Columns: map[string]*ingitdb.ColumnDef{
+			"id":      {Type: ingitdb.ColumnTypeString},
+			"country": {Type: ingitdb.ColumnTypeString},
+			"name":    {Type: ingitdb.ColumnTypeString},
+		},
+		ColumnsOrder: []string{"id", "country", "name"},
+	}
+}
+
+// listCmdDeps returns a DI set for the record commands over one local
+// collection, test.countries, kept as a YAML list file holding content.
+func listCmdDeps(t *testing.T, dir, content string) (
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDef func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) {
+	t.Helper()
+	col := &ingitdb.CollectionDef{
+		ID:      "test.countries",
+		DirPath: dir,
+		RecordFile: &ingitdb.RecordFileDef{
+			Name:       "countries.yaml",
+			Format:     ingitdb.RecordFormatYAML,
+			RecordType: ingitdb.ListOfRecords,
+		},
+		Columns: map[string]*ingitdb.ColumnDef{
+			"id":     {Type: ingitdb.ColumnTypeString},
+			"name":   {Type: ingitdb.ColumnTypeString},
+			"region": {Type: ingitdb.ColumnTypeString},
+		},
+		ColumnsOrder: []string{"id", "name", "region"},
+	}
+	writeFixTestFile(t, dir, "countries.yaml", content)
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{col.ID: col}}
+	homeDir = func() (string, error) { return "/tmp/home", nil }
+	getWd = func() (string, error) { return dir, nil }
+	readDef = func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil }
+	newDB = func(root string, d *ingitdb.Definition) (dal.DB, error) {
+		return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+	}
+	logf = func(...any) {}
+	return
+}
+
+// readListFixture returns the content of the list file listCmdDeps seeds.
+func readListFixture(t *testing.T, dir string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, "countries.yaml"))
+	if err != nil {
+		t.Fatalf("read countries.yaml: %v", err)
+	}
+	return string(content)
+}
+
+func TestReadListRecords(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		format  ingitdb.RecordFormat
+		content string
+		want    string
+	}{
+		{ingitdb.RecordFormatYAML, "- id: dub\n  name: Dublin\n- name: Nowhere\n- id: crk\n  name: Cork\n", "dub,,crk"},
+		{ingitdb.RecordFormatJSON, `[{"$id": "dub", "name": "Dublin"}, {"id": "crk"}]`, "dub,crk"},
+		{ingitdb.RecordFormatJSONL, "{\"id\": \"dub\"}\n\n{\"id\": \"crk\"}\n", "dub,crk"},
+		{ingitdb.RecordFormatCSV, "id,country,name\ndub,IE,Dublin\ncrk,IE,Cork\n", "dub,crk"},
+		{ingitdb.RecordFormatYAML, "", ""},
+	}
+	for _, tt := range tests {
+		col := listTestCollection(t.TempDir(), tt.format, "cities."+string(tt.format))
+		keys, rows, err := readListRecords([]byte(tt.content), col)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if got := strings.Join(keys, ","); got != tt.want || len(rows) != len(keys) {
+			t.Errorf("%s: keys %q, %d rows; want %q", tt.format, got, len(rows), tt.want)
+		}
+	}
+}
+
+func TestAppendListRecords(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	col := listTestCollection(dir, ingitdb.RecordFormatJSONL, "cities.jsonl", "name")
+	writeFixTestFile(t, dir, "cities.jsonl", `{"id":"dub","name":"Dublin"}`+"\n")
+
+	err := appendListRecords(col, []dalgo2ingitdb.ParsedRecord{
+		{Key: "Cork", Data: map[string]any{"country": "IE"}, Position: 1},
+		{Key: "Galway", Data: map[string]any{"name": "Galway"}, Position: 2},
+	})
+	if err != nil {
+		t.Fatalf("appendListRecords: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "cities.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"dub","name":"Dublin"}
+{"country":"IE","name":"Cork"}
+{"name":"Galway"}
+`
+	if string(content) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", content, want)
+	}
+
+	for _, tt := range []struct {
+		rec     dalgo2ingitdb.ParsedRecord
+		wantErr string
+	}{
+		{dalgo2ingitdb.ParsedRecord{Key: "Dublin", Position: 1}, "already exists"},
+		{dalgo2ingitdb.ParsedRecord{Key: "Sligo", Data: map[string]any{"name": "Tralee"}, Position: 1}, `resolve to key "Tralee"`},
+	} {
+		if err = appendListRecords(col, []dalgo2ingitdb.ParsedRecord{tt.rec}); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected %q, got %v", tt.rec.Key, tt.wantErr, err)
+		}
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "cities.jsonl")); string(after) != want {
+		t.Errorf("a failed append changed the file:\n%s", after)
+	}
+
+	composite := listTestCollection(t.TempDir(), ingitdb.RecordFormatYAML, "cities.yaml", "country", "name")
+	err = appendListRecords(composite, []dalgo2ingitdb.ParsedRecord{{Key: "IE-Cork", Position: 1}})
+	if err == nil || !strings.Contains(err.Error(), "composite primary key") {
+		t.Errorf("expected a composite key error, got %v", err)
+	}
+
+	keyless := listTestCollection(t.TempDir(), ingitdb.RecordFormatYAML, "cities.yaml")
+	delete(keyless.Columns, "id")
+	if err = appendListRecords(keyless, []dalgo2ingitdb.ParsedRecord{{Key: "dub", Data: map[string]any{"name": "Dublin"}}}); err != nil {
+		t.Fatalf("appendListRecords: %v", err)
+	}
+	content, _ = os.ReadFile(filepath.Join(keyless.DirPath, "cities.yaml"))
+	if want = "- name: Dublin\n  $ID: dub\n"; string(content) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", content, want)
+	}
+}
+
+func TestPatchListRecords(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	col := listTestCollection(dir, ingitdb.RecordFormatJSONL, "cities.jsonl")
+	original := `{"id":"dub","name":"Dublin"}` + "\n" + `{"name":"keyless"}` + "\n" + `{"id":"crk","name":"Cork"}` + "\n"
+	writeFixTestFile(t, dir, "cities.jsonl", original)
+
+	err := patchListRecords(col, map[string]map[string]any{
+		"crk": {"id": "crk", "country": "IE", "name": "Cork"},
+	})
+	if err != nil {
+		t.Fatalf("patchListRecords: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "cities.jsonl"))
+	want := `{"id":"dub","name":"Dublin"}
+{"name":"keyless"}
+{"id":"crk","country":"IE","name":"Cork"}
+`
+	if string(content) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", content, want)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		writes  map[string]map[string]any
+		wantErr string
+	}{
+		{"missing row", map[string]map[string]any{"gal": {"id": "gal"}}, "record not found: cities/gal"},
+		{"key change", map[string]map[string]any{"dub": {"id": "bfs"}}, `resolve to key "bfs"`},
+	} {
+		if err = patchListRecords(col, tt.writes); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+	if after, _ := os.ReadFile(filepath.Join(dir, "cities.jsonl")); string(after) != want {
+		t.Errorf("a failed patch changed the file:\n%s", after)
+	}
+}
+
+func TestDeleteListRecords(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	col := listTestCollection(dir, ingitdb.RecordFormatJSON, "cities.json")
+	original := `[{"id": "dub"}, {"id": "crk"}, {"id": "gal"}]`
+	writeFixTestFile(t, dir, "cities.json", original)
+
+	if _, err := deleteListRecords(col, []string{"dub", "nope"}); err == nil || !strings.Contains(err.Error(), "record not found: cities/nope") {
+		t.Fatalf("expected a not-found error, got %v", err)
+	}
+	previous, err := deleteListRecords(col, []string{"gal", "dub"})
+	if err != nil {
+		t.Fatalf("deleteListRecords: %v", err)
+	}
+	if string(previous) != original {
+		t.Errorf("previous content: got %q, want %q", previous, original)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, "cities.json"))
+	if want := "[\n  {\"id\":\"crk\"}\n]\n"; string(content) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", content, want)
+	}
+}
+
+func TestValidateListRecordKeys(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities.yaml", "- id: dub\n- id: crk\n- name: keyless\n- id: dub\n")
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"cities": listTestCollection(dir, ingitdb.RecordFormatYAML, "cities.yaml"),
+	}}
+	violations, err := validateListRecordKeys(def)
+	if err != nil {
+		t.Fatalf("validateListRecordKeys: %v", err)
+	}
+	if len(violations) != 1 || violations[0].RecordKey != "dub" || violations[0].Message != `list rows 1 and 4 share the record key "dub"` {
+		t.Errorf("violations = %+v", violations)
+	}
+}
+
+func TestParseKeyedRecords_CSVList(t *testing.T) {
+	t.Parallel()
+	col := listTestCollection(t.TempDir(), ingitdb.RecordFormatCSV, "cities.csv")
+	got := parseKeyedRecords([]byte("id,country,name\ndub,IE,Dublin\n"), col, "cities.csv")
+	if len(got) != 1 || got["dub"]["name"] != "Dublin" {
+		t.Errorf("parseKeyedRecords = %v", got)
+	}
+}
@@ -57,42 +57,72 @@ func materializeCommandRunE(
 			viewsSel = selection{kind: selectionAll}
 		}
 
-		var totalResult ingitdb.MaterializeResult
+		var recordsDelimiter *int
+		if cmd.Flags().Changed("records-delimiter") {
+			v, _ := cmd.Flags().GetInt("records-delimiter")
+			recordsDelimiter = &v
+		}
 
-		if colsSel.kind != selectionNone {
-			colResult, colErr := materializeCollections(ctx, def, dirPath, colsSel)
-			if colErr != nil {
-				return colErr
+		var changedCols changedCollectionSet
+		if since, _ := cmd.Flags().GetString("since"); since != "" && viewsSel.kind != selectionNone {
+			changedCols, err = collectionsChangedSince(ctx, repoRoot, def, since)
+			if err != nil {
+				return err
 			}
-			mergeMaterializeResult(&totalResult, colResult)
+			logf(changedCollectionsSummary(changedCols, since))
 		}
 
-		if viewsSel.kind != selectionNone {
-			var recordsDelimiter *int
-			if cmd.Flags().Changed("records-delimiter") {
-				v, _ := cmd.Flags().GetInt("records-delimiter")
-				recordsDelimiter = &v
+		build := func(def *ingitdb.Definition, dirPath, repoRoot string, changedCols changedCollectionSet) (*ingitdb.MaterializeResult, error) {
+			totalResult := &ingitdb.MaterializeResult{}
+			if colsSel.kind != selectionNone {
+				colResult, colErr := materializeCollections(ctx, def, dirPath, colsSel)
+				if colErr != nil {
+					return nil, colErr
+				}
+				mergeMaterializeResult(totalResult, colResult)
 			}
-			def.RuntimeOverrides.RecordsDelimiter = recordsDelimiter
-
-			viewResult, viewErr := materializeViews(ctx, viewBuilder, def, dirPath, repoRoot, viewsSel)
-			if viewErr != nil {
-				return viewErr
+			if viewsSel.kind != selectionNone {
+				def.RuntimeOverrides.RecordsDelimiter = recordsDelimiter
+				viewResult, viewErr := materializeViews(ctx, viewBuilder, def, dirPath, repoRoot, viewsSel, changedCols)
+				if viewErr != nil {
+					return nil, viewErr
+				}
+				mergeMaterializeResult(totalResult, viewResult)
 			}
-			mergeMaterializeResult(&totalResult, viewResult)
+			return totalResult, nil
 		}
 
-		logf(materializeSummary(&totalResult))
-		if len(totalResult.Errors) > 0 {
-			for _, e := range totalResult.Errors {
-				logf(fmt.Sprintf("error: %v", e))
-			}
-			return fmt.Errorf("%d view error(s)", len(totalResult.Errors))
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			return checkMaterialized(cmd, dirPath, repoRoot, logf, func(tmpDBPath, tmpRoot string) (*ingitdb.MaterializeResult, error) {
+				tmpDef, readErr := readDefinition(tmpDBPath)
+				if readErr != nil {
+					return nil, fmt.Errorf("failed to read database definition: %w", readErr)
+				}
+				return build(tmpDef, tmpDBPath, tmpRoot, changedCols.rebase(dirPath, tmpDBPath, tmpDef))
+			})
+		}
+
+		totalResult, err := build(def, dirPath, repoRoot, changedCols)
+		if err != nil {
+			return err
 		}
-		return nil
+		return reportMaterializeResult(totalResult, logf)
 	}
 }
 
+// reportMaterializeResult logs the summary line and any per-view errors,
+// failing the command when there were errors.
+func reportMaterializeResult(totalResult *ingitdb.MaterializeResult, logf func(...any)) error {
+	logf(materializeSummary(totalResult))
+	if len(totalResult.Errors) > 0 {
+		for _, e := range totalResult.Errors {
+			logf(fmt.Sprintf("error: %v", e))
+		}
+		return fmt.Errorf("%d view error(s)", len(totalResult.Errors))
+	}
+	return nil
+}
+
 // materializeCollections regenerates collection READMEs through docsbuilder.
 // For selectionAll it uses the "**" glob; for selectionList it runs once per
 // pattern and merges the results.
@@ -123,7 +153,8 @@ func materializeCollections(
 // materializeViews regenerates materialized views through the view builder.
 // For selectionAll it rebuilds every view of every collection (BuildViews). For
 // selectionList it rebuilds only the views whose names match the glob list,
-// leaving non-matching view output files untouched.
+// leaving non-matching view output files untouched. A non-nil changed set
+// (from --since) further limits the rebuild to the collections it contains.
 func materializeViews(
 	ctx context.Context,
 	viewBuilder materializer.ViewBuilder,
@@ -131,11 +162,15 @@ func materializeViews(
 	dirPath string,
 	repoRoot string,
 	sel selection,
+	changed changedCollectionSet,
 ) (*ingitdb.MaterializeResult, error) {
 	total := &ingitdb.MaterializeResult{}
 
 	if sel.kind == selectionAll {
 		for _, col := range eachCollection(def.Collections) {
+			if !changed.contains(col) {
+				continue
+			}
 			result, err := viewBuilder.BuildViews(ctx, dirPath, repoRoot, col, def)
 			if err != nil {
 				return nil, fmt.Errorf("failed to materialize views for collection %s: %w", col.ID, err)
@@ -148,11 +183,14 @@ func materializeViews(
 	// selectionList: build only the views whose names match the glob list. We
 	// rely on the per-view BuildView entry point so non-matching views are never
 	// re-rendered, satisfying the views-subset contract.
-	builder, ok := viewBuilder.(materializer.SimpleViewBuilder)
+	builder, ok := viewBuilder.(singleViewBuilder)
 	if !ok {
 		return nil, fmt.Errorf("view-name filtering requires the standard view builder")
 	}
 	for _, col := range eachCollection(def.Collections) {
+		if !changed.contains(col) {
+			continue
+		}
 		names := sortedViewNames(col.Views)
 		matched := matchViewNames(names, sel.globs)
 		for _, name := range matched {
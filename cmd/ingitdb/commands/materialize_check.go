@@ -0,0 +1,349 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// staleContextLines is the number of unchanged lines shown around each change
+// in the unified diff printed by --check.
+const staleContextLines = 3
+
+// maxDiffCells bounds the line-diff table (old lines × new lines). Larger
+// files are reported as changed without a line diff.
+const maxDiffCells = 4_000_000
+
+// staleFile is a derived file whose committed content differs from what
+// materialize would write. A nil old (new) slice means the file is missing
+// on disk (would be deleted).
+type staleFile struct {
+	path     string
+	old, new []byte
+}
+
+// checkMaterialized implements --check: it reports every file that build
+// would change and fails if there is one. Nothing under dirPath or repoRoot is
+// written. Stale files are printed to stdout as unified diffs.
+func checkMaterialized(
+	cmd *cobra.Command,
+	dirPath, repoRoot string,
+	logf func(...any),
+	build func(tmpDBPath, tmpRoot string) (*ingitdb.MaterializeResult, error),
+) error {
+	stale, result, err := findStaleFiles(dirPath, repoRoot, build)
+	if err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return reportMaterializeResult(result, logf)
+	}
+	if len(stale) == 0 {
+		logf("materialized files are up to date")
+		return nil
+	}
+	out := cmd.OutOrStdout()
+	for _, f := range stale {
+		logf("stale: " + f.path)
+		writeUnifiedDiff(out, f)
+	}
+	return fmt.Errorf("%d materialized file(s) are stale; run `ingitdb materialize` and commit the result", len(stale))
+}
+
+// findStaleFiles copies the database (and the repository-level $ingitdb/
+// export directory) into a temporary directory, lets build materialize that
+// copy, and returns every file whose content would change, together with the
+// build result. Stale files are not computed when the build reports errors.
+func findStaleFiles(
+	dirPath, repoRoot string,
+	build func(tmpDBPath, tmpRoot string) (*ingitdb.MaterializeResult, error),
+) ([]staleFile, *ingitdb.MaterializeResult, error) {
+	root := repoRoot
+	if root == "" {
+		root = dirPath
+	}
+	relDB, err := filepath.Rel(root, dirPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve database path: %w", err)
+	}
+	areas := []string{relDB}
+	if relDB != "." {
+		areas = append(areas, ingitdb.IngitdbDir)
+	}
+
+	tmpRoot, err := os.MkdirTemp("", "ingitdb-check-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmpRoot) }()
+	for _, area := range areas {
+		if err = copyTree(filepath.Join(root, area), filepath.Join(tmpRoot, area)); err != nil {
+			return nil, nil, fmt.Errorf("failed to copy %s for --check: %w", area, err)
+		}
+	}
+	if repoRoot != "" {
+		// Keeps view output paths relative to the copy's repository root.
+		if err = os.Mkdir(filepath.Join(tmpRoot, ".git"), 0o755); err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare temporary repository: %w", err)
+		}
+	}
+
+	result, err := build(filepath.Join(tmpRoot, relDB), tmpRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(result.Errors) > 0 {
+		return nil, result, nil
+	}
+
+	var stale []staleFile
+	for _, area := range areas {
+		areaStale, diffErr := diffTrees(filepath.Join(root, area), filepath.Join(tmpRoot, area), area)
+		if diffErr != nil {
+			return nil, nil, diffErr
+		}
+		stale = append(stale, areaStale...)
+	}
+	return stale, result, nil
+}
+
+// copyTree copies the regular files under src into dst, skipping .git
+// directories. A missing src is not an error.
+func copyTree(src, dst string) error {
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, p)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, readErr := os.ReadFile(p)
+		if readErr != nil {
+			return readErr
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+}
+
+// diffTrees compares the regular files under the original and regenerated
+// trees and returns the ones that differ, with paths prefixed by label.
+func diffTrees(origDir, newDir, label string) ([]staleFile, error) {
+	orig, err := readTreeFiles(origDir)
+	if err != nil {
+		return nil, err
+	}
+	regenerated, err := readTreeFiles(newDir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(orig)+len(regenerated))
+	for p := range orig {
+		paths = append(paths, p)
+	}
+	for p := range regenerated {
+		if _, ok := orig[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	var stale []staleFile
+	for _, p := range paths {
+		oldContent, hadOld := orig[p]
+		newContent, hasNew := regenerated[p]
+		if hadOld == hasNew && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+		stale = append(stale, staleFile{
+			path: filepath.ToSlash(filepath.Join(label, p)),
+			old:  oldContent,
+			new:  newContent,
+		})
+	}
+	return stale, nil
+}
+
+// readTreeFiles loads every regular file under dir (excluding .git), keyed by
+// its path relative to dir. A missing dir yields an empty map.
+func readTreeFiles(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, readErr := os.ReadFile(p)
+		if readErr != nil {
+			return readErr
+		}
+		rel, _ := filepath.Rel(dir, p)
+		// Never-nil so an existing empty file is distinguishable from a missing one.
+		files[rel] = append([]byte{}, data...)
+		return nil
+	})
+	return files, err
+}
+
+// writeUnifiedDiff prints f as a unified diff (a/ = on disk, b/ = regenerated).
+func writeUnifiedDiff(w io.Writer, f staleFile) {
+	oldName, newName := "a/"+f.path, "b/"+f.path
+	if f.old == nil {
+		oldName = "/dev/null"
+	}
+	if f.new == nil {
+		newName = "/dev/null"
+	}
+	_, _ = fmt.Fprintf(w, "--- %s\n+++ %s\n", oldName, newName)
+
+	a, b := splitDiffLines(f.old), splitDiffLines(f.new)
+	if len(a)*len(b) > maxDiffCells {
+		_, _ = fmt.Fprintf(w, "@@ %d line(s) -> %d line(s); too large to diff @@\n", len(a), len(b))
+		return
+	}
+	ops := diffLines(a, b)
+
+	// aLine[k]/bLine[k]: number of old/new lines consumed before ops[k].
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLine[k+1], bLine[k+1] = aLine[k], bLine[k]
+		if op.kind != '+' {
+			aLine[k+1]++
+		}
+		if op.kind != '-' {
+			bLine[k+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+		start := max(i-staleContextLines, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := 0
+			for end+run < len(ops) && ops[end+run].kind == ' ' {
+				run++
+			}
+			if end+run == len(ops) || run > 2*staleContextLines {
+				end += min(run, staleContextLines)
+				break
+			}
+			end += run
+		}
+		aCount, bCount := aLine[end]-aLine[start], bLine[end]-bLine[start]
+		_, _ = fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, op := range ops[start:end] {
+			_, _ = fmt.Fprintf(w, "%c%s\n", op.kind, op.text)
+		}
+		i = end
+	}
+}
+
+// hunkRange formats a unified-diff range; an empty range points at the line
+// before it, as in GNU diff.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+// diffOp is one line of an edit script: ' ' keep, '-' delete, '+' insert.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffLines computes a minimal line edit script from a to b via the longest
+// common subsequence.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// splitDiffLines splits content into lines without their trailing newline.
+func splitDiffLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
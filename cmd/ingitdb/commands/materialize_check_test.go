@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// rebasedDefinition returns a copy of def whose collection directories under
+// from are moved under to, mimicking ReadDefinition on a copied database.
+func rebasedDefinition(def *ingitdb.Definition, from, to string) *ingitdb.Definition {
+	var rebase func(cols map[string]*ingitdb.CollectionDef) map[string]*ingitdb.CollectionDef
+	rebase = func(cols map[string]*ingitdb.CollectionDef) map[string]*ingitdb.CollectionDef {
+		if cols == nil {
+			return nil
+		}
+		out := make(map[string]*ingitdb.CollectionDef, len(cols))
+		for id, col := range cols {
+			c := *col
+			if rel, err := filepath.Rel(from, col.DirPath); err == nil {
+				c.DirPath = filepath.Join(to, rel)
+			}
+			c.SubCollections = rebase(col.SubCollections)
+			out[id] = &c
+		}
+		return out
+	}
+	clone := *def
+	clone.Collections = rebase(def.Collections)
+	return &clone
+}
+
+// runMaterializeCheck runs `materialize` with a definition reader that honours
+// the path it is given, returning stdout and the command error.
+func runMaterializeCheck(t *testing.T, f *materializeFixture, args ...string) (string, error) {
+	t.Helper()
+	readDef := func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+		if p == f.dir {
+			return f.def, nil
+		}
+		return rebasedDefinition(f.def, f.dir, p), nil
+	}
+	cmd := Materialize(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return f.dir, nil },
+		readDef, realViewBuilder(), func(...any) {},
+	)
+	root := &cobra.Command{Use: "app", SilenceUsage: true, SilenceErrors: true}
+	root.AddCommand(cmd)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(append([]string{cmd.Name(), "--path=" + f.dir}, args...))
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestMaterialize_CheckUpToDate(t *testing.T) {
+	t.Parallel()
+	f := newMaterializeFixture(t)
+	if err := runMaterialize(t, f); err != nil {
+		t.Fatalf("materialize: %v", err)
+	}
+	out, err := runMaterializeCheck(t, f, "--check")
+	if err != nil {
+		t.Fatalf("--check after materialize should pass, got %v\n%s", err, out)
+	}
+	if out != "" {
+		t.Errorf("expected no diff output, got:\n%s", out)
+	}
+}
+
+func TestMaterialize_CheckReportsStaleWithoutWriting(t *testing.T) {
+	t.Parallel()
+	f := newMaterializeFixture(t)
+	if err := runMaterialize(t, f); err != nil {
+		t.Fatalf("materialize: %v", err)
+	}
+	recordPath := filepath.Join(f.dir, "cities", "$records", "sf.yaml")
+	if err := os.WriteFile(recordPath, []byte("name: San Jose\npopulation: 800000\nactive: true\n"), 0o644); err != nil {
+		t.Fatalf("edit record: %v", err)
+	}
+	viewPath := f.templateViewFile("cities", "active_cities.md")
+	before, err := os.ReadFile(viewPath)
+	if err != nil {
+		t.Fatalf("read view: %v", err)
+	}
+
+	out, err := runMaterializeCheck(t, f, "--check", "--views")
+	if err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Fatalf("expected a stale-files error, got %v", err)
+	}
+	for _, want := range []string{"--- a/cities/active_cities.md", "+++ b/cities/active_cities.md", "-- San Francisco", "+- San Jose"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("diff output misses %q:\n%s", want, out)
+		}
+	}
+	after, err := os.ReadFile(viewPath)
+	if err != nil {
+		t.Fatalf("read view: %v", err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("--check must not rewrite view output")
+	}
+}
+
+func TestMaterialize_CheckReportsMissingFile(t *testing.T) {
+	t.Parallel()
+	f := newMaterializeFixture(t)
+	out, err := runMaterializeCheck(t, f, "--check", "--collections=teams")
+	if err == nil {
+		t.Fatal("expected --check to fail when README.md was never generated")
+	}
+	if !strings.Contains(out, "--- /dev/null\n+++ b/teams/README.md") {
+		t.Errorf("expected a creation diff for teams/README.md, got:\n%s", out)
+	}
+	if _, ok := f.readme(t, filepath.Join(f.dir, "teams")); ok {
+		t.Error("--check must not write README.md")
+	}
+}
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		file staleFile
+		want string
+	}{
+		{
+			name: "changed line with context",
+			file: staleFile{path: "v.md", old: []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n"), new: []byte("1\n2\n3\n4\nfive\n6\n7\n8\n9\n")},
+			want: "--- a/v.md\n+++ b/v.md\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "deleted file",
+			file: staleFile{path: "old.md", old: []byte("a\n")},
+			want: "--- a/old.md\n+++ /dev/null\n@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "new empty file",
+			file: staleFile{path: "empty.md", new: []byte{}},
+			want: "--- /dev/null\n+++ b/empty.md\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			writeUnifiedDiff(&buf, tc.file)
+			if buf.String() != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", buf.String(), tc.want)
+			}
+		})
+	}
+}
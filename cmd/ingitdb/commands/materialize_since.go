@@ -0,0 +1,209 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/config"
+)
+
+// changedCollectionSet is the set of collections whose views must be rebuilt
+// by an incremental run. A nil set means "everything" (a change was detected
+// that affects the whole database, e.g. the root config).
+type changedCollectionSet map[*ingitdb.CollectionDef]bool
+
+// contains reports whether col's views must be rebuilt.
+func (s changedCollectionSet) contains(col *ingitdb.CollectionDef) bool {
+	return s == nil || s[col]
+}
+
+// mark adds col and, because subcollection records live beneath their
+// parent's directory, all of its nested subcollections.
+func (s changedCollectionSet) mark(col *ingitdb.CollectionDef) {
+	s[col] = true
+	for _, sub := range eachCollection(col.SubCollections) {
+		s[sub] = true
+	}
+}
+
+// rebase maps the set onto def, whose collections live under toDBPath instead
+// of fromDBPath (as when materializing a temporary copy of the database).
+// Collections are matched by their directory relative to the database root.
+func (s changedCollectionSet) rebase(fromDBPath, toDBPath string, def *ingitdb.Definition) changedCollectionSet {
+	if s == nil {
+		return nil
+	}
+	relDirs := make(map[string]bool, len(s))
+	for col := range s {
+		if rel, err := filepath.Rel(fromDBPath, col.DirPath); err == nil {
+			relDirs[rel] = true
+		}
+	}
+	out := changedCollectionSet{}
+	for _, col := range eachCollection(def.Collections) {
+		if rel, err := filepath.Rel(toDBPath, col.DirPath); err == nil && relDirs[rel] {
+			out[col] = true
+		}
+	}
+	return out
+}
+
+// collectionsChangedSince diffs the working tree (including untracked files)
+// against since and returns the collections whose records, schema or view
+// definitions were touched. Record files are mapped through the change-set
+// resolver; every other changed path (deleted records, rename sources,
+// definition.yaml, $views/ files, scripts) marks the collection whose
+// directory contains it. Root configuration changes return a nil set,
+// meaning a full rebuild.
+func collectionsChangedSince(
+	ctx context.Context,
+	repoRoot string,
+	def *ingitdb.Definition,
+	since string,
+) (changedCollectionSet, error) {
+	if repoRoot == "" {
+		return nil, fmt.Errorf("--since requires the database to be inside a git repository")
+	}
+	changed, err := gitDiffer.DiffFiles(ctx, repoRoot, since, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files changed since %s: %w", since, err)
+	}
+	// git diff against the working tree does not report new, untracked files.
+	untracked, err := gitUntrackedFiles(ctx, repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	changed = append(changed, untracked...)
+	if rootConfigChanged(changed) {
+		return nil, nil
+	}
+
+	set := changedCollectionSet{}
+	affected, err := changeSetResolver.Resolve(repoRoot, def, changed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve changed records: %w", err)
+	}
+	for _, ar := range affected {
+		if col := def.Collections[ar.CollectionID]; col != nil {
+			set.mark(col)
+		}
+	}
+
+	all := eachCollection(def.Collections)
+	for _, cf := range changed {
+		for _, p := range []string{cf.Path, cf.OldPath} {
+			if p == "" {
+				continue
+			}
+			absPath := filepath.Join(repoRoot, filepath.FromSlash(p))
+			for _, col := range all {
+				if col.DirPath != "" && pathWithin(col.DirPath, absPath) {
+					set.mark(col)
+				}
+			}
+		}
+	}
+	return set, nil
+}
+
+// changedCollectionsSummary renders the log line describing the scope of an
+// incremental run.
+func changedCollectionsSummary(set changedCollectionSet, since string) string {
+	if set == nil {
+		return fmt.Sprintf("database configuration changed since %s: rebuilding all views", since)
+	}
+	ids := make([]string, 0, len(set))
+	for col := range set {
+		ids = append(ids, col.ID)
+	}
+	sort.Strings(ids)
+	if len(ids) == 0 {
+		return fmt.Sprintf("no collections changed since %s", since)
+	}
+	return fmt.Sprintf("collections changed since %s: %s", since, strings.Join(ids, ", "))
+}
+
+// rootConfigChanged reports whether any changed file is database-wide
+// configuration, in which case every view must be rebuilt.
+func rootConfigChanged(changed []ingitdb.ChangedFile) bool {
+	for _, cf := range changed {
+		for _, p := range []string{cf.Path, cf.OldPath} {
+			base := filepath.Base(p)
+			if base == config.RootCollectionsFileName || base == ".ingitdb.yaml" {
+				return true
+			}
+			if strings.HasPrefix(p, config.IngitDBDirName+"/") || strings.Contains(p, "/"+config.IngitDBDirName+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pathWithin reports whether path is dir itself or lies beneath it.
+func pathWithin(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// gitUntrackedFiles lists untracked, non-ignored files as added changes.
+func gitUntrackedFiles(ctx context.Context, repoRoot string) ([]ingitdb.ChangedFile, error) {
+	gitCmd := exec.CommandContext(ctx, "git", "ls-files", "--others", "--exclude-standard")
+	gitCmd.Dir = repoRoot
+	out, err := gitCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+	var files []ingitdb.ChangedFile
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line != "" {
+			files = append(files, ingitdb.ChangedFile{Kind: ingitdb.ChangeKindAdded, Path: line})
+		}
+	}
+	return files, nil
+}
+
+// ciBaseRef returns the branch `ci` diffs against: --base, then BASE_REF, then
+// GITHUB_BASE_REF (as set on pull-request workflows). Empty means none is
+// configured.
+func ciBaseRef(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if v := os.Getenv("BASE_REF"); v != "" {
+		return v
+	}
+	return os.Getenv("GITHUB_BASE_REF")
+}
+
+// gitMergeBaseCmd returns the merge-base of HEAD and base. A bare branch name
+// that is not a local ref is retried as origin/<base>, which is how CI
+// checkouts usually expose the pull-request target.
+func gitMergeBaseCmd(ctx context.Context, repoRoot, base string) (string, error) {
+	candidates := []string{base}
+	if !strings.Contains(base, "/") {
+		candidates = append(candidates, "origin/"+base)
+	}
+	var lastErr error
+	for _, ref := range candidates {
+		gitCmd := exec.CommandContext(ctx, "git", "merge-base", "HEAD", ref)
+		gitCmd.Dir = repoRoot
+		out, err := gitCmd.Output()
+		if err == nil {
+			return strings.TrimSpace(string(out)), nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("git merge-base HEAD %s failed: %w", base, lastErr)
+}
@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// sinceFixture is a committed git repository holding two collections
+// ("cities" and "countries") with single-record YAML files.
+type sinceFixture struct {
+	dir string
+	def *ingitdb.Definition
+}
+
+func newSinceFixture(t *testing.T) *sinceFixture {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test User")
+	def := &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{}}
+	for _, name := range []string{"cities", "countries"} {
+		colDir := seedCollection(t, dir, name)
+		if err := os.MkdirAll(filepath.Join(colDir, "$records"), 0o755); err != nil {
+			t.Fatalf("mkdir $records: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(colDir, "$records", "a.yaml"), []byte("name: a\n"), 0o644); err != nil {
+			t.Fatalf("write record: %v", err)
+		}
+		def.Collections[name] = &ingitdb.CollectionDef{
+			ID:      name,
+			DirPath: colDir,
+			RecordFile: &ingitdb.RecordFileDef{
+				Name:       "{key}.yaml",
+				Format:     "yaml",
+				RecordType: ingitdb.SingleRecord,
+			},
+		}
+	}
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "seed")
+	return &sinceFixture{dir: dir, def: def}
+}
+
+func (f *sinceFixture) write(t *testing.T, rel, content string) {
+	t.Helper()
+	p := filepath.Join(f.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func changedIDs(set changedCollectionSet) []string {
+	ids := []string{}
+	for col := range set {
+		ids = append(ids, col.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestCollectionsChangedSince(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		change  func(t *testing.T, f *sinceFixture)
+		wantAll bool
+		want    []string
+	}{
+		{name: "no changes", change: func(*testing.T, *sinceFixture) {}, want: []string{}},
+		{name: "updated record", change: func(t *testing.T, f *sinceFixture) {
+			f.write(t, ".collections/cities/$records/a.yaml", "name: b\n")
+		}, want: []string{"cities"}},
+		{name: "deleted record", change: func(t *testing.T, f *sinceFixture) {
+			if err := os.Remove(filepath.Join(f.dir, ".collections", "countries", "$records", "a.yaml")); err != nil {
+				t.Fatal(err)
+			}
+		}, want: []string{"countries"}},
+		{name: "collection definition", change: func(t *testing.T, f *sinceFixture) {
+			f.write(t, ".collections/cities/definition.yaml", "columns:\n  name: {}\n")
+		}, want: []string{"cities"}},
+		{name: "root config", change: func(t *testing.T, f *sinceFixture) {
+			f.write(t, ".ingitdb/root-collections.yaml", "cities: .collections/cities\n")
+		}, wantAll: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := newSinceFixture(t)
+			tc.change(t, f)
+			set, err := collectionsChangedSince(context.Background(), f.dir, f.def, "HEAD")
+			if err != nil {
+				t.Fatalf("collectionsChangedSince: %v", err)
+			}
+			if tc.wantAll {
+				if set != nil {
+					t.Errorf("expected a full rebuild, got %v", changedIDs(set))
+				}
+				return
+			}
+			if set == nil {
+				t.Fatal("unexpected full rebuild")
+			}
+			if got := changedIDs(set); !equalStrings(got, tc.want) {
+				t.Errorf("changed collections: got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCollectionsChangedSince_RequiresRepo(t *testing.T) {
+	t.Parallel()
+	if _, err := collectionsChangedSince(context.Background(), "", &ingitdb.Definition{}, "HEAD"); err == nil {
+		t.Fatal("expected an error outside a git repository")
+	}
+}
+
+func TestMaterialize_SinceRebuildsChangedCollectionsOnly(t *testing.T) {
+	t.Parallel()
+	f := newSinceFixture(t)
+	f.write(t, ".collections/countries/$records/b.yaml", "name: b\n")
+
+	vb := &mockViewBuilder{result: &ingitdb.MaterializeResult{}}
+	cmd := Materialize(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return f.dir, nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil },
+		vb, func(...any) {},
+	)
+	if err := runCobraCommand(cmd, "--path="+f.dir, "--views", "--since=HEAD"); err != nil {
+		t.Fatalf("materialize --since: %v", err)
+	}
+	if len(vb.lastCols) != 1 || vb.lastCols[0].ID != "countries" {
+		var ids []string
+		for _, col := range vb.lastCols {
+			ids = append(ids, col.ID)
+		}
+		t.Errorf("expected only countries to be rebuilt, got %v", ids)
+	}
+}
+
+func TestCI_SinceAndFull(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "since", args: []string{"--since=HEAD"}, want: 1},
+		{name: "full", args: []string{"--full"}, want: 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := newSinceFixture(t)
+			f.write(t, ".collections/cities/$records/a.yaml", "name: b\n")
+			vb := &mockViewBuilder{result: &ingitdb.MaterializeResult{}}
+			cmd := CI(
+				func() (string, error) { return "/tmp/home", nil },
+				func() (string, error) { return f.dir, nil },
+				func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil },
+				vb, nil, nil, func(...any) {},
+			)
+			if err := runCobraCommand(cmd, append([]string{"--path=" + f.dir}, tc.args...)...); err != nil {
+				t.Fatalf("ci %v: %v", tc.args, err)
+			}
+			if len(vb.lastCols) != tc.want {
+				t.Errorf("rebuilt %d collections, want %d", len(vb.lastCols), tc.want)
+			}
+		})
+	}
+}
+
+func TestCI_DefaultsToMergeBase(t *testing.T) {
+	f := newSinceFixture(t)
+	runGit(t, f.dir, "branch", "-M", "main")
+	runGit(t, f.dir, "checkout", "-q", "-b", "feature")
+	f.write(t, ".collections/countries/$records/a.yaml", "name: b\n")
+	runGit(t, f.dir, "commit", "-q", "-am", "change countries")
+	t.Setenv("BASE_REF", "")
+	t.Setenv("GITHUB_BASE_REF", "main")
+
+	vb := &mockViewBuilder{result: &ingitdb.MaterializeResult{}}
+	cmd := CI(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return f.dir, nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return f.def, nil },
+		vb, nil, nil, func(...any) {},
+	)
+	if err := runCobraCommand(cmd, "--path="+f.dir); err != nil {
+		t.Fatalf("ci: %v", err)
+	}
+	if len(vb.lastCols) != 1 || vb.lastCols[0].ID != "countries" {
+		t.Errorf("expected only countries to be rebuilt, got %d collections", len(vb.lastCols))
+	}
+}
+
+func TestPathWithin(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		dir, path string
+		want      bool
+	}{
+		{"/db/cities", "/db/cities", true},
+		{"/db/cities", "/db/cities/$records/a.yaml", true},
+		{"/db/cities", "/db/cities2/a.yaml", false},
+		{"/db/cities", "/db/a.yaml", false},
+	}
+	for _, tc := range cases {
+		if got := pathWithin(tc.dir, tc.path); got != tc.want {
+			t.Errorf("pathWithin(%q, %q) = %v, want %v", tc.dir, tc.path, got, tc.want)
+		}
+	}
+}
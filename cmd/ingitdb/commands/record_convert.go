@@ -0,0 +1,401 @@
+package commands
+
+// specscore: feature/cli/convert
+// specscore: feature/cli/dump
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// recordFileExtension returns the extension of the record files of format.
+func recordFileExtension(format ingitdb.RecordFormat) string {
+	if format == ingitdb.RecordFormatMarkdown {
+		return ".md"
+	}
+	return importFormatExtensions[format]
+}
+
+// withExtension replaces the extension of name with ext, or appends ext when
+// name has none.
+func withExtension(name, ext string) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + ext
+}
+
+// parseRecordType maps a --record-type value — single, map, list or the
+// record type itself — to a record type.
+func parseRecordType(raw string) (ingitdb.RecordType, bool) {
+	switch raw {
+	case "single", string(ingitdb.SingleRecord):
+		return ingitdb.SingleRecord, true
+	case "list", string(ingitdb.ListOfRecords):
+		return ingitdb.ListOfRecords, true
+	case "map", string(ingitdb.MapOfRecords):
+		return ingitdb.MapOfRecords, true
+	}
+	return "", false
+}
+
+// convertedRecordFile returns the record_file of col stored as format and
+// recordType; an empty value keeps the current one. Unless name is given, a
+// changed layout is named {key}.<ext> for single records and <id>.<ext>
+// otherwise, and an unchanged one keeps its name with the new extension.
+func convertedRecordFile(col *ingitdb.CollectionDef, format ingitdb.RecordFormat, recordType ingitdb.RecordType, name string) (*ingitdb.RecordFileDef, error) {
+	rf := *col.RecordFile
+	if format != "" {
+		rf.Format = format
+	}
+	if recordType != "" {
+		rf.RecordType = recordType
+	}
+	ext := recordFileExtension(rf.Format)
+	if ext == "" {
+		return nil, fmt.Errorf("unknown record format %q", rf.Format)
+	}
+	switch {
+	case name != "":
+		rf.Name = name
+	case rf.RecordType == col.RecordFile.RecordType:
+		rf.Name = withExtension(rf.Name, ext)
+	case rf.RecordType == ingitdb.SingleRecord:
+		rf.Name = "{key}" + ext
+	default:
+		rf.Name = col.ID + ext
+	}
+	if err := rf.Validate(); err != nil {
+		return nil, fmt.Errorf("collection %s cannot be stored as %s %s: %w", col.ID, rf.Format, rf.RecordType, err)
+	}
+	return &rf, nil
+}
+
+// decodeRecordFile reads the records of a record file in file order. Layouts
+// the repair reader does not cover are read through the library parsers.
+func decodeRecordFile(content []byte, col *ingitdb.CollectionDef, filePath string) (*fixDocument, error) {
+	doc, err := parseFixDocument(content, col, filePath)
+	if err != nil || doc != nil {
+		return doc, err
+	}
+	rf := col.RecordFile
+	switch rf.RecordType {
+	case ingitdb.SingleRecord:
+		fields, parseErr := ingitdb.ParseRecordContentForCollection(content, col)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		return &fixDocument{records: []*fixRecord{{key: recordKeyFromPath(filePath), fields: fields}}}, nil
+	case ingitdb.MapOfRecords:
+		return parseKeyedFixDocument(content, rf.Format)
+	}
+	keys, rows, err := readListRecords(content, col)
+	if err != nil {
+		return nil, err
+	}
+	doc = &fixDocument{}
+	for i, row := range rows {
+		doc.records = append(doc.records, &fixRecord{key: keys[i], fields: row})
+	}
+	return doc, nil
+}
+
+// encodeRecordFile renders records as a record file of col. For Markdown
+// the body is taken from the content field.
+func encodeRecordFile(records []*fixRecord, col *ingitdb.CollectionDef) ([]byte, error) {
+	rf := col.RecordFile
+	doc := &fixDocument{records: records}
+	if rf.Format == ingitdb.RecordFormatMarkdown && len(records) == 1 {
+		rec := *records[0]
+		rec.fields = maps.Clone(rec.fields)
+		body, _ := rec.fields[rf.ResolvedContentField()].(string)
+		delete(rec.fields, rf.ResolvedContentField())
+		rec.order = slices.DeleteFunc(slices.Clone(rec.order), func(name string) bool { return name == rf.ResolvedContentField() })
+		doc = &fixDocument{records: []*fixRecord{&rec}, body: []byte(body)}
+	}
+	if rf.RecordType != ingitdb.ListOfRecords {
+		return encodeFixDocument(doc, col)
+	}
+	switch rf.Format {
+	case ingitdb.RecordFormatCSV, ingitdb.RecordFormatINGR, ingitdb.RecordFormatTOML:
+		merged := make([]recordmerge.Record, len(records))
+		for i, rec := range records {
+			merged[i] = recordmerge.Record{Key: rec.key, Fields: rec.fields}
+		}
+		return serializeListRecords(merged, col)
+	}
+	return encodeFixDocument(doc, col)
+}
+
+// readRecordFiles reads the records of an instance of a collection in file
+// order. The Markdown body of a record becomes its content field.
+func readRecordFiles(inst *ingitdb.CollectionDef) (records []*fixRecord, files []string, err error) {
+	if files, err = collectionRecordFiles(inst); err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files {
+		content, readErr := os.ReadFile(file)
+		if readErr != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", file, readErr)
+		}
+		doc, decodeErr := decodeRecordFile(content, inst, file)
+		if decodeErr != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", file, decodeErr)
+		}
+		if doc == nil {
+			return nil, nil, fmt.Errorf("cannot read the records of %s", file)
+		}
+		if inst.RecordFile.Format == ingitdb.RecordFormatMarkdown {
+			for _, rec := range doc.records {
+				rec.fields[inst.RecordFile.ResolvedContentField()] = string(doc.body)
+				if rec.order != nil {
+					rec.order = append(rec.order, inst.RecordFile.ResolvedContentField())
+				}
+			}
+		}
+		records = append(records, doc.records...)
+	}
+	return records, files, nil
+}
+
+// recordConversion is the plan of a record file conversion: the files to
+// write, the files they replace and the subcollection directories that move
+// because the records base path of their parent changes.
+type recordConversion struct {
+	writes  []recordFileWrite
+	removes []string
+	moves   [][2]string
+}
+
+// recordFileWrite is a record file to write.
+type recordFileWrite struct {
+	path    string
+	content []byte
+}
+
+// planRecordConversion re-encodes every record file of every instance of the
+// root collection col whose declared collection has a new record_file in
+// targets. Nothing is written.
+func planRecordConversion(col *ingitdb.CollectionDef, targets map[*ingitdb.CollectionDef]*ingitdb.RecordFileDef) (*recordConversion, error) {
+	plan := &recordConversion{}
+	err := walkCollectionInstances(col, func(def, inst *ingitdb.CollectionDef, _ []string, _ map[string]map[string]any) error {
+		rf := targets[def]
+		if rf == nil {
+			return nil
+		}
+		target := *inst
+		target.RecordFile = rf
+		records, files, err := readRecordFiles(inst)
+		if err != nil {
+			return err
+		}
+		written := make(map[string]bool)
+		write := func(path string, records []*fixRecord) error {
+			content, encodeErr := encodeRecordFile(records, &target)
+			if encodeErr != nil {
+				return fmt.Errorf("failed to encode the records of %s as %s: %w", def.ID, rf.Format, encodeErr)
+			}
+			plan.writes = append(plan.writes, recordFileWrite{path: path, content: content})
+			written[path] = true
+			return nil
+		}
+		baseDir := filepath.Join(inst.DirPath, rf.RecordsBasePath())
+		switch {
+		case rf.RecordType == ingitdb.SingleRecord:
+			for _, rec := range records {
+				if err = write(filepath.Join(baseDir, strings.ReplaceAll(rf.Name, "{key}", rec.key)), []*fixRecord{rec}); err != nil {
+					return err
+				}
+			}
+		case len(records) > 0:
+			if rf.RecordType == ingitdb.ListOfRecords {
+				for _, rec := range records {
+					if key, ok := ingitdb.ResolveListRecordKey(rec.fields, &target); !ok || key != rec.key {
+						return fmt.Errorf("record %s of %s would lose its key in a list: declare a primary_key or an id column first", rec.key, def.ID)
+					}
+				}
+			}
+			if err = write(filepath.Join(baseDir, rf.Name), records); err != nil {
+				return err
+			}
+		}
+		for _, file := range files {
+			if !written[file] {
+				plan.removes = append(plan.removes, file)
+			}
+		}
+		if inst.RecordFile.RecordsBasePath() != rf.RecordsBasePath() {
+			for _, subID := range slices.Sorted(maps.Keys(def.SubCollections)) {
+				for _, rec := range records {
+					from := subCollectionInstanceDir(inst, rec.key, subID)
+					if _, statErr := os.Stat(from); statErr == nil {
+						plan.moves = append(plan.moves, [2]string{from, subCollectionInstanceDir(&target, rec.key, subID)})
+					}
+				}
+			}
+		}
+		return nil
+	})
+	return plan, err
+}
+
+// apply writes the planned files, removes the replaced ones and moves the
+// subcollection directories. It returns a function that restores the
+// previous state, for when a later step fails.
+func (p *recordConversion) apply() (undo func(), err error) {
+	var undos []func()
+	undo = func() {
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			undo()
+		}
+	}()
+	restore := func(path string) {
+		if old, readErr := os.ReadFile(path); readErr == nil {
+			undos = append(undos, func() { _ = os.WriteFile(path, old, 0o644) })
+		} else {
+			undos = append(undos, func() {
+				_ = os.Remove(path)
+				removeEmptyDir(filepath.Dir(path))
+			})
+		}
+	}
+	for _, w := range p.writes {
+		restore(w.path)
+		if err = os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(w.path, w.content, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", w.path, err)
+		}
+	}
+	for _, path := range p.removes {
+		restore(path)
+		if err = os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removeEmptyDir(filepath.Dir(path))
+	}
+	for _, mv := range p.moves {
+		if err = os.MkdirAll(filepath.Dir(mv[1]), 0o755); err != nil {
+			return nil, err
+		}
+		if err = os.Rename(mv[0], mv[1]); err != nil {
+			return nil, fmt.Errorf("failed to move %s to %s: %w", mv[0], mv[1], err)
+		}
+		undos = append(undos, func() {
+			_ = os.MkdirAll(filepath.Dir(mv[0]), 0o755)
+			_ = os.Rename(mv[1], mv[0])
+		})
+		removeEmptyDir(filepath.Dir(mv[0]))
+	}
+	return undo, nil
+}
+
+// removeEmptyDir removes dir, and then its parent, while they are empty
+// $records directories or per-record directories left behind by a move.
+func removeEmptyDir(dir string) {
+	for range 2 {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// collectionDefinitionFile returns the definition.yaml of the collection at
+// fullID ("countries", "countries/regions") whose root collection lives in
+// the database-relative directory rootRel. Both definition layouts are
+// understood, as in readDropGraph.
+func collectionDefinitionFile(dbDir, rootRel, fullID string) string {
+	schemaDir := filepath.Join(dbDir, filepath.FromSlash(rootRel))
+	shared := true
+	if _, err := os.Stat(filepath.Join(schemaDir, ingitdb.CollectionDefFileName)); err != nil {
+		schemaDir, shared = filepath.Join(schemaDir, ingitdb.SchemaDir), false
+	}
+	_, subPath, _ := strings.Cut(fullID, "/")
+	for sub := range strings.SplitSeq(subPath, "/") {
+		switch {
+		case sub == "":
+		case shared:
+			schemaDir = filepath.Join(schemaDir, sub)
+		default:
+			schemaDir = filepath.Join(schemaDir, "subcollections", sub)
+		}
+	}
+	return filepath.Join(schemaDir, ingitdb.CollectionDefFileName)
+}
+
+// setYAMLKey sets the scalar at keyPath (a chain of mapping keys) of a YAML
+// document to value, keeping the order of the other keys and their
+// comments. Missing keys are appended.
+func setYAMLKey(content []byte, value string, keyPath ...string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	node := doc.Content[0]
+	for _, key := range keyPath {
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(keyPath, "."))
+		}
+		idx := -1
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == key {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+				&yaml.Node{Kind: yaml.MappingNode})
+			idx = len(node.Content) - 2
+		}
+		node = node.Content[idx+1]
+	}
+	*node = yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeRecordFileDefinition updates the record_file name, format and type in
+// the definition file of a collection.
+func writeRecordFileDefinition(defFile string, rf *ingitdb.RecordFileDef) error {
+	content, err := os.ReadFile(defFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", defFile, err)
+	}
+	for _, kv := range [][2]string{{"name", rf.Name}, {"format", string(rf.Format)}, {"type", string(rf.RecordType)}} {
+		if content, err = setYAMLKey(content, kv[1], "record_file", kv[0]); err != nil {
+			return fmt.Errorf("failed to update %s: %w", defFile, err)
+		}
+	}
+	if err = os.WriteFile(defFile, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", defFile, err)
+	}
+	return nil
+}
@@ -68,7 +68,18 @@ func resolveRecordMergeConflicts(
 // resolution — when the merge escalates or the merged records cannot be
 // serialized for the collection's format.
 func mergeAndSerialize(base, ours, theirs []byte, col *ingitdb.CollectionDef, opts recordmerge.Options) ([]byte, bool) {
-	outcome := recordmerge.MergeFiles(base, ours, theirs, col, opts)
+	var outcome recordmerge.Outcome
+	cons, err := readCollectionConstraints(col)
+	if err != nil {
+		return nil, false
+	}
+	if auto := cons.autoColumns(); len(auto) > 0 {
+		// Auto-managed columns change on every write, so they are merged
+		// apart from the fields people edit.
+		outcome = mergeWithAutoColumns(base, ours, theirs, col, opts, auto)
+	} else {
+		outcome = recordmerge.MergeFiles(base, ours, theirs, col, opts)
+	}
 	if outcome.Escalate {
 		return nil, false
 	}
@@ -0,0 +1,526 @@
+package commands
+
+// specscore: feature/cli/rename
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/dal-go/record"
+	"github.com/spf13/cobra"
+
+	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/recordmerge"
+)
+
+// Rename returns the `ingitdb rename` command. It moves the record --id
+// names to a new key of its collection (--to=KEY) or to another collection
+// (--to=COLLECTION/KEY). A single-record file is moved with `git mv`, so its
+// history follows it; foreign_key values referencing the record are updated
+// to the new key, and the views of every collection touched are rebuilt.
+func Rename(
+	homeDir func() (string, error),
+	getWd func() (string, error),
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	newDB func(string, *ingitdb.Definition) (dal.DB, error),
+	logf func(...any),
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename",
+		Short: "Move a record to a new key or collection",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			_ = logf
+			ctx := cmd.Context()
+			id, _ := cmd.Flags().GetString("id")
+			to, _ := cmd.Flags().GetString("to")
+			if id == "" {
+				return fmt.Errorf("--id is required")
+			}
+			if to == "" {
+				return fmt.Errorf("--to is required")
+			}
+			src, err := resolveRecordContext(ctx, cmd, id, homeDir, getWd, readDefinition, newDB)
+			if err != nil {
+				return err
+			}
+			dst := src
+			if strings.Contains(to, "/") {
+				if dst, err = resolveRecordContext(ctx, cmd, to, homeDir, getWd, readDefinition, newDB); err != nil {
+					return fmt.Errorf("invalid --to: %w", err)
+				}
+				// Both records live in the database --path names; sharing one
+				// handle lets a move run in a single transaction.
+				dst.db = src.db
+			} else {
+				dst.recordKey = to
+			}
+			return renameRecord(ctx, src, dst)
+		},
+	}
+	addPathFlag(cmd)
+	sqlflags.RegisterIDFlag(cmd)
+	cmd.Flags().String("to", "", "new record key, or COLLECTION/KEY to move the record to another collection")
+	return cmd
+}
+
+// renameReference is a foreign_key field that holds the key of a renamed
+// record.
+type renameReference struct {
+	recordRef
+	column string
+}
+
+// renameRecord moves the record src names to the key and collection dst
+// names. Everything that can fail — a missing source, a taken destination,
+// an incompatible schema, references a cross-collection move would break —
+// is checked before anything is written.
+func renameRecord(ctx context.Context, src, dst recordContext) error {
+	if src.dirPath == "" || dst.dirPath == "" {
+		return fmt.Errorf("rename supports local databases only")
+	}
+	sameCollection := src.colDef.ID == dst.colDef.ID && src.colDef.DirPath == dst.colDef.DirPath
+	if sameCollection && src.recordKey == dst.recordKey {
+		return fmt.Errorf("--to names the record being renamed")
+	}
+	if dst.recordKey == "" || strings.TrimSpace(dst.recordKey) != dst.recordKey {
+		return fmt.Errorf("invalid --to record key %q", dst.recordKey)
+	}
+	for _, col := range []*ingitdb.CollectionDef{src.colDef, dst.colDef} {
+		if col.RecordFile == nil {
+			return fmt.Errorf("collection %s has no record-file definition", col.ID)
+		}
+		if col.RecordFile.RecordType == ingitdb.ListOfRecords && !sameCollection {
+			return fmt.Errorf("cannot move a record into or out of collection %s: its records are rows of a list file", col.ID)
+		}
+	}
+
+	data, err := readRenameRecord(ctx, src)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return fmt.Errorf("record not found: %s/%s", src.colDef.ID, src.recordKey)
+	}
+	existing, err := readRenameRecord(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("record %q already exists in collection %s", dst.recordKey, dst.colDef.ID)
+	}
+
+	refs, err := findRenameReferences(src.def, src.colDef.ID, src.recordKey)
+	if err != nil {
+		return err
+	}
+	// A record referencing itself keeps referencing itself.
+	rewrite := src.colDef.RecordFile.Format != dst.colDef.RecordFile.Format
+	refs = slices.DeleteFunc(refs, func(ref renameReference) bool {
+		if ref.collection != src.colDef.ID || ref.key != src.recordKey {
+			return false
+		}
+		if sameCollection {
+			data[ref.column] = dst.recordKey
+			rewrite = true
+		}
+		return sameCollection
+	})
+	if !sameCollection {
+		if len(refs) > 0 {
+			return fmt.Errorf("cannot move record %q out of collection %s: record %q in collection %s references it through foreign key %q",
+				src.recordKey, src.colDef.ID, refs[0].key, refs[0].collection, refs[0].column)
+		}
+		if err = checkRecordFits(dst.colDef, dst.recordKey, data); err != nil {
+			return err
+		}
+		if err = checkLocalWrite(dst.dirPath, dst.def, dst.colDef, map[string]map[string]any{dst.recordKey: data}); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case src.colDef.RecordFile.RecordType == ingitdb.ListOfRecords:
+		err = renameListRow(ctx, src, dst, data, refs)
+	case src.colDef.RecordFile.RecordType == ingitdb.SingleRecord && dst.colDef.RecordFile.RecordType == ingitdb.SingleRecord:
+		err = moveRenamedFile(ctx, src, dst, data, rewrite, refs)
+	default:
+		err = moveRenamedEntry(ctx, src, dst, data, refs)
+	}
+	if err != nil {
+		return err
+	}
+	return buildRenameViews(ctx, src, dst, refs)
+}
+
+// readRenameRecord returns the fields of the record rctx names, or nil when
+// there is no such record.
+func readRenameRecord(ctx context.Context, rctx recordContext) (map[string]any, error) {
+	colID, key := rctx.colDef.ID, rctx.recordKey
+	if rctx.colDef.RecordFile.RecordType == ingitdb.ListOfRecords {
+		_, _, records, err := readListFile(rctx.colDef)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range records {
+			if r.Key == key {
+				return r.Fields, nil
+			}
+		}
+		return nil, nil
+	}
+	data := map[string]any{}
+	rec := record.NewRecordWithData(record.NewKeyWithID(colID, key), data)
+	err := rctx.db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
+		return tx.Get(ctx, rec)
+	})
+	if err != nil && !record.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to read record %q from collection %s: %w", key, colID, err)
+	}
+	if !rec.Exists() {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// findRenameReferences returns the foreign_key fields of root collections
+// that hold key as a reference to collection colID, in collection, record
+// and column order. Only root collections are referenced and scanned, as in
+// planLocalDelete.
+func findRenameReferences(def *ingitdb.Definition, colID, key string) ([]renameReference, error) {
+	if def == nil || def.Collections[colID] == nil {
+		return nil, nil
+	}
+	var refs []renameReference
+	for _, id := range slices.Sorted(maps.Keys(def.Collections)) {
+		col := def.Collections[id]
+		fks := foreignKeyColumns(col, def)
+		var columns []string
+		for _, column := range slices.Sorted(maps.Keys(fks)) {
+			if fks[column] == colID {
+				columns = append(columns, column)
+			}
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		records, _, err := loadCollectionRecords(col)
+		if err != nil {
+			return nil, err
+		}
+		for _, recKey := range slices.Sorted(maps.Keys(records)) {
+			for _, column := range columns {
+				if foreignKeyValue(records[recKey], column) == key {
+					refs = append(refs, renameReference{recordRef: recordRef{collection: id, key: recKey}, column: column})
+				}
+			}
+		}
+	}
+	return refs, nil
+}
+
+// checkRecordFits verifies that a record moved or copied to collection col
+// fits it: every field is a declared column and the record validator finds
+// nothing wrong.
+func checkRecordFits(col *ingitdb.CollectionDef, key string, data map[string]any) error {
+	for _, name := range slices.Sorted(maps.Keys(data)) {
+		if strings.HasPrefix(name, "$") {
+			continue
+		}
+		if _, ok := col.Columns[name]; !ok {
+			return fmt.Errorf("record %q does not fit collection %s: it does not declare column %q", key, col.ID, name)
+		}
+	}
+	if errs := datavalidator.ValidateRecordData(col, key, data); len(errs) > 0 {
+		return fmt.Errorf("record %q does not fit collection %s: %s", key, col.ID, errs[0].Message)
+	}
+	return nil
+}
+
+// moveRenamedFile moves the file (or key directory) of a single-record
+// record and then, in one transaction, rewrites it when its format or
+// self-references changed and updates the references to it. A failed
+// transaction moves the file back.
+func moveRenamedFile(ctx context.Context, src, dst recordContext, data map[string]any, rewrite bool, refs []renameReference) error {
+	from, to, err := renamedRecordPaths(src.colDef, src.recordKey, dst.colDef, dst.recordKey)
+	if err != nil {
+		return err
+	}
+	// The original content is restored if a rewritten record is moved back.
+	original, err := os.ReadFile(resolveBatchRecordPath(src.colDef, src.recordKey))
+	if err != nil {
+		return fmt.Errorf("failed to read record %q of collection %s: %w", src.recordKey, src.colDef.ID, err)
+	}
+	if err = moveRecordPath(ctx, src.dirPath, from, to); err != nil {
+		return err
+	}
+	refs, restoreRefs, err := updateListRenameReferences(src, refs, dst.recordKey)
+	if err == nil && rewrite {
+		err = dst.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+			return tx.Set(ctx, record.NewRecordWithData(record.NewKeyWithID(dst.colDef.ID, dst.recordKey), data))
+		})
+	}
+	if err == nil && len(refs) > 0 {
+		err = src.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+			return updateRenameReferences(ctx, tx, refs, dst.recordKey)
+		})
+	}
+	if err != nil {
+		err = restoreRefs(err)
+		if backErr := moveRecordPath(ctx, src.dirPath, to, from); backErr != nil {
+			return fmt.Errorf("%w (moving %s back failed: %v)", err, from, backErr)
+		}
+		_ = os.WriteFile(resolveBatchRecordPath(src.colDef, src.recordKey), original, 0o644)
+		return err
+	}
+	return nil
+}
+
+// moveRenamedEntry moves a record that is an entry of a map-of-records file,
+// or that moves between layouts, by inserting it under the new key and
+// deleting the old one. The file of a map keeps its history as is. Within
+// one database both happen in one transaction; otherwise a failed delete
+// removes the inserted record again.
+func moveRenamedEntry(ctx context.Context, src, dst recordContext, data map[string]any, refs []renameReference) error {
+	refs, restoreRefs, err := updateListRenameReferences(src, refs, dst.recordKey)
+	if err != nil {
+		return err
+	}
+	newKey := record.NewKeyWithID(dst.colDef.ID, dst.recordKey)
+	insert := func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		if err := tx.Insert(ctx, record.NewRecordWithData(newKey, data)); err != nil {
+			return fmt.Errorf("failed to write record %q to collection %s: %w", dst.recordKey, dst.colDef.ID, err)
+		}
+		return nil
+	}
+	remove := func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		if err := tx.Delete(ctx, record.NewKeyWithID(src.colDef.ID, src.recordKey)); err != nil {
+			return fmt.Errorf("failed to delete record %q from collection %s: %w", src.recordKey, src.colDef.ID, err)
+		}
+		return updateRenameReferences(ctx, tx, refs, dst.recordKey)
+	}
+	if src.db == dst.db {
+		err = src.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+			if err := insert(ctx, tx); err != nil {
+				return err
+			}
+			return remove(ctx, tx)
+		})
+		if err != nil {
+			return restoreRefs(err)
+		}
+		return nil
+	}
+	if err = dst.db.RunReadwriteTransaction(ctx, insert); err != nil {
+		return restoreRefs(err)
+	}
+	err = src.db.RunReadwriteTransaction(ctx, remove)
+	if err == nil {
+		return nil
+	}
+	err = restoreRefs(err)
+	undoErr := dst.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+		return tx.Delete(ctx, newKey)
+	})
+	if undoErr != nil {
+		return fmt.Errorf("%w (removing record %q from collection %s again failed: %v)", err, dst.recordKey, dst.colDef.ID, undoErr)
+	}
+	return err
+}
+
+// renameListRow changes the key of a row of a list file in place, so the
+// row keeps its position, and then updates the references to it. A failed
+// reference update puts the list file back.
+func renameListRow(ctx context.Context, src, dst recordContext, data map[string]any, refs []renameReference) error {
+	column := listRowKeyField(src.colDef, data)
+	if column == "" {
+		return fmt.Errorf("cannot rename record %q of collection %s: its key is made of several primary_key columns", src.recordKey, src.colDef.ID)
+	}
+	data[column] = dst.recordKey
+	path, previous, records, err := readListFile(src.colDef)
+	if err != nil {
+		return err
+	}
+	for i, r := range records {
+		if r.Key == src.recordKey {
+			records[i] = recordmerge.Record{Key: dst.recordKey, Fields: data}
+			break
+		}
+	}
+	if err = writeListFile(src.colDef, path, records); err != nil {
+		return err
+	}
+	refs, restoreRefs, err := updateListRenameReferences(src, refs, dst.recordKey)
+	if err == nil && len(refs) > 0 {
+		err = src.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+			return updateRenameReferences(ctx, tx, refs, dst.recordKey)
+		})
+		err = restoreRefs(err)
+	}
+	if err != nil {
+		if restoreErr := os.WriteFile(path, previous, 0o644); restoreErr != nil {
+			return fmt.Errorf("%w (restoring %s also failed: %v)", err, path, restoreErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// renamedRecordPaths returns the paths a rename moves: the record file, or
+// the record's directory when record_file.name puts {key} in a directory
+// name, so the files kept next to the record move with it.
+func renamedRecordPaths(src *ingitdb.CollectionDef, oldKey string, dst *ingitdb.CollectionDef, newKey string) (from, to string, err error) {
+	segments := strings.Split(src.RecordFile.Name, "/")
+	i := slices.IndexFunc(segments, func(s string) bool { return strings.Contains(s, "{key}") })
+	switch {
+	case i < 0:
+		return "", "", fmt.Errorf("collection %s: record_file.name %q has no {key}", src.ID, src.RecordFile.Name)
+	case i == len(segments)-1:
+		return resolveBatchRecordPath(src, oldKey), resolveBatchRecordPath(dst, newKey), nil
+	case dst.RecordFile.Name != src.RecordFile.Name:
+		return "", "", fmt.Errorf("cannot move a record kept in its own directory (%s) to collection %s, whose record_file.name is %s",
+			src.RecordFile.Name, dst.ID, dst.RecordFile.Name)
+	}
+	dir := filepath.Join(segments[:i+1]...)
+	from = filepath.Join(src.DirPath, src.RecordFile.RecordsBasePath(), strings.ReplaceAll(dir, "{key}", oldKey))
+	to = filepath.Join(dst.DirPath, dst.RecordFile.RecordsBasePath(), strings.ReplaceAll(dir, "{key}", newKey))
+	return from, to, nil
+}
+
+// moveRecordPath moves from to to, with `git mv` when from is tracked in the
+// git working tree at dirPath so that history follows the record, and with
+// a plain rename otherwise.
+func moveRecordPath(ctx context.Context, dirPath, from, to string) error {
+	if _, err := os.Stat(to); err == nil {
+		return fmt.Errorf("cannot move %s: %s already exists", from, to)
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", to, err)
+	}
+	if isGitWorkingTree(ctx, dirPath) && isTracked(ctx, dirPath, from) {
+		out, err := exec.CommandContext(ctx, "git", "-C", dirPath, "mv", from, to).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("git mv %s: %w (%s)", from, err, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("failed to move %s: %w", from, err)
+	}
+	return nil
+}
+
+// updateListRenameReferences sets the referencing fields of the refs held
+// by local list collections of rctx's database to newKey, with one
+// patchListRecords write per list file, so a reference that is also the
+// row's own key is rejected. It returns the other refs, for
+// updateRenameReferences, and a function that, given the error of a later
+// step, puts the rewritten list files back; with a nil error it does
+// nothing.
+func updateListRenameReferences(rctx recordContext, refs []renameReference, newKey string) (rest []renameReference, restore func(error) error, err error) {
+	var backups []listFileBackup
+	restore = func(err error) error {
+		if err == nil {
+			return nil
+		}
+		return restoreListFiles(backups, err)
+	}
+	columns := make(map[string]map[string][]string)
+	for _, ref := range refs {
+		col := rctx.def.Collections[ref.collection]
+		if col == nil || !isLocalListCollection(rctx.dirPath, col) {
+			rest = append(rest, ref)
+			continue
+		}
+		if columns[ref.collection] == nil {
+			columns[ref.collection] = make(map[string][]string)
+		}
+		columns[ref.collection][ref.key] = append(columns[ref.collection][ref.key], ref.column)
+	}
+	for _, id := range slices.Sorted(maps.Keys(columns)) {
+		col := rctx.def.Collections[id]
+		path, content, records, readErr := readListFile(col)
+		if readErr != nil {
+			return nil, keepError, restore(readErr)
+		}
+		writes := make(map[string]map[string]any, len(columns[id]))
+		for _, r := range records {
+			if fields, ok := columns[id][r.Key]; ok && r.Key != "" {
+				data := maps.Clone(r.Fields)
+				for _, column := range fields {
+					data[column] = newKey
+				}
+				writes[r.Key] = data
+			}
+		}
+		backups = append(backups, listFileBackup{path: path, content: content})
+		if err = patchListRecords(col, writes); err != nil {
+			return nil, keepError, restore(fmt.Errorf("failed to update the references in collection %s: %w", id, err))
+		}
+	}
+	return rest, restore, nil
+}
+
+// keepError is the restore function of a step that left nothing to undo.
+func keepError(err error) error { return err }
+
+// updateRenameReferences sets the referencing fields of refs to newKey
+// inside tx.
+func updateRenameReferences(ctx context.Context, tx dal.ReadwriteTransaction, refs []renameReference, newKey string) error {
+	columns := make(map[recordRef][]string)
+	for _, ref := range refs {
+		columns[ref.recordRef] = append(columns[ref.recordRef], ref.column)
+	}
+	records := slices.SortedFunc(maps.Keys(columns), func(a, b recordRef) int {
+		return cmp.Or(cmp.Compare(a.collection, b.collection), cmp.Compare(a.key, b.key))
+	})
+	for _, ref := range records {
+		data := map[string]any{}
+		rec := record.NewRecordWithData(record.NewKeyWithID(ref.collection, ref.key), data)
+		if err := tx.Get(ctx, rec); err != nil {
+			return fmt.Errorf("failed to read record %q from collection %s: %w", ref.key, ref.collection, err)
+		}
+		for _, column := range columns[ref] {
+			data[column] = newKey
+		}
+		if err := tx.Set(ctx, rec); err != nil {
+			return fmt.Errorf("failed to update record %q in collection %s: %w", ref.key, ref.collection, err)
+		}
+	}
+	return nil
+}
+
+// buildRenameViews rebuilds the views of the source and destination
+// collections and of every collection whose references were updated.
+func buildRenameViews(ctx context.Context, src, dst recordContext, refs []renameReference) error {
+	if err := buildLocalViews(ctx, src); err != nil {
+		return err
+	}
+	if dst.colDef != src.colDef {
+		if err := buildLocalViews(ctx, dst); err != nil {
+			return err
+		}
+	}
+	seen := map[string]bool{src.colDef.ID: true, dst.colDef.ID: true}
+	for _, ref := range refs {
+		if seen[ref.collection] {
+			continue
+		}
+		seen[ref.collection] = true
+		child := src
+		child.colDef = src.def.Collections[ref.collection]
+		if err := buildLocalViews(ctx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
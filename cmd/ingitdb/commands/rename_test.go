@@ -0,0 +1,212 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dal-go/dalgo/dal"
+	"github.com/ingitdb/dalgo2ingitdb4local"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// renameTestDefinition is fkTestDefinition with a towns collection that
+// declares only name, so cities do not fit it.
+func renameTestDefinition(dir string) *ingitdb.Definition {
+	def := fkTestDefinition(dir)
+	def.Collections["towns"] = &ingitdb.CollectionDef{
+		ID:         "towns",
+		DirPath:    filepath.Join(dir, "towns"),
+		Columns:    map[string]*ingitdb.ColumnDef{"name": {Type: ingitdb.ColumnTypeString}},
+		RecordFile: &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: "yaml", RecordType: ingitdb.SingleRecord},
+	}
+	return def
+}
+
+// runRenameCmd invokes the Rename command over renameTestDefinition at dir
+// and returns captured stdout + any error.
+func runRenameCmd(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	return runRenameCmdWithDef(t, dir, renameTestDefinition(dir), args...)
+}
+
+// runRenameCmdWithDef invokes the Rename command over def at dir.
+func runRenameCmdWithDef(t *testing.T, dir string, def *ingitdb.Definition, args ...string) (string, error) {
+	t.Helper()
+	cmd := Rename(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return def, nil },
+		func(root string, d *ingitdb.Definition) (dal.DB, error) {
+			return dalgo2fsingitdb.NewLocalDBWithDef(root, d)
+		},
+		func(...any) {},
+	)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs(append([]string{"--path=" + dir}, args...))
+	err := cmd.Execute()
+	return buf.String(), err
+}
+
+func TestRename_MovesFileAndUpdatesReferences(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	initGitRepo(t, dir)
+	disableGitBackgroundMaintenance(t, dir)
+	seedFKTestRecords(t, dir)
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-m", "seed")
+
+	if _, err := runRenameCmd(t, dir, "--id=countries/ie", "--to=irl"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "countries", "$records", "ie.yaml")); !os.IsNotExist(err) {
+		t.Errorf("the old record file must be gone: %v", err)
+	}
+	status := string(runGit(t, dir, "status", "--porcelain"))
+	if !strings.Contains(status, "R  countries/$records/ie.yaml -> countries/$records/irl.yaml") {
+		t.Errorf("expected a staged rename, got:\n%s", status)
+	}
+	for _, city := range []string{"dublin", "cork"} {
+		content, err := os.ReadFile(filepath.Join(dir, "cities", "$records", city+".yaml"))
+		if err != nil || !strings.Contains(string(content), "country: irl") {
+			t.Errorf("city %s must reference irl: %v\n%s", city, err, content)
+		}
+	}
+}
+
+func TestRename_RejectsTakenKey(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+
+	_, err := runRenameCmd(t, dir, "--id=countries/ie", "--to=fr")
+	if err == nil || !strings.Contains(err.Error(), `record "fr" already exists in collection countries`) {
+		t.Fatalf("expected a taken key error, got %v", err)
+	}
+	for _, key := range []string{"ie", "fr"} {
+		if _, statErr := os.Stat(filepath.Join(dir, "countries", "$records", key+".yaml")); statErr != nil {
+			t.Errorf("record %s must be kept: %v", key, statErr)
+		}
+	}
+}
+
+func TestRename_ToOtherCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+
+	_, err := runRenameCmd(t, dir, "--id=cities/cork", "--to=towns/cork")
+	if err == nil || !strings.Contains(err.Error(), `it does not declare column "country"`) {
+		t.Errorf("expected an incompatible schema error, got %v", err)
+	}
+	_, err = runRenameCmd(t, dir, "--id=countries/ie", "--to=towns/ie")
+	if err == nil || !strings.Contains(err.Error(), `record "cork" in collection cities references it`) {
+		t.Errorf("expected a referenced record error, got %v", err)
+	}
+
+	if _, err = runRenameCmd(t, dir, "--id=countries/fr", "--to=towns/paris"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "towns", "$records", "paris.yaml"))
+	if err != nil || !strings.Contains(string(content), "name: France") {
+		t.Errorf("moved record: %v\n%s", err, content)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "countries", "$records", "fr.yaml")); !os.IsNotExist(err) {
+		t.Errorf("the old record file must be gone: %v", err)
+	}
+}
+
+func TestMoveRenamedEntry_RemovesInsertedRecordOnFailure(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	def := renameTestDefinition(dir)
+	openDB := func() dal.DB {
+		db, err := dalgo2fsingitdb.NewLocalDBWithDef(dir, def)
+		if err != nil {
+			t.Fatalf("open db: %v", err)
+		}
+		return db
+	}
+	src := recordContext{db: openDB(), colDef: def.Collections["countries"], recordKey: "fr", dirPath: dir, def: def}
+	dst := recordContext{db: openDB(), colDef: def.Collections["towns"], recordKey: "paris", dirPath: dir, def: def}
+	// A reference to a record that does not exist fails the second step.
+	refs := []renameReference{{recordRef: recordRef{collection: "cities", key: "lyon"}, column: "country"}}
+
+	err := moveRenamedEntry(context.Background(), src, dst, map[string]any{"name": "France"}, refs)
+	if err == nil || !strings.Contains(err.Error(), `record "lyon"`) {
+		t.Fatalf("expected the reference update to fail, got %v", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(dir, "towns", "$records", "paris.yaml")); !os.IsNotExist(statErr) {
+		t.Errorf("the inserted record must be removed again: %v", statErr)
+	}
+}
+
+func TestRename_ListRow(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	def := renameTestDefinition(dir)
+	countries := def.Collections["countries"]
+	countries.Columns["id"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	countries.ColumnsOrder = []string{"id", "name"}
+	countries.RecordFile = &ingitdb.RecordFileDef{Name: "countries.yaml", Format: "yaml", RecordType: ingitdb.ListOfRecords}
+	writeFixTestFile(t, dir, "countries/countries.yaml", "- id: ie\n  name: Ireland\n- id: fr\n  name: France\n")
+
+	if _, err := runRenameCmdWithDef(t, dir, def, "--id=countries/ie", "--to=irl"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "countries", "countries.yaml"))
+	if want := "- id: irl\n  name: Ireland\n- id: fr\n  name: France\n"; err != nil || string(content) != want {
+		t.Errorf("countries.yaml: %v\n%s\nwant:\n%s", err, content, want)
+	}
+	cork, err := os.ReadFile(filepath.Join(dir, "cities", "$records", "cork.yaml"))
+	if err != nil || !strings.Contains(string(cork), "country: irl") {
+		t.Errorf("cork must reference irl: %v\n%s", err, cork)
+	}
+
+	_, err = runRenameCmdWithDef(t, dir, def, "--id=countries/fr", "--to=towns/paris")
+	if err == nil || !strings.Contains(err.Error(), "rows of a list file") {
+		t.Errorf("expected a list move error, got %v", err)
+	}
+}
+
+func TestRename_UpdatesReferencesInListCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	seedFKTestRecords(t, dir)
+	def := renameTestDefinition(dir)
+	streets := def.Collections["streets"]
+	streets.Columns["id"] = &ingitdb.ColumnDef{Type: ingitdb.ColumnTypeString}
+	streets.ColumnsOrder = []string{"id", "name", "city"}
+	streets.RecordFile = &ingitdb.RecordFileDef{Name: "streets.yaml", Format: "yaml", RecordType: ingitdb.ListOfRecords}
+	streetsFile := writeFixTestFile(t, dir, "streets/streets.yaml",
+		"- id: oconnell\n  name: O'Connell Street\n  city: dublin\n- id: patrick\n  name: Patrick Street\n  city: cork\n")
+
+	if _, err := runRenameCmdWithDef(t, dir, def, "--id=cities/dublin", "--to=baile"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	want := "- id: oconnell\n  name: O'Connell Street\n  city: baile\n- id: patrick\n  name: Patrick Street\n  city: cork\n"
+	if got := readFixTestFile(t, streetsFile); got != want {
+		t.Errorf("streets.yaml:\n got %q\nwant %q", got, want)
+	}
+
+	// A reference that is also the row's own key cannot be rewritten.
+	streets.PrimaryKey = []string{"city"}
+	_, err := runRenameCmdWithDef(t, dir, def, "--id=cities/cork", "--to=corcaigh")
+	if err == nil || !strings.Contains(err.Error(), `resolve to key "corcaigh"`) {
+		t.Fatalf("expected a key-change error, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "cities", "$records", "cork.yaml")); err != nil {
+		t.Errorf("a failed rename must move the record back: %v", err)
+	}
+	if got := readFixTestFile(t, streetsFile); got != want {
+		t.Errorf("a failed rename must leave streets.yaml alone:\n%s", got)
+	}
+}
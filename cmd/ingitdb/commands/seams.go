@@ -7,6 +7,8 @@ import (
 
 	"github.com/ingitdb/dalgo2ingitdb4github"
 	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/datavalidator"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitdiff"
 	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
 )
 
@@ -53,6 +55,15 @@ var (
 
 	// treeWriterFactory is the factory for creating atomic multi-file writers.
 	treeWriterFactory TreeWriterFactory = &defaultTreeWriterFactory{}
+
+	// gitDiffer lists the files changed since a ref for incremental materialization.
+	gitDiffer = gitdiff.NewGitDiffer()
+
+	// changeSetResolver maps changed files to the collection records they affect.
+	changeSetResolver = datavalidator.NewChangeSetResolver()
+
+	// gitMergeBase returns the merge-base of HEAD and a base branch.
+	gitMergeBase = gitMergeBaseCmd
 )
 
 // defaultGitHubDBFactory is the default implementation of GitHubDBFactory.
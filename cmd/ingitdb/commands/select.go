@@ -170,7 +170,19 @@ func runSelectFromSet(
 		if dbErr != nil {
 			return fmt.Errorf("failed to open remote database: %w", dbErr)
 		}
-		return runSelectFromSetWithDB(ctx, cmd, from, fields, format, db, def.Collections[from])
+		return runSelectFromSetWithDB(ctx, cmd, fields, format, []selectSource{{from: from, db: db, colDef: def.Collections[from]}})
+	}
+
+	if isSubCollectionPath(from) {
+		ictxs, err := resolveSubCollectionContexts(cmd, from, homeDir, getWd, readDefinition, newDB)
+		if err != nil {
+			return err
+		}
+		sources := make([]selectSource, len(ictxs))
+		for i, ictx := range ictxs {
+			sources[i] = selectSource{from: ictx.colDef.ID, db: ictx.db, colDef: ictx.colDef, parentFields: ictx.parentFields}
+		}
+		return runSelectFromSetWithDB(ctx, cmd, fields, format, sources)
 	}
 
 	dirPath, err := resolveDBPath(cmd, homeDir, getWd)
@@ -188,19 +200,29 @@ func runSelectFromSet(
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
-	return runSelectFromSetWithDB(ctx, cmd, from, fields, format, db, def.Collections[from])
+	return runSelectFromSetWithDB(ctx, cmd, fields, format, []selectSource{{from: from, db: db, colDef: def.Collections[from]}})
 }
 
-// runSelectFromSetWithDB executes the set-mode query against a pre-opened DB.
-// Both local and GitHub paths call this after resolving their respective DBs.
+// selectSource is one collection a set-mode select reads: the collection
+// named by --from, or one instance a subcollection path matched.
+type selectSource struct {
+	from   string
+	db     dal.DB
+	colDef *ingitdb.CollectionDef
+	// parentFields are added to every row of the source before --where is
+	// evaluated.
+	parentFields map[string]any
+}
+
+// runSelectFromSetWithDB executes the set-mode query against pre-opened DBs.
+// Both local and GitHub paths call this after resolving their respective DBs;
+// the rows of every source are ordered and limited together.
 func runSelectFromSetWithDB(
 	ctx context.Context,
 	cmd *cobra.Command,
-	from string,
 	fields []string,
 	format string,
-	db dal.DB,
-	colDef *ingitdb.CollectionDef,
+	sources []selectSource,
 ) error {
 	whereExprs, _ := cmd.Flags().GetStringArray("where")
 	conds := make([]sqlflags.Condition, 0, len(whereExprs))
@@ -212,38 +234,13 @@ func runSelectFromSetWithDB(
 		conds = append(conds, c)
 	}
 
-	q := newQueryForCollection(from)
-
 	var rows []map[string]any
-	err := db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
-		reader, qerr := tx.ExecuteQueryToRecordsetReader(ctx, q)
-		if qerr != nil {
-			return qerr
-		}
-		defer func() { _ = reader.Close() }()
-		var names []string
-		for {
-			row, rs, nextErr := reader.Next()
-			if nextErr != nil {
-				break
-			}
-			if names == nil {
-				names = selectColumnsToRead(rs, fields, conds)
-			}
-			recKey := dalgo2ingitdb.RowKey(row, rs)
-			data, derr := dalgo2ingitdb.RowData(row, rs, from, recKey, colDef, names)
-			if derr != nil {
-				return derr
-			}
-			if match, _ := evalAllWhere(data, recKey, conds); !match {
-				continue
-			}
-			rows = append(rows, projectRecord(data, recKey, fields))
+	for _, src := range sources {
+		srcRows, err := readSelectRows(ctx, src.db, src.from, src.colDef, fields, conds, src.parentFields)
+		if err != nil {
+			return err
 		}
-		return nil
-	})
-	if err != nil {
-		return fmt.Errorf("query failed: %w", err)
+		rows = append(rows, srcRows...)
 	}
 
 	// --order-by: sort the result slice after filtering.
@@ -290,6 +287,55 @@ func runSelectFromSetWithDB(
 	return writeSetMode(cmd.OutOrStdout(), rows, format, fields)
 }
 
+// readSelectRows reads the records of collection from that match conds,
+// projected onto fields. parentFields are added to every record first, so
+// conds and fields can refer to them.
+func readSelectRows(
+	ctx context.Context,
+	db dal.DB,
+	from string,
+	colDef *ingitdb.CollectionDef,
+	fields []string,
+	conds []sqlflags.Condition,
+	parentFields map[string]any,
+) ([]map[string]any, error) {
+	q := newQueryForCollection(from)
+
+	var rows []map[string]any
+	err := db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
+		reader, qerr := tx.ExecuteQueryToRecordsetReader(ctx, q)
+		if qerr != nil {
+			return qerr
+		}
+		defer func() { _ = reader.Close() }()
+		var names []string
+		for {
+			row, rs, nextErr := reader.Next()
+			if nextErr != nil {
+				break
+			}
+			if names == nil {
+				names = selectColumnsToRead(rs, fields, conds)
+			}
+			recKey := dalgo2ingitdb.RowKey(row, rs)
+			data, derr := dalgo2ingitdb.RowData(row, rs, from, recKey, colDef, names)
+			if derr != nil {
+				return derr
+			}
+			data = withParentFields(data, parentFields)
+			if match, _ := evalAllWhere(data, recKey, conds); !match {
+				continue
+			}
+			rows = append(rows, projectRecord(data, recKey, fields))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return rows, nil
+}
+
 // selectColumnsToRead returns the recordset columns select must read per row:
 // the projected columns (every column when --fields is empty) plus any column a
 // --where condition references. Only columns that exist in the recordset are
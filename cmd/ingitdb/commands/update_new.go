@@ -133,6 +133,9 @@ func runUpdateByID(
 	if err != nil {
 		return err
 	}
+	if isLocalListCollection(rctx.dirPath, rctx.colDef) {
+		return updateListRecordByID(ctx, rctx, id, sets, unsets)
+	}
 
 	key := record.NewKeyWithID(rctx.colDef.ID, rctx.recordKey)
 	err = rctx.db.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
@@ -145,6 +148,13 @@ func runUpdateByID(
 			return fmt.Errorf("record not found: %s", id)
 		}
 		applyPatch(data, sets, unsets)
+		writes := map[string]map[string]any{rctx.recordKey: data}
+		if applyErr := applyColumnDefaults(ctx, rctx.dirPath, rctx.colDef, writes, false); applyErr != nil {
+			return applyErr
+		}
+		if checkErr := checkLocalWrite(rctx.dirPath, rctx.def, rctx.colDef, writes); checkErr != nil {
+			return checkErr
+		}
 		return tx.Set(ctx, rec)
 	})
 	if err != nil {
@@ -153,6 +163,37 @@ func runUpdateByID(
 	return buildLocalViews(ctx, rctx)
 }
 
+// updateListRecordByID is the --id mode of a local list collection: the
+// row is patched in the collection's list file.
+func updateListRecordByID(ctx context.Context, rctx recordContext, id string, sets []sqlflags.Assignment, unsets []string) error {
+	_, _, records, err := readListFile(rctx.colDef)
+	if err != nil {
+		return err
+	}
+	var data map[string]any
+	for _, r := range records {
+		if r.Key == rctx.recordKey {
+			data = r.Fields
+			break
+		}
+	}
+	if data == nil {
+		return fmt.Errorf("record not found: %s", id)
+	}
+	applyPatch(data, sets, unsets)
+	writes := map[string]map[string]any{rctx.recordKey: data}
+	if err = applyColumnDefaults(ctx, rctx.dirPath, rctx.colDef, writes, false); err != nil {
+		return err
+	}
+	if err = checkLocalWrite(rctx.dirPath, rctx.def, rctx.colDef, writes); err != nil {
+		return err
+	}
+	if err = patchListRecords(rctx.colDef, writes); err != nil {
+		return err
+	}
+	return buildLocalViews(ctx, rctx)
+}
+
 // parseSetExprs converts the raw --set strings into Assignment values
 // via sqlflags.ParseSet. Returns the first parse error.
 func parseSetExprs(exprs []string) ([]sqlflags.Assignment, error) {
@@ -240,16 +281,74 @@ func runUpdateFromSet(
 		conds = append(conds, c)
 	}
 
-	// Resolve collection (local or GitHub).
-	ictx, err := resolveInsertContext(ctx, cmd, from, homeDir, getWd, readDefinition, newDB)
+	// Resolve the collection (local or GitHub), or every instance a
+	// subcollection path matches.
+	ictxs, err := resolveCollectionContexts(ctx, cmd, from, homeDir, getWd, readDefinition, newDB)
 	if err != nil {
 		return err
 	}
 
 	// Fetch matching records via a read-only pass.
+	matches := make([][]patchTarget, len(ictxs))
+	matched := 0
+	for i, ictx := range ictxs {
+		if matches[i], err = readPatchTargets(ctx, ictx, conds, allFlag); err != nil {
+			return err
+		}
+		matched += len(matches[i])
+	}
+
+	// --min-affected pre-flight check. If the matched count is below
+	// the threshold, fail BEFORE opening the write transaction.
+	if n, supplied, mErr := sqlflags.MinAffectedFromCmd(cmd); mErr != nil {
+		return mErr
+	} else if supplied && matched < n {
+		return fmt.Errorf("matched %d records, required at least %d", matched, n)
+	}
+
+	// Apply the patches up front so the integrity checks see the final
+	// values of every matched record before anything is written.
+	checker := newWriteChecker()
+	for i, ictx := range ictxs {
+		writes := make(map[string]map[string]any, len(matches[i]))
+		for _, m := range matches[i] {
+			applyPatch(m.data, sets, unsets)
+			writes[m.key] = m.data
+		}
+		if err = applyColumnDefaults(ctx, ictx.dirPath, ictx.colDef, writes, false); err != nil {
+			return err
+		}
+		if err = checker.check(ictx.dirPath, ictx.def, ictx.colDef, writes); err != nil {
+			return err
+		}
+	}
+
+	for i, ictx := range ictxs {
+		if err = writePatchTargets(ctx, cmd, ictx, matches[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPatchTargets returns the stored fields of every record of the
+// collection that matches conds, or of every record when all is set.
+func readPatchTargets(ctx context.Context, ictx insertContext, conds []sqlflags.Condition, all bool) ([]patchTarget, error) {
+	if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+		rows, err := matchListRecords(ictx, conds, all)
+		if err != nil {
+			return nil, err
+		}
+		matches := make([]patchTarget, len(rows))
+		for i, r := range rows {
+			matches[i] = patchTarget{key: r.Key, data: r.Fields}
+		}
+		return matches, nil
+	}
+	from := ictx.colDef.ID
 	q := newQueryForCollection(from)
 	var matches []patchTarget
-	err = ictx.db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
+	err := ictx.db.RunReadonlyTransaction(ctx, func(ctx context.Context, tx dal.ReadTransaction) error {
 		reader, qerr := tx.ExecuteQueryToRecordsetReader(ctx, q)
 		if qerr != nil {
 			return qerr
@@ -284,13 +383,14 @@ func runUpdateFromSet(
 			if derr != nil {
 				return derr
 			}
-			if !allFlag {
-				if matched, _ := evalAllWhere(data, recKey, conds); !matched {
+			if !all {
+				if matched, _ := evalAllWhere(withParentFields(data, ictx.parentFields), recKey, conds); !matched {
 					continue
 				}
 			}
-			// Write-back persists stored fields only — computed columns are never
-			// stored (the write path rejects them).
+			// Write-back persists stored fields only — computed columns and
+			// parent key pseudo-fields are never stored (the write path
+			// rejects them).
 			storedData := make(map[string]any, len(data))
 			for k, v := range data {
 				if storedSet[k] {
@@ -302,15 +402,25 @@ func runUpdateFromSet(
 		return nil
 	})
 	if err != nil {
-		return fmt.Errorf("query failed: %w", err)
+		return nil, fmt.Errorf("query failed: %w", err)
 	}
+	return matches, nil
+}
 
-	// --min-affected pre-flight check. If the matched count is below
-	// the threshold, fail BEFORE opening the write transaction.
-	if n, supplied, mErr := sqlflags.MinAffectedFromCmd(cmd); mErr != nil {
-		return mErr
-	} else if supplied && len(matches) < n {
-		return fmt.Errorf("matched %d records, required at least %d", len(matches), n)
+// writePatchTargets writes the patched records of one collection in a
+// single read-write transaction, or in one write of its list file, and
+// materializes its local views.
+func writePatchTargets(ctx context.Context, cmd *cobra.Command, ictx insertContext, matches []patchTarget) error {
+	from := ictx.colDef.ID
+	if isLocalListCollection(ictx.dirPath, ictx.colDef) {
+		writes := make(map[string]map[string]any, len(matches))
+		for _, m := range matches {
+			writes[m.key] = m.data
+		}
+		if err := patchListRecords(ictx.colDef, writes); err != nil {
+			return err
+		}
+		return buildLocalViews(ctx, ictx.toRecordContext())
 	}
 
 	// For --remote, wrap the db with a batching variant so the worker's
@@ -319,10 +429,8 @@ func runUpdateFromSet(
 	writeDB, _ := maybeWrapWithBatching(cmd, ictx.db, ictx.def,
 		fmt.Sprintf("ingitdb: update %s (batch)", from))
 
-	// Apply patches in a single read-write transaction.
-	err = writeDB.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
+	err := writeDB.RunReadwriteTransaction(ctx, func(ctx context.Context, tx dal.ReadwriteTransaction) error {
 		for _, m := range matches {
-			applyPatch(m.data, sets, unsets)
 			key := record.NewKeyWithID(from, m.key)
 			record := record.NewRecordWithData(key, m.data)
 			if setErr := tx.Set(ctx, record); setErr != nil {
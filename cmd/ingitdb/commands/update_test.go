@@ -315,6 +315,33 @@ func TestUpdate_SetMode_All(t *testing.T) {
 	}
 }
 
+func TestUpdate_UniqueViolationWritesNothing(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := updateTestDeps(t, dir)
+	writeFixTestFile(t, dir, ".collection/constraints.yaml", "columns:\n  name:\n    unique: true\n")
+	seedItem(t, dir, "a", map[string]any{"name": "A"})
+	seedItem(t, dir, "b", map[string]any{"name": "B"})
+
+	_, err := runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.items/b", "--set=name=A",
+	)
+	if err == nil || !strings.Contains(err.Error(), `name="A" is already used by record "a"`) {
+		t.Fatalf("single-record mode: expected a unique violation, got %v", err)
+	}
+	_, err = runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--from=test.items", "--all", "--set=name=Same",
+	)
+	if err == nil || !strings.Contains(err.Error(), "duplicate value for unique key name") {
+		t.Fatalf("set mode: expected a unique violation, got %v", err)
+	}
+	for key, want := range map[string]string{"a": "name: A", "b": "name: B"} {
+		if got := readItem(t, dir, key); !strings.Contains(got, want) {
+			t.Errorf("record %s MUST NOT be modified, got:\n%s", key, got)
+		}
+	}
+}
+
 func TestUpdate_SetMode_WhereAndAllMutuallyExclusive(t *testing.T) {
 	t.Parallel()
 	dir := t.TempDir()
@@ -518,3 +545,43 @@ func TestUpdate_MinAffected_WithAll(t *testing.T) {
 		t.Fatal("expected error when collection size (1) < threshold (4)")
 	}
 }
+
+func TestUpdate_ListCollection(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	homeDir, getWd, readDef, newDB, logf := listCmdDeps(t, dir,
+		"- id: ie\n  name: Ireland\n  region: west\n- id: fr\n  name: France\n  region: west\n- id: pl\n  name: Poland\n  region: east\n")
+
+	_, err := runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--from=test.countries", "--where=region==west", "--set=region=europe",
+	)
+	if err != nil {
+		t.Fatalf("set mode: %v", err)
+	}
+	_, err = runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.countries/pl", "--set=name=Polska", "--unset=region",
+	)
+	if err != nil {
+		t.Fatalf("--id mode: %v", err)
+	}
+	want := "- id: ie\n  name: Ireland\n  region: europe\n- id: fr\n  name: France\n  region: europe\n- id: pl\n  name: Polska\n"
+	if got := readListFixture(t, dir); got != want {
+		t.Errorf("countries.yaml:\n%s\nwant:\n%s", got, want)
+	}
+
+	_, err = runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.countries/de", "--set=name=Germany",
+	)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected a not-found error, got %v", err)
+	}
+	_, err = runUpdateCmd(t, homeDir, getWd, readDef, newDB, logf,
+		"--path="+dir, "--id=test.countries/ie", "--set=id=eire",
+	)
+	if err == nil || !strings.Contains(err.Error(), `resolve to key "eire"`) {
+		t.Errorf("expected a key-change error, got %v", err)
+	}
+	if got := readListFixture(t, dir); got != want {
+		t.Errorf("a failed update changed countries.yaml:\n%s", got)
+	}
+}
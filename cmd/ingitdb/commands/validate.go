@@ -48,21 +48,81 @@ func Validate(
 				return fmt.Errorf("invalid --only value: %q (must be \"definition\", \"records\", or empty)", onlyVal)
 			}
 
+			format, _ := cmd.Flags().GetString("format")
+			switch format {
+			case "", "text":
+				format = "text"
+			case "json", "sarif", "junit", "github":
+			default:
+				return fmt.Errorf("invalid --format=%q (must be text, json, sarif, junit, or github)", format)
+			}
+			// report renders the findings in a machine-readable format and
+			// turns them into the command's exit status.
+			report := func(def *ingitdb.Definition, result *ingitdb.ValidationResult, defErr error) error {
+				findings := validationFindings(dirPath, result, defErr)
+				if err := writeValidationReport(cmd.OutOrStdout(), format, def, findings); err != nil {
+					return err
+				}
+				if len(findings) > 0 {
+					return fmt.Errorf("validation found %d error(s)", len(findings))
+				}
+				return nil
+			}
+
 			fromCommit, _ := cmd.Flags().GetString("from-commit")
 			toCommit, _ := cmd.Flags().GetString("to-commit")
 
+			fix, _ := cmd.Flags().GetBool("fix")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if dryRun && !fix {
+				return fmt.Errorf("--dry-run requires --fix")
+			}
+			// valPath is the database validation reads: a repaired temporary
+			// copy with --fix --dry-run, the database itself otherwise.
+			valPath := dirPath
+			pendingRepairs := 0
+			if fix {
+				if fromCommit != "" || toCommit != "" {
+					return fmt.Errorf("--fix cannot be combined with --from-commit/--to-commit")
+				}
+				if onlyVal == "definition" {
+					return fmt.Errorf("--fix repairs records and cannot be combined with --only=definition")
+				}
+				fixPath, repairs, cleanup, fixErr := prepareFix(dirPath, dryRun, readDefinition, logf)
+				defer cleanup()
+				if fixErr != nil {
+					return fixErr
+				}
+				valPath = fixPath
+				if dryRun {
+					pendingRepairs = len(repairs)
+				}
+			}
+
 			if fromCommit != "" || toCommit != "" {
 				if incVal == nil {
 					return fmt.Errorf("incremental validation (--from-commit/--to-commit) is not yet implemented")
 				}
 				def, defErr := readDefinition(dirPath)
 				if defErr != nil {
+					if format != "text" {
+						return report(nil, nil, defErr)
+					}
 					return fmt.Errorf("failed to read database definition: %w", defErr)
 				}
 				result, valErr := incVal.ValidateChanges(ctx, dirPath, def, fromCommit, toCommit)
 				if valErr != nil {
 					return fmt.Errorf("incremental validation failed: %w", valErr)
 				}
+				// A changed record can collide with an unchanged one, and a
+				// deleted record can orphan unchanged children, so unique and
+				// foreign keys are checked across whole collections.
+				if result, err = appendIntegrityViolations(result, def, true); err != nil {
+					return err
+				}
+				if format != "text" {
+					return report(def, result, nil)
+				}
 				if result.HasErrors() {
 					message := formatValidationFailure("incremental validation", result)
 					return fmt.Errorf("%s", message)
@@ -78,26 +138,38 @@ func Validate(
 			var def *ingitdb.Definition
 			if shouldValidateDef {
 				validateOpt := ingitdb.Validate()
-				defRes, defErr := readDefinition(dirPath, validateOpt)
+				defRes, defErr := readDefinition(valPath, validateOpt)
 				if defErr != nil {
+					if format != "text" {
+						return report(nil, nil, defErr)
+					}
 					return fmt.Errorf("inGitDB database validation failed: %w", defErr)
 				}
 				def = defRes
 			} else {
-				defRes, defErr := readDefinition(dirPath)
+				defRes, defErr := readDefinition(valPath)
 				if defErr != nil {
+					if format != "text" {
+						return report(nil, nil, defErr)
+					}
 					return fmt.Errorf("inGitDB database validation failed: %w", defErr)
 				}
 				def = defRes
 			}
 
 			// Validate records if needed
+			var result *ingitdb.ValidationResult
 			if shouldValidateRecords && dataVal != nil {
-				result, valErr := dataVal.Validate(ctx, dirPath, def)
+				var valErr error
+				result, valErr = dataVal.Validate(ctx, valPath, def)
 				if valErr != nil {
 					return fmt.Errorf("data validation failed: %w", valErr)
 				}
-				if result.HasErrors() {
+				if result, err = appendIntegrityViolations(result, def, false); err != nil {
+					return err
+				}
+				result = relocateValidationResult(result, def, valPath, dirPath)
+				if result.HasErrors() && format == "text" {
 					message := formatValidationFailure("data validation", result)
 					return fmt.Errorf("%s", message)
 				}
@@ -111,6 +183,14 @@ func Validate(
 					}
 				}
 			}
+			if format != "text" {
+				if err := report(def, result, nil); err != nil {
+					return err
+				}
+			}
+			if pendingRepairs > 0 {
+				return fmt.Errorf("%d issue(s) can be fixed automatically; run `ingitdb validate --fix`", pendingRepairs)
+			}
 			return nil
 		},
 	}
@@ -118,9 +198,55 @@ func Validate(
 	cmd.Flags().String("from-commit", "", "validate only records changed since this commit")
 	cmd.Flags().String("to-commit", "", "validate only records up to this commit")
 	cmd.Flags().String("only", "", `validate only "definition" or "records" (default: both)`)
+	cmd.Flags().String("format", "text", "report format: text, json, sarif, junit, or github")
+	cmd.Flags().Bool("fix", false, "repair mechanically fixable record violations before validating")
+	cmd.Flags().Bool("dry-run", false, "with --fix: report the repairs without writing them")
 	return cmd
 }
 
+// appendIntegrityViolations adds the CLI-level integrity findings of the
+// collections in def to result, allocating result when it is nil: unique-key
+// violations, list rows sharing a record key and, with withOrphans, foreign
+// keys referencing missing records. The record validator checks foreign keys
+// itself in a full pass, but not in an incremental one. Findings already in
+// result are not repeated.
+func appendIntegrityViolations(result *ingitdb.ValidationResult, def *ingitdb.Definition, withOrphans bool) (*ingitdb.ValidationResult, error) {
+	violations, err := validateUniqueConstraints(def)
+	if err != nil {
+		return result, fmt.Errorf("unique constraint validation failed: %w", err)
+	}
+	duplicates, err := validateListRecordKeys(def)
+	if err != nil {
+		return result, fmt.Errorf("list record key validation failed: %w", err)
+	}
+	violations = append(violations, duplicates...)
+	if withOrphans {
+		orphans, orphanErr := validateForeignKeyOrphans(def)
+		if orphanErr != nil {
+			return result, fmt.Errorf("foreign key validation failed: %w", orphanErr)
+		}
+		violations = append(violations, orphans...)
+	}
+	if len(violations) == 0 {
+		return result, nil
+	}
+	if result == nil {
+		result = &ingitdb.ValidationResult{}
+	}
+	type findingID struct{ collection, key, field, message string }
+	seen := make(map[findingID]bool)
+	for _, ve := range result.Errors() {
+		seen[findingID{ve.CollectionID, ve.RecordKey, ve.FieldName, ve.Message}] = true
+	}
+	for _, v := range violations {
+		if id := (findingID{v.CollectionID, v.RecordKey, v.FieldName, v.Message}); !seen[id] {
+			seen[id] = true
+			result.Append(v)
+		}
+	}
+	return result, nil
+}
+
 func formatValidationFailure(prefix string, result *ingitdb.ValidationResult) string {
 	details := formatValidationErrors(result.Errors())
 	if details == "" {
@@ -0,0 +1,727 @@
+package commands
+
+// specscore: feature/cli/validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/markdown"
+)
+
+// recordRepair is one change `validate --fix` made (or, with --dry-run, would
+// make) to a record file.
+type recordRepair struct {
+	File      string
+	RecordKey string
+	Rule      string
+	Change    string
+}
+
+// fixRecord is a record loaded for repair. order is the field order found in
+// the file, or nil for formats whose encoder fixes the order itself.
+type fixRecord struct {
+	key    string
+	fields map[string]any
+	order  []string
+	// node is the record's mapping in a YAML or JSON file and nodes its key
+	// and value node per field, so a YAML file is written back by editing
+	// the parsed document. keyNode is the record key of a map-of-records
+	// file.
+	node    *yaml.Node
+	nodes   map[string][2]*yaml.Node
+	keyNode *yaml.Node
+}
+
+// rename moves a field to a new name, keeping its position.
+func (r *fixRecord) rename(from, to string) {
+	r.fields[to] = r.fields[from]
+	delete(r.fields, from)
+	if i := slices.Index(r.order, from); i >= 0 {
+		r.order[i] = to
+	}
+	if pair, ok := r.nodes[from]; ok {
+		delete(r.nodes, from)
+		pair[0].Value, pair[0].Style = to, 0
+		r.nodes[to] = pair
+	}
+}
+
+// recordRepairRule is a safe, mechanical repair. It edits rec in place and
+// returns a description of every change it made.
+type recordRepairRule struct {
+	id     string
+	repair func(col *ingitdb.CollectionDef, rec *fixRecord) []string
+}
+
+// recordRepairRules run in order: renames come first so later rules see the
+// declared column names, and reordering comes last so it places filled-in
+// defaults too.
+var recordRepairRules = []recordRepairRule{
+	{"key-whitespace", trimFieldNames},
+	{"numeric-string", convertNumericStrings},
+	{"default", fillColumnDefaults},
+	{"key-order", reorderFields},
+}
+
+// trimFieldNames renames fields whose name only differs from a declared
+// column by surrounding whitespace, unless the column is already present.
+func trimFieldNames(col *ingitdb.CollectionDef, rec *fixRecord) []string {
+	var changes []string
+	for _, name := range sortedFieldNames(rec.fields) {
+		trimmed := strings.TrimSpace(name)
+		if trimmed == name {
+			continue
+		}
+		if _, declared := col.Columns[trimmed]; !declared {
+			continue
+		}
+		if _, taken := rec.fields[trimmed]; taken {
+			continue
+		}
+		rec.rename(name, trimmed)
+		changes = append(changes, fmt.Sprintf("renamed field %q to %q", name, trimmed))
+	}
+	return changes
+}
+
+// convertNumericStrings turns string values of int and float columns into
+// numbers when the whole string is a valid number of that type.
+func convertNumericStrings(col *ingitdb.CollectionDef, rec *fixRecord) []string {
+	var changes []string
+	for _, name := range sortedFieldNames(rec.fields) {
+		s, isString := rec.fields[name].(string)
+		colDef := col.Columns[name]
+		if !isString || colDef == nil {
+			continue
+		}
+		text := strings.TrimSpace(s)
+		switch colDef.Type {
+		case ingitdb.ColumnTypeInt:
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				continue
+			}
+			rec.fields[name] = n
+		case ingitdb.ColumnTypeFloat:
+			f, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				continue
+			}
+			rec.fields[name] = f
+		default:
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("converted field %q from string %q to %s", name, s, colDef.Type))
+	}
+	return changes
+}
+
+// fillColumnDefaults gives a record the constraints.yaml default of every
+// column it lacks, as insert would. Auto columns are left alone: a repair
+// cannot know when, or by whom, an existing record was created. Unreadable
+// constraints are left to the validation pass to report.
+func fillColumnDefaults(col *ingitdb.CollectionDef, rec *fixRecord) []string {
+	cons, err := readCollectionConstraints(col)
+	if err != nil || cons == nil {
+		return nil
+	}
+	var changes []string
+	for _, name := range slices.Sorted(maps.Keys(cons.Columns)) {
+		cc := cons.Columns[name]
+		if cc.Default == nil || cc.Auto != "" || rec.fields[name] != nil {
+			continue
+		}
+		if _, present := rec.fields[name]; !present && rec.order != nil {
+			rec.order = append(rec.order, name)
+		}
+		rec.fields[name] = cc.Default
+		changes = append(changes, fmt.Sprintf("set missing field %q to its default %v", name, cc.Default))
+	}
+	return changes
+}
+
+// reorderFields puts fields in columns_order order (then the rest
+// alphabetically), which is the order every record encoder writes.
+func reorderFields(col *ingitdb.CollectionDef, rec *fixRecord) []string {
+	if len(col.ColumnsOrder) == 0 || rec.order == nil {
+		return nil
+	}
+	want := canonicalFieldOrder(rec.fields, col.ColumnsOrder)
+	if slices.Equal(rec.order, want) {
+		return nil
+	}
+	rec.order = want
+	return []string{"reordered fields to follow columns_order"}
+}
+
+// canonicalFieldOrder returns the keys of fields that appear in columnsOrder,
+// in that order, followed by the remaining keys alphabetically.
+func canonicalFieldOrder(fields map[string]any, columnsOrder []string) []string {
+	ordered := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, name := range columnsOrder {
+		if _, ok := fields[name]; ok && !seen[name] {
+			ordered = append(ordered, name)
+			seen[name] = true
+		}
+	}
+	var rest []string
+	for name := range fields {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(ordered, rest...)
+}
+
+func sortedFieldNames(fields map[string]any) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// repairRecords applies every repair rule to the records of all collections
+// in def, rewriting the files that change. Files that cannot be parsed and
+// record formats without a repair path (csv, ingr lists) are left alone; the
+// validation pass that follows reports them.
+func repairRecords(def *ingitdb.Definition) ([]recordRepair, error) {
+	cols := eachCollection(def.Collections)
+	sort.Slice(cols, func(i, j int) bool { return cols[i].DirPath < cols[j].DirPath })
+	var repairs []recordRepair
+	for _, col := range cols {
+		files, err := collectionRecordFiles(col)
+		if err != nil {
+			return repairs, err
+		}
+		for _, path := range files {
+			fileRepairs, repairErr := repairRecordFile(col, path)
+			if repairErr != nil {
+				return repairs, repairErr
+			}
+			repairs = append(repairs, fileRepairs...)
+		}
+	}
+	return repairs, nil
+}
+
+// collectionRecordFiles lists the existing record files of col.
+func collectionRecordFiles(col *ingitdb.CollectionDef) ([]string, error) {
+	rf := col.RecordFile
+	if rf == nil || rf.Format == "" || rf.RecordType == "" {
+		return nil, nil
+	}
+	baseDir := filepath.Join(col.DirPath, rf.RecordsBasePath())
+	if rf.RecordType != ingitdb.SingleRecord || !strings.Contains(rf.Name, "{key}") {
+		path := filepath.Join(baseDir, rf.Name)
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil
+		}
+		return []string{path}, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(baseDir, strings.ReplaceAll(rf.Name, "{key}", "*")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list records of collection %s: %w", col.ID, err)
+	}
+	var files []string
+	for _, path := range matches {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, ".") || rf.IsExcluded(name) {
+			continue
+		}
+		if info, statErr := os.Stat(path); statErr == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// repairRecordFile repairs the records of one file and rewrites it through
+// the collection's encoder when anything changed.
+func repairRecordFile(col *ingitdb.CollectionDef, path string) ([]recordRepair, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	doc, err := parseFixDocument(content, col, path)
+	if err != nil || doc == nil {
+		return nil, nil
+	}
+	var repairs []recordRepair
+	if col.RecordFile.RecordType == ingitdb.MapOfRecords {
+		repairs = append(repairs, trimRecordKeys(doc, path)...)
+	}
+	for _, rec := range doc.records {
+		for _, rule := range recordRepairRules {
+			for _, change := range rule.repair(col, rec) {
+				repairs = append(repairs, recordRepair{File: path, RecordKey: rec.key, Rule: rule.id, Change: change})
+			}
+		}
+	}
+	if len(repairs) == 0 {
+		return nil, nil
+	}
+	out, err := encodeFixDocument(doc, col)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if err = os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return repairs, nil
+}
+
+// trimRecordKeys strips surrounding whitespace from the record keys of a
+// map-of-records file, unless the trimmed key is already taken.
+func trimRecordKeys(doc *fixDocument, path string) []recordRepair {
+	taken := make(map[string]bool, len(doc.records))
+	for _, rec := range doc.records {
+		taken[rec.key] = true
+	}
+	var repairs []recordRepair
+	for _, rec := range doc.records {
+		trimmed := strings.TrimSpace(rec.key)
+		if trimmed == rec.key || trimmed == "" || taken[trimmed] {
+			continue
+		}
+		repairs = append(repairs, recordRepair{
+			File: path, RecordKey: trimmed, Rule: "key-whitespace",
+			Change: fmt.Sprintf("renamed record %q to %q", rec.key, trimmed),
+		})
+		delete(taken, rec.key)
+		taken[trimmed] = true
+		rec.key = trimmed
+	}
+	return repairs
+}
+
+// fixDocument is a record file loaded for repair. body is the Markdown body
+// of a Markdown record; root is the parsed document of a YAML or JSON file and
+// indent the indentation a JSON file uses.
+type fixDocument struct {
+	records []*fixRecord
+	body    []byte
+	root    *yaml.Node
+	indent  string
+}
+
+// parseFixDocument loads a record file. YAML and JSON files (and Markdown
+// frontmatter) are read through yaml.Node so the field order in the file is
+// known. It returns nil for layouts without a repair path.
+func parseFixDocument(content []byte, col *ingitdb.CollectionDef, path string) (*fixDocument, error) {
+	rf := col.RecordFile
+	switch rf.Format {
+	case ingitdb.RecordFormatYAML, ingitdb.RecordFormatYML, ingitdb.RecordFormatJSON:
+		var docNode yaml.Node
+		if err := yaml.Unmarshal(content, &docNode); err != nil {
+			return nil, err
+		}
+		if len(docNode.Content) == 0 {
+			return nil, nil
+		}
+		doc, err := parseFixNode(docNode.Content[0], col, path)
+		if err != nil || doc == nil {
+			return doc, err
+		}
+		doc.root = &docNode
+		doc.indent = jsonIndent(content)
+		return doc, nil
+	case ingitdb.RecordFormatJSONL:
+		if rf.RecordType != ingitdb.ListOfRecords {
+			return nil, nil
+		}
+		doc := &fixDocument{}
+		for _, line := range bytes.Split(content, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) == 0 {
+				continue
+			}
+			var docNode yaml.Node
+			if err := yaml.Unmarshal(line, &docNode); err != nil {
+				return nil, err
+			}
+			rec, err := decodeFixRecord(docNode.Content[0], "")
+			if err != nil {
+				return nil, err
+			}
+			rec.key, _ = ingitdb.ResolveListRecordKey(rec.fields, col)
+			doc.records = append(doc.records, rec)
+		}
+		return doc, nil
+	case ingitdb.RecordFormatMarkdown:
+		frontmatter, body, err := markdown.Parse(content)
+		if err != nil {
+			return nil, err
+		}
+		return &fixDocument{
+			records: []*fixRecord{{key: recordKeyFromPath(path), fields: frontmatter}},
+			body:    body,
+		}, nil
+	case ingitdb.RecordFormatTOML:
+		switch rf.RecordType {
+		case ingitdb.SingleRecord:
+			fields, err := ingitdb.ParseRecordContent(content, rf.Format)
+			if err != nil {
+				return nil, err
+			}
+			return &fixDocument{records: []*fixRecord{{key: recordKeyFromPath(path), fields: fields}}}, nil
+		case ingitdb.MapOfRecords:
+			return parseKeyedFixDocument(content, rf.Format)
+		}
+	case ingitdb.RecordFormatINGR:
+		if rf.RecordType == ingitdb.MapOfRecords {
+			return parseKeyedFixDocument(content, rf.Format)
+		}
+	}
+	return nil, nil
+}
+
+func parseKeyedFixDocument(content []byte, format ingitdb.RecordFormat) (*fixDocument, error) {
+	records, err := ingitdb.ParseMapOfRecordsContent(content, format)
+	if err != nil {
+		return nil, err
+	}
+	doc := &fixDocument{}
+	for _, key := range sortedRecordKeys(records) {
+		doc.records = append(doc.records, &fixRecord{key: key, fields: records[key]})
+	}
+	return doc, nil
+}
+
+func sortedRecordKeys(records map[string]map[string]any) []string {
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseFixNode splits the root node of a YAML or JSON record file into
+// records according to the collection's record type.
+func parseFixNode(root *yaml.Node, col *ingitdb.CollectionDef, path string) (*fixDocument, error) {
+	doc := &fixDocument{}
+	switch col.RecordFile.RecordType {
+	case ingitdb.SingleRecord:
+		rec, err := decodeFixRecord(root, recordKeyFromPath(path))
+		if err != nil {
+			return nil, err
+		}
+		doc.records = append(doc.records, rec)
+	case ingitdb.MapOfRecords:
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("records file is not a mapping")
+		}
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			rec, err := decodeFixRecord(root.Content[i+1], root.Content[i].Value)
+			if err != nil {
+				return nil, err
+			}
+			rec.keyNode = root.Content[i]
+			doc.records = append(doc.records, rec)
+		}
+	case ingitdb.ListOfRecords:
+		if root.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("records file is not a list")
+		}
+		for _, item := range root.Content {
+			rec, err := decodeFixRecord(item, "")
+			if err != nil {
+				return nil, err
+			}
+			rec.key, _ = ingitdb.ResolveListRecordKey(rec.fields, col)
+			doc.records = append(doc.records, rec)
+		}
+	default:
+		return nil, nil
+	}
+	return doc, nil
+}
+
+// decodeFixRecord decodes a mapping node into a record, remembering the order
+// of its keys.
+func decodeFixRecord(n *yaml.Node, key string) (*fixRecord, error) {
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("record %q is not a mapping", key)
+	}
+	rec := &fixRecord{key: key, fields: map[string]any{}, node: n, nodes: map[string][2]*yaml.Node{}}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var value any
+		if err := n.Content[i+1].Decode(&value); err != nil {
+			return nil, err
+		}
+		name := n.Content[i].Value
+		rec.fields[name] = value
+		rec.order = append(rec.order, name)
+		rec.nodes[name] = [2]*yaml.Node{n.Content[i], n.Content[i+1]}
+	}
+	return rec, nil
+}
+
+// encodeFixDocument writes repaired records back in the collection's format.
+// YAML is written by editing the parsed document, so comments, quoting and
+// untouched values stay as they were; JSON keeps each record's field order
+// (as corrected by key-order) and the file's indentation. The other formats
+// go through the library encoders, which apply columns_order themselves.
+func encodeFixDocument(doc *fixDocument, col *ingitdb.CollectionDef) ([]byte, error) {
+	rf := col.RecordFile
+	switch rf.Format {
+	case ingitdb.RecordFormatYAML, ingitdb.RecordFormatYML:
+		return encodeFixYAML(doc)
+	case ingitdb.RecordFormatJSON:
+		return encodeFixJSON(doc, rf.RecordType)
+	case ingitdb.RecordFormatJSONL:
+		var buf bytes.Buffer
+		for _, rec := range doc.records {
+			line, err := orderedJSONObject(rec)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+		return buf.Bytes(), nil
+	case ingitdb.RecordFormatMarkdown:
+		return markdown.Serialize(doc.records[0].fields, col.ColumnsOrder, doc.body)
+	}
+	if rf.RecordType == ingitdb.SingleRecord {
+		return ingitdb.EncodeRecordContentForCollection(doc.records[0].fields, col)
+	}
+	data := make(map[string]map[string]any, len(doc.records))
+	for _, rec := range doc.records {
+		data[rec.key] = rec.fields
+	}
+	return ingitdb.EncodeMapOfRecordsContent(data, rf.Format, rf.Name, col.ColumnsOrder)
+}
+
+// encodeFixYAML writes the repairs into the parsed document and encodes it.
+func encodeFixYAML(doc *fixDocument) ([]byte, error) {
+	for _, rec := range doc.records {
+		if err := rec.updateYAMLNode(); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc.root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// updateYAMLNode rebuilds the record's mapping in field order. A field whose
+// value is unchanged keeps its parsed nodes; a changed value is re-encoded
+// and keeps the comments of the value it replaces.
+func (r *fixRecord) updateYAMLNode() error {
+	if r.keyNode != nil && r.keyNode.Value != r.key {
+		r.keyNode.Value, r.keyNode.Style = r.key, 0
+	}
+	content := make([]*yaml.Node, 0, 2*len(r.fields))
+	for _, name := range recordFieldOrder(r) {
+		pair, parsed := r.nodes[name]
+		if !parsed {
+			pair = [2]*yaml.Node{{Kind: yaml.ScalarNode, Value: name}, nil}
+		}
+		var old any
+		if pair[1] == nil || pair[1].Decode(&old) != nil || !reflect.DeepEqual(old, r.fields[name]) {
+			value := &yaml.Node{}
+			if err := value.Encode(r.fields[name]); err != nil {
+				return fmt.Errorf("failed to encode field %q: %w", name, err)
+			}
+			if prev := pair[1]; prev != nil {
+				value.HeadComment, value.LineComment, value.FootComment = prev.HeadComment, prev.LineComment, prev.FootComment
+			}
+			pair[1] = value
+		}
+		content = append(content, pair[0], pair[1])
+	}
+	r.node.Content = content
+	return nil
+}
+
+func encodeFixJSON(doc *fixDocument, recordType ingitdb.RecordType) ([]byte, error) {
+	var compact bytes.Buffer
+	switch recordType {
+	case ingitdb.SingleRecord:
+		obj, err := orderedJSONObject(doc.records[0])
+		if err != nil {
+			return nil, err
+		}
+		compact.Write(obj)
+	case ingitdb.MapOfRecords:
+		compact.WriteByte('{')
+		for i, rec := range doc.records {
+			if i > 0 {
+				compact.WriteByte(',')
+			}
+			key, _ := json.Marshal(rec.key)
+			obj, err := orderedJSONObject(rec)
+			if err != nil {
+				return nil, err
+			}
+			compact.Write(key)
+			compact.WriteByte(':')
+			compact.Write(obj)
+		}
+		compact.WriteByte('}')
+	default:
+		compact.WriteByte('[')
+		for i, rec := range doc.records {
+			if i > 0 {
+				compact.WriteByte(',')
+			}
+			obj, err := orderedJSONObject(rec)
+			if err != nil {
+				return nil, err
+			}
+			compact.Write(obj)
+		}
+		compact.WriteByte(']')
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", doc.indent); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// jsonIndent returns the indentation unit of a JSON file: the leading
+// whitespace of its first indented line, or two spaces when no line is
+// indented.
+func jsonIndent(content []byte) string {
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " \t")
+		if len(trimmed) > 0 && len(trimmed) < len(line) {
+			return string(line[:len(line)-len(trimmed)])
+		}
+	}
+	return "  "
+}
+
+// orderedJSONObject marshals one record as a compact JSON object with its
+// fields in order.
+func orderedJSONObject(rec *fixRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range recordFieldOrder(rec) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		value, err := json.Marshal(rec.fields[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode field %q: %w", name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// recordFieldOrder is the order fields are written in: the record's own
+// order when known, otherwise alphabetical.
+func recordFieldOrder(rec *fixRecord) []string {
+	if rec.order == nil {
+		return sortedFieldNames(rec.fields)
+	}
+	return rec.order
+}
+
+// prepareFix runs `validate --fix` before validation and returns the
+// database path validation must then read. With dryRun the repairs are made
+// in a temporary copy of the database, so the validation pass reports what
+// would remain; cleanup removes that copy.
+func prepareFix(
+	dirPath string,
+	dryRun bool,
+	readDefinition func(string, ...ingitdb.ReadOption) (*ingitdb.Definition, error),
+	logf func(...any),
+) (fixPath string, repairs []recordRepair, cleanup func(), err error) {
+	fixPath, cleanup = dirPath, func() {}
+	if dryRun {
+		tmpDir, tmpErr := os.MkdirTemp("", "ingitdb-fix-")
+		if tmpErr != nil {
+			return "", nil, cleanup, fmt.Errorf("failed to create temporary directory: %w", tmpErr)
+		}
+		cleanup = func() { _ = os.RemoveAll(tmpDir) }
+		if err = copyTree(dirPath, tmpDir); err != nil {
+			return "", nil, cleanup, fmt.Errorf("failed to copy database for --dry-run: %w", err)
+		}
+		fixPath = tmpDir
+	}
+	def, err := readDefinition(fixPath)
+	if err != nil {
+		return "", nil, cleanup, fmt.Errorf("failed to read database definition: %w", err)
+	}
+	repairs, err = repairRecords(def)
+	if err != nil {
+		return "", nil, cleanup, err
+	}
+	verb := "fixed"
+	if dryRun {
+		verb = "would fix"
+	}
+	files := map[string]bool{}
+	for _, r := range repairs {
+		files[r.File] = true
+		rel, relErr := filepath.Rel(fixPath, r.File)
+		if relErr != nil {
+			rel = r.File
+		}
+		line := fmt.Sprintf("%s %s: ", verb, filepath.ToSlash(rel))
+		if r.RecordKey != "" {
+			line += fmt.Sprintf("record %q: ", r.RecordKey)
+		}
+		logf(line + r.Change)
+	}
+	if len(repairs) > 0 {
+		logf(fmt.Sprintf("%s %d issue(s) in %d file(s)", verb, len(repairs), len(files)))
+	}
+	return fixPath, repairs, cleanup, nil
+}
+
+// relocateValidationResult rewrites the file paths of a result produced
+// against a copy of the database so they point into the real one.
+func relocateValidationResult(result *ingitdb.ValidationResult, def *ingitdb.Definition, from, to string) *ingitdb.ValidationResult {
+	if result == nil || from == to {
+		return result
+	}
+	out := &ingitdb.ValidationResult{}
+	for _, ve := range result.Errors() {
+		if rel, err := filepath.Rel(from, ve.FilePath); err == nil && ve.FilePath != "" && !strings.HasPrefix(rel, "..") {
+			ve.FilePath = filepath.Join(to, rel)
+		}
+		out.Append(ve)
+	}
+	for id := range def.Collections {
+		passed, total := result.GetRecordCounts(id)
+		out.SetRecordCounts(id, passed, total)
+	}
+	return out
+}
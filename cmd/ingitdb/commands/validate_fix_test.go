@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// fixTestDefinition describes a database at dir with a single-record YAML
+// "cities" collection and a map-of-records JSON "countries" collection.
+func fixTestDefinition(dir string) *ingitdb.Definition {
+	columns := map[string]*ingitdb.ColumnDef{
+		"name":       {Type: ingitdb.ColumnTypeString},
+		"population": {Type: ingitdb.ColumnTypeInt},
+		"area":       {Type: ingitdb.ColumnTypeFloat},
+	}
+	return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+		"cities": {
+			ID:           "cities",
+			DirPath:      filepath.Join(dir, "cities"),
+			Columns:      columns,
+			ColumnsOrder: []string{"name", "population", "area"},
+			RecordFile:   &ingitdb.RecordFileDef{Name: "{key}.yaml", Format: "yaml", RecordType: ingitdb.SingleRecord},
+		},
+		"countries": {
+			ID:         "countries",
+			DirPath:    filepath.Join(dir, "countries"),
+			Columns:    columns,
+			RecordFile: &ingitdb.RecordFileDef{Name: "countries.json", Format: "json", RecordType: ingitdb.MapOfRecords},
+		},
+	}}
+}
+
+func writeFixTestFile(t *testing.T, dir, rel, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+	return p
+}
+
+func readFixTestFile(t *testing.T, p string) string {
+	t.Helper()
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("read %s: %v", p, err)
+	}
+	return string(data)
+}
+
+func runValidateFix(t *testing.T, dir string, args ...string) ([]string, error) {
+	t.Helper()
+	var logs []string
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) { return fixTestDefinition(p), nil },
+		nil, nil,
+		func(args ...any) { logs = append(logs, fmt.Sprint(args...)) },
+	)
+	err := runCobraCommand(cmd, append([]string{"--path=" + dir}, args...)...)
+	return logs, err
+}
+
+func TestValidate_FixRepairsRecords(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	city := writeFixTestFile(t, dir, "cities/$records/sf.yaml", "area: \"121.4\"\n\"name \": San Francisco\npopulation: \"808437\"\n")
+	countries := writeFixTestFile(t, dir, "countries/countries.json",
+		`{"fr ": {"name": "France", "population": " 68000000 "}, "ie": {"name": "Ireland", "population": "n/a"}}`)
+
+	logs, err := runValidateFix(t, dir, "--fix")
+	if err != nil {
+		t.Fatalf("validate --fix: %v", err)
+	}
+	if got, want := readFixTestFile(t, city), "name: San Francisco\npopulation: 808437\narea: 121.4\n"; got != want {
+		t.Errorf("cities/$records/sf.yaml:\n got %q\nwant %q", got, want)
+	}
+	wantCountries := "{\n  \"fr\": {\n    \"name\": \"France\",\n    \"population\": 68000000\n  },\n" +
+		"  \"ie\": {\n    \"name\": \"Ireland\",\n    \"population\": \"n/a\"\n  }\n}\n"
+	if got := readFixTestFile(t, countries); got != wantCountries {
+		t.Errorf("countries.json:\n got %q\nwant %q", got, wantCountries)
+	}
+	joined := strings.Join(logs, "\n")
+	for _, want := range []string{
+		`fixed cities/$records/sf.yaml: record "sf": renamed field "name " to "name"`,
+		`fixed cities/$records/sf.yaml: record "sf": converted field "population" from string "808437" to int`,
+		`fixed cities/$records/sf.yaml: record "sf": reordered fields to follow columns_order`,
+		`fixed countries/countries.json: record "fr": renamed record "fr " to "fr"`,
+		"fixed 6 issue(s) in 2 file(s)",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("log misses %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestValidate_FixFillsColumnDefaults(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/.collection/constraints.yaml", "columns:\n  population:\n    default: 0\n")
+	oslo := writeFixTestFile(t, dir, "cities/$records/oslo.yaml", "name: Oslo\narea: 454\n")
+	rome := writeFixTestFile(t, dir, "cities/$records/rome.yaml", "name: Rome\npopulation: 2873000\n")
+
+	logs, err := runValidateFix(t, dir, "--fix")
+	if err != nil {
+		t.Fatalf("validate --fix: %v", err)
+	}
+	if got, want := readFixTestFile(t, oslo), "name: Oslo\npopulation: 0\narea: 454\n"; got != want {
+		t.Errorf("cities/$records/oslo.yaml:\n got %q\nwant %q", got, want)
+	}
+	if got, want := readFixTestFile(t, rome), "name: Rome\npopulation: 2873000\n"; got != want {
+		t.Errorf("a record carrying the column was rewritten:\n%s", got)
+	}
+	joined := strings.Join(logs, "\n")
+	if want := `fixed cities/$records/oslo.yaml: record "oslo": set missing field "population" to its default 0`; !strings.Contains(joined, want) {
+		t.Errorf("log misses %q:\n%s", want, joined)
+	}
+}
+
+func TestValidate_FixLeavesCleanFilesAlone(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	content := "name:   Oslo   # spacing and comments are kept\npopulation: 709037\n"
+	city := writeFixTestFile(t, dir, "cities/$records/oslo.yaml", content)
+	if _, err := runValidateFix(t, dir, "--fix"); err != nil {
+		t.Fatalf("validate --fix: %v", err)
+	}
+	if got := readFixTestFile(t, city); got != content {
+		t.Errorf("a record without violations was rewritten:\n%s", got)
+	}
+}
+
+func TestValidate_FixKeepsCommentsAndFormatting(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	city := writeFixTestFile(t, dir, "cities/$records/oslo.yaml",
+		"# Oslo, Norway\nname: 'Oslo' # the capital\npopulation: \"709037\" # 2023 census\n")
+	countries := writeFixTestFile(t, dir, "countries/countries.json",
+		"{\n    \"no\": {\n        \"name\": \"Norway\",\n        \"population\": \"5500000\"\n    }\n}\n")
+
+	if _, err := runValidateFix(t, dir, "--fix"); err != nil {
+		t.Fatalf("validate --fix: %v", err)
+	}
+	if got, want := readFixTestFile(t, city), "# Oslo, Norway\nname: 'Oslo' # the capital\npopulation: 709037 # 2023 census\n"; got != want {
+		t.Errorf("cities/$records/oslo.yaml:\n got %q\nwant %q", got, want)
+	}
+	wantCountries := "{\n    \"no\": {\n        \"name\": \"Norway\",\n        \"population\": 5500000\n    }\n}\n"
+	if got := readFixTestFile(t, countries); got != wantCountries {
+		t.Errorf("countries.json:\n got %q\nwant %q", got, wantCountries)
+	}
+}
+
+func TestValidate_FixDryRun(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	content := "name: Paris\npopulation: \"2100000\"\n"
+	city := writeFixTestFile(t, dir, "cities/$records/paris.yaml", content)
+
+	logs, err := runValidateFix(t, dir, "--fix", "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "1 issue(s) can be fixed automatically") {
+		t.Fatalf("expected a pending-fixes error, got %v", err)
+	}
+	if got := readFixTestFile(t, city); got != content {
+		t.Errorf("--dry-run must not write, file is now:\n%s", got)
+	}
+	if !strings.Contains(strings.Join(logs, "\n"), `would fix cities/$records/paris.yaml: record "paris": converted field "population"`) {
+		t.Errorf("expected a would-fix line, got:\n%s", strings.Join(logs, "\n"))
+	}
+}
+
+func TestValidate_FixDryRunReportsRemainingErrors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFixTestFile(t, dir, "cities/$records/rome.yaml", "name: Rome\npopulation: \"2800000\"\n")
+	var validatedAt string
+	dataVal := &mockDataValidator{result: &ingitdb.ValidationResult{}}
+	dataVal.result.Append(ingitdb.ValidationError{Message: "still broken"})
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		func(p string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+			validatedAt = p
+			return fixTestDefinition(p), nil
+		},
+		dataVal, nil, func(...any) {},
+	)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	err := runCobraCommand(cmd, "--path="+dir, "--fix", "--dry-run")
+	if err == nil || !strings.Contains(err.Error(), "still broken") {
+		t.Fatalf("expected the remaining validation error, got %v", err)
+	}
+	if validatedAt == dir {
+		t.Error("--dry-run must validate the repaired copy, not the database")
+	}
+}
+
+func TestValidate_FixFlagConflicts(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--dry-run"}, "--dry-run requires --fix"},
+		{[]string{"--fix", "--from-commit=HEAD"}, "cannot be combined with --from-commit"},
+		{[]string{"--fix", "--only=definition"}, "cannot be combined with --only=definition"},
+	}
+	for _, tc := range cases {
+		_, err := runValidateFix(t, t.TempDir(), tc.args...)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: expected %q, got %v", tc.args, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,480 @@
+package commands
+
+// specscore: feature/cli/validate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/gitrepo"
+)
+
+// validationFinding is one validation error in the shape shared by every
+// machine-readable report format. File is relative to the repository root
+// (or to the database directory outside a git repository) and uses forward
+// slashes; Line and Column are 1-based and zero when unknown.
+type validationFinding struct {
+	RuleID     string `json:"rule_id"`
+	Severity   string `json:"severity"`
+	Collection string `json:"collection,omitempty"`
+	RecordKey  string `json:"record_key,omitempty"`
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Column     int    `json:"column,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Message    string `json:"message"`
+}
+
+// validationRule classifies validator messages into stable rule ids.
+type validationRule struct {
+	id          string
+	description string
+	matches     func(message string) bool
+}
+
+func messageHasPrefix(prefix string) func(string) bool {
+	return func(m string) bool { return strings.HasPrefix(m, prefix) }
+}
+
+func messageContains(parts ...string) func(string) bool {
+	return func(m string) bool {
+		for _, p := range parts {
+			if strings.Contains(m, p) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// validationRules is checked in order; the first match wins. The validator
+// does not attach rule ids to its findings, so they are derived from the
+// messages it produces.
+var validationRules = []validationRule{
+	{"definition", "The database definition is invalid", nil},
+	{"required", "A required field is missing", messageHasPrefix("missing required field")},
+	{"required-when", "A required_when expression could not be evaluated", messageHasPrefix("required_when")},
+	{"type", "A field value does not match the column type", messageHasPrefix("wrong type for field")},
+	{"undeclared-field", "A record holds a field with no column definition", messageHasPrefix("undeclared field")},
+	{"computed-stored", "A computed column value is stored in a record", messageHasPrefix("computed column")},
+	{"enum", "A field value is not one of the permitted values", messageContains("is not one of the permitted values")},
+	{"value-range", "A field value is outside min_value/max_value", messageContains("min_value", "max_value")},
+	{"length", "A field value has the wrong length", messageContains("min_length", "max_length", "required length")},
+	{"foreign-key", "A foreign key has no matching record", messageHasPrefix("foreign key")},
+	{"unique", "Two records share the values of a unique key", messageHasPrefix(uniqueViolationPrefix)},
+	{"records-count", "A collection holds too few or too many records", messageContains("min_records_count", "max_records_count")},
+	{"record-key", "A record has no resolvable key", messageContains("no resolvable key")},
+	{"parse", "A record file cannot be parsed", messageHasPrefix("failed to parse")},
+	{"read", "A record file cannot be read", messageHasPrefix("failed to")},
+	{"collection", "A collection's record file settings are invalid", messageContains("unsupported record type", "invalid record file pattern")},
+	{"invalid", "A record is invalid", func(string) bool { return true }},
+}
+
+// validationRuleID returns the rule id for a validator message.
+func validationRuleID(message string) string {
+	for _, rule := range validationRules {
+		if rule.matches != nil && rule.matches(message) {
+			return rule.id
+		}
+	}
+	return "invalid"
+}
+
+// validationFindings converts a validation result (and a definition error, if
+// reading the definition failed) into findings with repository-relative file
+// paths and, where the offending key can be found in the file, a line and
+// column.
+func validationFindings(dirPath string, result *ingitdb.ValidationResult, defErr error) []validationFinding {
+	findings := []validationFinding{}
+	if defErr != nil {
+		findings = append(findings, validationFinding{
+			RuleID:   "definition",
+			Severity: string(ingitdb.SeverityError),
+			Message:  defErr.Error(),
+		})
+	}
+	if result == nil {
+		return findings
+	}
+	root, err := gitrepo.FindRepoRoot(dirPath)
+	if err != nil || root == "" {
+		root = dirPath
+	}
+	files := map[string][]string{}
+	for _, ve := range result.Errors() {
+		message := ve.Message
+		if ve.Err != nil {
+			message = fmt.Sprintf("%s: %v", ve.Message, ve.Err)
+		}
+		severity := ve.Severity
+		if severity == "" {
+			severity = ingitdb.SeverityError
+		}
+		f := validationFinding{
+			RuleID:     validationRuleID(ve.Message),
+			Severity:   string(severity),
+			Collection: ve.CollectionID,
+			RecordKey:  ve.RecordKey,
+			Field:      ve.FieldName,
+			Message:    message,
+		}
+		if ve.FilePath != "" {
+			f.File = filepath.ToSlash(ve.FilePath)
+			if rel, relErr := filepath.Rel(root, ve.FilePath); relErr == nil && !strings.HasPrefix(rel, "..") {
+				f.File = filepath.ToSlash(rel)
+			}
+			lines, ok := files[ve.FilePath]
+			if !ok {
+				lines = readFileLines(ve.FilePath)
+				files[ve.FilePath] = lines
+			}
+			f.Line, f.Column = locateFinding(lines, ve.RecordKey, ve.FieldName)
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// readFileLines returns the lines of path, or nil if it cannot be read.
+func readFileLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// locateFinding finds the 1-based line and column of field in a YAML or JSON
+// record file. In files holding several records the search for the field
+// starts at the record's own key. It returns the record's position when the
+// field is not present (e.g. a missing required field), and zeros when
+// neither can be found.
+func locateFinding(lines []string, recordKey, field string) (line, column int) {
+	start := 0
+	if recordKey != "" {
+		if i, col := findKeyLine(lines, 0, recordKey); i >= 0 {
+			start, line, column = i, i+1, col
+		}
+	}
+	if field != "" {
+		if i, col := findKeyLine(lines, start, field); i >= 0 {
+			return i + 1, col
+		}
+	}
+	return line, column
+}
+
+// findKeyLine returns the index of the first line at or after from that
+// declares key as a YAML or JSON mapping key, and the 1-based column of the
+// key. It returns -1 when there is none.
+func findKeyLine(lines []string, from int, key string) (int, int) {
+	candidates := []string{key + ":", `"` + key + `":`, `"` + key + `" :`, "'" + key + "':"}
+	for i := from; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " \t-")
+		for _, c := range candidates {
+			if strings.HasPrefix(trimmed, c) {
+				return i, len(lines[i]) - len(trimmed) + 1
+			}
+		}
+	}
+	return -1, 0
+}
+
+// writeValidationReport renders findings in format (json, sarif, junit or
+// github). def supplies the collections that passed for the JUnit report and
+// may be nil.
+func writeValidationReport(w io.Writer, format string, def *ingitdb.Definition, findings []validationFinding) error {
+	switch format {
+	case "json":
+		return writeValidationJSON(w, findings)
+	case "sarif":
+		return writeValidationSARIF(w, findings)
+	case "junit":
+		return writeValidationJUnit(w, def, findings)
+	case "github":
+		writeValidationGitHub(w, findings)
+		return nil
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+func writeValidationJSON(w io.Writer, findings []validationFinding) error {
+	doc := struct {
+		Valid      bool                `json:"valid"`
+		ErrorCount int                 `json:"error_count"`
+		Errors     []validationFinding `json:"errors"`
+	}{Valid: len(findings) == 0, ErrorCount: len(findings), Errors: findings}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode validation report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/), the subset
+// GitHub code scanning reads.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func writeValidationSARIF(w io.Writer, findings []validationFinding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "ingitdb",
+			InformationURI: "https://github.com/ingitdb/ingitdb-cli",
+		}},
+		Results: []sarifResult{},
+	}
+	for _, rule := range validationRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               rule.id,
+			ShortDescription: sarifMessage{Text: rule.description},
+		})
+	}
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   f.Severity,
+			Message: sarifMessage{Text: findingText(f)},
+		}
+		if f.File != "" {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: f.File, URIBaseID: "%SRCROOT%"},
+			}}
+			if f.Line > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Line, StartColumn: f.Column}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		run.Results = append(run.Results, result)
+	}
+	doc := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr,omitempty"`
+	Line      int            `xml:"line,attr,omitempty"`
+	Failures  []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeValidationJUnit writes one test suite per collection and one failing
+// test case per invalid record (or file, for findings without a record key).
+// Collections without findings get a single passing test case.
+func writeValidationJUnit(w io.Writer, def *ingitdb.Definition, findings []validationFinding) error {
+	suites := map[string]*junitTestSuite{}
+	suite := func(name string) *junitTestSuite {
+		s := suites[name]
+		if s == nil {
+			s = &junitTestSuite{Name: name}
+			suites[name] = s
+		}
+		return s
+	}
+	if def != nil {
+		for id := range def.Collections {
+			suite(id)
+		}
+	}
+	caseIndex := map[string]int{}
+	for _, f := range findings {
+		suiteName := f.Collection
+		if suiteName == "" {
+			suiteName = f.RuleID
+		}
+		s := suite(suiteName)
+		caseName := f.RecordKey
+		if caseName == "" {
+			caseName = f.File
+		}
+		if caseName == "" {
+			caseName = suiteName
+		}
+		key := suiteName + "\x00" + caseName
+		i, ok := caseIndex[key]
+		if !ok {
+			i = len(s.Cases)
+			caseIndex[key] = i
+			s.Cases = append(s.Cases, junitTestCase{Name: caseName, ClassName: suiteName, File: f.File, Line: f.Line})
+		}
+		s.Cases[i].Failures = append(s.Cases[i].Failures, junitFailure{
+			Message: f.Message,
+			Type:    f.RuleID,
+			Text:    findingText(f),
+		})
+	}
+
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	doc := junitTestSuites{Name: "ingitdb validate"}
+	for _, name := range names {
+		s := suites[name]
+		if len(s.Cases) == 0 {
+			s.Cases = []junitTestCase{{Name: "records", ClassName: name}}
+		}
+		s.Tests = len(s.Cases)
+		for _, c := range s.Cases {
+			if len(c.Failures) > 0 {
+				s.Failures++
+			}
+		}
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Suites = append(doc.Suites, *s)
+	}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+// writeValidationGitHub writes GitHub Actions workflow commands, which the
+// runner turns into annotations on the pull request's changed files.
+func writeValidationGitHub(w io.Writer, findings []validationFinding) {
+	for _, f := range findings {
+		command := "error"
+		if f.Severity == string(ingitdb.SeverityWarning) {
+			command = "warning"
+		}
+		props := []string{}
+		if f.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(f.File))
+			if f.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", f.Line))
+			}
+			if f.Column > 0 {
+				props = append(props, fmt.Sprintf("col=%d", f.Column))
+			}
+		}
+		props = append(props, "title="+escapeGitHubProperty("ingitdb "+f.RuleID))
+		_, _ = fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), escapeGitHubData(findingText(f)))
+	}
+}
+
+// findingText is the human-readable message of a finding, prefixed with the
+// record and field it concerns.
+func findingText(f validationFinding) string {
+	parts := make([]string, 0, 3)
+	if f.RecordKey != "" {
+		parts = append(parts, fmt.Sprintf("record %q", f.RecordKey))
+	}
+	if f.Field != "" {
+		parts = append(parts, fmt.Sprintf("field %q", f.Field))
+	}
+	parts = append(parts, f.Message)
+	return strings.Join(parts, ": ")
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// runValidateReport runs `validate --format=<format>` against a database
+// holding one YAML file with two records, where the validator reports a type
+// error on record "sf" and a missing required field on record "la".
+func runValidateReport(t *testing.T, format string) (string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	recordsPath := filepath.Join(dir, "cities", "cities.yaml")
+	if err := os.MkdirAll(filepath.Dir(recordsPath), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	content := "sf:\n  name: San Francisco\n  population: many\nla:\n  population: 3900000\n"
+	if err := os.WriteFile(recordsPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("write records: %v", err)
+	}
+	result := &ingitdb.ValidationResult{}
+	result.Append(ingitdb.ValidationError{
+		Severity: ingitdb.SeverityError, CollectionID: "cities", FilePath: recordsPath,
+		RecordKey: "sf", FieldName: "population",
+		Message: `wrong type for field "population": expected int, got string`,
+	})
+	result.Append(ingitdb.ValidationError{
+		Severity: ingitdb.SeverityError, CollectionID: "cities", FilePath: recordsPath,
+		RecordKey: "la", FieldName: "name", Message: "missing required field",
+	})
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+		return &ingitdb.Definition{Collections: map[string]*ingitdb.CollectionDef{
+			"cities":    {ID: "cities"},
+			"countries": {ID: "countries"},
+		}}, nil
+	}
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		readDef, &mockDataValidator{result: result}, nil, func(...any) {},
+	)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	err := runCobraCommand(cmd, "--path="+dir, "--format="+format)
+	return out.String(), err
+}
+
+func TestValidate_FormatJSON(t *testing.T) {
+	t.Parallel()
+	out, err := runValidateReport(t, "json")
+	if err == nil || !strings.Contains(err.Error(), "2 error(s)") {
+		t.Fatalf("expected a 2-error failure, got %v", err)
+	}
+	var doc struct {
+		Valid      bool                `json:"valid"`
+		ErrorCount int                 `json:"error_count"`
+		Errors     []validationFinding `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	if doc.Valid || doc.ErrorCount != 2 {
+		t.Errorf("valid=%v error_count=%d, want false and 2", doc.Valid, doc.ErrorCount)
+	}
+	want := []validationFinding{
+		{RuleID: "type", Severity: "error", Collection: "cities", RecordKey: "sf", File: "cities/cities.yaml", Line: 3, Column: 3, Field: "population",
+			Message: `wrong type for field "population": expected int, got string`},
+		{RuleID: "required", Severity: "error", Collection: "cities", RecordKey: "la", File: "cities/cities.yaml", Line: 4, Column: 1, Field: "name",
+			Message: "missing required field"},
+	}
+	if len(doc.Errors) != len(want) {
+		t.Fatalf("got %d findings, want %d", len(doc.Errors), len(want))
+	}
+	for i := range want {
+		if doc.Errors[i] != want[i] {
+			t.Errorf("finding %d:\n got %+v\nwant %+v", i, doc.Errors[i], want[i])
+		}
+	}
+}
+
+func TestValidate_FormatSARIF(t *testing.T) {
+	t.Parallel()
+	out, _ := runValidateReport(t, "sarif")
+	var doc sarifLog
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid SARIF: %v\n%s", err, out)
+	}
+	if doc.Version != "2.1.0" || len(doc.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: version=%q runs=%d", doc.Version, len(doc.Runs))
+	}
+	results := doc.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	r := results[0]
+	if r.RuleID != "type" || r.Level != "error" || len(r.Locations) != 1 {
+		t.Fatalf("unexpected first result: %+v", r)
+	}
+	loc := r.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "cities/cities.yaml" || loc.Region == nil || loc.Region.StartLine != 3 {
+		t.Errorf("unexpected location: %+v region=%+v", loc.ArtifactLocation, loc.Region)
+	}
+}
+
+func TestValidate_FormatJUnit(t *testing.T) {
+	t.Parallel()
+	out, _ := runValidateReport(t, "junit")
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, out)
+	}
+	if doc.Tests != 3 || doc.Failures != 2 {
+		t.Errorf("tests=%d failures=%d, want 3 and 2", doc.Tests, doc.Failures)
+	}
+	if len(doc.Suites) != 2 || doc.Suites[0].Name != "cities" || doc.Suites[1].Name != "countries" {
+		t.Fatalf("unexpected suites: %+v", doc.Suites)
+	}
+	if got := doc.Suites[0].Cases[0]; got.Name != "sf" || len(got.Failures) != 1 || got.Failures[0].Type != "type" {
+		t.Errorf("unexpected first case: %+v", got)
+	}
+	if got := doc.Suites[1]; got.Failures != 0 || len(got.Cases) != 1 {
+		t.Errorf("expected one passing case for countries, got %+v", got)
+	}
+}
+
+func TestValidate_FormatGitHub(t *testing.T) {
+	t.Parallel()
+	out, _ := runValidateReport(t, "github")
+	want := "::error file=cities/cities.yaml,line=3,col=3,title=ingitdb type::" +
+		`record "sf": field "population": wrong type for field "population": expected int, got string` + "\n" +
+		"::error file=cities/cities.yaml,line=4,col=1,title=ingitdb required::" +
+		`record "la": field "name": missing required field` + "\n"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestValidate_FormatDefinitionError(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	readDef := func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+		return nil, fmt.Errorf("collection %q: bad column", "cities")
+	}
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return dir, nil },
+		readDef, nil, nil, func(...any) {},
+	)
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := runCobraCommand(cmd, "--path="+dir, "--format=json"); err == nil {
+		t.Fatal("expected a validation failure")
+	}
+	if !strings.Contains(out.String(), `"rule_id": "definition"`) {
+		t.Errorf("expected a definition finding, got:\n%s", out.String())
+	}
+}
+
+func TestValidate_FormatInvalid(t *testing.T) {
+	t.Parallel()
+	cmd := Validate(
+		func() (string, error) { return "/tmp/home", nil },
+		func() (string, error) { return t.TempDir(), nil },
+		func(_ string, _ ...ingitdb.ReadOption) (*ingitdb.Definition, error) {
+			return &ingitdb.Definition{}, nil
+		},
+		nil, nil, func(...any) {},
+	)
+	err := runCobraCommand(cmd, "--format=xml")
+	if err == nil || !strings.Contains(err.Error(), "invalid --format") {
+		t.Fatalf("expected an invalid --format error, got %v", err)
+	}
+}
+
+func TestValidationRuleID(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"missing required field":                                                "required",
+		`value 7 for field "x" is above max_value 5`:                            "value-range",
+		`value "z" for field "x" is not one of the permitted values: a, b`:      "enum",
+		`length 9 for field "x" is above max_length 4`:                          "length",
+		`foreign key "country" = "xx" has no matching record in collection "c"`: "foreign-key",
+		"failed to parse record file":                                           "parse",
+		"failed to read record file":                                            "read",
+		"something new":                                                         "invalid",
+	}
+	for message, want := range cases {
+		if got := validationRuleID(message); got != want {
+			t.Errorf("validationRuleID(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// viewAggregate is one parsed "func(field) [as name]" aggregate of a
+// grouping view.
+type viewAggregate struct {
+	fn    string // count, sum, avg, min or max
+	field string // empty for count(*)
+	name  string // output column
+}
+
+// viewAggregateRe matches "func(field)" or "func(*)" with an optional
+// "as name" alias.
+var viewAggregateRe = regexp.MustCompile(`^(?i:(count|sum|avg|min|max))\s*\(\s*(\*|[^()\s]*)\s*\)(?:\s+(?i:as)\s+([A-Za-z_][A-Za-z0-9_]*))?$`)
+
+// parseViewAggregate parses one aggregate expression. Without an alias the
+// output column is "count" for count(*) and "<func>_<field>" otherwise.
+func parseViewAggregate(expr string) (viewAggregate, error) {
+	m := viewAggregateRe.FindStringSubmatch(strings.TrimSpace(expr))
+	if m == nil {
+		return viewAggregate{}, fmt.Errorf("expected func(field) [as name] with func one of count, sum, avg, min, max")
+	}
+	agg := viewAggregate{fn: strings.ToLower(m[1]), field: m[2], name: m[3]}
+	if agg.field == "*" {
+		agg.field = ""
+	}
+	if agg.field == "" && agg.fn != "count" {
+		return viewAggregate{}, fmt.Errorf("%s() requires a field", agg.fn)
+	}
+	if agg.name == "" {
+		if agg.field == "" {
+			agg.name = agg.fn
+		} else {
+			agg.name = agg.fn + "_" + agg.field
+		}
+	}
+	return agg, nil
+}
+
+// parseViewAggregates parses every aggregate of a view.
+func parseViewAggregates(exprs []string) ([]viewAggregate, error) {
+	aggs := make([]viewAggregate, 0, len(exprs))
+	for _, expr := range exprs {
+		agg, err := parseViewAggregate(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aggregate %q: %w", expr, err)
+		}
+		aggs = append(aggs, agg)
+	}
+	return aggs, nil
+}
+
+// aggregatingRecordsReader folds the records of the wrapped reader into one
+// row per distinct combination of the groupBy values. Each row holds the
+// group-by fields plus one column per aggregate; its ID is the group values
+// joined with "/". Rows are yielded in group-value order so the output does
+// not depend on the order records are read in. Without groupBy the whole
+// collection is one group with the ID "all", yielded even when it is empty.
+type aggregatingRecordsReader struct {
+	reader  ingitdb.RecordsReader
+	groupBy []string
+	aggs    []viewAggregate
+}
+
+// aggregateGroup accumulates the records of one group.
+type aggregateGroup struct {
+	values []any
+	states []aggregateState
+}
+
+// aggregateState accumulates one aggregate of one group.
+type aggregateState struct {
+	count  int
+	sum    float64
+	intSum int64
+	allInt bool
+	best   any
+}
+
+func (r aggregatingRecordsReader) ReadRecords(
+	ctx context.Context,
+	dbPath string,
+	col *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	groups := make(map[string]*aggregateGroup)
+	newGroup := func(values []any) *aggregateGroup {
+		g := &aggregateGroup{values: values, states: make([]aggregateState, len(r.aggs))}
+		for i := range g.states {
+			g.states[i].allInt = true
+		}
+		return g
+	}
+	if len(r.groupBy) == 0 {
+		groups[""] = newGroup(nil)
+	}
+	err := r.reader.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
+		data := entry.GetData()
+		values := make([]any, len(r.groupBy))
+		for i, field := range r.groupBy {
+			values[i], _ = resolveField(data, entry.GetID(), field)
+		}
+		key := aggregateGroupKey(values)
+		g, ok := groups[key]
+		if !ok {
+			g = newGroup(values)
+			groups[key] = g
+		}
+		for i, agg := range r.aggs {
+			g.states[i].add(agg, data, entry.GetID())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	ordered := make([]*aggregateGroup, 0, len(groups))
+	for _, g := range groups {
+		ordered = append(ordered, g)
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		for k := range ordered[i].values {
+			if c := compareValues(ordered[i].values[k], ordered[j].values[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	for _, g := range ordered {
+		row := make(map[string]any, len(r.groupBy)+len(r.aggs))
+		for i, field := range r.groupBy {
+			row[field] = g.values[i]
+		}
+		for i, agg := range r.aggs {
+			row[agg.name] = g.states[i].result(agg)
+		}
+		id := "all"
+		if len(r.groupBy) > 0 {
+			id = aggregateGroupID(g.values)
+		}
+		if err = yield(ingitdb.RecordEntry{ID: id, Data: row}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aggregateGroupKey is the map key of a group. The unit separator keeps
+// ("a b", "c") and ("a", "b c") apart.
+func aggregateGroupKey(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%T:%v", v, v)
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+func aggregateGroupID(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if v != nil {
+			parts[i] = fmt.Sprintf("%v", v)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// add folds one record into the state. Missing and nil values are skipped by
+// every aggregate except count(*); sum and avg skip non-numeric values too.
+func (s *aggregateState) add(agg viewAggregate, data map[string]any, key string) {
+	if agg.field == "" {
+		s.count++
+		return
+	}
+	v, present := resolveField(data, key, agg.field)
+	if !present || v == nil {
+		return
+	}
+	switch agg.fn {
+	case "count":
+		s.count++
+	case "sum", "avg":
+		f, ok := asFloat(v)
+		if !ok {
+			return
+		}
+		s.count++
+		s.sum += f
+		switch n := v.(type) {
+		case int:
+			s.intSum += int64(n)
+		case int64:
+			s.intSum += n
+		default:
+			s.allInt = false
+		}
+	case "min":
+		if s.count == 0 || compareValues(v, s.best) < 0 {
+			s.best = v
+		}
+		s.count++
+	case "max":
+		if s.count == 0 || compareValues(v, s.best) > 0 {
+			s.best = v
+		}
+		s.count++
+	}
+}
+
+// result returns the final aggregate value. sum stays an integer while every
+// summed value is one; avg, min and max of an empty group are nil.
+func (s *aggregateState) result(agg viewAggregate) any {
+	switch agg.fn {
+	case "count":
+		return s.count
+	case "sum":
+		if s.allInt {
+			return s.intSum
+		}
+		return s.sum
+	case "avg":
+		if s.count == 0 {
+			return nil
+		}
+		return s.sum / float64(s.count)
+	default:
+		return s.best
+	}
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// sliceRecordsReader is an in-memory ingitdb.RecordsReader.
+type sliceRecordsReader []ingitdb.IRecordEntry
+
+func (r sliceRecordsReader) ReadRecords(
+	_ context.Context,
+	_ string,
+	_ *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	for _, entry := range r {
+		if err := yield(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readAllEntries(t *testing.T, reader ingitdb.RecordsReader) []ingitdb.IRecordEntry {
+	t.Helper()
+	var out []ingitdb.IRecordEntry
+	err := reader.ReadRecords(context.Background(), "", &ingitdb.CollectionDef{ID: "test"}, func(e ingitdb.IRecordEntry) error {
+		out = append(out, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadRecords: %v", err)
+	}
+	return out
+}
+
+func TestParseViewAggregate(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		expr    string
+		want    viewAggregate
+		wantErr bool
+	}{
+		{expr: "count(*)", want: viewAggregate{fn: "count", name: "count"}},
+		{expr: "COUNT()", want: viewAggregate{fn: "count", name: "count"}},
+		{expr: "count(email) as with_email", want: viewAggregate{fn: "count", field: "email", name: "with_email"}},
+		{expr: "sum(population)", want: viewAggregate{fn: "sum", field: "population", name: "sum_population"}},
+		{expr: " avg( age ) AS mean_age ", want: viewAggregate{fn: "avg", field: "age", name: "mean_age"}},
+		{expr: "max(score) as top", want: viewAggregate{fn: "max", field: "score", name: "top"}},
+		{expr: "sum(*)", wantErr: true},
+		{expr: "median(age)", wantErr: true},
+		{expr: "sum(age) as", wantErr: true},
+		{expr: "population", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+			got, err := parseViewAggregate(tc.expr)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAggregatingRecordsReader_GroupBy(t *testing.T) {
+	t.Parallel()
+	source := sliceRecordsReader{
+		ingitdb.RecordEntry{ID: "sf", Data: map[string]any{"state": "CA", "population": 800000, "area": 121.4}},
+		ingitdb.RecordEntry{ID: "la", Data: map[string]any{"state": "CA", "population": 4000000, "area": 1302.0}},
+		ingitdb.RecordEntry{ID: "nyc", Data: map[string]any{"state": "NY", "population": 8000000}},
+	}
+	aggs, err := parseViewAggregates([]string{"count(*) as cities", "sum(population)", "avg(population) as avg_pop", "min(area)", "max(area)"})
+	if err != nil {
+		t.Fatalf("parseViewAggregates: %v", err)
+	}
+	rows := readAllEntries(t, aggregatingRecordsReader{reader: source, groupBy: []string{"state"}, aggs: aggs})
+
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(rows))
+	}
+	ca, ny := rows[0], rows[1]
+	if ca.GetID() != "CA" || ny.GetID() != "NY" {
+		t.Fatalf("groups out of order: %s, %s", ca.GetID(), ny.GetID())
+	}
+	want := map[string]any{
+		"state": "CA", "cities": 2, "sum_population": int64(4800000),
+		"avg_pop": float64(2400000), "min_area": 121.4, "max_area": 1302.0,
+	}
+	for k, v := range want {
+		if ca.GetData()[k] != v {
+			t.Errorf("CA %s: got %#v, want %#v", k, ca.GetData()[k], v)
+		}
+	}
+	if ny.GetData()["min_area"] != nil || ny.GetData()["cities"] != 1 {
+		t.Errorf("NY row: %v", ny.GetData())
+	}
+}
+
+func TestAggregatingRecordsReader_NoGroupBy(t *testing.T) {
+	t.Parallel()
+	aggs, err := parseViewAggregates([]string{"count(*)", "sum(n)"})
+	if err != nil {
+		t.Fatalf("parseViewAggregates: %v", err)
+	}
+
+	rows := readAllEntries(t, aggregatingRecordsReader{reader: sliceRecordsReader{}, aggs: aggs})
+	if len(rows) != 1 || rows[0].GetID() != "all" {
+		t.Fatalf("expected a single 'all' row for an empty collection, got %v", rows)
+	}
+	if rows[0].GetData()["count"] != 0 || rows[0].GetData()["sum_n"] != int64(0) {
+		t.Errorf("empty totals: %v", rows[0].GetData())
+	}
+
+	source := sliceRecordsReader{
+		ingitdb.RecordEntry{ID: "a", Data: map[string]any{"n": 1}},
+		ingitdb.RecordEntry{ID: "b", Data: map[string]any{"n": 2.5}},
+		ingitdb.RecordEntry{ID: "c", Data: map[string]any{"n": "n/a"}},
+	}
+	rows = readAllEntries(t, aggregatingRecordsReader{reader: source, aggs: aggs})
+	if rows[0].GetData()["count"] != 3 || rows[0].GetData()["sum_n"] != 3.5 {
+		t.Errorf("totals: %v", rows[0].GetData())
+	}
+}
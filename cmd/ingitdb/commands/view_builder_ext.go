@@ -0,0 +1,147 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+// singleViewBuilder is a view builder that can also rebuild one view at a
+// time. Both materializer.SimpleViewBuilder and ExtendedViewBuilder implement it.
+type singleViewBuilder interface {
+	materializer.ViewBuilder
+	BuildView(
+		ctx context.Context,
+		dbPath string,
+		repoRoot string,
+		col *ingitdb.CollectionDef,
+		def *ingitdb.Definition,
+		view *ingitdb.ViewDef,
+	) (*ingitdb.MaterializeResult, error)
+}
+
+// ExtendedViewBuilder is a materializer.SimpleViewBuilder that honours the
+// view attributes the library leaves to the CLI:
+//
+//   - where: the select --where grammar (conditions joined with " && "); only
+//     records matching every condition reach the view.
+//   - group_by / aggregates: one output row per group with count, sum, avg,
+//     min and max columns.
+//   - script: a Starlark file whose view(records) function returns the rows.
+//
+// Each is applied by swapping the records reader of the wrapped builder, so
+// projection, ordering, top, rendering and the created/updated/unchanged
+// accounting stay with the library. Views using none of them are built
+// exactly as the wrapped builder builds them.
+type ExtendedViewBuilder struct {
+	materializer.SimpleViewBuilder
+}
+
+var _ singleViewBuilder = ExtendedViewBuilder{}
+
+// NewExtendedViewBuilder wraps b so views get the CLI-level view attributes.
+func NewExtendedViewBuilder(b materializer.SimpleViewBuilder) ExtendedViewBuilder {
+	return ExtendedViewBuilder{SimpleViewBuilder: b}
+}
+
+// BuildViews builds every view of col, in view-name order, through BuildView
+// so each view gets its own filter.
+func (b ExtendedViewBuilder) BuildViews(
+	ctx context.Context,
+	dbPath string,
+	repoRoot string,
+	col *ingitdb.CollectionDef,
+	def *ingitdb.Definition,
+) (*ingitdb.MaterializeResult, error) {
+	views, err := b.collectionViews(col)
+	if err != nil {
+		return nil, err
+	}
+	result := &ingitdb.MaterializeResult{}
+	for _, name := range sortedViewNames(views) {
+		viewResult, err := b.BuildView(ctx, dbPath, repoRoot, col, def, views[name])
+		if err != nil {
+			return nil, err
+		}
+		mergeMaterializeResult(result, viewResult)
+	}
+	return result, nil
+}
+
+// BuildView builds a single view. Records are filtered by `where` first and
+// then grouped or handed to the view script.
+func (b ExtendedViewBuilder) BuildView(
+	ctx context.Context,
+	dbPath string,
+	repoRoot string,
+	col *ingitdb.CollectionDef,
+	def *ingitdb.Definition,
+	view *ingitdb.ViewDef,
+) (*ingitdb.MaterializeResult, error) {
+	conds, err := parseViewWhere(view.Where)
+	if err != nil {
+		return nil, fmt.Errorf("view %s/%s: %w", col.ID, view.ID, err)
+	}
+	inner := b.SimpleViewBuilder
+	if inner.RecordsReader == nil {
+		return inner.BuildView(ctx, dbPath, repoRoot, col, def, view)
+	}
+	if len(conds) > 0 {
+		inner.RecordsReader = whereRecordsReader{reader: inner.RecordsReader, conds: conds}
+	}
+	if view.IsDefault {
+		// The inline default_view has no view file, so where is all it can
+		// carry.
+		return inner.BuildView(ctx, dbPath, repoRoot, col, def, view)
+	}
+	ext, viewsDir, err := readViewExtensions(col.DirPath, view.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case ext.Script != "" && ext.isAggregating():
+		return nil, fmt.Errorf("view %s/%s: script cannot be combined with group_by or aggregates", col.ID, view.ID)
+	case ext.Script != "":
+		inner.RecordsReader = scriptRecordsReader{
+			reader:     inner.RecordsReader,
+			scriptPath: filepath.Join(viewsDir, ext.Script),
+		}
+	case ext.isAggregating():
+		aggs, err := parseViewAggregates(ext.Aggregates)
+		if err != nil {
+			return nil, fmt.Errorf("view %s/%s: %w", col.ID, view.ID, err)
+		}
+		inner.RecordsReader = aggregatingRecordsReader{reader: inner.RecordsReader, groupBy: ext.GroupBy, aggs: aggs}
+	}
+	return inner.BuildView(ctx, dbPath, repoRoot, col, def, view)
+}
+
+// collectionViews mirrors SimpleViewBuilder.BuildViews: views preloaded on the
+// collection definition win, otherwise they are read from disk and the inline
+// default_view is injected.
+func (b ExtendedViewBuilder) collectionViews(col *ingitdb.CollectionDef) (map[string]*ingitdb.ViewDef, error) {
+	if col.Views != nil {
+		return col.Views, nil
+	}
+	if b.DefReader == nil {
+		return nil, fmt.Errorf("view definition reader is required")
+	}
+	views, err := b.DefReader.ReadViewDefs(col.DirPath)
+	if err != nil {
+		return nil, err
+	}
+	if col.DefaultView != nil {
+		if _, exists := views[ingitdb.DefaultViewID]; !exists {
+			dv := *col.DefaultView
+			dv.ID = ingitdb.DefaultViewID
+			dv.IsDefault = true
+			views[ingitdb.DefaultViewID] = &dv
+		}
+	}
+	return views, nil
+}
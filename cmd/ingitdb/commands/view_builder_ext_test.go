@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+	"github.com/ingitdb/ingitdb-go/ingitdb/materializer"
+)
+
+func newExtendedViewBuilderForTest() ExtendedViewBuilder {
+	return NewExtendedViewBuilder(materializer.NewViewBuilder(materializer.NewFileRecordsReader(), nil))
+}
+
+func readViewOutput(t *testing.T, f *viewCmdFixture, fileName string) string {
+	t.Helper()
+	out := filepath.Join(f.dir, ingitdb.IngitdbDir, ".collections", "cities", fileName)
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read view output %s: %v", out, err)
+	}
+	return string(content)
+}
+
+func TestExtendedViewBuilder_BuildViewsFiltersRecords(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	col := f.def.Collections["cities"]
+	col.Views = map[string]*ingitdb.ViewDef{
+		"big": {ID: "big", Formats: []string{"md"}, FileName: "big.md", Where: "population > 1000000"},
+		"all": {ID: "all", Formats: []string{"md"}, FileName: "all.md"},
+	}
+
+	result, err := newExtendedViewBuilderForTest().BuildViews(context.Background(), f.dir, f.dir, col, f.def)
+	if err != nil {
+		t.Fatalf("BuildViews: %v", err)
+	}
+	if result.FilesCreated != 2 {
+		t.Errorf("FilesCreated: got %d, want 2", result.FilesCreated)
+	}
+	big := readViewOutput(t, f, "big.md")
+	if !strings.Contains(big, "Los Angeles") || strings.Contains(big, "San Francisco") {
+		t.Errorf("filtered view has wrong records:\n%s", big)
+	}
+	all := readViewOutput(t, f, "all.md")
+	if !strings.Contains(all, "Los Angeles") || !strings.Contains(all, "San Francisco") {
+		t.Errorf("unfiltered view should keep every record:\n%s", all)
+	}
+}
+
+func TestExtendedViewBuilder_MultipleConditionsAreANDed(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	col := f.def.Collections["cities"]
+	view := &ingitdb.ViewDef{
+		ID: "none", Formats: []string{"md"}, FileName: "none.md",
+		Where: "population > 1000000 && name == San Francisco",
+	}
+
+	if _, err := newExtendedViewBuilderForTest().BuildView(context.Background(), f.dir, f.dir, col, f.def, view); err != nil {
+		t.Fatalf("BuildView: %v", err)
+	}
+	out := readViewOutput(t, f, "none.md")
+	if strings.Contains(out, "Los Angeles") || strings.Contains(out, "San Francisco") {
+		t.Errorf("no record satisfies both conditions, got:\n%s", out)
+	}
+}
+
+func TestExtendedViewBuilder_FilterByID(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	col := f.def.Collections["cities"]
+	view := &ingitdb.ViewDef{ID: "sf", Formats: []string{"md"}, FileName: "sf.md", Where: "$id == sf"}
+
+	if _, err := newExtendedViewBuilderForTest().BuildView(context.Background(), f.dir, f.dir, col, f.def, view); err != nil {
+		t.Fatalf("BuildView: %v", err)
+	}
+	out := readViewOutput(t, f, "sf.md")
+	if !strings.Contains(out, "San Francisco") || strings.Contains(out, "Los Angeles") {
+		t.Errorf("expected only the sf record, got:\n%s", out)
+	}
+}
+
+func TestExtendedViewBuilder_DefaultViewWhere(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	col := f.def.Collections["cities"]
+	col.Views = map[string]*ingitdb.ViewDef{
+		ingitdb.DefaultViewID: {
+			ID: ingitdb.DefaultViewID, IsDefault: true, Format: "csv", FileName: "big",
+			Where: "population > 1000000",
+		},
+	}
+
+	if _, err := newExtendedViewBuilderForTest().BuildViews(context.Background(), f.dir, f.dir, col, f.def); err != nil {
+		t.Fatalf("BuildViews: %v", err)
+	}
+	out := readViewOutput(t, f, "big.csv")
+	if !strings.Contains(out, "Los Angeles") || strings.Contains(out, "San Francisco") {
+		t.Errorf("default view ignored where:\n%s", out)
+	}
+}
+
+func TestExtendedViewBuilder_InvalidWhere(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	col := f.def.Collections["cities"]
+	col.Views = map[string]*ingitdb.ViewDef{
+		"broken": {ID: "broken", Formats: []string{"md"}, Where: "population"},
+	}
+
+	_, err := newExtendedViewBuilderForTest().BuildViews(context.Background(), f.dir, f.dir, col, f.def)
+	if err == nil || !strings.Contains(err.Error(), "cities/broken") {
+		t.Fatalf("expected error naming the view, got %v", err)
+	}
+}
+
+func TestCreateView_WhereFiltersMaterializedOutput(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	err := runCreateCmd(t, f, newExtendedViewBuilderForTest(), "view", "small", "--in=cities",
+		"--where=population<1000000", "--file-name=small.md")
+	if err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	out := readViewOutput(t, f, "small.md")
+	if !strings.Contains(out, "San Francisco") || strings.Contains(out, "Los Angeles") {
+		t.Errorf("expected only San Francisco, got:\n%s", out)
+	}
+}
+
+func TestCreateView_GroupByMaterializesAggregates(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	err := runCreateCmd(t, f, newExtendedViewBuilderForTest(), "view", "totals", "--in=cities",
+		"--aggregate=count(*) as cities", "--aggregate=sum(population) as total",
+		"--columns=cities,total", "--file-name=totals.md")
+	if err != nil {
+		t.Fatalf("create view: %v", err)
+	}
+	out := readViewOutput(t, f, "totals.md")
+	if !strings.Contains(out, "4800000") || strings.Contains(out, "San Francisco") {
+		t.Errorf("expected one aggregated row, got:\n%s", out)
+	}
+}
+
+func TestCreateView_GroupByValidation(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "unknown group-by column", args: []string{"--group-by=state"}, wantErr: "state"},
+		{name: "unknown aggregate column", args: []string{"--aggregate=sum(area)"}, wantErr: "area"},
+		{name: "bad aggregate", args: []string{"--aggregate=median(population)"}, wantErr: "--aggregate"},
+		{name: "column not in output", args: []string{"--group-by=name", "--columns=population"}, wantErr: "population"},
+		{name: "script with group-by", args: []string{"--group-by=name", "--script=x.star"}, wantErr: "script"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			f := newViewCmdFixture(t)
+			args := append([]string{"view", "v", "--in=cities"}, tc.args...)
+			err := runCreateCmd(t, f, nil, args...)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestExtendedViewBuilder_ScriptedView(t *testing.T) {
+	t.Parallel()
+	f := newViewCmdFixture(t)
+	viewsDir := filepath.Join(f.colDir, "$views")
+	if err := os.MkdirAll(viewsDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	script := "def view(records):\n    return [{\"n\": len(records)}]\n"
+	if err := os.WriteFile(filepath.Join(viewsDir, "count.star"), []byte(script), 0o644); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	viewYAML := "formats: [md]\nfile_name: count.md\nscript: count.star\n"
+	if err := os.WriteFile(filepath.Join(viewsDir, "count.yaml"), []byte(viewYAML), 0o644); err != nil {
+		t.Fatalf("write view: %v", err)
+	}
+	col := f.def.Collections["cities"]
+	col.Views = map[string]*ingitdb.ViewDef{"count": {ID: "count", Formats: []string{"md"}, FileName: "count.md"}}
+
+	result, err := newExtendedViewBuilderForTest().BuildViews(context.Background(), f.dir, f.dir, col, f.def)
+	if err != nil {
+		t.Fatalf("BuildViews: %v", err)
+	}
+	if result.FilesCreated != 1 {
+		t.Errorf("FilesCreated: got %d, want 1", result.FilesCreated)
+	}
+	out := readViewOutput(t, f, "count.md")
+	if !strings.Contains(out, "| n |") || strings.Contains(out, "San Francisco") {
+		t.Errorf("expected script rows only, got:\n%s", out)
+	}
+}
@@ -24,5 +24,5 @@ func viewBuilderForCollection(colDef *ingitdb.CollectionDef) (materializer.ViewB
 		}
 	}
 	// Use the filesystem reader for template-based views like README builders.
-	return materializer.NewViewBuilder(materializer.NewFileRecordsReader(), nil), nil
+	return NewExtendedViewBuilder(materializer.NewViewBuilder(materializer.NewFileRecordsReader(), nil)), nil
 }
@@ -0,0 +1,365 @@
+package commands
+
+// specscore: feature/cli/create
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// viewWhereSeparator joins the individual --where conditions stored in a
+// view's `where` attribute. Each side is one select-style condition, and the
+// conditions are ANDed, mirroring repeated --where flags on `select`. A
+// separator inside a quoted value does not split it; see splitViewWhere.
+const viewWhereSeparator = " && "
+
+// viewFormatMarkdown is the only format the materializer renders for a named
+// view without a template: a Markdown table.
+const viewFormatMarkdown = "md"
+
+// collectionViewsDir returns the directory holding the named-view definition
+// files of a root collection. The layout is detected the same way the
+// definition reader does it: a definition.yaml directly in the collection
+// directory is the shared layout (views under $views/), otherwise the legacy
+// .collection/views/ directory is used.
+func collectionViewsDir(dbDir, colRel string) string {
+	colDir := filepath.Join(dbDir, colRel)
+	if _, err := os.Stat(filepath.Join(colDir, ingitdb.CollectionDefFileName)); err == nil {
+		return filepath.Join(colDir, ingitdb.SharedViewsDir)
+	}
+	return filepath.Join(colDir, ingitdb.SchemaDir, "views")
+}
+
+// viewFile is the on-disk shape of a view definition: the core ViewDef plus
+// the CLI-level extensions the library's ViewDef does not know about. The
+// library decodes view files leniently, so the extra keys are ignored there
+// and picked up again by ExtendedViewBuilder.
+type viewFile struct {
+	ingitdb.ViewDef `yaml:",inline"`
+	viewExtensions  `yaml:",inline"`
+}
+
+// viewExtensions holds the aggregating and scripted view modes. A view uses
+// at most one of them: GroupBy/Aggregates or Script.
+type viewExtensions struct {
+	// GroupBy lists the fields whose values identify a group. With GroupBy or
+	// Aggregates set, the view emits one row per group instead of one row per
+	// record.
+	GroupBy []string `yaml:"group_by,omitempty"`
+
+	// Aggregates lists "func(field) [as name]" expressions evaluated per group.
+	Aggregates []string `yaml:"aggregates,omitempty"`
+
+	// Script is a Starlark file, relative to the views directory, defining
+	// view(records) that returns the view rows.
+	Script string `yaml:"script,omitempty"`
+}
+
+func (e viewExtensions) isAggregating() bool {
+	return len(e.GroupBy) > 0 || len(e.Aggregates) > 0
+}
+
+// readViewDefFile parses a view-definition file. The view ID is derived from
+// the file name, as the definition reader does.
+func readViewDefFile(path string) (*viewFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read view file %s: %w", path, err)
+	}
+	view := new(viewFile)
+	if err = yaml.Unmarshal(raw, view); err != nil {
+		return nil, fmt.Errorf("parse view file %s: %w", path, err)
+	}
+	view.ID = strings.TrimSuffix(filepath.Base(path), ".yaml")
+	return view, nil
+}
+
+// readViewExtensions returns the CLI-level extensions of a view of the
+// collection at colDirPath. A view without a definition file (e.g. the inline
+// default view) has no extensions.
+func readViewExtensions(colDirPath, viewID string) (viewExtensions, string, error) {
+	viewsDir := collectionViewsDir(colDirPath, "")
+	path := filepath.Join(viewsDir, viewID+".yaml")
+	if _, err := os.Stat(path); err != nil {
+		return viewExtensions{}, viewsDir, nil
+	}
+	view, err := readViewDefFile(path)
+	if err != nil {
+		return viewExtensions{}, viewsDir, err
+	}
+	return view.viewExtensions, viewsDir, nil
+}
+
+// writeViewDefFile serialises a view definition to path, creating the views
+// directory when needed.
+func writeViewDefFile(path string, view *viewFile) error {
+	out, err := yaml.Marshal(view)
+	if err != nil {
+		return fmt.Errorf("marshal view %s: %w", view.ID, err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create views directory: %w", err)
+	}
+	if err = os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("write view file %s: %w", path, err)
+	}
+	return nil
+}
+
+// addViewDefFlags registers the view-shape flags shared by `create view` and
+// `alter view`.
+func addViewDefFlags(cmd *cobra.Command) {
+	cmd.Flags().String("in", "", "collection the view reads records from (required)")
+	cmd.Flags().String("columns", "", "comma-separated list of columns to include (default: all)")
+	cmd.Flags().String("order-by", "", "field to order by; prefix with '-' for descending order")
+	cmd.Flags().Int("top", 0, "maximum number of records to include (0 = all)")
+	cmd.Flags().StringArray("where", nil, "filter condition using the select --where grammar (repeatable, ANDed)")
+	cmd.Flags().String("format", "", "built-in output format when no --template is given: md (Markdown table)")
+	cmd.Flags().String("template", "", "Go template path relative to the collection directory")
+	cmd.Flags().String("file-name", "", "output file name relative to the collection directory")
+	cmd.Flags().String("group-by", "", "comma-separated fields to group records by (one output row per group)")
+	cmd.Flags().StringArray("aggregate", nil, "aggregate per group: count(*), sum(f), avg(f), min(f), max(f), optionally 'as name' (repeatable)")
+	cmd.Flags().String("script", "", "Starlark file, relative to the views directory, defining view(records)")
+}
+
+// applyViewDefFlags copies every view-shape flag the user supplied onto view.
+// Flags that were not supplied leave the corresponding attribute untouched,
+// which is what makes the same function serve both create and alter.
+func applyViewDefFlags(cmd *cobra.Command, view *viewFile) error {
+	flags := cmd.Flags()
+	if flags.Changed("columns") {
+		raw, _ := flags.GetString("columns")
+		columns, err := sqlflags.ParseFields(raw)
+		if err != nil {
+			return fmt.Errorf("invalid --columns: %w", err)
+		}
+		view.Columns = columns
+	}
+	if flags.Changed("order-by") {
+		raw, _ := flags.GetString("order-by")
+		orderBy, err := viewOrderByFromFlag(raw)
+		if err != nil {
+			return err
+		}
+		view.OrderBy = orderBy
+	}
+	if flags.Changed("top") {
+		top, _ := flags.GetInt("top")
+		if top < 0 {
+			return fmt.Errorf("--top must be >= 0, got %d", top)
+		}
+		view.Top = top
+	}
+	if flags.Changed("where") {
+		raw, _ := flags.GetStringArray("where")
+		// An empty --where= clears the filter rather than failing to parse.
+		exprs := make([]string, 0, len(raw))
+		for _, expr := range raw {
+			if strings.TrimSpace(expr) == "" {
+				continue
+			}
+			if _, err := sqlflags.ParseWhere(expr); err != nil {
+				return fmt.Errorf("invalid --where %q: %w", expr, err)
+			}
+			// The stored attribute must read back as the same conditions.
+			if len(splitViewWhere(expr)) != 1 {
+				return fmt.Errorf("invalid --where %q: quote a value that contains %q", expr, strings.TrimSpace(viewWhereSeparator))
+			}
+			exprs = append(exprs, expr)
+		}
+		view.Where = strings.Join(exprs, viewWhereSeparator)
+	}
+	if flags.Changed("format") {
+		format, _ := flags.GetString("format")
+		switch strings.ToLower(format) {
+		case "":
+			view.Formats = nil
+		case viewFormatMarkdown:
+			view.Formats = []string{viewFormatMarkdown}
+		default:
+			return fmt.Errorf("unsupported --format %q: a view renders as %s or through --template", format, viewFormatMarkdown)
+		}
+	}
+	if flags.Changed("template") {
+		view.Template, _ = flags.GetString("template")
+	}
+	if flags.Changed("file-name") {
+		view.FileName, _ = flags.GetString("file-name")
+	}
+	if flags.Changed("group-by") {
+		raw, _ := flags.GetString("group-by")
+		groupBy, err := sqlflags.ParseFields(raw)
+		if err != nil {
+			return fmt.Errorf("invalid --group-by: %w", err)
+		}
+		view.GroupBy = groupBy
+	}
+	if flags.Changed("aggregate") {
+		raw, _ := flags.GetStringArray("aggregate")
+		aggregates := make([]string, 0, len(raw))
+		for _, expr := range raw {
+			if strings.TrimSpace(expr) == "" {
+				continue
+			}
+			if _, err := parseViewAggregate(expr); err != nil {
+				return fmt.Errorf("invalid --aggregate %q: %w", expr, err)
+			}
+			aggregates = append(aggregates, strings.TrimSpace(expr))
+		}
+		view.Aggregates = aggregates
+	}
+	if flags.Changed("script") {
+		view.Script, _ = flags.GetString("script")
+	}
+	return nil
+}
+
+// viewOrderByFromFlag converts the shared --order-by syntax ("field" or
+// "-field") into the view's order_by form ("field" or "field desc"). Views
+// sort by a single field, so a multi-field list is rejected.
+func viewOrderByFromFlag(raw string) (string, error) {
+	terms, err := sqlflags.ParseOrderBy(raw)
+	if err != nil {
+		return "", err
+	}
+	switch len(terms) {
+	case 0:
+		return "", nil
+	case 1:
+		if terms[0].Descending {
+			return terms[0].Field + " desc", nil
+		}
+		return terms[0].Field, nil
+	default:
+		return "", fmt.Errorf("views can be ordered by a single field, got %d in --order-by %q", len(terms), raw)
+	}
+}
+
+// validateViewAgainstCollection runs ViewDef.Validate and additionally
+// checks that every field the view references is a column of colDef, so a
+// typo is reported when the view is written rather than silently rendering
+// empty cells at materialization time. In an aggregating view, columns and
+// order-by refer to the output rows: group-by fields and aggregate names. The
+// rows of a scripted view are only known at run time, so only the where
+// fields are checked for it.
+func validateViewAgainstCollection(view *viewFile, colDef *ingitdb.CollectionDef) error {
+	if err := view.Validate(); err != nil {
+		return fmt.Errorf("invalid view %q: %w", view.ID, err)
+	}
+	if view.Template == "" && !slices.ContainsFunc(view.Formats, func(f string) bool {
+		return strings.EqualFold(f, viewFormatMarkdown)
+	}) {
+		return fmt.Errorf("view %q has nothing to render: use --format=%s or --template", view.ID, viewFormatMarkdown)
+	}
+	known := func(field string) bool {
+		if field == "$id" {
+			return true
+		}
+		_, ok := colDef.Columns[field]
+		return ok
+	}
+	conds, err := parseViewWhere(view.Where)
+	if err != nil {
+		return fmt.Errorf("view %q: %w", view.ID, err)
+	}
+	for _, c := range conds {
+		if !known(c.Field) {
+			return fmt.Errorf("view %q: unknown where column %q in collection %q", view.ID, c.Field, colDef.ID)
+		}
+	}
+
+	outputColumn := known
+	switch {
+	case view.Script != "" && view.isAggregating():
+		return fmt.Errorf("view %q: script cannot be combined with group_by or aggregates", view.ID)
+	case view.Script != "":
+		return nil
+	case view.isAggregating():
+		aggs, err := parseViewAggregates(view.Aggregates)
+		if err != nil {
+			return fmt.Errorf("view %q: %w", view.ID, err)
+		}
+		outputs := make(map[string]bool, len(view.GroupBy)+len(aggs))
+		for _, field := range view.GroupBy {
+			if !known(field) {
+				return fmt.Errorf("view %q: unknown group-by column %q in collection %q", view.ID, field, colDef.ID)
+			}
+			outputs[field] = true
+		}
+		for _, agg := range aggs {
+			if agg.field != "" && !known(agg.field) {
+				return fmt.Errorf("view %q: unknown aggregate column %q in collection %q", view.ID, agg.field, colDef.ID)
+			}
+			if outputs[agg.name] {
+				return fmt.Errorf("view %q: duplicate output column %q", view.ID, agg.name)
+			}
+			outputs[agg.name] = true
+		}
+		outputColumn = func(field string) bool {
+			return field == "$id" || outputs[field]
+		}
+	}
+	for _, column := range view.Columns {
+		if !outputColumn(column) {
+			return fmt.Errorf("view %q: unknown column %q in collection %q", view.ID, column, colDef.ID)
+		}
+	}
+	if fields := strings.Fields(view.OrderBy); len(fields) > 0 && !outputColumn(fields[0]) {
+		return fmt.Errorf("view %q: unknown order-by column %q in collection %q", view.ID, fields[0], colDef.ID)
+	}
+	return nil
+}
+
+// parseViewWhere splits a view's `where` attribute into its conditions and
+// parses each with the select --where grammar. An empty attribute yields no
+// conditions.
+func parseViewWhere(where string) ([]sqlflags.Condition, error) {
+	where = strings.TrimSpace(where)
+	if where == "" {
+		return nil, nil
+	}
+	parts := splitViewWhere(where)
+	conds := make([]sqlflags.Condition, 0, len(parts))
+	for _, part := range parts {
+		c, err := sqlflags.ParseWhere(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid where %q: %w", part, err)
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
+
+// splitViewWhere splits a `where` attribute on the separator, skipping any
+// separator inside a single- or double-quoted value, so `name == "R&&D"`
+// stays one condition.
+func splitViewWhere(where string) []string {
+	sep := strings.TrimSpace(viewWhereSeparator)
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(where); i++ {
+		switch c := where[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(where[i:], sep):
+			parts = append(parts, where[start:i])
+			start = i + len(sep)
+			i = start - 1
+		}
+	}
+	return append(parts, where[start:])
+}
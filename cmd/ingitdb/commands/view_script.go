@@ -0,0 +1,217 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// maxViewScriptSteps bounds the Starlark execution steps of one scripted
+// view run. Unlike a per-record formula, a view script loops over the whole
+// collection, so the ceiling is higher; it still stops a runaway loop.
+const maxViewScriptSteps = 100_000_000
+
+// viewScriptEntryPoint is the function a view script must define.
+const viewScriptEntryPoint = "view"
+
+// scriptRecordsReader runs a Starlark view script over the records of the
+// wrapped reader and yields the rows it returns.
+//
+// The script must define view(records). records is a list of dicts, one per
+// record, holding the record fields plus "$id". view must return a list of
+// dicts; a row's "$id" entry becomes its record ID, otherwise its 1-based
+// position is used. The sandbox has no load(), no I/O and a no-op print, so
+// the same records always produce the same rows.
+type scriptRecordsReader struct {
+	reader     ingitdb.RecordsReader
+	scriptPath string
+}
+
+func (r scriptRecordsReader) ReadRecords(
+	ctx context.Context,
+	dbPath string,
+	col *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	src, err := os.ReadFile(r.scriptPath)
+	if err != nil {
+		return fmt.Errorf("read view script: %w", err)
+	}
+	var records []starlark.Value
+	err = r.reader.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
+		data := make(map[string]any, len(entry.GetData())+1)
+		for k, v := range entry.GetData() {
+			data[k] = v
+		}
+		data["$id"] = entry.GetID()
+		v, convErr := goToStarlarkValue(data)
+		if convErr != nil {
+			return fmt.Errorf("record %s: %w", entry.GetID(), convErr)
+		}
+		records = append(records, v)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	thread := &starlark.Thread{
+		Name:  "view:" + r.scriptPath,
+		Print: func(_ *starlark.Thread, _ string) {},
+	}
+	thread.SetMaxExecutionSteps(maxViewScriptSteps)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, r.scriptPath, src, nil)
+	if err != nil {
+		return fmt.Errorf("view script %s: %w", r.scriptPath, err)
+	}
+	fn, ok := globals[viewScriptEntryPoint].(starlark.Callable)
+	if !ok {
+		return fmt.Errorf("view script %s must define %s(records)", r.scriptPath, viewScriptEntryPoint)
+	}
+	out, err := starlark.Call(thread, fn, starlark.Tuple{starlark.NewList(records)}, nil)
+	if err != nil {
+		return fmt.Errorf("view script %s: %w", r.scriptPath, err)
+	}
+	rows, ok := out.(*starlark.List)
+	if !ok {
+		return fmt.Errorf("view script %s: %s() must return a list of dicts, got %s", r.scriptPath, viewScriptEntryPoint, out.Type())
+	}
+	for i := 0; i < rows.Len(); i++ {
+		row, convErr := starlarkToGoValue(rows.Index(i))
+		if convErr != nil {
+			return fmt.Errorf("view script %s: row %d: %w", r.scriptPath, i+1, convErr)
+		}
+		data, isMap := row.(map[string]any)
+		if !isMap {
+			return fmt.Errorf("view script %s: row %d must be a dict, got %s", r.scriptPath, i+1, rows.Index(i).Type())
+		}
+		id := strconv.Itoa(i + 1)
+		if v, hasID := data["$id"]; hasID {
+			id = fmt.Sprintf("%v", v)
+			delete(data, "$id")
+		}
+		if err = yield(ingitdb.RecordEntry{ID: id, Data: data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// goToStarlarkValue converts a decoded record value into a Starlark value.
+// Map keys are inserted in sorted order so scripts iterating a dict see a
+// stable order.
+func goToStarlarkValue(v any) (starlark.Value, error) {
+	switch t := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(t), nil
+	case string:
+		return starlark.String(t), nil
+	case int:
+		return starlark.MakeInt(t), nil
+	case int64:
+		return starlark.MakeInt64(t), nil
+	case uint64:
+		return starlark.MakeUint64(t), nil
+	case float32:
+		return starlark.Float(t), nil
+	case float64:
+		return starlark.Float(t), nil
+	case []any:
+		elems := make([]starlark.Value, len(t))
+		for i, e := range t {
+			sv, err := goToStarlarkValue(e)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = sv
+		}
+		return starlark.NewList(elems), nil
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := starlark.NewDict(len(t))
+		for _, k := range keys {
+			sv, err := goToStarlarkValue(t[k])
+			if err != nil {
+				return nil, err
+			}
+			if err = d.SetKey(starlark.String(k), sv); err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	default:
+		return starlark.String(fmt.Sprintf("%v", t)), nil
+	}
+}
+
+// starlarkToGoValue converts a Starlark value returned by a view script into
+// a plain Go value. Dict keys must be strings.
+func starlarkToGoValue(v starlark.Value) (any, error) {
+	switch t := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(t), nil
+	case starlark.String:
+		return string(t), nil
+	case starlark.Int:
+		i, ok := t.Int64()
+		if !ok {
+			return nil, fmt.Errorf("integer %s does not fit in int64", t.String())
+		}
+		return i, nil
+	case starlark.Float:
+		return float64(t), nil
+	case *starlark.List:
+		out := make([]any, t.Len())
+		for i := 0; i < t.Len(); i++ {
+			e, err := starlarkToGoValue(t.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = e
+		}
+		return out, nil
+	case starlark.Tuple:
+		out := make([]any, len(t))
+		for i, e := range t {
+			ge, err := starlarkToGoValue(e)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = ge
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, t.Len())
+		for _, item := range t.Items() {
+			k, ok := starlark.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is not a string", item[0].String())
+			}
+			gv, err := starlarkToGoValue(item[1])
+			if err != nil {
+				return nil, err
+			}
+			out[k] = gv
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %s", v.Type())
+	}
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+func writeViewScript(t *testing.T, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "view.star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+	return path
+}
+
+func TestScriptRecordsReader_ReturnsRows(t *testing.T) {
+	t.Parallel()
+	script := writeViewScript(t, `
+def view(records):
+    totals = {}
+    for r in records:
+        totals[r["state"]] = totals.get(r["state"], 0) + r["population"]
+    return [{"$id": s, "state": s, "population": totals[s]} for s in sorted(totals)]
+`)
+	source := sliceRecordsReader{
+		ingitdb.RecordEntry{ID: "sf", Data: map[string]any{"state": "CA", "population": 800000}},
+		ingitdb.RecordEntry{ID: "la", Data: map[string]any{"state": "CA", "population": 4000000}},
+		ingitdb.RecordEntry{ID: "nyc", Data: map[string]any{"state": "NY", "population": 8000000}},
+	}
+
+	rows := readAllEntries(t, scriptRecordsReader{reader: source, scriptPath: script})
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if rows[0].GetID() != "CA" || rows[0].GetData()["population"] != int64(4800000) {
+		t.Errorf("CA row: id=%s data=%v", rows[0].GetID(), rows[0].GetData())
+	}
+	if _, hasID := rows[0].GetData()["$id"]; hasID {
+		t.Error("$id must be moved to the record ID, not kept as a field")
+	}
+}
+
+func TestScriptRecordsReader_RecordIDAndPositionalIDs(t *testing.T) {
+	t.Parallel()
+	script := writeViewScript(t, `
+def view(records):
+    return [{"key": r["$id"]} for r in records]
+`)
+	source := sliceRecordsReader{ingitdb.RecordEntry{ID: "only", Data: map[string]any{}}}
+
+	rows := readAllEntries(t, scriptRecordsReader{reader: source, scriptPath: script})
+	if len(rows) != 1 || rows[0].GetID() != "1" || rows[0].GetData()["key"] != "only" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+}
+
+func TestScriptRecordsReader_Errors(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "no entry point", src: "x = 1\n", wantErr: "must define view(records)"},
+		{name: "not a list", src: "def view(records):\n    return 1\n", wantErr: "must return a list"},
+		{name: "row not a dict", src: "def view(records):\n    return [1]\n", wantErr: "must be a dict"},
+		{name: "load is unavailable", src: "load('x.star', 'y')\ndef view(records):\n    return []\n", wantErr: "load"},
+		{name: "runtime error", src: "def view(records):\n    return [{}][5]\n", wantErr: "index"},
+		{name: "syntax error", src: "def view(records)\n", wantErr: "view.star"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			reader := scriptRecordsReader{reader: sliceRecordsReader{}, scriptPath: writeViewScript(t, tc.src)}
+			err := reader.ReadRecords(context.Background(), "", &ingitdb.CollectionDef{ID: "test"}, func(ingitdb.IRecordEntry) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package commands
+
+// specscore: feature/cli/materialize
+
+import (
+	"context"
+
+	"github.com/ingitdb/ingitdb-cli/cmd/ingitdb/commands/sqlflags"
+	"github.com/ingitdb/ingitdb-go/ingitdb"
+)
+
+// whereRecordsReader yields only the records matching every condition. As in
+// `select`, a condition that cannot be evaluated against a record (e.g. an
+// ordering comparison between mismatched types) counts as a non-match.
+type whereRecordsReader struct {
+	reader ingitdb.RecordsReader
+	conds  []sqlflags.Condition
+}
+
+func (r whereRecordsReader) ReadRecords(
+	ctx context.Context,
+	dbPath string,
+	col *ingitdb.CollectionDef,
+	yield func(ingitdb.IRecordEntry) error,
+) error {
+	return r.reader.ReadRecords(ctx, dbPath, col, func(entry ingitdb.IRecordEntry) error {
+		if match, _ := evalAllWhere(entry.GetData(), entry.GetID(), r.conds); !match {
+			return nil
+		}
+		return yield(entry)
+	})
+}
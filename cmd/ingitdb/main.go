@@ -58,7 +58,8 @@ func run(
 	newDB := defaultNewDB
 
 	viewBuilderLogf := makeViewBuilderLogf(logf)
-	vb := materializer.NewViewBuilder(materializer.NewFileRecordsReader(), viewBuilderLogf)
+	vb := commands.NewExtendedViewBuilder(materializer.NewViewBuilder(materializer.NewFileRecordsReader(), viewBuilderLogf))
+	incVal := datavalidator.NewIncrementalValidator(gitdiff.NewGitDiffer(), datavalidator.NewChangeSetResolver(), datavalidator.NewValidator())
 
 	rootCmd := &cobra.Command{
 		Use:           "ingitdb",
@@ -77,10 +78,9 @@ func run(
 		commands.Version(version, commit, date),
 		// No "update" alias: `ingitdb update` is the SQL UPDATE verb below.
 		commands.SelfUpdate(version, os.Exit),
-		commands.Validate(homeDir, getWd, readDefinition, datavalidator.NewValidator(),
-			datavalidator.NewIncrementalValidator(gitdiff.NewGitDiffer(), datavalidator.NewChangeSetResolver(), datavalidator.NewValidator()), logf),
+		commands.Validate(homeDir, getWd, readDefinition, datavalidator.NewValidator(), incVal, logf),
 		commands.Materialize(homeDir, getWd, readDefinition, vb, logf),
-		commands.CI(homeDir, getWd, readDefinition, vb, logf),
+		commands.CI(homeDir, getWd, readDefinition, vb, datavalidator.NewValidator(), incVal, logf),
 		commands.Pull(homeDir, getWd, readDefinition, vb, logf, defaultIsTerminal, launchConflictsTUI),
 		commands.Setup(),
 		commands.Resolve(homeDir, getWd, readDefinition, logf, defaultIsTerminal, launchConflictsTUI),
@@ -101,7 +101,19 @@ func run(
 		commands.Insert(homeDir, getWd, readDefinition, newDB, logf, nil, nil, nil),
 		commands.Update(homeDir, getWd, readDefinition, newDB, logf),
 		commands.Delete(homeDir, getWd, readDefinition, newDB, logf),
+		commands.Rename(homeDir, getWd, readDefinition, newDB, logf),
+		commands.Copy(homeDir, getWd, readDefinition, newDB, logf),
 		commands.Drop(homeDir, getWd, readDefinition, newDB, logf),
+		commands.Create(homeDir, getWd, readDefinition, vb, logf),
+		commands.Alter(homeDir, getWd, readDefinition, vb, logf),
+		commands.Infer(homeDir, getWd, logf),
+		commands.ExportSchema(homeDir, getWd, readDefinition, logf),
+		commands.Codegen(homeDir, getWd, readDefinition),
+		commands.Import(homeDir, getWd, readDefinition, newDB, logf),
+		commands.Export(homeDir, getWd, readDefinition, logf),
+		commands.Dump(homeDir, getWd, readDefinition, logf),
+		commands.RestoreDump(homeDir, getWd, readDefinition, logf),
+		commands.Convert(homeDir, getWd, readDefinition, newDB, logf),
 	)
 
 	rootCmd.SetArgs(args[1:])